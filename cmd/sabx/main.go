@@ -8,6 +8,6 @@ import (
 
 func main() {
 	if err := root.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(root.ExitCode(err))
 	}
 }
@@ -1,10 +1,14 @@
 package root
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 
+	"github.com/avivsinai/sabx/internal/output"
 	"github.com/avivsinai/sabx/internal/sabapi"
 )
 
@@ -12,11 +16,12 @@ func browseCmd() *cobra.Command {
 	var showFiles bool
 	var showHidden bool
 	var compact bool
+	var depth int
 
 	cmd := &cobra.Command{
 		Use:   "browse [path]",
 		Short: jsonShort("Browse filesystem paths on the SABnzbd host"),
-		Long:  appendJSONLong("Inspect directories exposed by SABnzbd. Combine flags like --files or --compact to tailor the response. Errors surface if SABnzbd refuses a path or the API call fails."),
+		Long:  appendJSONLong("Inspect directories exposed by SABnzbd. Combine flags like --files or --compact to tailor the response. Errors surface if SABnzbd refuses a path or the API call fails. --depth N descends into subdirectories up to N levels (1 lists only the given path); the walk stops after a fixed number of entries and never revisits a path."),
 		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			path := ""
@@ -24,6 +29,13 @@ func browseCmd() *cobra.Command {
 				path = args[0]
 			}
 
+			if depth < 1 {
+				return errors.New("--depth must be at least 1")
+			}
+			if depth > 1 && compact {
+				return errors.New("--depth cannot be combined with --compact (compact results do not mark directories)")
+			}
+
 			app, err := getApp(cmd)
 			if err != nil {
 				return err
@@ -38,6 +50,10 @@ func browseCmd() *cobra.Command {
 				ShowHiddenFolders: showHidden,
 			}
 
+			if depth > 1 {
+				return printBrowseTree(ctx, app.Client, app.Printer, path, opts, depth)
+			}
+
 			entries, err := app.Client.Browse(ctx, path, opts)
 			if err != nil {
 				return err
@@ -92,6 +108,101 @@ func browseCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&showFiles, "files", false, "Include files in results")
 	cmd.Flags().BoolVar(&showHidden, "hidden", false, "Include hidden folders")
 	cmd.Flags().BoolVar(&compact, "compact", false, "Return compact results (path strings only)")
+	cmd.Flags().IntVar(&depth, "depth", 1, "Recurse into subdirectories up to N levels")
 
 	return cmd
 }
+
+// browseNodeCap bounds a recursive browse so a huge or looping tree cannot
+// issue unbounded API calls.
+const browseNodeCap = 2000
+
+type browseNode struct {
+	Name     string        `json:"name"`
+	Path     string        `json:"path"`
+	Dir      bool          `json:"dir,omitempty"`
+	Children []*browseNode `json:"children,omitempty"`
+}
+
+type browseWalker struct {
+	client    *sabapi.Client
+	opts      sabapi.BrowseOptions
+	visited   map[string]bool
+	nodes     int
+	truncated bool
+}
+
+// walk lists path and, while depth allows, each subdirectory beneath it.
+// Parent links, already visited paths and anything past browseNodeCap are
+// skipped; the latter marks the result as truncated.
+func (w *browseWalker) walk(ctx context.Context, path string, depth int) ([]*browseNode, error) {
+	w.visited[path] = true
+	entries, err := w.client.Browse(ctx, path, w.opts)
+	if err != nil {
+		return nil, err
+	}
+	nodes := []*browseNode{}
+	for _, entry := range entries {
+		if entry.CurrentPath != "" || entry.Name == ".." {
+			continue
+		}
+		if w.nodes >= browseNodeCap {
+			w.truncated = true
+			break
+		}
+		w.nodes++
+		node := &browseNode{Name: entry.Name, Path: entry.Path, Dir: entry.Dir}
+		nodes = append(nodes, node)
+		if !entry.Dir || depth <= 1 || entry.Path == "" || w.visited[entry.Path] {
+			continue
+		}
+		children, err := w.walk(ctx, entry.Path, depth-1)
+		if err != nil {
+			return nil, fmt.Errorf("browse %s: %w", entry.Path, err)
+		}
+		node.Children = children
+	}
+	return nodes, nil
+}
+
+func printBrowseTree(ctx context.Context, client *sabapi.Client, printer *output.Printer, path string, opts sabapi.BrowseOptions, depth int) error {
+	walker := &browseWalker{client: client, opts: opts, visited: map[string]bool{}}
+	tree, err := walker.walk(ctx, path, depth)
+	if err != nil {
+		return err
+	}
+
+	if printer.JSON {
+		return printer.Print(map[string]any{
+			"path":      path,
+			"depth":     depth,
+			"entries":   tree,
+			"truncated": walker.truncated,
+		})
+	}
+
+	rows := browseTreeRows(tree, 0, nil)
+	if len(rows) == 0 {
+		return printer.Print("No entries")
+	}
+	if err := printer.Table([]string{"Name", "Path", "Type"}, rows); err != nil {
+		return err
+	}
+	if walker.truncated {
+		printer.Error("Stopped after %d entries; narrow the path or lower --depth", browseNodeCap)
+	}
+	return nil
+}
+
+// browseTreeRows flattens the tree depth-first, indenting names by level.
+func browseTreeRows(nodes []*browseNode, level int, rows [][]string) [][]string {
+	for _, node := range nodes {
+		kind := "File"
+		if node.Dir {
+			kind = "Dir"
+		}
+		rows = append(rows, []string{strings.Repeat("  ", level) + node.Name, node.Path, kind})
+		rows = browseTreeRows(node.Children, level+1, rows)
+	}
+	return rows
+}
@@ -0,0 +1,93 @@
+package root
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+func TestBrowseDepthWalksTwoLevels(t *testing.T) {
+	t.Parallel()
+
+	listings := map[string]string{
+		"/data":        `{"paths":[{"current_path":"/data"},{"name":"..","path":"/","dir":true},{"name":"movies","path":"/data/movies","dir":true},{"name":"loop","path":"/data","dir":true},{"name":"readme.txt","path":"/data/readme.txt"}]}`,
+		"/data/movies": `{"paths":[{"current_path":"/data/movies"},{"name":"..","path":"/data","dir":true},{"name":"2024","path":"/data/movies/2024","dir":true}]}`,
+	}
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("mode") != "browse" {
+			http.Error(w, "unexpected mode", http.StatusBadRequest)
+			return
+		}
+		calls.Add(1)
+		body, ok := listings[r.URL.Query().Get("name")]
+		if !ok {
+			t.Errorf("unexpected browse of %q", r.URL.Query().Get("name"))
+			body = `{"paths":[]}`
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := sabapi.NewClient(server.URL, "secret")
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	var out bytes.Buffer
+	cmd := browseCmd()
+	cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{
+		Client:  client,
+		Printer: &output.Printer{JSON: true, Out: &out, Err: &bytes.Buffer{}},
+	}))
+	cmd.SetArgs([]string{"/data", "--depth", "2", "--files"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("browse returned error: %v", err)
+	}
+
+	var payload struct {
+		Depth     int           `json:"depth"`
+		Truncated bool          `json:"truncated"`
+		Entries   []*browseNode `json:"entries"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("decode output: %v\n%s", err, out.String())
+	}
+	if payload.Depth != 2 || payload.Truncated {
+		t.Fatalf("unexpected depth/truncated %d/%v", payload.Depth, payload.Truncated)
+	}
+	if len(payload.Entries) != 3 {
+		t.Fatalf("expected movies, loop and readme at the top level, got %+v", payload.Entries)
+	}
+	movies := payload.Entries[0]
+	if movies.Name != "movies" || len(movies.Children) != 1 || movies.Children[0].Path != "/data/movies/2024" {
+		t.Fatalf("expected nested 2024 under movies, got %+v", movies)
+	}
+	if movies.Children[0].Children != nil {
+		t.Fatalf("depth 2 must not descend a third level, got %+v", movies.Children[0])
+	}
+	if loop := payload.Entries[1]; loop.Children != nil {
+		t.Fatalf("already visited path must not be walked again, got %+v", loop)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("expected 2 browse calls, got %d", got)
+	}
+}
+
+func TestBrowseTreeRowsIndentsByLevel(t *testing.T) {
+	t.Parallel()
+
+	tree := []*browseNode{{Name: "a", Path: "/a", Dir: true, Children: []*browseNode{{Name: "b.txt", Path: "/a/b.txt"}}}}
+	rows := browseTreeRows(tree, 0, nil)
+	if len(rows) != 2 || rows[0][0] != "a" || rows[0][2] != "Dir" || rows[1][0] != "  b.txt" || rows[1][2] != "File" {
+		t.Fatalf("unexpected rows %v", rows)
+	}
+}
@@ -5,11 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/sabapi"
 )
 
 func categoriesCmd() *cobra.Command {
@@ -26,9 +30,15 @@ func categoriesCmd() *cobra.Command {
 }
 
 func categoriesListCmd() *cobra.Command {
+	var (
+		usage        bool
+		historyLimit int
+	)
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: jsonShort("List categories"),
+		Long: appendJSONLong("List configured categories. --usage adds how many queued jobs and how many completed jobs " +
+			"among the most recent history entries (--history-limit) use each category."),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			app, err := getApp(cmd)
 			if err != nil {
@@ -36,28 +46,102 @@ func categoriesListCmd() *cobra.Command {
 			}
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
-			payload, err := app.Client.CategoriesList(ctx)
+			payload, err := app.CategoriesList(ctx)
 			if err != nil {
 				return err
 			}
-			if app.Printer.JSON {
-				return app.Printer.Print(payload)
+			if !usage {
+				if app.Printer.JSON {
+					return app.Printer.Print(payload)
+				}
+				cats := parseNamedConfig(payload)
+				headers := []string{"Name", "Dir", "Script", "Priority"}
+				rows := make([][]string, 0, len(cats))
+				for _, cat := range cats {
+					rows = append(rows, []string{cat.Name, cat.Values["dir"], cat.Values["script"], cat.Values["priority"]})
+				}
+				if err := app.Printer.Table(headers, rows); err != nil {
+					return err
+				}
+				return app.Printer.Print(fmt.Sprintf("%d categories", len(cats)))
+			}
+
+			queue, err := app.Client.Queue(ctx, 0, 0, "")
+			if err != nil {
+				return err
+			}
+			history, err := app.Client.History(ctx, false, historyLimit)
+			if err != nil {
+				return err
 			}
 			cats := parseNamedConfig(payload)
-			headers := []string{"Name", "Dir", "Script", "Priority"}
-			rows := make([][]string, 0, len(cats))
-			for _, cat := range cats {
-				rows = append(rows, []string{cat.Name, cat.Values["dir"], cat.Values["script"], cat.Values["priority"]})
+			usages := countCategoryUsage(cats, queue.Slots, history.Slots)
+			if app.Printer.JSON {
+				return app.Printer.Print(map[string]any{
+					"categories": usages,
+					"count":      len(usages),
+				})
+			}
+			headers := []string{"Name", "Dir", "Script", "Priority", "Queued", "Completed"}
+			rows := make([][]string, 0, len(usages))
+			for i, u := range usages {
+				cat := cats[i]
+				rows = append(rows, []string{u.Name, cat.Values["dir"], cat.Values["script"], cat.Values["priority"], strconv.Itoa(u.Queued), strconv.Itoa(u.Completed)})
 			}
 			if err := app.Printer.Table(headers, rows); err != nil {
 				return err
 			}
-			return app.Printer.Print(fmt.Sprintf("%d categories", len(cats)))
+			return app.Printer.Print(fmt.Sprintf("%d categories", len(usages)))
 		},
 	}
+	cmd.Flags().BoolVar(&usage, "usage", false, "Show how many queued and completed jobs use each category")
+	cmd.Flags().IntVar(&historyLimit, "history-limit", 100, "With --usage, how many recent history entries to count (0 for all)")
 	return cmd
 }
 
+// categoryUsage counts the jobs using one configured category.
+type categoryUsage struct {
+	Name      string `json:"name"`
+	Queued    int    `json:"queued"`
+	Completed int    `json:"completed"`
+}
+
+// countCategoryUsage joins queue and history slots to cats by category name,
+// ignoring case, and returns one entry per category in the same order. Only
+// history entries with status Completed count as completed. Jobs without a
+// category (empty or None) belong to the default "*" category; jobs in a
+// category that is no longer configured are not counted.
+func countCategoryUsage(cats []namedConfig, queue []sabapi.QueueSlot, history []sabapi.HistorySlot) []categoryUsage {
+	usages := make([]categoryUsage, len(cats))
+	index := make(map[string]int, len(cats))
+	for i, cat := range cats {
+		usages[i] = categoryUsage{Name: cat.Name}
+		index[categoryUsageKey(cat.Name)] = i
+	}
+	for _, slot := range queue {
+		if i, ok := index[categoryUsageKey(slot.Category)]; ok {
+			usages[i].Queued++
+		}
+	}
+	for _, slot := range history {
+		if !strings.EqualFold(slot.Status, "Completed") {
+			continue
+		}
+		if i, ok := index[categoryUsageKey(slot.Category)]; ok {
+			usages[i].Completed++
+		}
+	}
+	return usages
+}
+
+func categoryUsageKey(name string) string {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if key == "" || key == "none" {
+		return "*"
+	}
+	return key
+}
+
 func categoriesAddCmd() *cobra.Command {
 	var dir string
 	var script string
@@ -167,21 +251,15 @@ func parseNamedConfig(m map[string]any) []namedConfig {
 	result := []namedConfig{}
 	value := extractValueMap(m)
 	if items, ok := value["items"].([]any); ok {
-		for _, item := range items {
-			if asMap, ok := item.(map[string]any); ok {
-				entry := namedConfig{Values: map[string]string{}}
-				for key, raw := range asMap {
-					str := fmt.Sprintf("%v", raw)
-					if key == "name" {
-						entry.Name = str
-					} else {
-						entry.Values[key] = str
-					}
-				}
-				result = append(result, entry)
+		return namedConfigItems(items)
+	}
+	// get_config answers {"config": {"<section>": [...]}}.
+	if cfg, ok := value["config"].(map[string]any); ok && len(cfg) == 1 {
+		for _, section := range cfg {
+			if items, ok := section.([]any); ok {
+				return namedConfigItems(items)
 			}
 		}
-		return result
 	}
 	for key, raw := range value {
 		entry := namedConfig{Name: key, Values: map[string]string{}}
@@ -195,6 +273,110 @@ func parseNamedConfig(m map[string]any) []namedConfig {
 	return result
 }
 
+func namedConfigItems(items []any) []namedConfig {
+	result := []namedConfig{}
+	for _, item := range items {
+		if asMap, ok := item.(map[string]any); ok {
+			entry := namedConfig{Values: map[string]string{}}
+			for key, raw := range asMap {
+				str := fmt.Sprintf("%v", raw)
+				if key == "name" {
+					entry.Name = str
+				} else {
+					entry.Values[key] = str
+				}
+			}
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// inferCategory picks the category for a job name when --auto-cat is set.
+// The first category whose rules match wins; otherwise fallback (the --cat
+// value, possibly empty) is returned.
+func inferCategory(ctx context.Context, app *cobraext.App, name, fallback string) (string, error) {
+	payload, err := app.CategoriesList(ctx)
+	if err != nil {
+		return "", err
+	}
+	if cat, ok := matchCategoryRule(parseNamedConfig(payload), name); ok {
+		return cat, nil
+	}
+	return fallback, nil
+}
+
+// checkCategory rejects a --cat value that is not a configured category,
+// listing the valid names. An empty category or force skips the lookup.
+// SABnzbd compares category names case-insensitively.
+func checkCategory(ctx context.Context, app *cobraext.App, category string, force bool) error {
+	category = strings.TrimSpace(category)
+	if category == "" || force {
+		return nil
+	}
+	payload, err := app.CategoriesList(ctx)
+	if err != nil {
+		return fmt.Errorf("validate category: %w", err)
+	}
+	names := make([]string, 0)
+	for _, cat := range parseNamedConfig(payload) {
+		if cat.Name == "" {
+			continue
+		}
+		if strings.EqualFold(cat.Name, category) {
+			return nil
+		}
+		names = append(names, cat.Name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("unknown category %q (valid: %s); use --force to send it anyway", category, strings.Join(names, ", "))
+}
+
+// matchCategoryRule matches name against each category's indexer rules
+// (SABnzbd's comma-separated "newzbin" field). Plain terms match as
+// case-insensitive substrings and "re:" terms as case-insensitive regular
+// expressions. Categories are tried in SABnzbd's display order; the default
+// "*" category never matches.
+func matchCategoryRule(cats []namedConfig, name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", false
+	}
+	ordered := append([]namedConfig(nil), cats...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		oi, _ := strconv.Atoi(ordered[i].Values["order"])
+		oj, _ := strconv.Atoi(ordered[j].Values["order"])
+		if oi != oj {
+			return oi < oj
+		}
+		return ordered[i].Name < ordered[j].Name
+	})
+
+	lower := strings.ToLower(name)
+	for _, cat := range ordered {
+		if cat.Name == "" || cat.Name == "*" {
+			continue
+		}
+		for _, term := range strings.Split(cat.Values["newzbin"], ",") {
+			term = strings.TrimSpace(term)
+			if term == "" {
+				continue
+			}
+			if pattern, ok := strings.CutPrefix(term, "re:"); ok {
+				re, err := regexp.Compile("(?i)" + pattern)
+				if err == nil && re.MatchString(name) {
+					return cat.Name, true
+				}
+				continue
+			}
+			if strings.Contains(lower, strings.ToLower(term)) {
+				return cat.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
 func applyNamedProperties(ctx context.Context, app *cobraext.App, section, name string, props map[string]string) error {
 	values := url.Values{}
 	for key, val := range props {
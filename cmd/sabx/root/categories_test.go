@@ -0,0 +1,281 @@
+package root
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+const autoCatCategories = `{"config":{"categories":[
+	{"name":"*","order":0,"newzbin":""},
+	{"name":"movies","order":2,"newzbin":"re:\\.(19|20)\\d{2}\\.(1080p|2160p)"},
+	{"name":"tv","order":1,"newzbin":"HDTV, re:S\\d{2}E\\d{2}"}
+]}}`
+
+func TestMatchCategoryRule(t *testing.T) {
+	t.Parallel()
+
+	cats := []namedConfig{
+		{Name: "*", Values: map[string]string{"order": "0", "newzbin": "anything"}},
+		{Name: "movies", Values: map[string]string{"order": "2", "newzbin": `re:\.(19|20)\d{2}\.(1080p|2160p)`}},
+		{Name: "tv", Values: map[string]string{"order": "1", "newzbin": `HDTV, re:S\d{2}E\d{2}`}},
+		{Name: "broken", Values: map[string]string{"order": "3", "newzbin": "re:("}},
+	}
+
+	tests := []struct {
+		name   string
+		job    string
+		want   string
+		wantOK bool
+	}{
+		{name: "regex match", job: "Show.Name.s01e02.720p.nzb", want: "tv", wantOK: true},
+		{name: "plain term is case-insensitive", job: "show.name.hdtv.x264", want: "tv", wantOK: true},
+		{name: "order decides ties", job: "Film.2023.1080p.S01E01", want: "tv", wantOK: true},
+		{name: "second category", job: "Film.2023.1080p.BluRay", want: "movies", wantOK: true},
+		{name: "no match skips default", job: "anything.goes", wantOK: false},
+		{name: "empty name", job: "", wantOK: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := matchCategoryRule(cats, tt.job)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("matchCategoryRule(%q) = (%q, %v), want (%q, %v)", tt.job, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestQueueAddURLAutoCat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantCat string
+	}{
+		{name: "matched category", args: []string{"--auto-cat", "https://indexer.example/get/Show.S01E02.nzb"}, wantCat: "tv"},
+		{name: "falls back to --cat", args: []string{"--auto-cat", "--force", "--cat", "misc", "https://indexer.example/get/Unknown.nzb"}, wantCat: "misc"},
+		{name: "no match without --cat", args: []string{"--auto-cat", "https://indexer.example/get/Unknown.nzb"}, wantCat: ""},
+		{name: "match overrides --cat", args: []string{"--auto-cat", "--cat", "misc", "https://indexer.example/get/Film.2023.2160p.nzb"}, wantCat: "movies"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var added url.Values
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				q := r.URL.Query()
+				switch q.Get("mode") {
+				case "get_config":
+					_, _ = w.Write([]byte(autoCatCategories))
+				case "addurl":
+					added = q
+					_, _ = w.Write([]byte(`{"status":true,"nzo_ids":["SABnzbd_nzo_1"]}`))
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+			if err != nil {
+				t.Fatalf("NewClient returned error: %v", err)
+			}
+			cmd := queueAddURLCmd()
+			cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{Client: client, Printer: &output.Printer{Out: &bytes.Buffer{}}}))
+			cmd.SetArgs(tt.args)
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("Execute returned error: %v", err)
+			}
+			if added == nil {
+				t.Fatal("expected an addurl call")
+			}
+			if got := added.Get("cat"); got != tt.wantCat {
+				t.Fatalf("cat = %q, want %q", got, tt.wantCat)
+			}
+		})
+	}
+}
+
+func TestCategoryValidation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		cmd       func() *cobra.Command
+		args      []string
+		wantErr   string
+		wantCall  string
+		wantValue string
+	}{
+		{name: "item set valid", cmd: queueItemSetCmd, args: []string{"SABnzbd_nzo_1", "--cat", "TV"}, wantCall: "change_cat", wantValue: "TV"},
+		{name: "item set invalid", cmd: queueItemSetCmd, args: []string{"SABnzbd_nzo_1", "--cat", "tvv"}, wantErr: `unknown category "tvv" (valid: *, movies, tv)`},
+		{name: "item set force", cmd: queueItemSetCmd, args: []string{"SABnzbd_nzo_1", "--cat", "tvv", "--force"}, wantCall: "change_cat", wantValue: "tvv"},
+		{name: "add valid", cmd: queueAddURLCmd, args: []string{"--cat", "movies", "https://indexer.example/get/a.nzb"}, wantCall: "addurl", wantValue: "movies"},
+		{name: "add invalid", cmd: queueAddURLCmd, args: []string{"--cat", "film", "https://indexer.example/get/a.nzb"}, wantErr: "use --force"},
+		{name: "add force", cmd: queueAddURLCmd, args: []string{"--cat", "film", "--force", "https://indexer.example/get/a.nzb"}, wantCall: "addurl", wantValue: "film"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var mu sync.Mutex
+			calls := map[string]string{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				q := r.URL.Query()
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case q.Get("mode") == "get_config":
+					calls["get_config"] = q.Get("section")
+					_, _ = w.Write([]byte(autoCatCategories))
+				case q.Get("mode") == "change_cat":
+					calls["change_cat"] = q.Get("value2")
+					_, _ = w.Write([]byte(`{"status":true}`))
+				case q.Get("mode") == "addurl":
+					calls["addurl"] = q.Get("cat")
+					_, _ = w.Write([]byte(`{"status":true,"nzo_ids":["SABnzbd_nzo_1"]}`))
+				default:
+					_, _ = w.Write([]byte(`{"status":true}`))
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+			if err != nil {
+				t.Fatalf("NewClient returned error: %v", err)
+			}
+			cmd := tt.cmd()
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{Client: client, Printer: &output.Printer{Out: &bytes.Buffer{}}}))
+			cmd.SetArgs(tt.args)
+			err = cmd.Execute()
+
+			mu.Lock()
+			defer mu.Unlock()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				if _, sent := calls["change_cat"]; sent {
+					t.Fatal("category must not be sent after failed validation")
+				}
+				if _, sent := calls["addurl"]; sent {
+					t.Fatal("NZB must not be added after failed validation")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute returned error: %v", err)
+			}
+			if got, ok := calls[tt.wantCall]; !ok || got != tt.wantValue {
+				t.Fatalf("expected %s with %q, got calls %v", tt.wantCall, tt.wantValue, calls)
+			}
+			if _, looked := calls["get_config"]; looked == strings.Contains(strings.Join(tt.args, " "), "--force") {
+				t.Fatalf("categories lookup should happen only without --force, calls %v", calls)
+			}
+		})
+	}
+}
+
+func TestCountCategoryUsage(t *testing.T) {
+	t.Parallel()
+
+	cats := []namedConfig{{Name: "*"}, {Name: "movies"}, {Name: "tv"}, {Name: "unused"}}
+	queue := []sabapi.QueueSlot{
+		{NZOID: "q1", Category: "movies"},
+		{NZOID: "q2", Category: "TV"},
+		{NZOID: "q3", Category: "tv"},
+		{NZOID: "q4", Category: "None"},
+		{NZOID: "q5", Category: "deleted"},
+	}
+	history := []sabapi.HistorySlot{
+		{NZOID: "h1", Category: "movies", Status: "Completed"},
+		{NZOID: "h2", Category: "movies", Status: "Failed"},
+		{NZOID: "h3", Category: "", Status: "Completed"},
+		{NZOID: "h4", Category: "tv", Status: "completed"},
+	}
+
+	got := countCategoryUsage(cats, queue, history)
+	want := []categoryUsage{
+		{Name: "*", Queued: 1, Completed: 1},
+		{Name: "movies", Queued: 1, Completed: 1},
+		{Name: "tv", Queued: 2, Completed: 1},
+		{Name: "unused"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("countCategoryUsage = %+v, want %+v", got, want)
+	}
+}
+
+func TestCategoriesListUsageJSON(t *testing.T) {
+	t.Parallel()
+
+	var historyLimit string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("mode") {
+		case "get_config":
+			_, _ = w.Write([]byte(autoCatCategories))
+		case "queue":
+			_, _ = w.Write([]byte(`{"queue":{"slots":[{"nzo_id":"q1","cat":"tv"},{"nzo_id":"q2","cat":"movies"},{"nzo_id":"q3","cat":"tv"}]}}`))
+		case "history":
+			historyLimit = r.URL.Query().Get("limit")
+			_, _ = w.Write([]byte(`{"history":{"slots":[{"nzo_id":"h1","category":"movies","status":"Completed"}]}}`))
+		default:
+			t.Errorf("unexpected mode %q", r.URL.Query().Get("mode"))
+		}
+	}))
+	t.Cleanup(server.Close)
+	client, err := sabapi.NewClient(server.URL, "secret")
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	var out bytes.Buffer
+	cmd := categoriesListCmd()
+	cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{
+		Client:  client,
+		Printer: &output.Printer{Out: &out, Err: &bytes.Buffer{}, JSON: true},
+	}))
+	cmd.SetArgs([]string{"--usage", "--history-limit", "20"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("categories list returned error: %v", err)
+	}
+	if historyLimit != "20" {
+		t.Fatalf("expected history limit 20, got %q", historyLimit)
+	}
+
+	var payload struct {
+		Categories []categoryUsage `json:"categories"`
+		Count      int             `json:"count"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("decode output: %v\n%s", err, out.String())
+	}
+	want := []categoryUsage{
+		{Name: "*"},
+		{Name: "movies", Queued: 1, Completed: 1},
+		{Name: "tv", Queued: 2},
+	}
+	if payload.Count != 3 || !reflect.DeepEqual(payload.Categories, want) {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+}
@@ -2,13 +2,24 @@ package root
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/avivsinai/sabx/internal/prompt"
+	"github.com/avivsinai/sabx/internal/ref"
 )
 
 const requestTimeout = 15 * time.Second
+const defaultPollMinInterval = 250 * time.Millisecond
 const jsonHelpSuffix = " (supports --json output)"
 const jsonLongNote = "Supports the global --json flag for machine-readable output. Errors return a non-zero exit code."
+const refLongNote = "Items may be referenced by NZO ID, by a unique prefix of the ID after SABnzbd_nzo_ (e.g. p86t), by 1-based position (#1 is the top item), or by name substring (@name)."
 
 func timeoutContext(parent context.Context) (context.Context, context.CancelFunc) {
 	return context.WithTimeout(parent, requestTimeout)
@@ -31,3 +42,95 @@ func appendJSONLong(base string) string {
 	}
 	return base + "\n\n" + jsonLongNote
 }
+
+// errAborted is returned when the user declines a confirmation prompt.
+var errAborted = errors.New("aborted")
+
+func bindYesFlag(flags *pflag.FlagSet, yes *bool) {
+	flags.BoolVar(yes, "yes", false, "Skip the confirmation prompt")
+}
+
+// confirmAction asks before a destructive operation unless --yes was given.
+// Without a terminal the command fails instead of guessing.
+func confirmAction(cmd *cobra.Command, yes bool, question string) error {
+	if yes {
+		return nil
+	}
+	in := cmd.InOrStdin()
+	if !prompt.IsTerminal(in) {
+		return fmt.Errorf("%w: re-run with --yes to confirm", prompt.ErrNotInteractive)
+	}
+	ok, err := prompt.New(in, cmd.ErrOrStderr()).Confirm(question, false)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errAborted
+	}
+	return nil
+}
+
+// resolveRefs maps reference tokens to NZO IDs. Plain IDs pass through
+// untouched; load is only invoked when an index or name reference is present.
+func resolveRefs(tokens []string, load func() ([]ref.Candidate, error)) ([]string, error) {
+	ids := make([]string, len(tokens))
+	var candidates []ref.Candidate
+	loaded := false
+	for i, token := range tokens {
+		parsed, err := ref.Parse(token)
+		if err != nil {
+			return nil, err
+		}
+		if parsed.Kind == ref.KindID {
+			ids[i] = parsed.ID
+			continue
+		}
+		if !loaded {
+			candidates, err = load()
+			if err != nil {
+				return nil, err
+			}
+			loaded = true
+		}
+		match, err := parsed.Resolve(candidates)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = match.ID
+	}
+	return ids, nil
+}
+
+// parseAgeDuration extends time.ParseDuration with day (d) and week (w)
+// units, e.g. "30d" or "2w".
+func parseAgeDuration(raw string) (time.Duration, error) {
+	value := strings.TrimSpace(strings.ToLower(raw))
+	if value == "" {
+		return 0, errors.New("duration required")
+	}
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(value, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(value, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+	var d time.Duration
+	if unit > 0 {
+		n, err := strconv.ParseFloat(value[:len(value)-1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", raw)
+		}
+		d = time.Duration(n * float64(unit))
+	} else {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, err
+		}
+		d = parsed
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration %q must be positive", raw)
+	}
+	return d, nil
+}
@@ -0,0 +1,47 @@
+package root
+
+import (
+	"testing"
+
+	"github.com/avivsinai/sabx/internal/ref"
+)
+
+func TestResolveRefsPassesIDsThrough(t *testing.T) {
+	t.Parallel()
+
+	ids, err := resolveRefs([]string{"SABnzbd_nzo_a", "SABnzbd_nzo_b"}, func() ([]ref.Candidate, error) {
+		t.Fatal("load should not be called for plain ids")
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("resolveRefs returned error: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != "SABnzbd_nzo_a" || ids[1] != "SABnzbd_nzo_b" {
+		t.Fatalf("unexpected ids: %v", ids)
+	}
+}
+
+func TestResolveRefsLoadsOnce(t *testing.T) {
+	t.Parallel()
+
+	loads := 0
+	ids, err := resolveRefs([]string{"#2", "SABnzbd_nzo_x", "@first"}, func() ([]ref.Candidate, error) {
+		loads++
+		return []ref.Candidate{
+			{ID: "SABnzbd_nzo_1", Name: "First.Job"},
+			{ID: "SABnzbd_nzo_2", Name: "Second.Job"},
+		}, nil
+	})
+	if err != nil {
+		t.Fatalf("resolveRefs returned error: %v", err)
+	}
+	if loads != 1 {
+		t.Fatalf("expected a single load, got %d", loads)
+	}
+	want := []string{"SABnzbd_nzo_2", "SABnzbd_nzo_x", "SABnzbd_nzo_1"}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("ids[%d] = %q, want %q", i, ids[i], want[i])
+		}
+	}
+}
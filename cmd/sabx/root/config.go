@@ -1,13 +1,24 @@
 package root
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/avivsinai/sabx/internal/auth"
+	"github.com/avivsinai/sabx/internal/config"
+	"github.com/avivsinai/sabx/internal/prompt"
+	"github.com/avivsinai/sabx/internal/sabapi"
 )
 
 func configCmd() *cobra.Command {
@@ -31,9 +42,11 @@ func configCmd() *cobra.Command {
 
 func configGetCmd() *cobra.Command {
 	var key string
+	var reveal bool
 	cmd := &cobra.Command{
 		Use:   "get <section>",
 		Short: jsonShort("Fetch configuration values"),
+		Long:  appendJSONLong("Fetch configuration values. API keys, passwords, and other secrets are masked unless --reveal is given; on a terminal --reveal asks for confirmation first."),
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			section := args[0]
@@ -42,6 +55,16 @@ func configGetCmd() *cobra.Command {
 				return err
 			}
 
+			if reveal && prompt.IsTerminal(cmd.InOrStdin()) {
+				ok, err := prompt.New(cmd.InOrStdin(), cmd.ErrOrStderr()).Confirm("Print unmasked secrets to the terminal?", false)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return errAborted
+				}
+			}
+
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 
@@ -50,32 +73,47 @@ func configGetCmd() *cobra.Command {
 				return err
 			}
 
-			return app.Printer.Print(cfg)
+			return app.Printer.Print(configGetPayload(cfg, reveal))
 		},
 	}
 	cmd.Flags().StringVar(&key, "key", "", "Specific keyword within the section")
+	cmd.Flags().BoolVar(&reveal, "reveal", false, "Show secret values instead of masking them")
 	return cmd
 }
 
+func configGetPayload(cfg map[string]any, reveal bool) map[string]any {
+	if reveal {
+		return cfg
+	}
+	return sanitiseConfig(cfg)
+}
+
 func configSetCmd() *cobra.Command {
 	var name string
 	var entries []string
+	var fromFile string
+	var strict bool
 	cmd := &cobra.Command{
 		Use:   "set <section>",
 		Short: jsonShort("Set configuration values"),
-		Args:  cobra.ExactArgs(1),
+		Long: appendJSONLong("Set configuration values in a section. Well-known boolean and integer keys are checked before sending; mismatches print a warning, or fail with --strict.\n\n" +
+			"--from-file reads a YAML or JSON map of key to scalar value (use - for stdin) and applies every key; booleans are sent as 1/0. " +
+			"--set pairs may be combined with it and take precedence for the same key."),
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(entries) == 0 {
-				return errors.New("provide at least one --set key=value pair")
+			if len(entries) == 0 && fromFile == "" {
+				return errors.New("provide at least one --set key=value pair or --from-file")
 			}
 			section := args[0]
-			app, err := getApp(cmd)
-			if err != nil {
-				return err
-			}
-			ctx, cancel := timeoutContext(cmd.Context())
-			defer cancel()
 
+			var pairs [][2]string
+			if fromFile != "" {
+				filePairs, err := readConfigSetFile(fromFile, cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+				pairs = filePairs
+			}
 			for _, entry := range entries {
 				parts := strings.SplitN(entry, "=", 2)
 				if len(parts) != 2 {
@@ -86,6 +124,29 @@ func configSetCmd() *cobra.Command {
 				if key == "" {
 					return fmt.Errorf("invalid key in --set entry %q", entry)
 				}
+				pairs = setConfigPair(pairs, key, val)
+			}
+
+			app, err := getApp(cmd)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := timeoutContext(cmd.Context())
+			defer cancel()
+
+			applied := make([]string, 0, len(pairs))
+			for _, pair := range pairs {
+				if err := validateConfigValue(section, pair[0], pair[1]); err != nil {
+					if strict {
+						return err
+					}
+					app.Printer.Error("warning: %v", err)
+				}
+				applied = append(applied, pair[0]+"="+pair[1])
+			}
+
+			for _, pair := range pairs {
+				key, val := pair[0], pair[1]
 				values := url.Values{}
 				values.Set("keyword", key)
 				values.Add("value", val)
@@ -95,18 +156,163 @@ func configSetCmd() *cobra.Command {
 			}
 
 			if app.Printer.JSON {
-				payload := map[string]any{"section": section, "name": name, "applied": entries}
+				payload := map[string]any{"section": section, "name": name, "applied": applied, "count": len(applied)}
 				return app.Printer.Print(payload)
 			}
-			return app.Printer.Print("Config updated")
+			return app.Printer.Print(fmt.Sprintf("Config updated (%d keys applied)", len(applied)))
 		},
 	}
 
 	cmd.Flags().StringVar(&name, "name", "", "Named configuration item (RSS feed, server, etc.)")
 	cmd.Flags().StringArrayVar(&entries, "set", nil, "Key=value pairs (repeat for multiple keys)")
+	cmd.Flags().StringVar(&fromFile, "from-file", "", "YAML or JSON file mapping keys to values (- for stdin)")
+	cmd.Flags().BoolVar(&strict, "strict", false, "Fail instead of warning when a well-known key has an invalid value")
 	return cmd
 }
 
+// setConfigPair sets key in pairs, replacing an earlier value in place.
+func setConfigPair(pairs [][2]string, key, val string) [][2]string {
+	for i := range pairs {
+		if pairs[i][0] == key {
+			pairs[i][1] = val
+			return pairs
+		}
+	}
+	return append(pairs, [2]string{key, val})
+}
+
+// readConfigSetFile loads a key→value map for `config set --from-file`.
+// JSON is accepted as YAML. Keys are returned sorted so runs are repeatable.
+func readConfigSetFile(path string, stdin io.Reader) ([][2]string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read --from-file: %w", err)
+	}
+
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse %s: expected a map of key to value: %w", path, err)
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("%s contains no settings", path)
+	}
+
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([][2]string, 0, len(keys))
+	for _, key := range keys {
+		if strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("%s: empty key", path)
+		}
+		val, err := configScalar(raw[key])
+		if err != nil {
+			return nil, fmt.Errorf("%s: key %q: %w", path, key, err)
+		}
+		pairs = append(pairs, [2]string{strings.TrimSpace(key), val})
+	}
+	return pairs, nil
+}
+
+// configScalar renders a decoded YAML value the way SABnzbd expects it.
+func configScalar(v any) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return strings.TrimSpace(val), nil
+	case bool:
+		if val {
+			return "1", nil
+		}
+		return "0", nil
+	case int:
+		return strconv.Itoa(val), nil
+	case int64:
+		return strconv.FormatInt(val, 10), nil
+	case uint64:
+		return strconv.FormatUint(val, 10), nil
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), nil
+	case nil:
+		return "", errors.New("value is null; use \"\" to clear a setting")
+	default:
+		return "", fmt.Errorf("value must be a string, number or boolean, got %T", v)
+	}
+}
+
+type configValueKind int
+
+const (
+	configBool configValueKind = iota
+	configInt
+)
+
+// knownConfigKeys lists value types for commonly edited keys per section.
+// Keys missing here are sent unchecked.
+var knownConfigKeys = map[string]map[string]configValueKind{
+	"misc": {
+		"auto_browser":             configBool,
+		"direct_unpack":            configBool,
+		"enable_https":             configBool,
+		"enable_par_cleanup":       configBool,
+		"enable_unrar":             configBool,
+		"enable_7zip":              configBool,
+		"pause_on_post_processing": configBool,
+		"pre_check":                configBool,
+		"safe_postproc":            configBool,
+		"top_only":                 configBool,
+		"https_port":               configInt,
+		"max_art_tries":            configInt,
+		"port":                     configInt,
+		"queue_limit":              configInt,
+	},
+	"servers": {
+		"enable":      configBool,
+		"optional":    configBool,
+		"required":    configBool,
+		"ssl":         configBool,
+		"connections": configInt,
+		"port":        configInt,
+		"priority":    configInt,
+		"retention":   configInt,
+		"ssl_verify":  configInt,
+		"timeout":     configInt,
+	},
+	"categories": {
+		"pp":       configInt,
+		"priority": configInt,
+	},
+}
+
+// validateConfigValue checks value against the known type of section/key.
+func validateConfigValue(section, key, value string) error {
+	kind, ok := knownConfigKeys[strings.ToLower(section)][strings.ToLower(key)]
+	if !ok {
+		return nil
+	}
+	switch kind {
+	case configBool:
+		switch strings.ToLower(value) {
+		case "0", "1", "true", "false":
+			return nil
+		}
+		return fmt.Errorf("%s.%s expects a boolean (0/1/true/false), got %q", section, key, value)
+	case configInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%s.%s expects an integer, got %q", section, key, value)
+		}
+	}
+	return nil
+}
+
 func configDeleteCmd() *cobra.Command {
 	var name string
 	var key string
@@ -181,9 +387,14 @@ func configSetPauseCmd() *cobra.Command {
 }
 
 func configRotateAPIKeyCmd() *cobra.Command {
+	var updateLocal bool
 	cmd := &cobra.Command{
 		Use:   "rotate-api-key",
 		Short: jsonShort("Generate a new SABnzbd API key"),
+		Long: appendJSONLong("Generate a new SABnzbd API key. The old key stops working immediately, so every other client " +
+			"(Sonarr, Radarr, browser extensions) must be updated.\n\n" +
+			"With --update-local the new key is written to wherever the active profile keeps its key (keyring or config file), " +
+			"so later sabx commands keep working without another login."),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			app, err := getApp(cmd)
 			if err != nil {
@@ -196,15 +407,89 @@ func configRotateAPIKeyCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			app.Printer.Error("warning: the previous API key no longer works; update any other clients that use it")
+
+			var location string
+			var updateErr error
+			if updateLocal {
+				location, updateErr = updateLocalAPIKey(app.Config, app.ProfileName, app.BaseURL, key, keyringAPIKeyStore{})
+			}
+
 			if app.Printer.JSON {
-				return app.Printer.Print(map[string]any{"api_key": key})
+				payload := map[string]any{"api_key": key}
+				if updateLocal {
+					payload["updated_local"] = updateErr == nil
+					if location != "" {
+						payload["stored_in"] = location
+					}
+				}
+				if err := app.Printer.Print(payload); err != nil {
+					return err
+				}
+			} else {
+				if err := app.Printer.Print(fmt.Sprintf("New API key: %s", key)); err != nil {
+					return err
+				}
+				if updateLocal && updateErr == nil {
+					if err := app.Printer.Print(fmt.Sprintf("Updated profile %s (%s)", app.ProfileName, location)); err != nil {
+						return err
+					}
+				}
+			}
+			if updateErr != nil {
+				return fmt.Errorf("API key rotated but local profile not updated; run 'sabx login' with the new key: %w", updateErr)
 			}
-			return app.Printer.Print(fmt.Sprintf("New API key: %s", key))
+			return nil
 		},
 	}
+	cmd.Flags().BoolVar(&updateLocal, "update-local", false, "Store the new key for the active profile so sabx keeps working")
 	return cmd
 }
 
+// apiKeyStore persists an API key for a profile outside the config file.
+type apiKeyStore interface {
+	Save(profile, baseURL, apiKey string, allowFallback bool) error
+}
+
+// keyringAPIKeyStore saves keys in the OS keyring, the same store login uses.
+type keyringAPIKeyStore struct{}
+
+func (keyringAPIKeyStore) Save(profile, baseURL, apiKey string, allowFallback bool) error {
+	opts := []auth.Option{}
+	if allowFallback || auth.AllowInsecureStoreFromEnv() {
+		opts = append(opts, auth.WithAllowFileFallback(true))
+	}
+	return auth.SaveAPIKey(profile, baseURL, apiKey, opts...)
+}
+
+// updateLocalAPIKey replaces the stored key for profile after a rotation. A
+// profile that keeps its key in the config file is rewritten there; otherwise
+// the key goes to store. It returns where the key was written.
+func updateLocalAPIKey(cfg *config.Config, profile, baseURL, apiKey string, store apiKeyStore) (string, error) {
+	if cfg == nil || profile == "" {
+		return "", errors.New("no active profile")
+	}
+	prof, ok := cfg.GetProfile(profile)
+	if !ok {
+		return "", fmt.Errorf("profile %q is not saved; the key came from flags or environment", profile)
+	}
+	if prof.APIKey != "" {
+		prof.APIKey = apiKey
+		cfg.SetProfile(profile, prof)
+		if err := cfg.Save(); err != nil {
+			return "", err
+		}
+		return "config", nil
+	}
+	if baseURL == "" {
+		baseURL = prof.BaseURL
+	}
+	if err := store.Save(profile, baseURL, apiKey, prof.AllowInsecureStore); err != nil {
+		return "", fmt.Errorf("store api key: %w", err)
+	}
+	return "keyring", nil
+}
+
 func configRotateNZBKeyCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "rotate-nzb-key",
@@ -259,9 +544,12 @@ func configRegenerateCertsCmd() *cobra.Command {
 }
 
 func configCreateBackupCmd() *cobra.Command {
+	var download string
 	cmd := &cobra.Command{
 		Use:   "backup",
 		Short: jsonShort("Create a SABnzbd configuration backup"),
+		Long: appendJSONLong("Create a configuration backup on the SABnzbd host. With --download <dest> the backup is verified via the browse API and copied to dest when its path is readable from this machine (same host or shared mount). " +
+			"SABnzbd has no API to fetch the file, so for remote hosts the server-side path is reported with guidance and the command exits non-zero."),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			app, err := getApp(cmd)
 			if err != nil {
@@ -274,18 +562,101 @@ func configCreateBackupCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if download == "" || !success {
+				if app.Printer.JSON {
+					return app.Printer.Print(map[string]any{"success": success, "path": path})
+				}
+				if success {
+					return app.Printer.Print(fmt.Sprintf("Backup saved to %s", path))
+				}
+				return app.Printer.Print("No backup created")
+			}
+
+			verified := remoteFileExists(ctx, app.Client, path)
+			saved, copyErr := copyLocalFile(path, download)
 			if app.Printer.JSON {
-				return app.Printer.Print(map[string]any{"success": success, "path": path})
+				payload := map[string]any{"success": success, "path": path, "verified": verified, "downloaded": copyErr == nil}
+				if copyErr == nil {
+					payload["dest"] = saved
+				}
+				if err := app.Printer.Print(payload); err != nil {
+					return err
+				}
+			} else if copyErr == nil {
+				if err := app.Printer.Print(fmt.Sprintf("Backup saved to %s and copied to %s", path, saved)); err != nil {
+					return err
+				}
+			} else {
+				state := "exists on the SABnzbd host"
+				if !verified {
+					state = "could not be confirmed via browse"
+				}
+				if err := app.Printer.Print(fmt.Sprintf("Backup saved to %s (%s)", path, state)); err != nil {
+					return err
+				}
+				app.Printer.Error("The file is not readable from this machine; copy it from the SABnzbd host (e.g. scp host:%s %s) or download it from the web UI.", path, download)
 			}
-			if success {
-				return app.Printer.Print(fmt.Sprintf("Backup saved to %s", path))
+			if copyErr != nil {
+				return fmt.Errorf("backup not downloaded: %w", copyErr)
 			}
-			return app.Printer.Print("No backup created")
+			return nil
 		},
 	}
+	cmd.Flags().StringVar(&download, "download", "", "Copy the created backup to this local file or directory")
 	return cmd
 }
 
+// remoteFileExists reports whether the browse API lists remotePath in its
+// parent directory. Errors count as not found.
+func remoteFileExists(ctx context.Context, client *sabapi.Client, remotePath string) bool {
+	idx := strings.LastIndexAny(remotePath, `/\`)
+	if idx < 0 {
+		return false
+	}
+	dir, base := remotePath[:idx+1], remotePath[idx+1:]
+	entries, err := client.Browse(ctx, dir, sabapi.BrowseOptions{ShowFiles: true})
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if entry.Dir {
+			continue
+		}
+		if entry.Path == remotePath || entry.Name == base {
+			return true
+		}
+	}
+	return false
+}
+
+// copyLocalFile copies src to dest (a file, or a directory to copy into)
+// and returns the written path.
+func copyLocalFile(src, dest string) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	if info, err := in.Stat(); err != nil {
+		return "", err
+	} else if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", src)
+	}
+
+	if info, err := os.Stat(dest); err == nil && info.IsDir() {
+		dest = filepath.Join(dest, filepath.Base(src))
+	}
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return "", err
+	}
+	return dest, out.Close()
+}
+
 func configPurgeLogsCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "purge-logs",
@@ -0,0 +1,372 @@
+package root
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/config"
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+func TestValidateConfigValue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		section string
+		key     string
+		value   string
+		wantErr bool
+	}{
+		{section: "misc", key: "pre_check", value: "1"},
+		{section: "misc", key: "pre_check", value: "True"},
+		{section: "misc", key: "direct_unpack", value: "tru", wantErr: true},
+		{section: "misc", key: "port", value: "8080"},
+		{section: "misc", key: "port", value: "80a", wantErr: true},
+		{section: "MISC", key: "Max_Art_Tries", value: "three", wantErr: true},
+		{section: "servers", key: "connections", value: "20"},
+		{section: "misc", key: "unknown_key", value: "anything"},
+		{section: "rss", key: "enable", value: "maybe"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.section+"."+tt.key+"="+tt.value, func(t *testing.T) {
+			t.Parallel()
+			err := validateConfigValue(tt.section, tt.key, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateConfigValue error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigGetPayloadMasksUnlessRevealed(t *testing.T) {
+	t.Parallel()
+
+	raw := map[string]any{
+		"config": map[string]any{
+			"misc": map[string]any{
+				"api_key": "0123456789abcdef",
+				"port":    "8080",
+			},
+		},
+	}
+
+	masked := configGetPayload(raw, false)
+	misc := masked["config"].(map[string]any)["misc"].(map[string]any)
+	if misc["api_key"] != "***" {
+		t.Fatalf("expected api_key masked, got %v", misc["api_key"])
+	}
+	if misc["port"] != "8080" {
+		t.Fatalf("expected non-secret value untouched, got %v", misc["port"])
+	}
+
+	revealed := configGetPayload(raw, true)
+	misc = revealed["config"].(map[string]any)["misc"].(map[string]any)
+	if misc["api_key"] != "0123456789abcdef" {
+		t.Fatalf("expected api_key revealed, got %v", misc["api_key"])
+	}
+}
+
+func runConfigBackup(t *testing.T, backupPath string, jsonOut bool, args ...string) (string, string, error) {
+	t.Helper()
+
+	dir, base := filepath.Dir(backupPath), filepath.Base(backupPath)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		q := r.URL.Query()
+		switch q.Get("mode") {
+		case "config":
+			resp, _ := json.Marshal(map[string]any{"value": map[string]any{"result": true, "message": backupPath}})
+			_, _ = w.Write(resp)
+		case "browse":
+			resp, _ := json.Marshal(map[string]any{"paths": []map[string]any{
+				{"name": "..", "path": filepath.Dir(dir), "dir": true},
+				{"name": base, "path": backupPath},
+			}})
+			_, _ = w.Write(resp)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	var out, errOut bytes.Buffer
+	cmd := configCreateBackupCmd()
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{Client: client, Printer: &output.Printer{JSON: jsonOut, Out: &out, Err: &errOut}}))
+	cmd.SetArgs(args)
+	err = cmd.Execute()
+	return out.String(), errOut.String(), err
+}
+
+func TestConfigBackupDownloadReportsRemotePath(t *testing.T) {
+	t.Parallel()
+
+	remote := filepath.Join(t.TempDir(), "missing", "sabnzbd_config_backup.zip")
+	dest := t.TempDir()
+
+	out, errOut, err := runConfigBackup(t, remote, false, "--download", dest)
+	if err == nil || !strings.Contains(err.Error(), "not downloaded") {
+		t.Fatalf("expected not-downloaded error, got %v", err)
+	}
+	if !strings.Contains(out, remote) || !strings.Contains(out, "exists on the SABnzbd host") {
+		t.Fatalf("expected verified remote path in output, got %q", out)
+	}
+	if !strings.Contains(errOut, "copy it from the SABnzbd host") {
+		t.Fatalf("expected guidance on stderr, got %q", errOut)
+	}
+
+	out, _, err = runConfigBackup(t, remote, true, "--download", dest)
+	if err == nil {
+		t.Fatal("expected error in JSON mode too")
+	}
+	var payload map[string]any
+	if jsonErr := json.Unmarshal([]byte(out), &payload); jsonErr != nil {
+		t.Fatalf("decode payload: %v\n%s", jsonErr, out)
+	}
+	if payload["path"] != remote || payload["verified"] != true || payload["downloaded"] != false {
+		t.Fatalf("unexpected payload %v", payload)
+	}
+}
+
+func TestConfigBackupDownloadCopiesReadableFile(t *testing.T) {
+	t.Parallel()
+
+	backup := filepath.Join(t.TempDir(), "sabnzbd_config_backup.zip")
+	if err := os.WriteFile(backup, []byte("zip-bytes"), 0o600); err != nil {
+		t.Fatalf("write backup: %v", err)
+	}
+	dest := t.TempDir()
+
+	out, _, err := runConfigBackup(t, backup, false, "--download", dest)
+	if err != nil {
+		t.Fatalf("backup --download returned error: %v", err)
+	}
+	copied := filepath.Join(dest, "sabnzbd_config_backup.zip")
+	data, err := os.ReadFile(copied)
+	if err != nil || string(data) != "zip-bytes" {
+		t.Fatalf("expected backup copied to %s, got %q (%v)", copied, data, err)
+	}
+	if !strings.Contains(out, copied) {
+		t.Fatalf("expected destination in output, got %q", out)
+	}
+}
+
+func TestConfigSetFromFileAppliesEachKey(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var calls []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mu.Lock()
+		calls = append(calls, r.URL.Query())
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"status": true}`))
+	}))
+	t.Cleanup(server.Close)
+
+	settings := filepath.Join(t.TempDir(), "settings.yaml")
+	yamlBody := "enable: true\nretention: 3000\nhost: news.example.com\nssl_ciphers: \"\"\n"
+	if err := os.WriteFile(settings, []byte(yamlBody), 0o600); err != nil {
+		t.Fatalf("write settings: %v", err)
+	}
+
+	client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	var out bytes.Buffer
+	cmd := configSetCmd()
+	cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{Client: client, Printer: &output.Printer{JSON: true, Out: &out}}))
+	cmd.SetArgs([]string{"servers", "--name", "primary", "--from-file", settings, "--set", "retention=4000"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("config set returned error: %v", err)
+	}
+
+	want := [][2]string{{"enable", "1"}, {"host", "news.example.com"}, {"retention", "4000"}, {"ssl_ciphers", ""}}
+	if len(calls) != len(want) {
+		t.Fatalf("expected %d set_config calls, got %d: %v", len(want), len(calls), calls)
+	}
+	for i, q := range calls {
+		if q.Get("mode") != "set_config" || q.Get("section") != "servers" || q.Get("name") != "primary" {
+			t.Fatalf("call %d: unexpected request %v", i, q)
+		}
+		if q.Get("keyword") != want[i][0] || q.Get("value") != want[i][1] {
+			t.Fatalf("call %d: got %s=%q, want %s=%q", i, q.Get("keyword"), q.Get("value"), want[i][0], want[i][1])
+		}
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("decode output: %v\n%s", err, out.String())
+	}
+	if payload["count"] != float64(4) {
+		t.Fatalf("expected count 4, got %v", payload)
+	}
+}
+
+func TestReadConfigSetFileRejectsInvalidInput(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{name: "not a map", body: "- a\n- b\n", wantErr: "expected a map"},
+		{name: "nested value", body: "servers:\n  host: x\n", wantErr: `key "servers"`},
+		{name: "list value", body: "schedules: [a, b]\n", wantErr: "must be a string, number or boolean"},
+		{name: "null value", body: "host:\n", wantErr: "null"},
+		{name: "empty", body: "", wantErr: "no settings"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := readConfigSetFile("-", strings.NewReader(tt.body))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	pairs, err := readConfigSetFile("-", strings.NewReader(`{"cache_limit": "1G", "pre_check": false, "ratio": 1.5}`))
+	if err != nil {
+		t.Fatalf("JSON input returned error: %v", err)
+	}
+	want := [][2]string{{"cache_limit", "1G"}, {"pre_check", "0"}, {"ratio", "1.5"}}
+	if !reflect.DeepEqual(pairs, want) {
+		t.Fatalf("pairs = %v, want %v", pairs, want)
+	}
+}
+
+type fakeAPIKeyStore struct {
+	saved map[string]string
+	err   error
+}
+
+func (f *fakeAPIKeyStore) Save(profile, baseURL, apiKey string, allowFallback bool) error {
+	if f.err != nil {
+		return f.err
+	}
+	if f.saved == nil {
+		f.saved = map[string]string{}
+	}
+	f.saved[profile+"|"+baseURL] = apiKey
+	return nil
+}
+
+func TestUpdateLocalAPIKey(t *testing.T) {
+	t.Setenv("SABX_CONFIG_DIR", t.TempDir())
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+	cfg.SetProfile("home", config.Profile{BaseURL: "http://sab.local:8080"})
+	cfg.SetProfile("plain", config.Profile{BaseURL: "http://nas:8080", APIKey: "old-key"})
+
+	store := &fakeAPIKeyStore{}
+	where, err := updateLocalAPIKey(cfg, "home", "http://sab.local:8080", "new-key", store)
+	if err != nil || where != "keyring" {
+		t.Fatalf("keyring profile: got %q, %v", where, err)
+	}
+	if store.saved["home|http://sab.local:8080"] != "new-key" {
+		t.Fatalf("expected fake store updated, got %v", store.saved)
+	}
+
+	where, err = updateLocalAPIKey(cfg, "plain", "http://nas:8080", "rotated", store)
+	if err != nil || where != "config" {
+		t.Fatalf("config profile: got %q, %v", where, err)
+	}
+	reloaded, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+	if prof, _ := reloaded.GetProfile("plain"); prof.APIKey != "rotated" {
+		t.Fatalf("expected config key rotated, got %q", prof.APIKey)
+	}
+	if len(store.saved) != 1 {
+		t.Fatalf("config-stored profile must not touch the keyring, got %v", store.saved)
+	}
+
+	if _, err := updateLocalAPIKey(cfg, "adhoc", "http://other", "k", store); err == nil {
+		t.Fatal("expected error for unsaved profile")
+	}
+	if _, err := updateLocalAPIKey(cfg, "home", "http://sab.local:8080", "k", &fakeAPIKeyStore{err: errors.New("locked")}); err == nil || !strings.Contains(err.Error(), "locked") {
+		t.Fatalf("expected store error, got %v", err)
+	}
+}
+
+func TestConfigRotateAPIKeyUpdatesLocalProfile(t *testing.T) {
+	t.Setenv("SABX_CONFIG_DIR", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"apikey":"fresh-key"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+	cfg.SetProfile("ci", config.Profile{BaseURL: server.URL, APIKey: "stale-key"})
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("cfg.Save: %v", err)
+	}
+
+	client, err := sabapi.NewClient(server.URL, "stale-key", sabapi.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	var out, errOut bytes.Buffer
+	cmd := configRotateAPIKeyCmd()
+	cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{
+		Client:      client,
+		Config:      cfg,
+		ProfileName: "ci",
+		BaseURL:     server.URL,
+		Printer:     &output.Printer{JSON: true, Out: &out, Err: &errOut},
+	}))
+	cmd.SetArgs([]string{"--update-local"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("rotate-api-key returned error: %v", err)
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("decode output: %v\n%s", err, out.String())
+	}
+	if payload["api_key"] != "fresh-key" || payload["updated_local"] != true || payload["stored_in"] != "config" {
+		t.Fatalf("unexpected payload %v", payload)
+	}
+	if !strings.Contains(errOut.String(), "update any other clients") {
+		t.Fatalf("expected warning about other clients, got %q", errOut.String())
+	}
+	reloaded, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+	if prof, _ := reloaded.GetProfile("ci"); prof.APIKey != "fresh-key" {
+		t.Fatalf("expected stored key updated, got %q", prof.APIKey)
+	}
+}
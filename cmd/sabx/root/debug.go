@@ -1,11 +1,15 @@
 package root
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/spf13/cobra"
 
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/prompt"
 	"github.com/avivsinai/sabx/internal/sabapi"
 )
 
@@ -16,6 +20,7 @@ func debugCmd() *cobra.Command {
 	}
 	cmd.AddCommand(debugGCStatsCmd())
 	cmd.AddCommand(debugEvalSortCmd())
+	cmd.AddCommand(debugTranslateCmd())
 	return cmd
 }
 
@@ -62,22 +67,42 @@ func debugGCStatsCmd() *cobra.Command {
 func debugEvalSortCmd() *cobra.Command {
 	var job string
 	var label string
+	var interactive bool
 
 	cmd := &cobra.Command{
 		Use:   "eval-sort <expression>",
 		Short: jsonShort("Evaluate a sorting expression"),
-		Args:  cobra.ExactArgs(1),
+		Long: appendJSONLong("Evaluates a SABnzbd sorting expression against a sample job. " +
+			"With --interactive, expressions are read one per line from stdin until EOF, reusing --job and --label for every evaluation."),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if interactive {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			expr := args[0]
 			app, err := getApp(cmd)
 			if err != nil {
 				return err
 			}
 
-			ctx, cancel := timeoutContext(cmd.Context())
-			defer cancel()
+			opts := sabapi.EvalSortOptions{JobName: job, MultipartLabel: label}
+			eval := func(expr string) (string, error) {
+				ctx, cancel := timeoutContext(cmd.Context())
+				defer cancel()
+				return app.Client.EvalSort(ctx, expr, opts)
+			}
+
+			if interactive {
+				var promptOut io.Writer
+				if prompt.IsTerminal(cmd.InOrStdin()) {
+					promptOut = cmd.ErrOrStderr()
+				}
+				return runEvalSortREPL(cmd.InOrStdin(), promptOut, app.Printer, eval)
+			}
 
-			result, err := app.Client.EvalSort(ctx, expr, sabapi.EvalSortOptions{JobName: job, MultipartLabel: label})
+			expr := args[0]
+			result, err := eval(expr)
 			if err != nil {
 				return err
 			}
@@ -94,5 +119,82 @@ func debugEvalSortCmd() *cobra.Command {
 
 	cmd.Flags().StringVar(&job, "job", "", "Sample job name for the evaluation")
 	cmd.Flags().StringVar(&label, "label", "", "Multipart label for the evaluation")
+	cmd.Flags().BoolVar(&interactive, "interactive", false, "Read expressions line by line from stdin until EOF")
+	return cmd
+}
+
+// runEvalSortREPL evaluates each non-blank line of in. Failed evaluations are
+// reported and the session continues; promptOut, when set, receives a prompt.
+func runEvalSortREPL(in io.Reader, promptOut io.Writer, printer *output.Printer, eval func(string) (string, error)) error {
+	scanner := bufio.NewScanner(in)
+	for {
+		if promptOut != nil {
+			fmt.Fprint(promptOut, "sort> ")
+		}
+		if !scanner.Scan() {
+			break
+		}
+		expr := strings.TrimSpace(scanner.Text())
+		if expr == "" {
+			continue
+		}
+
+		result, err := eval(expr)
+		if err != nil {
+			printer.AlwaysError("%s: %v", expr, err)
+			continue
+		}
+		if printer.JSON {
+			if err := printer.Print(map[string]any{"expression": expr, "result": result}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := printer.Print(result); err != nil {
+			return err
+		}
+	}
+	if promptOut != nil {
+		fmt.Fprintln(promptOut)
+	}
+	return scanner.Err()
+}
+
+func debugTranslateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "translate <key...>",
+		Short: jsonShort("Translate several SABnzbd strings at once"),
+		Long:  appendJSONLong("Resolve multiple SABnzbd translation keys concurrently and print key/value pairs in argument order."),
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, err := getApp(cmd)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := timeoutContext(cmd.Context())
+			defer cancel()
+
+			translations, err := app.Client.TranslateMany(ctx, args)
+			if err != nil {
+				return err
+			}
+
+			if app.Printer.JSON {
+				return app.Printer.Print(map[string]any{"translations": translations})
+			}
+
+			rows := make([][]string, 0, len(args))
+			seen := make(map[string]bool, len(args))
+			for _, key := range args {
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				rows = append(rows, []string{key, translations[key]})
+			}
+			return app.Printer.Table([]string{"Key", "Translation"}, rows)
+		},
+	}
 	return cmd
 }
@@ -0,0 +1,65 @@
+package root
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+func TestDebugEvalSortInteractive(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		if q.Get("sort_string") == "%bad" {
+			http.Error(w, "invalid sort string", http.StatusInternalServerError)
+			return
+		}
+		// Echo the expression together with the session's job and label.
+		result := q.Get("sort_string") + "|" + q.Get("job_name") + "|" + q.Get("multipart_label")
+		_ = json.NewEncoder(w).Encode(map[string]string{"result": result})
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	var out, errOut bytes.Buffer
+	app := &cobraext.App{Client: client, Printer: &output.Printer{Out: &out, Err: &errOut}}
+
+	cmd := debugEvalSortCmd()
+	cmd.SetContext(cobraext.WithApp(context.Background(), app))
+	cmd.SetIn(strings.NewReader("%sn\n\n%bad\n%y/%sn\n"))
+	cmd.SetArgs([]string{"--interactive", "--job", "Show.S01E01", "--label", "CD1"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+
+	want := "%sn|Show.S01E01|CD1\n%y/%sn|Show.S01E01|CD1\n"
+	if out.String() != want {
+		t.Fatalf("unexpected REPL output %q, want %q", out.String(), want)
+	}
+	if !strings.Contains(errOut.String(), "%bad") {
+		t.Fatalf("expected failed expression reported, got %q", errOut.String())
+	}
+}
+
+func TestDebugEvalSortInteractiveRejectsArgs(t *testing.T) {
+	t.Parallel()
+
+	cmd := debugEvalSortCmd()
+	cmd.SetArgs([]string{"--interactive", "%sn"})
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected error when combining --interactive with an expression")
+	}
+}
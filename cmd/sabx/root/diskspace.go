@@ -0,0 +1,171 @@
+package root
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+func bindCheckSpaceFlag(flags *pflag.FlagSet, check *bool) {
+	flags.BoolVar(check, "check-space", false, "Refuse to add when the download or complete directory lacks room for the queue plus this NZB")
+}
+
+// spaceEstimate compares what the queue (plus the new NZB, when its size is
+// known) still needs against free space in SABnzbd's download and complete
+// directories. All sizes are in MB.
+type spaceEstimate struct {
+	QueueLeftMB    float64
+	NZBMB          float64
+	NZBSizeKnown   bool
+	NeededMB       float64
+	DownloadFreeMB float64
+	CompleteFreeMB float64
+	DownloadDir    string
+	CompleteDir    string
+}
+
+// estimateSpace reads SABnzbd's diskspace1/diskspace2 (free GB in the
+// download and complete directories) from a fullstatus payload. A negative
+// nzbBytes means the NZB's size is unknown.
+func estimateSpace(status map[string]any, queueLeftMB float64, nzbBytes int64) (spaceEstimate, error) {
+	downloadGB, err := statusFloat(status, "diskspace1")
+	if err != nil {
+		return spaceEstimate{}, err
+	}
+	completeGB, err := statusFloat(status, "diskspace2")
+	if err != nil {
+		return spaceEstimate{}, err
+	}
+	est := spaceEstimate{
+		QueueLeftMB:    queueLeftMB,
+		DownloadFreeMB: downloadGB * 1024,
+		CompleteFreeMB: completeGB * 1024,
+		DownloadDir:    statusString(status, "downloaddir"),
+		CompleteDir:    statusString(status, "completedir"),
+	}
+	if nzbBytes >= 0 {
+		est.NZBSizeKnown = true
+		est.NZBMB = float64(nzbBytes) / (1 << 20)
+	}
+	est.NeededMB = est.QueueLeftMB + est.NZBMB
+	return est, nil
+}
+
+// shortfall describes which directory cannot hold NeededMB, or returns ""
+// when both can.
+func (e spaceEstimate) shortfall() string {
+	var short []string
+	if e.NeededMB > e.DownloadFreeMB {
+		short = append(short, fmt.Sprintf("download dir %s has %s free", e.DownloadDir, humanBytes(e.DownloadFreeMB*(1<<20))))
+	}
+	if e.NeededMB > e.CompleteFreeMB {
+		short = append(short, fmt.Sprintf("complete dir %s has %s free", e.CompleteDir, humanBytes(e.CompleteFreeMB*(1<<20))))
+	}
+	if len(short) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("need %s but %s", humanBytes(e.NeededMB*(1<<20)), strings.Join(short, " and "))
+}
+
+// checkFreeSpace refuses the add when SABnzbd's directories cannot hold the
+// remaining queue plus the NZB. Pass a negative nzbBytes when the size is
+// unknown; only the queued backlog is then checked and a warning says so.
+func checkFreeSpace(ctx context.Context, app *cobraext.App, nzbBytes int64) error {
+	status, err := app.Client.FullStatus(ctx, sabapi.FullStatusOptions{})
+	if err != nil {
+		return fmt.Errorf("check free space: %w", err)
+	}
+	queue, err := app.Client.Queue(ctx, 0, 1, "")
+	if err != nil {
+		return fmt.Errorf("check free space: %w", err)
+	}
+	queueLeft, err := sabapi.ParseSABFloat(queue.MBLeft)
+	if err != nil {
+		return fmt.Errorf("check free space: queue mbleft: %w", err)
+	}
+	est, err := estimateSpace(status, queueLeft, nzbBytes)
+	if err != nil {
+		return fmt.Errorf("check free space: %w", err)
+	}
+	if !est.NZBSizeKnown {
+		app.Printer.Error("warning: NZB size unknown; --check-space only covers the queued downloads")
+	}
+	if msg := est.shortfall(); msg != "" {
+		return fmt.Errorf("not enough free space: %s; drop --check-space to add anyway", msg)
+	}
+	return nil
+}
+
+// nzbDeclaredBytes sums the segment sizes an NZB declares, which is what
+// SABnzbd will download for it.
+func nzbDeclaredBytes(path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var total int64
+	dec := xml.NewDecoder(f)
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return total, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("read nzb %s: %w", path, err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "segment" {
+			continue
+		}
+		for _, attr := range start.Attr {
+			if attr.Name.Local != "bytes" {
+				continue
+			}
+			n, err := strconv.ParseInt(strings.TrimSpace(attr.Value), 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("read nzb %s: invalid segment size %q", path, attr.Value)
+			}
+			total += n
+		}
+	}
+}
+
+// declaredSizeOrUnknown returns the NZB's declared size, or -1 when it cannot
+// be read (for example a compressed upload); the add itself will report any
+// real problem with the file.
+func declaredSizeOrUnknown(path string) int64 {
+	size, err := nzbDeclaredBytes(path)
+	if err != nil {
+		return -1
+	}
+	return size
+}
+
+func statusFloat(status map[string]any, key string) (float64, error) {
+	raw, ok := status[key]
+	if !ok || raw == nil {
+		return 0, fmt.Errorf("fullstatus has no %s", key)
+	}
+	v, err := sabapi.ParseSABFloat(fmt.Sprint(raw))
+	if err != nil {
+		return 0, fmt.Errorf("fullstatus %s: %w", key, err)
+	}
+	return v, nil
+}
+
+func statusString(status map[string]any, key string) string {
+	s, _ := status[key].(string)
+	return s
+}
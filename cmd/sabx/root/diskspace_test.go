@@ -0,0 +1,134 @@
+package root
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+func TestEstimateSpaceDecision(t *testing.T) {
+	t.Parallel()
+
+	status := map[string]any{
+		"diskspace1":  "10.00",
+		"diskspace2":  2.5,
+		"downloaddir": "/incomplete",
+		"completedir": "/complete",
+	}
+	tests := []struct {
+		name      string
+		queueMB   float64
+		nzbBytes  int64
+		wantShort string
+	}{
+		{name: "fits both", queueMB: 1000, nzbBytes: 500 << 20},
+		{name: "unknown size only counts queue", queueMB: 2000, nzbBytes: -1},
+		{name: "complete dir too small", queueMB: 2000, nzbBytes: 1 << 30, wantShort: "complete dir /complete"},
+		{name: "both too small", queueMB: 9000, nzbBytes: 2 << 30, wantShort: "download dir /incomplete has 10.00 GB free and complete dir"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			est, err := estimateSpace(status, tt.queueMB, tt.nzbBytes)
+			if err != nil {
+				t.Fatalf("estimateSpace returned error: %v", err)
+			}
+			if est.NZBSizeKnown != (tt.nzbBytes >= 0) {
+				t.Fatalf("NZBSizeKnown = %v for %d bytes", est.NZBSizeKnown, tt.nzbBytes)
+			}
+			got := est.shortfall()
+			if tt.wantShort == "" {
+				if got != "" {
+					t.Fatalf("expected room, got %q", got)
+				}
+				return
+			}
+			if !strings.Contains(got, tt.wantShort) {
+				t.Fatalf("shortfall %q does not contain %q", got, tt.wantShort)
+			}
+		})
+	}
+
+	if _, err := estimateSpace(map[string]any{"diskspace1": "1"}, 0, -1); err == nil {
+		t.Fatal("expected error when fullstatus lacks diskspace2")
+	}
+}
+
+func TestNZBDeclaredBytes(t *testing.T) {
+	t.Parallel()
+
+	nzb := `<?xml version="1.0" encoding="UTF-8"?>
+<nzb xmlns="http://www.newzbin.com/DTD/2003/nzb">
+  <file subject="a.rar"><segments>
+    <segment bytes="700000" number="1">a1@example</segment>
+    <segment bytes="300000" number="2">a2@example</segment>
+  </segments></file>
+  <file subject="b.par2"><segments><segment bytes="24" number="1">b1@example</segment></segments></file>
+</nzb>`
+	path := filepath.Join(t.TempDir(), "job.nzb")
+	if err := os.WriteFile(path, []byte(nzb), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := nzbDeclaredBytes(path)
+	if err != nil {
+		t.Fatalf("nzbDeclaredBytes returned error: %v", err)
+	}
+	if got != 1000024 {
+		t.Fatalf("nzbDeclaredBytes = %d, want 1000024", got)
+	}
+}
+
+func TestQueueAddFileCheckSpaceRefuses(t *testing.T) {
+	t.Parallel()
+
+	var added atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("mode") {
+		case "fullstatus":
+			_, _ = w.Write([]byte(`{"status":{"diskspace1":"0.50","diskspace2":"100","downloaddir":"/incomplete","completedir":"/complete"}}`))
+		case "queue":
+			_, _ = w.Write([]byte(`{"queue":{"mbleft":"400","slots":[]}}`))
+		default:
+			added.Store(true)
+			_, _ = w.Write([]byte(`{"status":true,"nzo_ids":["SABnzbd_nzo_1"]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	client, err := sabapi.NewClient(server.URL, "secret")
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "big.nzb")
+	nzb := `<nzb><file><segments><segment bytes="209715200" number="1">x@example</segment></segments></file></nzb>`
+	if err := os.WriteFile(path, []byte(nzb), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := queueAddFileCmd()
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{
+		Client:  client,
+		Printer: &output.Printer{Out: &bytes.Buffer{}, Err: &bytes.Buffer{}},
+	}))
+	cmd.SetArgs([]string{path, "--check-space"})
+	err = cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "not enough free space") || !strings.Contains(err.Error(), "download dir /incomplete") {
+		t.Fatalf("expected free space refusal, got %v", err)
+	}
+	if added.Load() {
+		t.Fatal("NZB was uploaded despite insufficient space")
+	}
+}
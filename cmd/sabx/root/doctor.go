@@ -1,15 +1,29 @@
 package root
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/sabapi"
 )
 
 func doctorCmd() *cobra.Command {
+	var expiryWindow string
+	var fix bool
+	var yes bool
 	cmd := &cobra.Command{
 		Use:   "doctor",
 		Short: jsonShort("Diagnose connectivity issues"),
+		Long: appendJSONLong("Run connectivity and health checks against SABnzbd.\n\n" +
+			"With --fix, issues that can be remediated safely are fixed one at a time after confirmation (or --yes): " +
+			"stale warnings are cleared, certificates are regenerated when SABnzbd warns about them, and servers " +
+			"blocked after errors are unblocked. Nothing is deleted and no configuration is changed."),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			app, err := getApp(cmd)
 			if err != nil {
@@ -18,15 +32,22 @@ func doctorCmd() *cobra.Command {
 			if app.Client == nil {
 				return fmt.Errorf("not logged in; run 'sabx login'")
 			}
+			window, err := parseAgeDuration(expiryWindow)
+			if err != nil {
+				return fmt.Errorf("invalid --expiry-window: %w", err)
+			}
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 
 			checks := map[string]string{}
 
-			if version, err := app.Client.Version(ctx); err == nil {
-				checks["version"] = version.Version
-			} else {
+			if version, err := lookupServerVersion(ctx, app, true); err != nil {
 				checks["version_error"] = err.Error()
+			} else if version.Offline {
+				checks["version_error"] = version.Error
+				checks["version_last_known"] = version.String()
+			} else {
+				checks["version"] = version.Version
 			}
 
 			if status, err := app.Client.Status(ctx); err == nil {
@@ -41,8 +62,136 @@ func doctorCmd() *cobra.Command {
 				checks["queue_error"] = err.Error()
 			}
 
+			if servers, err := app.ServerConfigs(ctx); err == nil {
+				if expiring := expiringServers(servers, time.Now(), window); len(expiring) > 0 {
+					notes := make([]string, 0, len(expiring))
+					for _, exp := range expiring {
+						notes = append(notes, exp.describe())
+					}
+					checks["server_expiry_warning"] = strings.Join(notes, "; ")
+				}
+			} else {
+				checks["servers_error"] = err.Error()
+			}
+
+			issues := detectDoctorIssues(ctx, app.Client, checks)
+			for _, issue := range issues {
+				checks[issue.check] = issue.detail
+			}
+
+			if fix {
+				for _, issue := range issues {
+					result, err := applyDoctorFix(cmd, app, issue, yes)
+					if err != nil {
+						return err
+					}
+					checks["fix_"+issue.check] = result
+				}
+			}
+
 			return app.Printer.Print(checks)
 		},
 	}
+	cmd.Flags().StringVar(&expiryWindow, "expiry-window", "7d", "Warn about server accounts expiring within this window (e.g. 7d, 2w)")
+	cmd.Flags().BoolVar(&fix, "fix", false, "Offer to fix detected issues (clear warnings, regenerate certificates, unblock servers)")
+	bindYesFlag(cmd.Flags(), &yes)
 	return cmd
 }
+
+// doctorIssue is a problem doctor --fix knows how to remediate without
+// deleting data or changing configuration.
+type doctorIssue struct {
+	check    string
+	detail   string
+	question string
+	fix      func(context.Context, *sabapi.Client) (string, error)
+}
+
+// detectDoctorIssues looks for fixable problems. Lookup failures are recorded
+// in checks rather than aborting the diagnosis.
+func detectDoctorIssues(ctx context.Context, client *sabapi.Client, checks map[string]string) []doctorIssue {
+	var issues []doctorIssue
+
+	warnings, err := client.Warnings(ctx)
+	if err != nil {
+		checks["warnings_error"] = err.Error()
+	}
+	var certNotes []string
+	for _, w := range warnings {
+		if strings.Contains(strings.ToLower(w.Text), "certificate") {
+			certNotes = append(certNotes, w.Text)
+		}
+	}
+	if len(certNotes) > 0 {
+		issues = append(issues, doctorIssue{
+			check:    "certificate_warning",
+			detail:   strings.Join(certNotes, "; "),
+			question: "Regenerate SABnzbd's HTTPS certificates? (takes effect after a restart)",
+			fix: func(ctx context.Context, client *sabapi.Client) (string, error) {
+				regenerated, err := client.ConfigRegenerateCertificates(ctx)
+				if err != nil {
+					return "", err
+				}
+				if !regenerated {
+					return "SABnzbd kept its certificates (custom certificates are never replaced)", nil
+				}
+				return "certificates regenerated; restart SABnzbd to use them", nil
+			},
+		})
+	}
+	if len(warnings) > 0 {
+		count := len(warnings)
+		issues = append(issues, doctorIssue{
+			check:    "warnings",
+			detail:   fmt.Sprintf("%d stored warnings", count),
+			question: fmt.Sprintf("Clear %d SABnzbd warnings?", count),
+			fix: func(ctx context.Context, client *sabapi.Client) (string, error) {
+				if err := client.WarningsClear(ctx); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("cleared %d warnings", count), nil
+			},
+		})
+	}
+
+	status, err := client.FullStatus(ctx, sabapi.FullStatusOptions{SkipDashboard: true})
+	if err != nil {
+		checks["fullstatus_error"] = err.Error()
+		return issues
+	}
+	for _, name := range blockedServersFromFullStatus(status) {
+		name := name
+		issues = append(issues, doctorIssue{
+			check:    "server_blocked_" + name,
+			detail:   "blocked after errors",
+			question: fmt.Sprintf("Unblock server %s?", name),
+			fix: func(ctx context.Context, client *sabapi.Client) (string, error) {
+				if err := client.UnblockServer(ctx, name); err != nil {
+					return "", err
+				}
+				return "unblocked", nil
+			},
+		})
+	}
+	return issues
+}
+
+// applyDoctorFix confirms and runs one fix. A declined prompt is reported as
+// skipped; a missing terminal without --yes fails the command. The fix gets
+// its own request timeout, started after the prompt is answered.
+func applyDoctorFix(cmd *cobra.Command, app *cobraext.App, issue doctorIssue, yes bool) (string, error) {
+	if err := confirmAction(cmd, yes, issue.question); err != nil {
+		if errors.Is(err, errAborted) {
+			return "skipped", nil
+		}
+		return "", err
+	}
+	ctx, cancel := timeoutContext(cmd.Context())
+	defer cancel()
+	result, err := issue.fix(ctx, app.Client)
+	if err != nil {
+		app.Printer.AlwaysError("fix %s failed: %v", issue.check, err)
+		return "failed: " + err.Error(), nil
+	}
+	return result, nil
+}
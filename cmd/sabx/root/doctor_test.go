@@ -0,0 +1,122 @@
+package root
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+func runDoctor(t *testing.T, args ...string) (map[string]string, []string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var fixes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		q := r.URL.Query()
+		switch q.Get("mode") {
+		case "warnings":
+			if q.Get("name") == "clear" {
+				mu.Lock()
+				fixes = append(fixes, "warnings_clear")
+				mu.Unlock()
+				_, _ = w.Write([]byte(`{"status": true}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"warnings":[{"type":"WARNING","text":"HTTPS certificate has expired"},{"type":"WARNING","text":"Disk almost full"}]}`))
+		case "fullstatus":
+			_, _ = w.Write([]byte(`{"status":{"servers":[
+				{"servername":"news.ok","serveractive":true,"servererror":""},
+				{"servername":"news.bad","serveractive":true,"servererror":"Server blocked for 10 minutes"}
+			]}}`))
+		case "config":
+			if q.Get("name") == "regenerate_certs" {
+				mu.Lock()
+				fixes = append(fixes, "regenerate_certs")
+				mu.Unlock()
+				_, _ = w.Write([]byte(`{"value": true}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{}`))
+		case "status":
+			if q.Get("name") == "unblock_server" {
+				mu.Lock()
+				fixes = append(fixes, "unblock_server:"+q.Get("value"))
+				mu.Unlock()
+			}
+			_, _ = w.Write([]byte(`{"status": true}`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	var out bytes.Buffer
+	cmd := doctorCmd()
+	cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{Client: client, Printer: &output.Printer{JSON: true, Out: &out, Err: &bytes.Buffer{}}}))
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("doctor returned error: %v", err)
+	}
+
+	var checks map[string]string
+	if err := json.Unmarshal(out.Bytes(), &checks); err != nil {
+		t.Fatalf("decode output: %v\n%s", err, out.String())
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Strings(fixes)
+	return checks, fixes
+}
+
+func TestDoctorReportsFixableIssuesWithoutFixing(t *testing.T) {
+	t.Parallel()
+
+	checks, fixes := runDoctor(t)
+	if len(fixes) != 0 {
+		t.Fatalf("doctor without --fix must not change anything, got %v", fixes)
+	}
+	if checks["warnings"] != "2 stored warnings" {
+		t.Fatalf("unexpected warnings check %q", checks["warnings"])
+	}
+	if checks["certificate_warning"] != "HTTPS certificate has expired" {
+		t.Fatalf("unexpected certificate check %q", checks["certificate_warning"])
+	}
+	if checks["server_blocked_news.bad"] == "" || checks["server_blocked_news.ok"] != "" {
+		t.Fatalf("expected only news.bad blocked, got %v", checks)
+	}
+}
+
+func TestDoctorFixCallsEachRemediation(t *testing.T) {
+	t.Parallel()
+
+	checks, fixes := runDoctor(t, "--fix", "--yes")
+	want := []string{"regenerate_certs", "unblock_server:news.bad", "warnings_clear"}
+	if len(fixes) != len(want) {
+		t.Fatalf("fixes = %v, want %v", fixes, want)
+	}
+	for i := range want {
+		if fixes[i] != want[i] {
+			t.Fatalf("fixes = %v, want %v", fixes, want)
+		}
+	}
+	if checks["fix_warnings"] != "cleared 2 warnings" || checks["fix_server_blocked_news.bad"] != "unblocked" {
+		t.Fatalf("unexpected fix report %v", checks)
+	}
+	if checks["fix_certificate_warning"] == "" {
+		t.Fatalf("expected certificate fix to be reported, got %v", checks)
+	}
+}
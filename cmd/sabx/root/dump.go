@@ -1,6 +1,7 @@
 package root
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"strings"
@@ -9,6 +10,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/sabapi"
 )
 
 func dumpCmd() *cobra.Command {
@@ -22,11 +24,21 @@ func dumpCmd() *cobra.Command {
 	return cmd
 }
 
+// defaultDumpSections are dumped when neither --section nor --all-sections
+// is given.
+var defaultDumpSections = []string{"misc", "servers", "rss", "categories", "scheduler"}
+
 func dumpConfigCmd() *cobra.Command {
-	var sections []string
+	var (
+		sections    []string
+		allSections bool
+	)
 	cmd := &cobra.Command{
 		Use:   "config",
 		Short: jsonShort("Dump configuration sections (sanitised)"),
+		Long: appendJSONLong("Dump configuration sections with keys, secrets and passwords masked. " +
+			"Without --section the whole config is fetched in one request and sliced locally; " +
+			"servers that do not support that are queried section by section."),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			app, err := getApp(cmd)
 			if err != nil {
@@ -35,30 +47,74 @@ func dumpConfigCmd() *cobra.Command {
 			if app.Client == nil {
 				return errors.New("not logged in; run 'sabx login'")
 			}
-
-			if len(sections) == 0 {
-				sections = []string{"misc", "servers", "rss", "categories", "scheduler"}
+			if allSections && len(sections) > 0 {
+				return errors.New("--all-sections cannot be combined with --section")
 			}
 
-			result := map[string]any{}
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 
-			for _, section := range sections {
-				raw, err := app.Client.ConfigGet(ctx, section, "")
+			var result map[string]any
+			if len(sections) == 0 {
+				result, err = dumpConfigBulk(ctx, app.Client, allSections)
+				if err != nil {
+					return err
+				}
+			} else {
+				result, err = dumpConfigSections(ctx, app.Client, sections)
 				if err != nil {
 					return err
 				}
-				result[section] = sanitiseConfig(raw)
 			}
 
 			return printJSONorText(app, result)
 		},
 	}
 	cmd.Flags().StringSliceVar(&sections, "section", nil, "Specific config sections to dump")
+	cmd.Flags().BoolVar(&allSections, "all-sections", false, "Dump every section the server reports instead of the default set")
 	return cmd
 }
 
+// dumpConfigBulk fetches the whole config once and slices out the default
+// sections, or every section when all is set. Each entry keeps the shape of
+// a per-section get_config response. Older servers that reject a bare
+// get_config, or omit a default section from it, fall back to one request
+// per section.
+func dumpConfigBulk(ctx context.Context, client *sabapi.Client, all bool) (map[string]any, error) {
+	full, err := client.ConfigGetAll(ctx)
+	if err != nil {
+		return dumpConfigSections(ctx, client, defaultDumpSections)
+	}
+	sections := defaultDumpSections
+	if all {
+		sections = make([]string, 0, len(full))
+		for section := range full {
+			sections = append(sections, section)
+		}
+	}
+	result := make(map[string]any, len(sections))
+	for _, section := range sections {
+		value, ok := full[section]
+		if !ok {
+			return dumpConfigSections(ctx, client, sections)
+		}
+		result[section] = sanitiseConfig(map[string]any{"config": map[string]any{section: value}})
+	}
+	return result, nil
+}
+
+func dumpConfigSections(ctx context.Context, client *sabapi.Client, sections []string) (map[string]any, error) {
+	result := make(map[string]any, len(sections))
+	for _, section := range sections {
+		raw, err := client.ConfigGet(ctx, section, "")
+		if err != nil {
+			return nil, err
+		}
+		result[section] = sanitiseConfig(raw)
+	}
+	return result, nil
+}
+
 func dumpStateCmd() *cobra.Command {
 	var historyLimit int
 	cmd := &cobra.Command{
@@ -0,0 +1,104 @@
+package root
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+func TestDumpConfigRequestCounts(t *testing.T) {
+	t.Parallel()
+
+	full := map[string]any{
+		"misc":       map[string]any{"api_key": "abc", "port": "8080"},
+		"servers":    []any{map[string]any{"name": "primary", "password": "pw"}},
+		"rss":        []any{},
+		"categories": []any{map[string]any{"name": "tv"}},
+		"scheduler":  map[string]any{},
+		"sorting":    []any{},
+	}
+
+	tests := []struct {
+		name         string
+		args         []string
+		supportsBulk bool
+		wantCalls    int32
+		wantSections []string
+	}{
+		{name: "default sections in one call", supportsBulk: true, wantCalls: 1, wantSections: defaultDumpSections},
+		{name: "all sections in one call", args: []string{"--all-sections"}, supportsBulk: true, wantCalls: 1, wantSections: append([]string{"sorting"}, defaultDumpSections...)},
+		{name: "older server falls back per section", wantCalls: 1 + int32(len(defaultDumpSections)), wantSections: defaultDumpSections},
+		{name: "explicit sections stay per section", args: []string{"--section", "misc", "--section", "rss"}, supportsBulk: true, wantCalls: 2, wantSections: []string{"misc", "rss"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				q := r.URL.Query()
+				if q.Get("mode") != "get_config" {
+					http.Error(w, "unexpected mode", http.StatusBadRequest)
+					return
+				}
+				calls.Add(1)
+				section := q.Get("section")
+				var payload any
+				switch {
+				case section != "":
+					payload = map[string]any{"config": map[string]any{section: full[section]}}
+				case tt.supportsBulk:
+					payload = map[string]any{"config": full}
+				default:
+					payload = map[string]any{"status": false, "error": "Missing section"}
+				}
+				_ = json.NewEncoder(w).Encode(payload)
+			}))
+			t.Cleanup(server.Close)
+			client, err := sabapi.NewClient(server.URL, "secret")
+			if err != nil {
+				t.Fatalf("NewClient returned error: %v", err)
+			}
+
+			var out bytes.Buffer
+			cmd := dumpConfigCmd()
+			cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{
+				Client:  client,
+				Printer: &output.Printer{Out: &out, Err: &bytes.Buffer{}, JSON: true},
+			}))
+			cmd.SetArgs(tt.args)
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("dump config returned error: %v", err)
+			}
+
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Fatalf("get_config calls = %d, want %d", got, tt.wantCalls)
+			}
+			var got map[string]map[string]map[string]any
+			if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+				t.Fatalf("decode output: %v\n%s", err, out.String())
+			}
+			if len(got) != len(tt.wantSections) {
+				t.Fatalf("dumped %d sections, want %d: %s", len(got), len(tt.wantSections), out.String())
+			}
+			for _, section := range tt.wantSections {
+				if _, ok := got[section]["config"][section]; !ok {
+					t.Fatalf("section %s missing or not wrapped in config: %s", section, out.String())
+				}
+			}
+			if strings.Contains(out.String(), `"abc"`) || strings.Contains(out.String(), `"pw"`) {
+				t.Fatalf("secrets leaked into dump: %s", out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package root
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// defaultEnvFile is loaded from the working directory when present, so a
+// project can pin its SABnzbd connection without exporting variables.
+const defaultEnvFile = ".sabx.env"
+
+// loadEnvFile reads SABX_* assignments from path and registers them as
+// defaults on v. Real environment variables still win because viper consults
+// the environment before defaults. A missing file is only an error when the
+// path was requested explicitly.
+func loadEnvFile(v *viper.Viper, path string, required bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if !required && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("env file: %w", err)
+	}
+	defer f.Close()
+
+	values, err := parseEnvFile(f, path)
+	if err != nil {
+		return err
+	}
+	for key, value := range values {
+		name, ok := strings.CutPrefix(key, "SABX_")
+		if !ok || name == "" {
+			continue
+		}
+		v.SetDefault(name, value)
+	}
+	return nil
+}
+
+// parseEnvFile understands the common dotenv subset: KEY=VALUE lines, blank
+// lines, # comments, an optional "export " prefix and single or double quotes
+// around the value.
+func parseEnvFile(r io.Reader, path string) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("env file %s:%d: expected KEY=VALUE", path, lineNo)
+		}
+		value = strings.TrimSpace(value)
+		if n := len(value); n >= 2 && (value[0] == '"' || value[0] == '\'') && value[n-1] == value[0] {
+			value = value[1 : n-1]
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("env file %s: %w", path, err)
+	}
+	return values, nil
+}
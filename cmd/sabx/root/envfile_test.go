@@ -0,0 +1,85 @@
+package root
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func useEnvConfig(t *testing.T) *viper.Viper {
+	t.Helper()
+	old := envConfig
+	t.Cleanup(func() { envConfig = old })
+	envConfig = viper.New()
+	envConfig.SetEnvPrefix("SABX")
+	envConfig.AutomaticEnv()
+	return envConfig
+}
+
+func writeEnvFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sabx.env")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write env file: %v", err)
+	}
+	return path
+}
+
+func TestEnvFilePopulatesConnection(t *testing.T) {
+	t.Setenv("SABX_BASE_URL", "")
+	t.Setenv("SABX_API_KEY", "")
+	os.Unsetenv("SABX_BASE_URL")
+	os.Unsetenv("SABX_API_KEY")
+	oldProfile, oldBase, oldKey := profileFlag, baseURLFlag, apiKeyFlag
+	t.Cleanup(func() { profileFlag, baseURLFlag, apiKeyFlag = oldProfile, oldBase, oldKey })
+	profileFlag, baseURLFlag, apiKeyFlag = "", "", ""
+
+	v := useEnvConfig(t)
+	path := writeEnvFile(t, "# CI secrets\nexport SABX_BASE_URL=\"http://ci-sab:8080\"\nSABX_API_KEY='file-key'\nOTHER=ignored\n")
+	if err := loadEnvFile(v, path, true); err != nil {
+		t.Fatalf("loadEnvFile returned error: %v", err)
+	}
+
+	_, baseURL, apiKey, err := resolveConnection(nil)
+	if err != nil {
+		t.Fatalf("resolveConnection returned error: %v", err)
+	}
+	if baseURL != "http://ci-sab:8080" || apiKey != "file-key" {
+		t.Fatalf("got baseURL=%q apiKey=%q", baseURL, apiKey)
+	}
+	if v.IsSet("OTHER") {
+		t.Fatal("non-SABX variables must not be loaded")
+	}
+}
+
+func TestEnvFileDoesNotOverrideRealEnv(t *testing.T) {
+	t.Setenv("SABX_API_KEY", "env-key")
+	v := useEnvConfig(t)
+	path := writeEnvFile(t, "SABX_API_KEY=file-key\n")
+	if err := loadEnvFile(v, path, true); err != nil {
+		t.Fatalf("loadEnvFile returned error: %v", err)
+	}
+	if got := v.GetString("API_KEY"); got != "env-key" {
+		t.Fatalf("API_KEY = %q, want the real environment value", got)
+	}
+}
+
+func TestEnvFileMissingAndMalformed(t *testing.T) {
+	v := viper.New()
+	missing := filepath.Join(t.TempDir(), "absent.env")
+	if err := loadEnvFile(v, missing, false); err != nil {
+		t.Fatalf("optional env file should be skipped, got %v", err)
+	}
+	if err := loadEnvFile(v, missing, true); err == nil {
+		t.Fatal("expected error for explicit missing env file")
+	}
+
+	bad := writeEnvFile(t, "SABX_BASE_URL=http://x\nnot an assignment\n")
+	err := loadEnvFile(v, bad, true)
+	if err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Fatalf("expected line-numbered parse error, got %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package root
+
+import "errors"
+
+// exitCodeWarnings is returned when --fail-on-warnings finds active warnings,
+// so CI can tell a warning gate apart from a connection or usage error (1).
+const exitCodeWarnings = 3
+
+// exitError carries a specific process exit code alongside the error.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+
+func (e *exitError) Unwrap() error { return e.err }
+
+// ExitCode maps an Execute error to the process exit status.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return 1
+}
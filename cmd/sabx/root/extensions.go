@@ -16,7 +16,9 @@ func extensionsCmd() *cobra.Command {
 
 	cmd.AddCommand(extensionListCmd())
 	cmd.AddCommand(extensionInstallCmd())
+	cmd.AddCommand(extensionLinkCmd())
 	cmd.AddCommand(extensionRemoveCmd())
+	cmd.AddCommand(extensionRunCmd())
 	return cmd
 }
 
@@ -29,9 +31,15 @@ func extensionListCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			app, err := getApp(cmd)
+			if err != nil {
+				return err
+			}
+			if app.Printer.JSON {
+				return app.Printer.Print(exts)
+			}
 			if len(exts) == 0 {
-				cmd.Println("No extensions installed")
-				return nil
+				return app.Printer.Print("No extensions installed")
 			}
 			headers := []string{"Name", "Binary", "Kind", "Source"}
 			rows := make([][]string, 0, len(exts))
@@ -43,38 +51,60 @@ func extensionListCmd() *cobra.Command {
 					ext.Source,
 				})
 			}
+			return app.Printer.Table(headers, rows)
+		},
+	}
+	return cmd
+}
+
+func extensionInstallCmd() *cobra.Command {
+	var overwrite bool
+	cmd := &cobra.Command{
+		Use:   "install <source>",
+		Short: jsonShort("Install an extension from GitHub (owner/repo) or local path"),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
 			app, err := getApp(cmd)
 			if err != nil {
 				return err
 			}
-			if app.Printer.JSON {
-				return app.Printer.Print(exts)
-			}
-			if err := app.Printer.Table(headers, rows); err != nil {
+			ext, err := extensions.Install(args[0], overwrite)
+			if err != nil {
 				return err
 			}
-			return nil
+			if app.Printer.JSON {
+				return app.Printer.Print(map[string]any{"installed": ext})
+			}
+			return app.Printer.Print(fmt.Sprintf("Installed extension %s (%s)", ext.Name, ext.Source))
 		},
 	}
+	cmd.Flags().BoolVar(&overwrite, "force", false, "Overwrite if the extension already exists")
 	return cmd
 }
 
-func extensionInstallCmd() *cobra.Command {
+func extensionLinkCmd() *cobra.Command {
 	var overwrite bool
 	cmd := &cobra.Command{
-		Use:   "install <source>",
-		Short: jsonShort("Install an extension from GitHub (owner/repo) or local path"),
+		Use:   "link <dir>",
+		Short: jsonShort("Link a local extension directory for development"),
+		Long:  appendJSONLong("Register the sabx-<name> binary in <dir> without copying it, so rebuilds are picked up immediately. Removing a linked extension leaves the directory untouched."),
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ext, err := extensions.Install(args[0], overwrite)
+			app, err := getApp(cmd)
 			if err != nil {
 				return err
 			}
-			cmd.Printf("Installed extension %s (%s)\n", ext.Name, ext.Source)
-			return nil
+			ext, err := extensions.Link(args[0], overwrite)
+			if err != nil {
+				return err
+			}
+			if app.Printer.JSON {
+				return app.Printer.Print(map[string]any{"linked": ext})
+			}
+			return app.Printer.Print(fmt.Sprintf("Linked extension %s -> %s", ext.Name, ext.Binary))
 		},
 	}
-	cmd.Flags().BoolVar(&overwrite, "force", false, "Overwrite if the extension already exists")
+	cmd.Flags().BoolVar(&overwrite, "force", false, "Replace an existing extension with the same name")
 	return cmd
 }
 
@@ -84,13 +114,42 @@ func extensionRemoveCmd() *cobra.Command {
 		Short: jsonShort("Remove an installed extension"),
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			app, err := getApp(cmd)
+			if err != nil {
+				return err
+			}
 			if err := extensions.Remove(args[0]); err != nil {
 				return err
 			}
-			cmd.Printf("Removed extension %s\n", args[0])
-			return nil
+			if app.Printer.JSON {
+				return app.Printer.Print(map[string]any{"removed": args[0]})
+			}
+			return app.Printer.Print(fmt.Sprintf("Removed extension %s", args[0]))
+		},
+	}
+	return cmd
+}
+
+func extensionRunCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run <name> [-- args...]",
+		Short: "Run an installed extension explicitly",
+		Long: "Run the sabx-<name> extension with the remaining arguments, bypassing built-in command lookup. " +
+			"Unlike the implicit `sabx <name>` fallback this cannot be shadowed by a built-in command, so scripts get a stable entry point.",
+		Args: cobra.MinimumNArgs(1),
+		Annotations: map[string]string{
+			"skipPersistent": "true",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			extArgs := args[1:]
+			if len(extArgs) > 0 && extArgs[0] == "--" {
+				extArgs = extArgs[1:]
+			}
+			return extensionExecFallback(args[0], extArgs)
 		},
 	}
+	// Everything after the extension name belongs to the extension.
+	cmd.Flags().SetInterspersed(false)
 	return cmd
 }
 
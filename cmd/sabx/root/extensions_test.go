@@ -0,0 +1,150 @@
+package root
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/extensions"
+	"github.com/avivsinai/sabx/internal/output"
+)
+
+func runExtensionJSON(t *testing.T, cmd *cobra.Command, args ...string) map[string]any {
+	t.Helper()
+
+	var out bytes.Buffer
+	app := &cobraext.App{Printer: &output.Printer{JSON: true, Out: &out}}
+	cmd.SetContext(cobraext.WithApp(context.Background(), app))
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("decode output: %v\n%s", err, out.String())
+	}
+	return payload
+}
+
+func TestExtensionInstallAndRemoveJSON(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+
+	src := filepath.Join(t.TempDir(), "sabx-hello")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "sabx-hello"), []byte("#!/bin/sh\necho hello\n"), 0o755); err != nil {
+		t.Fatalf("write binary: %v", err)
+	}
+
+	installed := runExtensionJSON(t, extensionInstallCmd(), src)
+	ext, ok := installed["installed"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected installed object, got %v", installed)
+	}
+	if ext["name"] != "hello" || ext["source"] != src || ext["kind"] != "local" {
+		t.Fatalf("unexpected installed payload %v", ext)
+	}
+	if binary, _ := ext["binary"].(string); filepath.Base(binary) != "sabx-hello" {
+		t.Fatalf("unexpected binary %v", ext["binary"])
+	}
+
+	removed := runExtensionJSON(t, extensionRemoveCmd(), "hello")
+	if removed["removed"] != "hello" {
+		t.Fatalf("unexpected removed payload %v", removed)
+	}
+}
+
+func TestExtensionRunForwardsArgs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	argsFile := filepath.Join(t.TempDir(), "args.txt")
+	t.Setenv("SABX_TEST_ARGS_FILE", argsFile)
+
+	src := filepath.Join(t.TempDir(), "sabx-echoargs")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > \"$SABX_TEST_ARGS_FILE\"\n"
+	if err := os.WriteFile(filepath.Join(src, "sabx-echoargs"), []byte(script), 0o755); err != nil {
+		t.Fatalf("write binary: %v", err)
+	}
+	runExtensionJSON(t, extensionInstallCmd(), src)
+
+	cmd := extensionRunCmd()
+	cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{Printer: &output.Printer{Out: &bytes.Buffer{}}}))
+	cmd.SetArgs([]string{"echoargs", "--", "status", "--json", "two words"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("extension run returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("extension did not run: %v", err)
+	}
+	if got, want := string(data), "status\n--json\ntwo words\n"; got != want {
+		t.Fatalf("forwarded args = %q, want %q", got, want)
+	}
+
+	cmd = extensionRunCmd()
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	cmd.SetArgs([]string{"missing"})
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected error for unknown extension")
+	}
+}
+
+func TestExtensionLinkResolvesLiveBinary(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("PATH", "")
+
+	dev := filepath.Join(t.TempDir(), "sabx-devtool")
+	if err := os.MkdirAll(filepath.Join(dev, "bin"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	binary := filepath.Join(dev, "bin", "sabx-devtool")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\necho v1\n"), 0o755); err != nil {
+		t.Fatalf("write binary: %v", err)
+	}
+
+	linked := runExtensionJSON(t, extensionLinkCmd(), dev)
+	ext, ok := linked["linked"].(map[string]any)
+	if !ok || ext["name"] != "devtool" || ext["kind"] != "link" || ext["binary"] != binary {
+		t.Fatalf("unexpected linked payload %v", linked)
+	}
+
+	resolved, err := extensions.Resolve("devtool")
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if resolved.Binary != binary || resolved.Kind != "link" {
+		t.Fatalf("expected live binary %s, got %+v", binary, resolved)
+	}
+
+	// A rebuild replaces the binary in place; nothing is copied.
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\necho v2\n"), 0o755); err != nil {
+		t.Fatalf("rewrite binary: %v", err)
+	}
+	list, err := extensions.List()
+	if err != nil || len(list) != 1 || list[0].Binary != binary {
+		t.Fatalf("expected linked extension in list, got %+v (%v)", list, err)
+	}
+
+	runExtensionJSON(t, extensionRemoveCmd(), "devtool")
+	if _, err := os.Stat(binary); err != nil {
+		t.Fatalf("remove must not delete the linked directory: %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package root
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+// findResult is one match from either the queue or history.
+type findResult struct {
+	Location string `json:"location"`
+	NZOID    string `json:"nzo_id"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Category string `json:"category,omitempty"`
+}
+
+func findCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "find <query>",
+		Short: jsonShort("Search the queue and history by name"),
+		Long: appendJSONLong("Search queued jobs and history entries whose name contains the query (case-insensitive). " +
+			"Queue matches are listed first, each with its location, status and NZO ID."),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, err := getApp(cmd)
+			if err != nil {
+				return err
+			}
+			query := strings.TrimSpace(args[0])
+			if query == "" {
+				return errors.New("query must not be empty")
+			}
+
+			ctx, cancel := timeoutContext(cmd.Context())
+			defer cancel()
+
+			queue, err := app.Client.Queue(ctx, 0, 0, query)
+			if err != nil {
+				return err
+			}
+			history, err := app.Client.HistorySearch(ctx, query, 0)
+			if err != nil {
+				return err
+			}
+			results := mergeFindResults(queue.Slots, history.Slots, query)
+
+			if app.Printer.NDJSON {
+				return app.Printer.PrintLines(results)
+			}
+			if app.Printer.JSON {
+				payload := map[string]any{
+					"query":   query,
+					"results": results,
+					"count":   len(results),
+				}
+				return app.Printer.Print(payload)
+			}
+
+			if len(results) == 0 {
+				return app.Printer.Print(fmt.Sprintf("No matches for %q", query))
+			}
+
+			headers := []string{"Location", "ID", "Name", "Status", "Category"}
+			rows := make([][]string, 0, len(results))
+			queued := 0
+			for _, r := range results {
+				if r.Location == "queue" {
+					queued++
+				}
+				rows = append(rows, []string{r.Location, r.NZOID, r.Name, r.Status, r.Category})
+			}
+			if err := app.Printer.Table(headers, rows); err != nil {
+				return err
+			}
+			return app.Printer.Print(fmt.Sprintf("%d matches (%d queued, %d in history)", len(results), queued, len(results)-queued))
+		},
+	}
+	return cmd
+}
+
+// mergeFindResults combines the queue slots SABnzbd matched for query with the
+// history slots whose names contain it, queue first. History is already
+// filtered by SABnzbd's search; the case-insensitive check here guards
+// against servers that ignore the parameter and return everything.
+func mergeFindResults(queue []sabapi.QueueSlot, history []sabapi.HistorySlot, query string) []findResult {
+	needle := strings.ToLower(query)
+	results := make([]findResult, 0, len(queue)+len(history))
+	for _, slot := range queue {
+		results = append(results, findResult{
+			Location: "queue",
+			NZOID:    slot.NZOID,
+			Name:     slot.Filename,
+			Status:   slot.Status,
+			Category: slot.Category,
+		})
+	}
+	for _, slot := range history {
+		if !strings.Contains(strings.ToLower(slot.Name), needle) {
+			continue
+		}
+		results = append(results, findResult{
+			Location: "history",
+			NZOID:    slot.NZOID,
+			Name:     slot.Name,
+			Status:   slot.Status,
+			Category: slot.Category,
+		})
+	}
+	return results
+}
@@ -0,0 +1,104 @@
+package root
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+func newFindServer(t *testing.T) *sabapi.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("mode") {
+		case "queue":
+			if got := r.URL.Query().Get("search"); got != "ubuntu" {
+				t.Errorf("expected queue search=ubuntu, got %q", got)
+			}
+			_, _ = w.Write([]byte(`{"queue":{"slots":[{"nzo_id":"SABnzbd_nzo_q1","filename":"Ubuntu 24.04 ISO","status":"Downloading","cat":"software"}]}}`))
+		case "history":
+			if got := r.URL.Query().Get("search"); got != "ubuntu" {
+				t.Errorf("expected history search=ubuntu, got %q", got)
+			}
+			_, _ = w.Write([]byte(`{"history":{"slots":[` +
+				`{"nzo_id":"SABnzbd_nzo_h1","name":"ubuntu-22.04-server","status":"Completed","category":"software"},` +
+				`{"nzo_id":"SABnzbd_nzo_h2","name":"Debian 12","status":"Completed","category":"software"},` +
+				`{"nzo_id":"SABnzbd_nzo_h3","name":"Ubuntu Docs","status":"Failed","category":"books"}]}}`))
+		default:
+			t.Errorf("unexpected mode %q", r.URL.Query().Get("mode"))
+		}
+	}))
+	t.Cleanup(server.Close)
+	client, err := sabapi.NewClient(server.URL, "secret")
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	return client
+}
+
+func TestFindMergesQueueAndHistoryJSON(t *testing.T) {
+	t.Parallel()
+
+	client := newFindServer(t)
+	var out bytes.Buffer
+	cmd := findCmd()
+	cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{
+		Client:  client,
+		Printer: &output.Printer{Out: &out, Err: &bytes.Buffer{}, JSON: true},
+	}))
+	cmd.SetArgs([]string{"ubuntu"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("find returned error: %v", err)
+	}
+
+	var payload struct {
+		Query   string       `json:"query"`
+		Results []findResult `json:"results"`
+		Count   int          `json:"count"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("decode output: %v\n%s", err, out.String())
+	}
+	want := []findResult{
+		{Location: "queue", NZOID: "SABnzbd_nzo_q1", Name: "Ubuntu 24.04 ISO", Status: "Downloading", Category: "software"},
+		{Location: "history", NZOID: "SABnzbd_nzo_h1", Name: "ubuntu-22.04-server", Status: "Completed", Category: "software"},
+		{Location: "history", NZOID: "SABnzbd_nzo_h3", Name: "Ubuntu Docs", Status: "Failed", Category: "books"},
+	}
+	if payload.Query != "ubuntu" || payload.Count != len(want) || !reflect.DeepEqual(payload.Results, want) {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+}
+
+func TestFindTableShowsLocation(t *testing.T) {
+	t.Parallel()
+
+	client := newFindServer(t)
+	var out bytes.Buffer
+	cmd := findCmd()
+	cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{
+		Client:  client,
+		Printer: &output.Printer{Out: &out, Err: &bytes.Buffer{}},
+	}))
+	cmd.SetArgs([]string{"ubuntu"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("find returned error: %v", err)
+	}
+
+	text := out.String()
+	for _, want := range []string{"Location", "queue", "history", "SABnzbd_nzo_h3", "3 matches (1 queued, 2 in history)"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected %q in output:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Debian") {
+		t.Fatalf("non-matching history entry listed:\n%s", text)
+	}
+}
@@ -1,15 +1,29 @@
 package root
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/ref"
 	"github.com/avivsinai/sabx/internal/sabapi"
 )
 
+const historyRefLongNote = "Entries may be referenced by NZO ID, by a unique prefix of the ID after SABnzbd_nzo_ (e.g. p86t), by 1-based position (#1 is the most recent entry), or by name substring (@name)."
+
 func historyCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "history",
@@ -20,6 +34,8 @@ func historyCmd() *cobra.Command {
 	cmd.AddCommand(historyDeleteCmd())
 	cmd.AddCommand(historyRetryCmd())
 	cmd.AddCommand(historyMarkCompletedCmd())
+	cmd.AddCommand(historyPathCmd())
+	cmd.AddCommand(historyOpenCmd())
 
 	return cmd
 }
@@ -28,10 +44,15 @@ func historyListCmd() *cobra.Command {
 	var limit int
 	var failedOnly bool
 	var completedOnly bool
+	var dedup bool
 
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: jsonShort("List history entries"),
+		Long: appendJSONLong("Lists history entries, newest first.\n\n" +
+			"With --dedup, retries of the same NZB are collapsed into one row per job showing the latest attempt's status " +
+			"and an attempt count. Names are grouped case-insensitively after dropping a trailing .nzb, a short numeric " +
+			"suffix such as .1 or (2), and a _retry marker."),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			app, err := getApp(cmd)
 			if err != nil {
@@ -46,6 +67,9 @@ func historyListCmd() *cobra.Command {
 			}
 
 			slots := history.Slots
+			if dedup {
+				return printHistoryGroups(app.Printer, groupHistoryAttempts(slots), completedOnly)
+			}
 			if completedOnly {
 				filtered := make([]sabapi.HistorySlot, 0, len(slots))
 				for _, slot := range slots {
@@ -55,6 +79,12 @@ func historyListCmd() *cobra.Command {
 				}
 				slots = filtered
 			}
+			if app.Printer.Template != nil {
+				return app.Printer.RenderTemplate(slots)
+			}
+			if app.Printer.NDJSON {
+				return app.Printer.PrintLines(slots)
+			}
 			if app.Printer.JSON {
 				return app.Printer.Print(slots)
 			}
@@ -74,22 +104,123 @@ func historyListCmd() *cobra.Command {
 	cmd.Flags().IntVar(&limit, "limit", 0, "Limit number of rows")
 	cmd.Flags().BoolVar(&failedOnly, "failed", false, "Only show failed items")
 	cmd.Flags().BoolVar(&completedOnly, "completed", false, "Only show completed items")
+	cmd.Flags().BoolVar(&dedup, "dedup", false, "Group retries of the same NZB into one row with an attempt count")
 	return cmd
 }
 
+// historyGroup is one logical job in `history list --dedup`: the latest
+// attempt plus how many attempts share its name.
+type historyGroup struct {
+	sabapi.HistorySlot
+	Attempts int      `json:"attempts"`
+	NZOIDs   []string `json:"nzo_ids"`
+}
+
+// historyRetrySuffix matches the decorations SABnzbd and indexers add to
+// repeated downloads of the same NZB. Numeric suffixes are capped at two
+// digits so years such as Movie.2024 are left alone.
+var historyRetrySuffix = regexp.MustCompile(`(?i)(\.nzb|\.\d{1,2}|\s*\(\d{1,2}\)|[._ -]retry(?:[._ -]?\d+)?)$`)
+
+// historyGroupKey normalizes a history name for grouping retries.
+func historyGroupKey(name string) string {
+	key := strings.TrimSpace(name)
+	for {
+		trimmed := strings.TrimSpace(historyRetrySuffix.ReplaceAllString(key, ""))
+		if trimmed == key || trimmed == "" {
+			break
+		}
+		key = trimmed
+	}
+	return strings.ToLower(key)
+}
+
+// groupHistoryAttempts collapses slots sharing a normalized name. Groups keep
+// the order in which their first slot appears (SABnzbd lists newest first)
+// and report the most recently completed attempt; without timestamps the
+// earliest listed slot is treated as the latest.
+func groupHistoryAttempts(slots []sabapi.HistorySlot) []historyGroup {
+	groups := make([]historyGroup, 0, len(slots))
+	index := make(map[string]int, len(slots))
+	for _, slot := range slots {
+		key := historyGroupKey(slot.Name)
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(groups)
+			groups = append(groups, historyGroup{HistorySlot: slot, Attempts: 1, NZOIDs: []string{slot.NZOID}})
+			continue
+		}
+		group := &groups[i]
+		group.Attempts++
+		group.NZOIDs = append(group.NZOIDs, slot.NZOID)
+		if slot.Completed > group.Completed {
+			group.HistorySlot = slot
+		}
+	}
+	return groups
+}
+
+func printHistoryGroups(printer *output.Printer, groups []historyGroup, completedOnly bool) error {
+	if completedOnly {
+		filtered := make([]historyGroup, 0, len(groups))
+		for _, group := range groups {
+			if strings.EqualFold(group.Status, "Completed") {
+				filtered = append(filtered, group)
+			}
+		}
+		groups = filtered
+	}
+	if printer.Template != nil {
+		return printer.RenderTemplate(groups)
+	}
+	if printer.NDJSON {
+		return printer.PrintLines(groups)
+	}
+	if printer.JSON {
+		return printer.Print(groups)
+	}
+
+	attempts := 0
+	headers := []string{"ID", "Name", "Status", "Category", "Attempts"}
+	rows := make([][]string, 0, len(groups))
+	for _, group := range groups {
+		attempts += group.Attempts
+		rows = append(rows, []string{group.NZOID, group.Name, group.Status, group.Category, strconv.Itoa(group.Attempts)})
+	}
+	if err := printer.Table(headers, rows); err != nil {
+		return err
+	}
+	return printer.Print(fmt.Sprintf("%d jobs (%d attempts)", len(groups), attempts))
+}
+
 func historyDeleteCmd() *cobra.Command {
 	var deleteAll bool
 	var deleteFailed bool
+	var olderThan string
+	var dryRun bool
+	var yes bool
 
 	cmd := &cobra.Command{
-		Use:   "delete [nzo-id ...]",
+		Use:   "delete [ref ...]",
 		Short: jsonShort("Delete history entries"),
+		Long: "Deletes history entries. " + historyRefLongNote + "\n\n" +
+			"With --older-than (e.g. 30d, 2w, 12h) only entries completed before the cutoff are deleted; " +
+			"combine with --failed to prune only old failures. Deleting more than " + strconv.Itoa(historyPruneConfirmThreshold) +
+			" entries this way asks for confirmation unless --yes is given.",
 		Args: func(cmd *cobra.Command, args []string) error {
+			if olderThan != "" {
+				if deleteAll || len(args) > 0 {
+					return errors.New("--older-than cannot be combined with --all or item references")
+				}
+				return nil
+			}
+			if dryRun {
+				return errors.New("--dry-run requires --older-than")
+			}
 			if deleteAll || deleteFailed {
 				return nil
 			}
 			if len(args) == 0 {
-				return errors.New("provide at least one nzo-id or use --all/--failed")
+				return errors.New("provide at least one item reference or use --all/--failed/--older-than")
 			}
 			return nil
 		},
@@ -98,10 +229,26 @@ func historyDeleteCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if olderThan != "" {
+				age, err := parseAgeDuration(olderThan)
+				if err != nil {
+					return fmt.Errorf("invalid --older-than: %w", err)
+				}
+				return pruneHistory(cmd, app, time.Now().Add(-age), deleteFailed, dryRun, yes)
+			}
+
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 
-			if err := app.Client.DeleteHistory(ctx, args, deleteFailed, deleteAll); err != nil {
+			ids := args
+			if !deleteAll && !deleteFailed {
+				ids, err = resolveHistoryRefs(ctx, app.Client, args)
+				if err != nil {
+					return err
+				}
+			}
+
+			if err := app.Client.DeleteHistory(ctx, ids, deleteFailed, deleteAll); err != nil {
 				return err
 			}
 
@@ -111,7 +258,7 @@ func historyDeleteCmd() *cobra.Command {
 			} else if deleteFailed {
 				target = "failed entries"
 			} else {
-				target = strings.Join(args, ",")
+				target = strings.Join(ids, ",")
 			}
 
 			if app.Printer.JSON {
@@ -123,23 +270,125 @@ func historyDeleteCmd() *cobra.Command {
 
 	cmd.Flags().BoolVar(&deleteAll, "all", false, "Delete entire history")
 	cmd.Flags().BoolVar(&deleteFailed, "failed", false, "Delete only failed items")
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "Delete entries completed longer ago than this age (e.g. 30d, 2w, 12h)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "With --older-than, list matching entries without deleting them")
+	bindYesFlag(cmd.Flags(), &yes)
 	return cmd
 }
 
+// historyPruneConfirmThreshold is the number of entries an --older-than
+// deletion may remove before confirmation is required.
+const historyPruneConfirmThreshold = 25
+
+// pruneHistory deletes the entries completed before cutoff. The lookup and
+// the deletion each get their own request timeout so a slow confirmation
+// cannot expire the delete call.
+func pruneHistory(cmd *cobra.Command, app *cobraext.App, cutoff time.Time, failedOnly, dryRun, yes bool) error {
+	lookupCtx, cancelLookup := timeoutContext(cmd.Context())
+	history, err := app.Client.History(lookupCtx, failedOnly, 0)
+	cancelLookup()
+	if err != nil {
+		return err
+	}
+	matched := selectHistoryOlderThan(history.Slots, cutoff, failedOnly)
+
+	if dryRun || len(matched) == 0 {
+		if app.Printer.JSON {
+			return app.Printer.Print(map[string]any{
+				"dry_run": dryRun,
+				"cutoff":  cutoff.UTC().Format(time.RFC3339),
+				"matched": matched,
+				"deleted": []string{},
+			})
+		}
+		if len(matched) == 0 {
+			return app.Printer.Print("No history entries older than " + cutoff.Format("2006-01-02 15:04"))
+		}
+		if err := printHistoryPruneTable(app.Printer, matched); err != nil {
+			return err
+		}
+		return app.Printer.Print(fmt.Sprintf("Would delete %d history entries", len(matched)))
+	}
+
+	if len(matched) > historyPruneConfirmThreshold {
+		if err := confirmAction(cmd, yes, fmt.Sprintf("Delete %d history entries completed before %s?", len(matched), cutoff.Format("2006-01-02 15:04"))); err != nil {
+			return err
+		}
+	}
+
+	ids := make([]string, 0, len(matched))
+	for _, slot := range matched {
+		ids = append(ids, slot.NZOID)
+	}
+	ctx, cancel := timeoutContext(cmd.Context())
+	defer cancel()
+	if err := app.Client.DeleteHistory(ctx, ids, false, false); err != nil {
+		return err
+	}
+
+	if app.Printer.JSON {
+		return app.Printer.Print(map[string]any{
+			"dry_run": false,
+			"cutoff":  cutoff.UTC().Format(time.RFC3339),
+			"matched": matched,
+			"deleted": ids,
+		})
+	}
+	return app.Printer.Print(fmt.Sprintf("Deleted %d history entries", len(ids)))
+}
+
+// selectHistoryOlderThan keeps entries completed before cutoff. Entries
+// without a completion time are never selected.
+func selectHistoryOlderThan(slots []sabapi.HistorySlot, cutoff time.Time, failedOnly bool) []sabapi.HistorySlot {
+	matched := make([]sabapi.HistorySlot, 0, len(slots))
+	for _, slot := range slots {
+		completed := slot.Completed.Time()
+		if completed.IsZero() || !completed.Before(cutoff) {
+			continue
+		}
+		if failedOnly && !strings.EqualFold(slot.Status, "Failed") {
+			continue
+		}
+		matched = append(matched, slot)
+	}
+	return matched
+}
+
+func printHistoryPruneTable(printer *output.Printer, slots []sabapi.HistorySlot) error {
+	rows := make([][]string, 0, len(slots))
+	for _, slot := range slots {
+		rows = append(rows, []string{slot.NZOID, slot.Name, slot.Status, slot.Completed.String()})
+	}
+	return printer.Table([]string{"ID", "Name", "Status", "Completed"}, rows)
+}
+
 func historyRetryCmd() *cobra.Command {
 	var retryAll bool
+	var failedOnly bool
+	var category string
+	var dryRun bool
 	cmd := &cobra.Command{
-		Use:   "retry [nzo-id]",
+		Use:   "retry [ref]",
 		Short: jsonShort("Re-queue history entries"),
+		Long:  "Re-queues a history entry. Use --failed to retry failed entries one by one, optionally limited with --category and previewed with --dry-run. " + historyRefLongNote,
 		Args: func(cmd *cobra.Command, args []string) error {
-			if retryAll {
+			if retryAll && failedOnly {
+				return errors.New("use either --all or --failed, not both")
+			}
+			if category != "" && !failedOnly {
+				return errors.New("--category requires --failed")
+			}
+			if dryRun && !failedOnly {
+				return errors.New("--dry-run requires --failed")
+			}
+			if retryAll || failedOnly {
 				if len(args) > 0 {
-					return errors.New("do not provide IDs when using --all")
+					return errors.New("do not provide IDs when using --all or --failed")
 				}
 				return nil
 			}
 			if len(args) != 1 {
-				return errors.New("provide an nzo-id or use --all")
+				return errors.New("provide an item reference or use --all/--failed")
 			}
 			return nil
 		},
@@ -148,6 +397,15 @@ func historyRetryCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if failedOnly {
+				listCtx, cancel := timeoutContext(cmd.Context())
+				history, err := app.Client.History(listCtx, true, 0)
+				cancel()
+				if err != nil {
+					return err
+				}
+				return retryFailedHistory(cmd.Context(), app.Printer, app.Client.HistoryRetry, history.Slots, category, dryRun)
+			}
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 			if retryAll {
@@ -156,23 +414,109 @@ func historyRetryCmd() *cobra.Command {
 				}
 				return app.Printer.Print("Re-queued all failed history entries")
 			}
-			if err := app.Client.HistoryRetry(ctx, args[0]); err != nil {
+			id, err := resolveHistoryRef(ctx, app.Client, args[0])
+			if err != nil {
 				return err
 			}
-			return app.Printer.Print(fmt.Sprintf("Re-queued %s", args[0]))
+			if err := app.Client.HistoryRetry(ctx, id); err != nil {
+				return err
+			}
+			return app.Printer.Print(fmt.Sprintf("Re-queued %s", id))
 		},
 	}
 	cmd.Flags().BoolVar(&retryAll, "all", false, "Retry all failed history entries")
+	cmd.Flags().BoolVar(&failedOnly, "failed", false, "Retry failed history entries individually (combine with --category)")
+	cmd.Flags().StringVar(&category, "category", "", "Only retry failed entries in this category (requires --failed)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the entries --failed would retry without re-queuing them")
 	return cmd
 }
 
+// historyRetryFailure records a retry that SABnzbd rejected.
+type historyRetryFailure struct {
+	NZOID string `json:"nzo_id"`
+	Error string `json:"error"`
+}
+
+// filterFailedHistory keeps failed slots, optionally restricted to a category.
+func filterFailedHistory(slots []sabapi.HistorySlot, category string) []sabapi.HistorySlot {
+	category = strings.TrimSpace(category)
+	matched := make([]sabapi.HistorySlot, 0, len(slots))
+	for _, slot := range slots {
+		if !strings.EqualFold(slot.Status, "Failed") {
+			continue
+		}
+		if category != "" && !strings.EqualFold(slot.Category, category) {
+			continue
+		}
+		matched = append(matched, slot)
+	}
+	return matched
+}
+
+// retryFailedHistory re-queues the failed slots matching category, giving each
+// retry its own request timeout so long lists are not cut short.
+func retryFailedHistory(ctx context.Context, printer *output.Printer, retry func(context.Context, string) error, slots []sabapi.HistorySlot, category string, dryRun bool) error {
+	matched := filterFailedHistory(slots, category)
+
+	retried := make([]string, 0, len(matched))
+	var failures []historyRetryFailure
+	if !dryRun {
+		for _, slot := range matched {
+			reqCtx, cancel := timeoutContext(ctx)
+			err := retry(reqCtx, slot.NZOID)
+			cancel()
+			if err != nil {
+				failures = append(failures, historyRetryFailure{NZOID: slot.NZOID, Error: err.Error()})
+				continue
+			}
+			retried = append(retried, slot.NZOID)
+		}
+	}
+
+	for _, failure := range failures {
+		printer.AlwaysError("Retry %s failed: %s", failure.NZOID, failure.Error)
+	}
+
+	if printer.JSON {
+		if err := printer.Print(map[string]any{
+			"category": category,
+			"dry_run":  dryRun,
+			"matched":  matched,
+			"retried":  retried,
+			"failed":   failures,
+		}); err != nil {
+			return err
+		}
+	} else {
+		if dryRun {
+			rows := make([][]string, 0, len(matched))
+			for _, slot := range matched {
+				rows = append(rows, []string{slot.NZOID, slot.Name, slot.Category})
+			}
+			if err := printer.Table([]string{"ID", "Name", "Category"}, rows); err != nil {
+				return err
+			}
+			return printer.Print(fmt.Sprintf("Would re-queue %d failed entries", len(matched)))
+		}
+		if err := printer.Print(fmt.Sprintf("Re-queued %d of %d failed entries", len(retried), len(matched))); err != nil {
+			return err
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d retries failed", len(failures), len(matched))
+	}
+	return nil
+}
+
 func historyMarkCompletedCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "mark-completed <nzo-id> [nzo-id...]",
+		Use:   "mark-completed <ref> [ref...]",
 		Short: jsonShort("Mark history entries as completed"),
+		Long:  "Marks history entries as completed. " + historyRefLongNote,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
-				return errors.New("provide at least one nzo-id")
+				return errors.New("provide at least one item reference")
 			}
 			return nil
 		},
@@ -184,15 +528,180 @@ func historyMarkCompletedCmd() *cobra.Command {
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 
-			if err := app.Client.HistoryMarkCompleted(ctx, args); err != nil {
+			ids, err := resolveHistoryRefs(ctx, app.Client, args)
+			if err != nil {
+				return err
+			}
+
+			if err := app.Client.HistoryMarkCompleted(ctx, ids); err != nil {
+				return err
+			}
+
+			if app.Printer.JSON {
+				return app.Printer.Print(map[string]any{"marked": ids})
+			}
+			return app.Printer.Print(fmt.Sprintf("Marked %s as completed", strings.Join(ids, ",")))
+		},
+	}
+	return cmd
+}
+
+func resolveHistoryRef(ctx context.Context, client *sabapi.Client, token string) (string, error) {
+	ids, err := resolveHistoryRefs(ctx, client, []string{token})
+	if err != nil {
+		return "", err
+	}
+	return ids[0], nil
+}
+
+func resolveHistoryRefs(ctx context.Context, client *sabapi.Client, tokens []string) ([]string, error) {
+	return resolveRefs(tokens, func() ([]ref.Candidate, error) {
+		history, err := client.History(ctx, false, 0)
+		if err != nil {
+			return nil, err
+		}
+		return historyCandidates(history.Slots), nil
+	})
+}
+
+func historyCandidates(slots []sabapi.HistorySlot) []ref.Candidate {
+	candidates := make([]ref.Candidate, 0, len(slots))
+	for _, slot := range slots {
+		candidates = append(candidates, ref.Candidate{ID: slot.NZOID, Name: slot.Name})
+	}
+	return candidates
+}
+
+func historyPathCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "path <ref>",
+		Short: jsonShort("Print the storage path of a completed job"),
+		Long:  appendJSONLong("Prints where SABnzbd stored a completed job. The path is on the SABnzbd host, which may not be this machine. " + historyRefLongNote),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, err := getApp(cmd)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := timeoutContext(cmd.Context())
+			defer cancel()
+
+			slot, err := findHistorySlot(ctx, app.Client, args[0])
+			if err != nil {
+				return err
+			}
+			storage, err := historyStoragePath(slot)
+			if err != nil {
 				return err
 			}
 
 			if app.Printer.JSON {
-				return app.Printer.Print(map[string]any{"marked": args})
+				return app.Printer.Print(map[string]any{
+					"nzo_id":  slot.NZOID,
+					"name":    slot.Name,
+					"storage": storage,
+					"local":   isLocalBaseURL(app.BaseURL),
+				})
 			}
-			return app.Printer.Print(fmt.Sprintf("Marked %s as completed", strings.Join(args, ",")))
+			return app.Printer.Print(storage)
 		},
 	}
 	return cmd
 }
+
+func historyOpenCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "open <ref>",
+		Short: "Open a completed job's folder in the file manager",
+		Long:  "Opens the storage folder of a completed job in the OS file manager. Only works when SABnzbd runs on this machine; use 'sabx history path' for remote hosts. " + historyRefLongNote,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, err := getApp(cmd)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := timeoutContext(cmd.Context())
+			defer cancel()
+
+			slot, err := findHistorySlot(ctx, app.Client, args[0])
+			if err != nil {
+				return err
+			}
+			storage, err := historyStoragePath(slot)
+			if err != nil {
+				return err
+			}
+			if !isLocalBaseURL(app.BaseURL) {
+				return fmt.Errorf("%s is stored on the SABnzbd host (%s), not this machine; use 'sabx history path' to print it", storage, app.BaseURL)
+			}
+			if _, err := os.Stat(storage); err != nil {
+				return fmt.Errorf("storage path not accessible: %w", err)
+			}
+			if err := openInFileManager(storage); err != nil {
+				return err
+			}
+			return app.Printer.Print(fmt.Sprintf("Opened %s", storage))
+		},
+	}
+	return cmd
+}
+
+func findHistorySlot(ctx context.Context, client *sabapi.Client, token string) (sabapi.HistorySlot, error) {
+	history, err := client.History(ctx, false, 0)
+	if err != nil {
+		return sabapi.HistorySlot{}, err
+	}
+	match, err := ref.Resolve(token, historyCandidates(history.Slots))
+	if err != nil {
+		if errors.Is(err, ref.ErrNotFound) {
+			return sabapi.HistorySlot{}, fmt.Errorf("history entry %s not found", token)
+		}
+		return sabapi.HistorySlot{}, err
+	}
+	for _, slot := range history.Slots {
+		if slot.NZOID == match.ID {
+			return slot, nil
+		}
+	}
+	return sabapi.HistorySlot{}, fmt.Errorf("history entry %s not found", token)
+}
+
+// historyStoragePath returns the final location of a completed job.
+func historyStoragePath(slot sabapi.HistorySlot) (string, error) {
+	storage := strings.TrimSpace(slot.Storage)
+	if storage == "" {
+		return "", fmt.Errorf("%s has no storage path (status %s)", firstNonEmpty(slot.Name, slot.NZOID), firstNonEmpty(slot.Status, "unknown"))
+	}
+	return storage, nil
+}
+
+// isLocalBaseURL reports whether SABnzbd is reached over the loopback
+// interface, meaning its filesystem paths are valid on this machine.
+func isLocalBaseURL(baseURL string) bool {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func openInFileManager(path string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", path)
+	case "windows":
+		cmd = exec.Command("explorer", path)
+	default:
+		cmd = exec.Command("xdg-open", path)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("open file manager: %w", err)
+	}
+	return cmd.Process.Release()
+}
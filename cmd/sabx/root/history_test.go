@@ -0,0 +1,396 @@
+package root
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+var cannedFailedHistory = []sabapi.HistorySlot{
+	{NZOID: "SABnzbd_nzo_tv1", Name: "Show.S01E01", Status: "Failed", Category: "tv"},
+	{NZOID: "SABnzbd_nzo_mv1", Name: "Movie.2024", Status: "Failed", Category: "movies"},
+	{NZOID: "SABnzbd_nzo_tv2", Name: "Show.S01E02", Status: "Failed", Category: "TV"},
+	{NZOID: "SABnzbd_nzo_ok1", Name: "Show.S01E03", Status: "Completed", Category: "tv"},
+}
+
+func TestFilterFailedHistory(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		category string
+		want     []string
+	}{
+		{name: "all failed", want: []string{"SABnzbd_nzo_tv1", "SABnzbd_nzo_mv1", "SABnzbd_nzo_tv2"}},
+		{name: "category ignores case", category: "tv", want: []string{"SABnzbd_nzo_tv1", "SABnzbd_nzo_tv2"}},
+		{name: "unknown category", category: "music", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := []string{}
+			for _, slot := range filterFailedHistory(cannedFailedHistory, tt.category) {
+				got = append(got, slot.NZOID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("filterFailedHistory = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryFailedHistoryRetriesEachMatch(t *testing.T) {
+	t.Parallel()
+
+	var called []string
+	retry := func(_ context.Context, id string) error {
+		called = append(called, id)
+		if id == "SABnzbd_nzo_tv2" {
+			return errors.New("boom")
+		}
+		return nil
+	}
+
+	var out, errOut bytes.Buffer
+	printer := &output.Printer{Out: &out, Err: &errOut}
+	err := retryFailedHistory(context.Background(), printer, retry, cannedFailedHistory, "tv", false)
+	if err == nil || !strings.Contains(err.Error(), "1 of 2") {
+		t.Fatalf("expected partial failure error, got %v", err)
+	}
+	if want := []string{"SABnzbd_nzo_tv1", "SABnzbd_nzo_tv2"}; !reflect.DeepEqual(called, want) {
+		t.Fatalf("retried %v, want %v", called, want)
+	}
+	if !strings.Contains(out.String(), "Re-queued 1 of 2") {
+		t.Fatalf("unexpected summary %q", out.String())
+	}
+	if !strings.Contains(errOut.String(), "SABnzbd_nzo_tv2") {
+		t.Fatalf("expected failure reported, got %q", errOut.String())
+	}
+}
+
+func TestRetryFailedHistoryQuietStillReportsFailures(t *testing.T) {
+	t.Parallel()
+
+	retry := func(_ context.Context, id string) error {
+		if id == "SABnzbd_nzo_tv2" {
+			return errors.New("boom")
+		}
+		return nil
+	}
+
+	var out, errOut bytes.Buffer
+	printer := &output.Printer{Quiet: true, Out: &out, Err: &errOut}
+	if err := retryFailedHistory(context.Background(), printer, retry, cannedFailedHistory, "tv", false); err == nil {
+		t.Fatal("expected partial failure error")
+	}
+	if out.Len() != 0 {
+		t.Fatalf("expected successes suppressed under --quiet, got %q", out.String())
+	}
+	if !strings.Contains(errOut.String(), "Retry SABnzbd_nzo_tv2 failed: boom") || strings.Contains(errOut.String(), "SABnzbd_nzo_tv1") {
+		t.Fatalf("expected only the failure on stderr, got %q", errOut.String())
+	}
+}
+
+func TestRetryFailedHistoryDryRun(t *testing.T) {
+	t.Parallel()
+
+	retry := func(context.Context, string) error {
+		t.Fatal("dry run must not retry")
+		return nil
+	}
+
+	var out bytes.Buffer
+	printer := &output.Printer{Out: &out}
+	if err := retryFailedHistory(context.Background(), printer, retry, cannedFailedHistory, "movies", true); err != nil {
+		t.Fatalf("retryFailedHistory returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "SABnzbd_nzo_mv1") || !strings.Contains(out.String(), "Would re-queue 1") {
+		t.Fatalf("unexpected dry-run output %q", out.String())
+	}
+}
+
+func TestHistoryStoragePath(t *testing.T) {
+	t.Parallel()
+
+	path, err := historyStoragePath(sabapi.HistorySlot{NZOID: "SABnzbd_nzo_1", Name: "Show.S01E01", Status: "Completed", Storage: " /downloads/complete/tv/Show.S01E01 "})
+	if err != nil {
+		t.Fatalf("historyStoragePath returned error: %v", err)
+	}
+	if path != "/downloads/complete/tv/Show.S01E01" {
+		t.Fatalf("unexpected path %q", path)
+	}
+
+	_, err = historyStoragePath(sabapi.HistorySlot{NZOID: "SABnzbd_nzo_2", Name: "Movie.2024", Status: "Failed"})
+	if err == nil || !strings.Contains(err.Error(), "Movie.2024") || !strings.Contains(err.Error(), "Failed") {
+		t.Fatalf("expected descriptive error for missing storage, got %v", err)
+	}
+}
+
+func TestIsLocalBaseURL(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]bool{
+		"http://localhost:8080/sabnzbd": true,
+		"http://127.0.0.1:8080":         true,
+		"http://[::1]:8080":             true,
+		"https://sab.example.com":       false,
+		"http://192.168.1.10:8080":      false,
+		"":                              false,
+	}
+	for baseURL, want := range tests {
+		if got := isLocalBaseURL(baseURL); got != want {
+			t.Fatalf("isLocalBaseURL(%q) = %v, want %v", baseURL, got, want)
+		}
+	}
+}
+
+func TestSelectHistoryOlderThan(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	day := func(n int) sabapi.UnixTime { return sabapi.UnixTime(now.AddDate(0, 0, -n).Unix()) }
+	slots := []sabapi.HistorySlot{
+		{NZOID: "SABnzbd_nzo_new", Status: "Completed", Completed: day(2)},
+		{NZOID: "SABnzbd_nzo_old", Status: "Completed", Completed: day(45)},
+		{NZOID: "SABnzbd_nzo_oldfail", Status: "Failed", Completed: day(60)},
+		{NZOID: "SABnzbd_nzo_newfail", Status: "Failed", Completed: day(1)},
+		{NZOID: "SABnzbd_nzo_unknown", Status: "Failed"},
+	}
+	cutoff := now.AddDate(0, 0, -30)
+
+	tests := []struct {
+		name       string
+		failedOnly bool
+		want       []string
+	}{
+		{name: "any status", want: []string{"SABnzbd_nzo_old", "SABnzbd_nzo_oldfail"}},
+		{name: "failed only", failedOnly: true, want: []string{"SABnzbd_nzo_oldfail"}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var got []string
+			for _, slot := range selectHistoryOlderThan(slots, cutoff, tt.failedOnly) {
+				got = append(got, slot.NZOID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("selectHistoryOlderThan = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAgeDuration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{raw: "30d", want: 30 * 24 * time.Hour},
+		{raw: "2w", want: 14 * 24 * time.Hour},
+		{raw: "12h", want: 12 * time.Hour},
+		{raw: "1.5d", want: 36 * time.Hour},
+		{raw: "", wantErr: true},
+		{raw: "xd", wantErr: true},
+		{raw: "-3d", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.raw, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseAgeDuration(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error for %q, got %v", tt.raw, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("parseAgeDuration(%q) = %v, %v; want %v", tt.raw, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistoryGroupKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "Show.S01E01", want: "show.s01e01"},
+		{name: "Show.S01E01.1", want: "show.s01e01"},
+		{name: "show.s01e01 (2)", want: "show.s01e01"},
+		{name: "Show.S01E01.nzb", want: "show.s01e01"},
+		{name: "Show.S01E01_retry2", want: "show.s01e01"},
+		{name: "Movie.2024", want: "movie.2024"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := historyGroupKey(tt.name); got != tt.want {
+				t.Fatalf("historyGroupKey(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupHistoryAttemptsFailedThenCompleted(t *testing.T) {
+	t.Parallel()
+
+	slots := []sabapi.HistorySlot{
+		{NZOID: "SABnzbd_nzo_try2", Name: "Show.S01E01.1", Status: "Completed", Category: "tv", Completed: 2000},
+		{NZOID: "SABnzbd_nzo_other", Name: "Movie.2024", Status: "Completed", Category: "movies", Completed: 1500},
+		{NZOID: "SABnzbd_nzo_try1", Name: "Show.S01E01", Status: "Failed", Category: "tv", Completed: 1000},
+	}
+
+	groups := groupHistoryAttempts(slots)
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %+v", groups)
+	}
+	show := groups[0]
+	if show.NZOID != "SABnzbd_nzo_try2" || show.Status != "Completed" || show.Attempts != 2 {
+		t.Fatalf("unexpected show group %+v", show)
+	}
+	if !reflect.DeepEqual(show.NZOIDs, []string{"SABnzbd_nzo_try2", "SABnzbd_nzo_try1"}) {
+		t.Fatalf("unexpected attempt ids %v", show.NZOIDs)
+	}
+	if groups[1].Attempts != 1 || groups[1].Name != "Movie.2024" {
+		t.Fatalf("unexpected movie group %+v", groups[1])
+	}
+
+	var out bytes.Buffer
+	if err := printHistoryGroups(&output.Printer{JSON: true, Out: &out}, groups, false); err != nil {
+		t.Fatalf("printHistoryGroups returned error: %v", err)
+	}
+	var payload []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("decode output: %v\n%s", err, out.String())
+	}
+	if payload[0]["attempts"] != float64(2) || payload[0]["status"] != "Completed" || payload[0]["nzo_id"] != "SABnzbd_nzo_try2" {
+		t.Fatalf("unexpected JSON group %v", payload[0])
+	}
+
+	// A job whose latest attempt failed stays visible as failed even though
+	// an earlier attempt existed.
+	reversed := []sabapi.HistorySlot{
+		{NZOID: "SABnzbd_nzo_b", Name: "Show.S01E02 (1)", Status: "Failed", Completed: 3000},
+		{NZOID: "SABnzbd_nzo_a", Name: "Show.S01E02", Status: "Completed", Completed: 100},
+	}
+	out.Reset()
+	if err := printHistoryGroups(&output.Printer{Out: &out}, groupHistoryAttempts(reversed), true); err != nil {
+		t.Fatalf("printHistoryGroups returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "0 jobs (0 attempts)") {
+		t.Fatalf("expected --completed to drop a job whose latest attempt failed, got %q", out.String())
+	}
+}
+
+func TestHistoryDeleteResolvesIDPrefix(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantIDs string
+		wantErr string
+	}{
+		{name: "unique prefix", args: []string{"mv"}, wantIDs: "SABnzbd_nzo_mv1"},
+		{name: "full id form passes through", args: []string{"SABnzbd_nzo_tv9"}, wantIDs: "SABnzbd_nzo_tv9"},
+		{name: "ambiguous prefix", args: []string{"tv"}, wantErr: "ambiguous"},
+		{name: "unknown prefix", args: []string{"zz"}, wantErr: "no matching item"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var deleted atomic.Value
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				q := r.URL.Query()
+				if q.Get("name") == "delete" {
+					deleted.Store(q.Get("value"))
+					_, _ = w.Write([]byte(`{"status":true}`))
+					return
+				}
+				_ = json.NewEncoder(w).Encode(map[string]any{"history": map[string]any{"slots": cannedFailedHistory}})
+			}))
+			t.Cleanup(server.Close)
+			client, err := sabapi.NewClient(server.URL, "secret")
+			if err != nil {
+				t.Fatalf("NewClient returned error: %v", err)
+			}
+
+			cmd := historyDeleteCmd()
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{
+				Client:  client,
+				Printer: &output.Printer{Out: &bytes.Buffer{}, Err: &bytes.Buffer{}},
+			}))
+			cmd.SetArgs(tt.args)
+			err = cmd.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				if deleted.Load() != nil {
+					t.Fatal("nothing may be deleted when the reference does not resolve")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("history delete returned error: %v", err)
+			}
+			if got, _ := deleted.Load().(string); got != tt.wantIDs {
+				t.Fatalf("deleted %q, want %q", got, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestRetryFailedHistoryUsesPerRetryTimeout(t *testing.T) {
+	t.Parallel()
+
+	var contexts []context.Context
+	retry := func(ctx context.Context, _ string) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Fatal("expected each retry to carry a deadline")
+		}
+		contexts = append(contexts, ctx)
+		return nil
+	}
+
+	printer := &output.Printer{Out: io.Discard, Err: io.Discard}
+	if err := retryFailedHistory(context.Background(), printer, retry, cannedFailedHistory, "tv", false); err != nil {
+		t.Fatalf("retryFailedHistory returned error: %v", err)
+	}
+	if len(contexts) < 2 || contexts[0] == contexts[1] {
+		t.Fatalf("expected a fresh context per retry, got %d", len(contexts))
+	}
+	if contexts[0].Err() == nil {
+		t.Fatal("expected the first retry's context to be released before the next retry")
+	}
+}
@@ -0,0 +1,88 @@
+package root
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avivsinai/sabx/internal/auth"
+	"github.com/avivsinai/sabx/internal/config"
+	"github.com/avivsinai/sabx/internal/prompt"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+func initCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Interactively configure a SABnzbd profile",
+		Long:  "Walks through base URL, API key, and profile setup, verifies the connection, then saves the profile as the default. Requires an interactive terminal; use 'sabx login' in scripts.",
+		Annotations: map[string]string{
+			"skipPersistent": "true",
+		},
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !prompt.IsTerminal(cmd.InOrStdin()) {
+				return fmt.Errorf("%w: sabx init must be run interactively; use 'sabx login --base-url <url> --api-key <key>' instead", prompt.ErrNotInteractive)
+			}
+
+			out := cmd.OutOrStdout()
+			p := prompt.New(cmd.InOrStdin(), out)
+
+			baseURL, err := p.String("SABnzbd base URL", firstNonEmpty(strings.TrimSpace(baseURLFlag), "http://localhost:8080"))
+			if err != nil {
+				return err
+			}
+			baseURL, err = sabapi.NormalizeBaseURL(baseURL)
+			if err != nil {
+				return err
+			}
+
+			apiKey, err := p.Secret("API key (leave empty to paste from clipboard)")
+			if err != nil {
+				return err
+			}
+			if apiKey == "" {
+				apiKey, err = prompt.ReadClipboard()
+				if err != nil {
+					return err
+				}
+				if apiKey == "" {
+					return errors.New("clipboard is empty; re-run init and type the API key")
+				}
+				fmt.Fprintln(out, "Read API key from clipboard.")
+			}
+
+			profile, err := p.String("Profile name", profileOrDefault(selectedProfile()))
+			if err != nil {
+				return err
+			}
+			profile = profileOrDefault(profile)
+
+			allowFallback, err := p.Confirm("Allow encrypted file store when the OS keychain is unavailable?", auth.AllowInsecureStoreFromEnv())
+			if err != nil {
+				return err
+			}
+
+			client, err := sabapi.NewClient(baseURL, apiKey)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := timeoutContext(cmd.Context())
+			defer cancel()
+			fmt.Fprintf(out, "Checking connection to %s...\n", baseURL)
+			if err := client.Ping(ctx); err != nil {
+				return fmt.Errorf("connection check failed: %w", err)
+			}
+
+			if err := saveLoginProfile(cmd.ErrOrStderr(), profile, config.Profile{BaseURL: baseURL, AllowInsecureStore: allowFallback}, apiKey, false, true); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(out, "Saved profile %q (base URL: %s) and set it as default\n", profile, baseURL)
+			return nil
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,29 @@
+package root
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/avivsinai/sabx/internal/prompt"
+)
+
+func TestInitRequiresTerminal(t *testing.T) {
+	cmd := initCmd()
+	cmd.SetIn(strings.NewReader("http://localhost:8080\n"))
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs(nil)
+
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatal("expected error for non-interactive input")
+	}
+	if !errors.Is(err, prompt.ErrNotInteractive) {
+		t.Fatalf("expected ErrNotInteractive, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "sabx login") {
+		t.Fatalf("expected login hint in error, got %q", err.Error())
+	}
+}
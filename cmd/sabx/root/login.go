@@ -3,12 +3,15 @@ package root
 import (
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/avivsinai/sabx/internal/auth"
 	"github.com/avivsinai/sabx/internal/config"
+	"github.com/avivsinai/sabx/internal/prompt"
+	"github.com/avivsinai/sabx/internal/sabapi"
 )
 
 func loginCmd() *cobra.Command {
@@ -19,75 +22,96 @@ func loginCmd() *cobra.Command {
 		setDefault         bool
 		allowInsecureStore bool
 		storeInConfig      bool
+		stdinAPIKey        bool
+		fromINI            string
+		apiKeyHeader       string
+		apiKeyQuery        bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "login",
 		Short: jsonShort("Authenticate sabx with a SABnzbd instance"),
-		Long:  "Stores SABnzbd connection details and API key securely in the system keychain.",
+		Long: "Stores SABnzbd connection details and API key securely in the system keychain. " +
+			"Use --stdin-api-key to pipe the key in (e.g. echo $KEY | sabx login --base-url ... --stdin-api-key) so it stays out of shell history and process listings. " +
+			"On the SABnzbd host, --from-ini reads the API key and listening address from sabnzbd.ini (--from-ini auto searches the usual locations); explicit flags still win. " +
+			"Behind an API gateway that authenticates by header, --api-key-header sends the key in that header instead of the apikey query parameter. " +
+			"SABnzbd itself only accepts the query parameter, so add --api-key-query when the gateway passes requests through unchanged. " +
+			"If the host has no OS keychain, login on a terminal offers encrypted file storage and remembers the choice on the profile; scripts must pass --allow-insecure-store.",
 		Annotations: map[string]string{
 			"skipPersistent": "true",
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			baseURL := firstNonEmpty(baseURLFlagLocal, baseURLFlag)
+			var ini sabnzbdINI
+			if fromINI != "" {
+				var iniPath string
+				var err error
+				ini, iniPath, err = loadSABnzbdINI(fromINI)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "Read connection details from %s\n", iniPath)
+			}
+
+			baseURL := firstNonEmpty(baseURLFlagLocal, baseURLFlag, ini.BaseURL)
 			baseURL = strings.TrimSpace(baseURL)
 			if baseURL == "" {
 				return errors.New("--base-url is required")
 			}
 
-			if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
-				baseURL = "http://" + baseURL
+			baseURL, err := sabapi.NormalizeBaseURL(baseURL)
+			if err != nil {
+				return err
 			}
 
 			apiKey := firstNonEmpty(apiKeyFlagLocal, apiKeyFlag)
 			apiKey = strings.TrimSpace(apiKey)
+			if stdinAPIKey {
+				if apiKey != "" {
+					return errors.New("--stdin-api-key cannot be combined with --api-key")
+				}
+				apiKey, err = readStdinAPIKey(cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+			}
+			if apiKey == "" {
+				apiKey = ini.APIKey
+			}
 			if apiKey == "" {
 				return errors.New("--api-key is required")
 			}
 
-			profile := firstNonEmpty(profileLocal, profileFlag)
+			profile := firstNonEmpty(profileLocal, selectedProfile())
 			profile = profileOrDefault(profile)
 
-			cfg, err := config.Load()
-			if err != nil {
-				return err
+			apiKeyHeader = strings.TrimSpace(apiKeyHeader)
+			if apiKeyQuery && apiKeyHeader == "" {
+				return errors.New("--api-key-query requires --api-key-header")
 			}
 
 			allowFallback := allowInsecureStore || auth.AllowInsecureStoreFromEnv()
-
 			prof := config.Profile{
 				BaseURL:            baseURL,
 				AllowInsecureStore: allowFallback,
+				APIKeyHeader:       apiKeyHeader,
+				APIKeyQuery:        apiKeyQuery,
 			}
-			if storeInConfig {
-				prof.APIKey = apiKey
-			}
-			cfg.SetProfile(profile, prof)
-			if setDefault {
-				cfg.DefaultProfile = profile
-			} else if cfg.DefaultProfile == "" {
-				cfg.DefaultProfile = profile
-			}
-
-			if err := cfg.Save(); err != nil {
-				return err
-			}
-
-			storeOpts := []auth.Option{}
-			if allowFallback {
-				storeOpts = append(storeOpts, auth.WithAllowFileFallback(true))
-			}
-
-			if !storeInConfig {
-				if err := auth.SaveAPIKey(profile, baseURL, apiKey, storeOpts...); err != nil {
-					return fmt.Errorf("failed to store api key securely: %w", err)
+			err = saveLoginProfile(cmd.ErrOrStderr(), profile, prof, apiKey, storeInConfig, setDefault)
+			if err != nil && !allowFallback && auth.IsNoKeyringError(err) {
+				in := cmd.InOrStdin()
+				useFile, promptErr := offerFileStore(in, cmd.ErrOrStderr(), prompt.IsTerminal(in), err)
+				if promptErr != nil {
+					return promptErr
 				}
-			} else {
-				// Best-effort cleanup in case a previous login wrote to the keyring.
-				if err := auth.DeleteAPIKey(profile, baseURL, storeOpts...); err != nil && !errors.Is(err, auth.ErrNotFound) {
-					fmt.Fprintf(cmd.ErrOrStderr(), "Warning: unable to remove keyring entry (%v)\n", err)
+				if useFile {
+					allowFallback = true
+					prof.AllowInsecureStore = true
+					err = saveLoginProfile(cmd.ErrOrStderr(), profile, prof, apiKey, storeInConfig, setDefault)
 				}
 			}
+			if err != nil {
+				return err
+			}
 
 			fmt.Fprintf(cmd.OutOrStdout(), "Saved profile %q (base URL: %s)\n", profile, baseURL)
 			if allowFallback {
@@ -102,6 +126,10 @@ func loginCmd() *cobra.Command {
 
 	cmd.Flags().StringVar(&baseURLFlagLocal, "base-url", "", "SABnzbd base URL (e.g., http://localhost:8080)")
 	cmd.Flags().StringVar(&apiKeyFlagLocal, "api-key", "", "SABnzbd API key")
+	cmd.Flags().BoolVar(&stdinAPIKey, "stdin-api-key", false, "Read the API key from standard input")
+	cmd.Flags().StringVar(&fromINI, "from-ini", "", "Read the API key and address from this sabnzbd.ini (\"auto\" searches the default locations)")
+	cmd.Flags().StringVar(&apiKeyHeader, "api-key-header", "", "Send the API key in this header (for API gateways) instead of the apikey query parameter")
+	cmd.Flags().BoolVar(&apiKeyQuery, "api-key-query", false, "With --api-key-header, still send the apikey query parameter SABnzbd expects")
 	cmd.Flags().StringVar(&profileLocal, "profile", "", "Profile name to associate with these credentials")
 	cmd.Flags().BoolVar(&setDefault, "set-default", false, "Set this profile as the default")
 	cmd.Flags().BoolVar(&allowInsecureStore, "allow-insecure-store", false, "Allow encrypted file-based storage when OS keychain is unavailable")
@@ -110,6 +138,88 @@ func loginCmd() *cobra.Command {
 	return cmd
 }
 
+// offerFileStore handles a login that failed because the host has no OS
+// keychain. On a terminal it asks once whether to use the encrypted file
+// store instead; the answer is kept on the profile by the caller. Without a
+// terminal the original error is returned, so scripts still need
+// --allow-insecure-store.
+func offerFileStore(in io.Reader, out io.Writer, interactive bool, saveErr error) (bool, error) {
+	if !interactive {
+		return false, saveErr
+	}
+	ok, err := prompt.New(in, out).Confirm("No OS keychain available. Use encrypted file storage for the API key?", false)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return false, saveErr
+	}
+	return true, nil
+}
+
+// maxStdinAPIKeyBytes bounds how much of stdin --stdin-api-key consumes.
+const maxStdinAPIKeyBytes = 4096
+
+// readStdinAPIKey reads a piped API key, trimming surrounding whitespace and
+// the trailing newline. An interactive terminal is rejected so the command
+// does not hang waiting for input that never comes.
+func readStdinAPIKey(in io.Reader) (string, error) {
+	if prompt.IsTerminal(in) {
+		return "", errors.New("--stdin-api-key expects the key on a pipe, not a terminal")
+	}
+	data, err := io.ReadAll(io.LimitReader(in, maxStdinAPIKeyBytes))
+	if err != nil {
+		return "", fmt.Errorf("read api key from stdin: %w", err)
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", errors.New("no api key received on stdin")
+	}
+	return key, nil
+}
+
+// saveLoginProfile persists prof to config and the API key to the keyring
+// (or config when storeInConfig is set).
+func saveLoginProfile(errOut io.Writer, profile string, prof config.Profile, apiKey string, storeInConfig, setDefault bool) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	baseURL := prof.BaseURL
+	allowFallback := prof.AllowInsecureStore
+	if storeInConfig {
+		prof.APIKey = apiKey
+	}
+	cfg.SetProfile(profile, prof)
+	if setDefault {
+		cfg.DefaultProfile = profile
+	} else if cfg.DefaultProfile == "" {
+		cfg.DefaultProfile = profile
+	}
+
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+
+	storeOpts := []auth.Option{}
+	if allowFallback {
+		storeOpts = append(storeOpts, auth.WithAllowFileFallback(true))
+	}
+
+	if !storeInConfig {
+		if err := auth.SaveAPIKey(profile, baseURL, apiKey, storeOpts...); err != nil {
+			return fmt.Errorf("failed to store api key securely: %w", err)
+		}
+	} else {
+		// Best-effort cleanup in case a previous login wrote to the keyring.
+		if err := auth.DeleteAPIKey(profile, baseURL, storeOpts...); err != nil && !errors.Is(err, auth.ErrNotFound) {
+			fmt.Fprintf(errOut, "Warning: unable to remove keyring entry (%v)\n", err)
+		}
+	}
+	return nil
+}
+
 func firstNonEmpty(values ...string) string {
 	for _, v := range values {
 		if strings.TrimSpace(v) != "" {
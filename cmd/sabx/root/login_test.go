@@ -0,0 +1,207 @@
+package root
+
+import (
+	"errors"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/avivsinai/sabx/internal/auth"
+	"github.com/avivsinai/sabx/internal/config"
+)
+
+func TestLoginReadsAPIKeyFromPipe(t *testing.T) {
+	t.Setenv("SABX_CONFIG_DIR", t.TempDir())
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe: %v", err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+	go func() {
+		_, _ = io.WriteString(w, "  piped-key-123 \n")
+		_ = w.Close()
+	}()
+
+	cmd := loginCmd()
+	cmd.SetIn(r)
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs([]string{"--base-url", "http://sab.local:8080", "--profile", "ci", "--stdin-api-key", "--store-in-config"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("login returned error: %v", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+	_, profile, err := cfg.ActiveProfile("ci")
+	if err != nil {
+		t.Fatalf("ActiveProfile: %v", err)
+	}
+	if profile.APIKey != "piped-key-123" {
+		t.Fatalf("expected trimmed piped key, got %q", profile.APIKey)
+	}
+}
+
+func TestLoginStdinAPIKeyErrors(t *testing.T) {
+	t.Setenv("SABX_CONFIG_DIR", t.TempDir())
+
+	tests := []struct {
+		name    string
+		stdin   string
+		args    []string
+		wantErr string
+	}{
+		{name: "conflicts with --api-key", stdin: "key\n", args: []string{"--api-key", "flag-key"}, wantErr: "cannot be combined"},
+		{name: "empty pipe", stdin: "\n", args: nil, wantErr: "no api key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := loginCmd()
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			cmd.SetIn(strings.NewReader(tt.stdin))
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			cmd.SetArgs(append([]string{"--base-url", "http://sab.local:8080", "--stdin-api-key", "--store-in-config"}, tt.args...))
+			err := cmd.Execute()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoginUsesProfileFromEnv(t *testing.T) {
+	t.Setenv("SABX_CONFIG_DIR", t.TempDir())
+	t.Setenv("SABX_PROFILE", "from-env")
+
+	cmd := loginCmd()
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs([]string{"--base-url", "http://sab.local:8080", "--api-key", "k", "--store-in-config"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("login returned error: %v", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+	if _, ok := cfg.GetProfile("from-env"); !ok {
+		t.Fatalf("expected login to save the SABX_PROFILE profile, got %+v", cfg.Profiles)
+	}
+}
+
+func TestLoginSavesAPIKeyHeader(t *testing.T) {
+	t.Setenv("SABX_CONFIG_DIR", t.TempDir())
+
+	cmd := loginCmd()
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs([]string{"--base-url", "https://gw.example.com/sab", "--api-key", "k", "--profile", "gw", "--store-in-config", "--api-key-header", "X-Api-Key", "--api-key-query"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("login returned error: %v", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+	_, profile, err := cfg.ActiveProfile("gw")
+	if err != nil {
+		t.Fatalf("ActiveProfile: %v", err)
+	}
+	if profile.APIKeyHeader != "X-Api-Key" || !profile.APIKeyQuery {
+		t.Fatalf("expected header mode with query kept, got %+v", profile)
+	}
+
+	cmd = loginCmd()
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs([]string{"--base-url", "http://sab.local:8080", "--api-key", "k", "--store-in-config", "--api-key-query"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "requires --api-key-header") {
+		t.Fatalf("expected --api-key-query without header to fail, got %v", err)
+	}
+}
+
+// noKeychainEnv points the keyring at a backend this platform cannot open,
+// with the encrypted file store available only when fallback is allowed.
+func noKeychainEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("SABX_CONFIG_DIR", t.TempDir())
+	t.Setenv("SABX_ALLOW_INSECURE_STORE", "")
+	t.Setenv("SABX_KEYRING_BACKEND", "wincred,file")
+	if runtime.GOOS == "windows" {
+		t.Setenv("SABX_KEYRING_BACKEND", "keychain,file")
+	}
+	t.Setenv("SABX_KEYRING_FILE_DIR", t.TempDir())
+	t.Setenv("SABX_KEYRING_PASSPHRASE", "test-passphrase")
+}
+
+func TestLoginWithoutKeychainRequiresFlagWhenNotInteractive(t *testing.T) {
+	noKeychainEnv(t)
+
+	var errOut strings.Builder
+	cmd := loginCmd()
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	cmd.SetIn(strings.NewReader("y\n"))
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(&errOut)
+	cmd.SetArgs([]string{"--base-url", "http://sab.local:8080", "--api-key", "k"})
+	err := cmd.Execute()
+	if err == nil || !auth.IsNoKeyringError(err) || !strings.Contains(err.Error(), "--allow-insecure-store") {
+		t.Fatalf("expected no-keyring error with flag hint, got %v", err)
+	}
+	if strings.Contains(errOut.String(), "encrypted file storage") {
+		t.Fatalf("must not prompt without a terminal, got %q", errOut.String())
+	}
+}
+
+func TestOfferFileStorePrompt(t *testing.T) {
+	noKeychainEnv(t)
+
+	saveErr := saveLoginProfile(io.Discard, "home", config.Profile{BaseURL: "http://sab.local:8080"}, "k", false, true)
+	if !auth.IsNoKeyringError(saveErr) {
+		t.Fatalf("expected no-keyring error, got %v", saveErr)
+	}
+
+	var out strings.Builder
+	useFile, err := offerFileStore(strings.NewReader("n\n"), &out, true, saveErr)
+	if useFile || !errors.Is(err, saveErr) {
+		t.Fatalf("declining must keep the original error, got %v, %v", useFile, err)
+	}
+	if !strings.Contains(out.String(), "No OS keychain available") {
+		t.Fatalf("expected the keychain prompt, got %q", out.String())
+	}
+
+	useFile, err = offerFileStore(strings.NewReader("y\n"), io.Discard, true, saveErr)
+	if err != nil || !useFile {
+		t.Fatalf("accepting must allow the file store, got %v, %v", useFile, err)
+	}
+	if err := saveLoginProfile(io.Discard, "home", config.Profile{BaseURL: "http://sab.local:8080", AllowInsecureStore: true}, "k", false, true); err != nil {
+		t.Fatalf("retry with file store failed: %v", err)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+	if _, profile, err := cfg.ActiveProfile("home"); err != nil || !profile.AllowInsecureStore {
+		t.Fatalf("expected the choice persisted on the profile, got %+v, %v", profile, err)
+	}
+	key, err := auth.LoadAPIKey("home", "http://sab.local:8080", auth.WithAllowFileFallback(true))
+	if err != nil || key != "k" {
+		t.Fatalf("expected key in file store, got %q, %v", key, err)
+	}
+
+	if useFile, err := offerFileStore(strings.NewReader("y\n"), io.Discard, false, saveErr); useFile || !errors.Is(err, saveErr) {
+		t.Fatalf("non-interactive must not prompt, got %v, %v", useFile, err)
+	}
+}
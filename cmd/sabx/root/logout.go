@@ -22,7 +22,7 @@ func logoutCmd() *cobra.Command {
 			"skipPersistent": "true",
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			profileName := firstNonEmpty(profileOverride, profileFlag)
+			profileName := firstNonEmpty(profileOverride, selectedProfile())
 			profileName = profileOrDefault(profileName)
 
 			cfg, err := config.Load()
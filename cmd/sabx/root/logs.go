@@ -59,6 +59,7 @@ func logsTailCmd() *cobra.Command {
 	var limit int
 	var follow bool
 	var interval time.Duration
+	var minInterval time.Duration
 	cmd := &cobra.Command{
 		Use:   "tail",
 		Short: jsonShort("Tail the end of the log"),
@@ -97,6 +98,7 @@ func logsTailCmd() *cobra.Command {
 				return nil
 			}
 
+			client := app.Client.Clone(sabapi.WithMinInterval(minInterval))
 			lastTotal := total
 			ticker := time.NewTicker(interval)
 			defer ticker.Stop()
@@ -106,7 +108,7 @@ func logsTailCmd() *cobra.Command {
 				case <-ctx.Done():
 					return nil
 				case <-ticker.C:
-					lines, currentTotal, err := fetchLogTail(ctx, app.Client, limit)
+					lines, currentTotal, err := fetchLogTail(ctx, client, limit)
 					if err != nil {
 						return err
 					}
@@ -142,6 +144,7 @@ func logsTailCmd() *cobra.Command {
 	cmd.Flags().IntVar(&limit, "lines", defaultTailLines, "Number of lines to display")
 	cmd.Flags().BoolVar(&follow, "follow", false, "Poll for new log lines")
 	cmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "Polling interval for follow mode")
+	cmd.Flags().DurationVar(&minInterval, "min-interval", defaultPollMinInterval, "Minimum delay between SABnzbd requests in follow mode")
 	return cmd
 }
 
@@ -1,16 +1,29 @@
 package root
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
 	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/prompt"
+	"github.com/avivsinai/sabx/internal/ref"
 	"github.com/avivsinai/sabx/internal/sabapi"
 )
 
@@ -29,20 +42,27 @@ func queueCmd() *cobra.Command {
 	cmd.AddCommand(queueCompleteActionCmd())
 	cmd.AddCommand(queueItemCmd())
 	cmd.AddCommand(queueSortCmd())
+	cmd.AddCommand(queueUndoCmd())
 
 	return cmd
 }
 
 func queueListCmd() *cobra.Command {
 	var search string
+	var searchField string
 	var limit int
 	var onlyActive bool
+	var verbose bool
 
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: jsonShort("List queue entries"),
-		Long:  appendJSONLong("Lists queue items, optionally filtering by search term or active download state."),
+		Long:  appendJSONLong("Lists queue items, optionally filtering by search term or active download state. Use --search-field category to match --search against category names instead of job names. The default (all) hands --search to SABnzbd's own search, which supports * wildcards; --search-field name instead matches it literally as a case-insensitive substring of job names. Use --template to render each slot through a Go template. --verbose adds each job's latest post-processing stage message (truncated in the table); JSON output then also carries it as last_stage next to the full stage_log."),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			field, err := sabapi.ParseSearchField(searchField)
+			if err != nil {
+				return err
+			}
 			app, err := getApp(cmd)
 			if err != nil {
 				return err
@@ -51,7 +71,7 @@ func queueListCmd() *cobra.Command {
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 
-			queue, err := app.Client.Queue(ctx, 0, limit, search)
+			queue, err := app.Client.QueueSearch(ctx, 0, limit, search, field)
 			if err != nil {
 				return err
 			}
@@ -60,50 +80,178 @@ func queueListCmd() *cobra.Command {
 			if onlyActive {
 				filtered := make([]sabapi.QueueSlot, 0, len(slots))
 				for _, slot := range slots {
-					if strings.EqualFold(slot.Status, "Downloading") || strings.EqualFold(slot.Status, "Fetching") {
+					if slot.Class() == sabapi.StatusClassActive {
 						filtered = append(filtered, slot)
 					}
 				}
 				slots = filtered
 			}
 
+			if app.Printer.Template != nil {
+				return app.Printer.RenderTemplate(slots)
+			}
+			listed := queueListSlots(slots, verbose)
+			if app.Printer.NDJSON {
+				return app.Printer.PrintLines(listed)
+			}
+
+			// The whole-queue estimate ignores --search/--active filtering.
+			_, slotsLeft := sabapi.SumQueueMB(queue.Slots)
+			queueLeft, err := sabapi.ParseSABFloat(queue.MBLeft)
+			if err != nil || queue.MBLeft == "" {
+				queueLeft = slotsLeft
+			}
+			eta, finish, etaKnown := queueETA(queueLeft, queue.Speed, time.Now())
+
 			if app.Printer.JSON {
-				payload := map[string]any{
-					"slots":      slots,
-					"paused":     queue.Paused,
-					"speed_kbps": queue.Speed,
-					"limit_kbps": queue.SpeedLimit,
+				sizeMB, mbLeft := sabapi.SumQueueMB(slots)
+				payload := queueListPayload{
+					Slots:     listed,
+					Paused:    queue.Paused,
+					SpeedKBps: queue.Speed,
+					LimitKBps: queue.SpeedLimit,
+					Count:     len(slots),
+					SizeMB:    sizeMB,
+					MBLeft:    mbLeft,
+					TimeLeft:  queue.TimeLeft,
+					QueueETA:  "unknown",
+				}
+				if etaKnown {
+					payload.QueueETA = eta.String()
+					payload.FinishAt = &finish
 				}
 				return app.Printer.Print(payload)
 			}
 
 			headers := []string{"ID", "Name", "Status", "Done/Left (MB)", "ETA", "Priority"}
-			rows := make([][]string, 0, len(slots))
-			for _, slot := range slots {
-				rows = append(rows, []string{
+			if verbose {
+				headers = append(headers, "Last Stage")
+			}
+			rows := make([][]string, 0, len(listed))
+			for _, slot := range listed {
+				row := []string{
 					slot.NZOID,
 					slot.Filename,
 					slot.Status,
 					fmt.Sprintf("%s/%s", slot.MB, slot.MBLeft),
 					slot.Eta,
 					priorityLabel(slot.Priority),
-				})
+				}
+				if verbose {
+					row = append(row, truncateText(slot.LastStage, lastStageWidth))
+				}
+				rows = append(rows, row)
 			}
 			if err := app.Printer.Table(headers, rows); err != nil {
 				return err
 			}
 			summary := fmt.Sprintf("%d items | Speed %s KB/s (limit %s) | Paused=%v", len(slots), queue.Speed, queue.SpeedLimit, queue.Paused)
+			if etaKnown {
+				summary += fmt.Sprintf(" | Queue ETA %s (finishes %s)", eta, finish.Format("2006-01-02 15:04"))
+			} else {
+				summary += " | Queue ETA unknown"
+			}
 			return app.Printer.Print(summary)
 		},
 	}
 
 	cmd.Flags().StringVar(&search, "search", "", "Filter queue by search string")
+	cmd.Flags().StringVar(&searchField, "search-field", string(sabapi.SearchFieldAll), "Field --search applies to: all (SABnzbd's search), name (literal job name match) or category")
 	cmd.Flags().IntVar(&limit, "limit", 0, "Limit number of results (0 = all)")
-	cmd.Flags().BoolVar(&onlyActive, "active", false, "Show only actively downloading items")
+	cmd.Flags().BoolVar(&onlyActive, "active", false, "Show only active items (downloading, fetching or grabbing)")
+	cmd.Flags().BoolVar(&verbose, "verbose", false, "Add each job's latest post-processing stage message")
 
 	return cmd
 }
 
+// queueListSlot is a queue slot as listed by `queue list`. LastStage is
+// only filled in with --verbose.
+type queueListSlot struct {
+	sabapi.QueueSlot
+	LastStage string `json:"last_stage,omitempty"`
+}
+
+func queueListSlots(slots []sabapi.QueueSlot, verbose bool) []queueListSlot {
+	listed := make([]queueListSlot, 0, len(slots))
+	for _, slot := range slots {
+		item := queueListSlot{QueueSlot: slot}
+		if verbose {
+			item.LastStage = lastStageMessage(slot.StageLog)
+		}
+		listed = append(listed, item)
+	}
+	return listed
+}
+
+// lastStageWidth caps the Last Stage column in the --verbose table.
+const lastStageWidth = 60
+
+// lastStageMessage condenses a stage log to its most recent message as
+// "Stage: message". Stages without messages fall back to the stage name;
+// an empty log yields "".
+func lastStageMessage(stages []sabapi.StageLog) string {
+	for i := len(stages) - 1; i >= 0; i-- {
+		stage := strings.TrimSpace(stages[i].Stage)
+		lines := stages[i].Lines()
+		switch {
+		case len(lines) > 0 && stage != "":
+			return stage + ": " + lines[len(lines)-1]
+		case len(lines) > 0:
+			return lines[len(lines)-1]
+		case stage != "":
+			return stage
+		}
+	}
+	return ""
+}
+
+// truncateText shortens s to at most width runes, marking the cut with an
+// ellipsis.
+func truncateText(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// queueListPayload is the JSON contract for `queue list`. Count, SizeMB and
+// MBLeft cover the listed slots (after --search/--active filtering);
+// TimeLeft is SABnzbd's estimate for the whole queue. QueueETA and FinishAt
+// are sabx's own estimate from the queue's MB left and current speed;
+// QueueETA is "unknown" and FinishAt absent while nothing is downloading.
+type queueListPayload struct {
+	Slots     []queueListSlot `json:"slots"`
+	Paused    bool            `json:"paused"`
+	SpeedKBps string          `json:"speed_kbps"`
+	LimitKBps string          `json:"limit_kbps"`
+	Count     int             `json:"count"`
+	SizeMB    float64         `json:"size_mb"`
+	MBLeft    float64         `json:"mbleft"`
+	TimeLeft  string          `json:"timeleft"`
+	QueueETA  string          `json:"queue_eta"`
+	FinishAt  *time.Time      `json:"finish_at,omitempty"`
+}
+
+// queueETA estimates how long the remaining mbLeft takes at kbps (SABnzbd's
+// kbpersec) and when that will be. ok is false when the speed is zero or
+// unparseable, i.e. the queue is paused or idle and the ETA is unbounded.
+func queueETA(mbLeft float64, kbps string, now time.Time) (eta time.Duration, finish time.Time, ok bool) {
+	if mbLeft <= 0 {
+		return 0, now, true
+	}
+	speed, err := sabapi.ParseSABFloat(kbps)
+	if err != nil || speed <= 0 {
+		return 0, time.Time{}, false
+	}
+	seconds := mbLeft * 1024 / speed
+	eta = time.Duration(seconds * float64(time.Second)).Round(time.Second)
+	return eta, now.Add(eta), true
+}
+
 func queueAddCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "add",
@@ -114,6 +262,7 @@ func queueAddCmd() *cobra.Command {
 	cmd.AddCommand(queueAddURLCmd())
 	cmd.AddCommand(queueAddFileCmd())
 	cmd.AddCommand(queueAddLocalCmd())
+	cmd.AddCommand(queueAddBatchCmd())
 
 	return cmd
 }
@@ -121,30 +270,84 @@ func queueAddCmd() *cobra.Command {
 func queueAddURLCmd() *cobra.Command {
 	var category string
 	var priorityStr string
+	var ppStr string
 	var script string
 	var password string
 	var name string
+	var dedupe duplicateCheck
+	var autoCat bool
+	var forceCat bool
+	var cleanURL bool
+	var checkSpace bool
+	var wait bool
+	var waitTimeout time.Duration
 
 	cmd := &cobra.Command{
 		Use:   "url <nzb-url>",
 		Short: jsonShort("Add an NZB by URL"),
-		Long:  appendJSONLong("Fetch an NZB from a remote URL and enqueue it. Errors surface when SABnzbd rejects the NZB."),
-		Args:  cobra.ExactArgs(1),
+		Long: appendJSONLong("Fetch an NZB from a remote URL and enqueue it. file:// links are uploaded from this machine, nzb:// and nzbs:// shorthands are rewritten to http:// and https://, and other schemes pass through to SABnzbd unchanged. Errors surface when SABnzbd rejects the NZB. " +
+			"With --wait the command blocks until every queued job reaches history, prints the final status, and exits non-zero if any job failed."),
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			app, err := getApp(cmd)
 			if err != nil {
 				return err
 			}
-			nzbURL := args[0]
+			if name, err = addTitle(cmd.Flags(), name); err != nil {
+				return err
+			}
+			target, err := normalizeAddURL(args[0], cleanURL)
+			if err != nil {
+				return err
+			}
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 
-			opts, err := buildAddOptions(priorityStr, category, script, password, name)
+			candidate := urlBaseName(target.value)
+			if target.local {
+				candidate = filepath.Base(target.value)
+			}
+			if autoCat {
+				if category, err = inferCategory(ctx, app, firstNonEmpty(name, candidate), category); err != nil {
+					return err
+				}
+			}
+			if err := checkCategory(ctx, app, category, forceCat); err != nil {
+				return err
+			}
+
+			opts, err := buildAddOptions(priorityStr, ppStr, category, script, password, name)
 			if err != nil {
 				return err
 			}
 
-			resp, err := app.Client.AddURL(ctx, nzbURL, opts)
+			if dedupe.enabled {
+				skipped, err := dedupe.check(ctx, app.Client, firstNonEmpty(name, candidate))
+				if err != nil {
+					return err
+				}
+				if skipped != nil {
+					return printDuplicateSkip(app, skipped)
+				}
+			}
+
+			if checkSpace {
+				size := int64(-1)
+				if target.local {
+					size = declaredSizeOrUnknown(target.value)
+				}
+				if err := checkFreeSpace(ctx, app, size); err != nil {
+					return err
+				}
+			}
+
+			var resp *sabapi.AddResponse
+			if target.local {
+				opts.Progress = uploadProgress(app.Printer, "Uploading "+filepath.Base(target.value))
+				resp, err = app.Client.AddFile(ctx, target.value, opts)
+			} else {
+				resp, err = app.Client.AddURL(ctx, target.value, opts)
+			}
 			if err != nil {
 				return err
 			}
@@ -152,43 +355,249 @@ func queueAddURLCmd() *cobra.Command {
 				return fmt.Errorf("sabnzbd refused nzb: %s", firstNonEmpty(resp.Error, resp.Message, "unknown error"))
 			}
 
-			if app.Printer.JSON {
-				return app.Printer.Print(resp)
+			if wait && len(resp.NZOIDs) == 0 {
+				return errors.New("sabnzbd returned no job ID, so --wait cannot track the job (possibly a duplicate or a rejected fetch)")
+			}
+			if wait {
+				if !app.Printer.JSON {
+					if err := app.Printer.Print(fmt.Sprintf("Queued %s; waiting for completion", strings.Join(resp.NZOIDs, ","))); err != nil {
+						return err
+					}
+				}
+				waitCtx, cancelWait := context.WithTimeout(cmd.Context(), waitTimeout)
+				defer cancelWait()
+				jobs, err := waitForJobs(waitCtx, app.Client, resp.NZOIDs, waitPollInterval)
+				if err != nil {
+					return err
+				}
+				return printJobOutcomes(app.Printer, resp, jobs)
 			}
-			return app.Printer.Print(fmt.Sprintf("Queued %s", strings.Join(resp.NZOIDs, ",")))
+
+			return printAddResponse(app, resp, "Queued", name)
 		},
 	}
 
-	bindAddFlags(cmd.Flags(), &category, &priorityStr, &script, &password, &name)
+	bindAddFlags(cmd.Flags(), &category, &priorityStr, &ppStr, &script, &password, &name)
+	bindDuplicateFlags(cmd.Flags(), &dedupe)
+	bindAutoCatFlag(cmd.Flags(), &autoCat)
+	bindForceCatFlag(cmd.Flags(), &forceCat)
+	bindCheckSpaceFlag(cmd.Flags(), &checkSpace)
+	cmd.Flags().BoolVar(&cleanURL, "clean-url", false, "Strip tracking query parameters (utm_*, fbclid, ...) before adding")
+	cmd.Flags().BoolVar(&wait, "wait", false, "Wait until the job leaves the queue and report its final history status")
+	cmd.Flags().DurationVar(&waitTimeout, "timeout", time.Hour, "Maximum time to wait with --wait")
 	return cmd
 }
 
+// waitPollInterval spaces queue/history checks while waiting on a job.
+const waitPollInterval = 5 * time.Second
+
+// jobOutcome is the final history state of a job added with --wait.
+type jobOutcome struct {
+	NZOID  string `json:"nzo_id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Failed bool   `json:"failed"`
+}
+
+// waitForJobs polls until every ID has left the queue and shows up in
+// history. A job briefly absent from both (between download and history
+// bookkeeping) is treated as still pending.
+func waitForJobs(ctx context.Context, client *sabapi.Client, ids []string, interval time.Duration) ([]jobOutcome, error) {
+	if len(ids) == 0 {
+		return nil, errors.New("no job IDs to wait for")
+	}
+	outcomes := make(map[string]jobOutcome, len(ids))
+	for {
+		for _, id := range ids {
+			if _, done := outcomes[id]; done {
+				continue
+			}
+			_, err := client.QueueSlotByID(ctx, id)
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, sabapi.ErrSlotNotFound) {
+				return nil, err
+			}
+			slot, err := client.HistorySlotByID(ctx, id)
+			if errors.Is(err, sabapi.ErrSlotNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			outcomes[id] = jobOutcome{
+				NZOID:  id,
+				Name:   slot.Name,
+				Status: slot.Status,
+				Failed: strings.EqualFold(slot.Status, "Failed"),
+			}
+		}
+
+		if len(outcomes) == len(ids) {
+			ordered := make([]jobOutcome, 0, len(ids))
+			for _, id := range ids {
+				ordered = append(ordered, outcomes[id])
+			}
+			return ordered, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for %d of %d jobs: %w", len(ids)-len(outcomes), len(ids), ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+func printJobOutcomes(printer *output.Printer, resp *sabapi.AddResponse, jobs []jobOutcome) error {
+	failed := 0
+	for _, job := range jobs {
+		if job.Failed {
+			failed++
+		}
+	}
+
+	if printer.JSON {
+		if err := printer.Print(map[string]any{"queued": resp, "jobs": jobs}); err != nil {
+			return err
+		}
+	} else {
+		rows := make([][]string, 0, len(jobs))
+		for _, job := range jobs {
+			rows = append(rows, []string{job.NZOID, job.Name, job.Status})
+		}
+		if err := printer.Table([]string{"ID", "Name", "Status"}, rows); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d jobs failed", failed, len(jobs))
+	}
+	return nil
+}
+
+// addURLTarget is a normalized `queue add url` argument. Local targets are
+// filesystem paths that must be uploaded instead of fetched by SABnzbd.
+type addURLTarget struct {
+	value string
+	local bool
+}
+
+// trackingParams are query keys removed by --clean-url in addition to utm_*.
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"ref":     true,
+	"ref_src": true,
+}
+
+func normalizeAddURL(raw string, clean bool) (addURLTarget, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return addURLTarget{}, errors.New("nzb url required")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" {
+		return addURLTarget{value: raw}, nil
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "file":
+		p := u.Path
+		if u.Host != "" && u.Host != "localhost" {
+			p = "//" + u.Host + p
+		}
+		if p == "" {
+			return addURLTarget{}, fmt.Errorf("file url %q has no path", raw)
+		}
+		if len(p) >= 3 && p[0] == '/' && p[2] == ':' {
+			// file:///C:/path on Windows
+			p = p[1:]
+		}
+		return addURLTarget{value: filepath.FromSlash(p), local: true}, nil
+	case "nzb":
+		u.Scheme = "http"
+	case "nzbs":
+		u.Scheme = "https"
+	}
+
+	if clean && u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			lower := strings.ToLower(key)
+			if strings.HasPrefix(lower, "utm_") || trackingParams[lower] {
+				query.Del(key)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+	return addURLTarget{value: u.String()}, nil
+}
+
 func queueAddFileCmd() *cobra.Command {
 	var category string
 	var priorityStr string
+	var ppStr string
 	var script string
 	var password string
 	var name string
+	var dedupe duplicateCheck
+	var autoCat bool
+	var forceCat bool
+	var checkSpace bool
 
 	cmd := &cobra.Command{
 		Use:   "file <path>",
 		Short: jsonShort("Upload an NZB file"),
-		Long:  appendJSONLong("Upload a local NZB file to SABnzbd. Errors surface if the file cannot be read or SABnzbd rejects it."),
+		Long:  appendJSONLong("Upload a local NZB file to SABnzbd, showing a progress bar on an interactive terminal. Errors surface if the file cannot be read or SABnzbd rejects it."),
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			app, err := getApp(cmd)
 			if err != nil {
 				return err
 			}
+			if name, err = addTitle(cmd.Flags(), name); err != nil {
+				return err
+			}
 			path := args[0]
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 
-			opts, err := buildAddOptions(priorityStr, category, script, password, name)
+			if autoCat {
+				if category, err = inferCategory(ctx, app, firstNonEmpty(name, filepath.Base(path)), category); err != nil {
+					return err
+				}
+			}
+			if err := checkCategory(ctx, app, category, forceCat); err != nil {
+				return err
+			}
+
+			opts, err := buildAddOptions(priorityStr, ppStr, category, script, password, name)
 			if err != nil {
 				return err
 			}
 
+			if dedupe.enabled {
+				skipped, err := dedupe.check(ctx, app.Client, firstNonEmpty(name, filepath.Base(path)))
+				if err != nil {
+					return err
+				}
+				if skipped != nil {
+					return printDuplicateSkip(app, skipped)
+				}
+			}
+
+			if checkSpace {
+				if err := checkFreeSpace(ctx, app, declaredSizeOrUnknown(path)); err != nil {
+					return err
+				}
+			}
+
+			opts.Progress = uploadProgress(app.Printer, "Uploading "+filepath.Base(path))
 			resp, err := app.Client.AddFile(ctx, path, opts)
 			if err != nil {
 				return err
@@ -197,23 +606,68 @@ func queueAddFileCmd() *cobra.Command {
 				return fmt.Errorf("sabnzbd refused nzb: %s", firstNonEmpty(resp.Error, resp.Message, "unknown error"))
 			}
 
-			if app.Printer.JSON {
-				return app.Printer.Print(resp)
-			}
-			return app.Printer.Print(fmt.Sprintf("Uploaded %s", strings.Join(resp.NZOIDs, ",")))
+			return printAddResponse(app, resp, "Uploaded", name)
 		},
 	}
 
-	bindAddFlags(cmd.Flags(), &category, &priorityStr, &script, &password, &name)
+	bindAddFlags(cmd.Flags(), &category, &priorityStr, &ppStr, &script, &password, &name)
+	bindDuplicateFlags(cmd.Flags(), &dedupe)
+	bindAutoCatFlag(cmd.Flags(), &autoCat)
+	bindForceCatFlag(cmd.Flags(), &forceCat)
+	bindCheckSpaceFlag(cmd.Flags(), &checkSpace)
 	return cmd
 }
 
+// progressBarWidth is the number of cells in the upload progress bar.
+const progressBarWidth = 30
+
+// uploadProgress returns an upload progress bar writing to the printer's
+// stderr, or nil when output is structured, quiet, or not a terminal.
+func uploadProgress(printer *output.Printer, label string) sabapi.ProgressFunc {
+	if printer.Structured() || printer.Quiet {
+		return nil
+	}
+	f, ok := printer.Err.(*os.File)
+	if !ok || !prompt.IsTerminal(f) {
+		return nil
+	}
+	return progressBar(f, label)
+}
+
+// progressBar redraws a single-line bar whenever the whole percentage
+// changes and ends the line once the upload completes.
+func progressBar(w io.Writer, label string) sabapi.ProgressFunc {
+	last := -1
+	return func(sent, total int64) {
+		if total <= 0 {
+			return
+		}
+		pct := int(sent * 100 / total)
+		if pct == last {
+			return
+		}
+		last = pct
+		filled := pct * progressBarWidth / 100
+		fmt.Fprintf(w, "\r%s [%s%s] %3d%% (%s/%s)", label,
+			strings.Repeat("#", filled), strings.Repeat("-", progressBarWidth-filled), pct,
+			humanBytes(float64(sent)), humanBytes(float64(total)))
+		if sent >= total {
+			fmt.Fprintln(w)
+		}
+	}
+}
+
 func queueAddLocalCmd() *cobra.Command {
 	var category string
 	var priorityStr string
+	var ppStr string
 	var script string
 	var password string
 	var name string
+	var dedupe duplicateCheck
+	var autoCat bool
+	var forceCat bool
+	var checkSpace bool
 
 	cmd := &cobra.Command{
 		Use:   "local <path>",
@@ -225,15 +679,44 @@ func queueAddLocalCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if name, err = addTitle(cmd.Flags(), name); err != nil {
+				return err
+			}
 			remotePath := args[0]
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 
-			opts, err := buildAddOptions(priorityStr, category, script, password, name)
+			if autoCat {
+				if category, err = inferCategory(ctx, app, firstNonEmpty(name, path.Base(filepath.ToSlash(remotePath))), category); err != nil {
+					return err
+				}
+			}
+			if err := checkCategory(ctx, app, category, forceCat); err != nil {
+				return err
+			}
+
+			opts, err := buildAddOptions(priorityStr, ppStr, category, script, password, name)
 			if err != nil {
 				return err
 			}
 
+			if dedupe.enabled {
+				skipped, err := dedupe.check(ctx, app.Client, firstNonEmpty(name, path.Base(filepath.ToSlash(remotePath))))
+				if err != nil {
+					return err
+				}
+				if skipped != nil {
+					return printDuplicateSkip(app, skipped)
+				}
+			}
+
+			// The NZB lives on the SABnzbd host, so its size is unknown here.
+			if checkSpace {
+				if err := checkFreeSpace(ctx, app, -1); err != nil {
+					return err
+				}
+			}
+
 			resp, err := app.Client.AddLocalFile(ctx, remotePath, opts)
 			if err != nil {
 				return err
@@ -242,26 +725,363 @@ func queueAddLocalCmd() *cobra.Command {
 				return errors.New("sabnzbd refused nzb")
 			}
 
+			return printAddResponse(app, resp, "Queued", name)
+		},
+	}
+
+	bindAddFlags(cmd.Flags(), &category, &priorityStr, &ppStr, &script, &password, &name)
+	bindDuplicateFlags(cmd.Flags(), &dedupe)
+	bindAutoCatFlag(cmd.Flags(), &autoCat)
+	bindForceCatFlag(cmd.Flags(), &forceCat)
+	bindCheckSpaceFlag(cmd.Flags(), &checkSpace)
+	return cmd
+}
+
+func queueAddBatchCmd() *cobra.Command {
+	var category string
+	var priorityStr string
+	var ppStr string
+	var script string
+	var password string
+	var name string
+	var forceCat bool
+
+	cmd := &cobra.Command{
+		Use:   "batch <file>",
+		Short: jsonShort("Add every NZB URL listed in a file"),
+		Long:  appendJSONLong("Read NZB URLs from a text file (one per line; blank lines and # comments are ignored) or an OPML outline (xmlUrl/url attributes) and enqueue each with the shared --cat/--priority/--script options. Use - to read standard input. Exits non-zero if any URL fails."),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, err := getApp(cmd)
+			if err != nil {
+				return err
+			}
+
+			if name, err = addTitle(cmd.Flags(), name); err != nil {
+				return err
+			}
+			var in io.Reader = cmd.InOrStdin()
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				in = f
+			}
+			entries, err := parseBatchFile(in)
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				return fmt.Errorf("no URLs found in %s", args[0])
+			}
+
+			opts, err := buildAddOptions(priorityStr, ppStr, category, script, password, name)
+			if err != nil {
+				return err
+			}
+			checkCtx, cancelCheck := timeoutContext(cmd.Context())
+			err = checkCategory(checkCtx, app, category, forceCat)
+			cancelCheck()
+			if err != nil {
+				return err
+			}
+
+			if name != "" && len(entries) > 1 {
+				return fmt.Errorf("--title names a single job; %s lists %d URLs", args[0], len(entries))
+			}
+			urls := make([]string, 0, len(entries))
+			for _, entry := range entries {
+				urls = append(urls, entry.URL)
+			}
+
+			// One request per URL; scale the deadline with the batch size.
+			ctx, cancel := context.WithTimeout(cmd.Context(), requestTimeout*time.Duration(len(urls)))
+			defer cancel()
+			results := app.Client.BatchAddURL(ctx, urls, opts)
+
+			type batchRow struct {
+				Line   int      `json:"line"`
+				URL    string   `json:"url"`
+				NZOIDs []string `json:"nzo_ids,omitempty"`
+				Error  string   `json:"error,omitempty"`
+			}
+			rows := make([]batchRow, 0, len(results))
+			failed := 0
+			for i, result := range results {
+				row := batchRow{Line: entries[i].Line, URL: result.URL}
+				if result.Err != nil {
+					row.Error = result.Err.Error()
+					failed++
+				} else if result.Response != nil {
+					row.NZOIDs = result.Response.NZOIDs
+				}
+				rows = append(rows, row)
+			}
+
+			for _, row := range rows {
+				if row.Error != "" {
+					app.Printer.AlwaysError("Line %d: %s: %s", row.Line, row.URL, row.Error)
+				}
+			}
+
 			if app.Printer.JSON {
-				return app.Printer.Print(resp)
+				if err := app.Printer.Print(map[string]any{
+					"results": rows,
+					"added":   len(rows) - failed,
+					"failed":  failed,
+				}); err != nil {
+					return err
+				}
+			} else {
+				table := make([][]string, 0, len(rows))
+				for _, row := range rows {
+					result := "queued " + strings.Join(row.NZOIDs, ",")
+					if len(row.NZOIDs) == 0 {
+						result = "accepted, no job id (possibly a duplicate)"
+					}
+					if row.Error != "" {
+						result = "error: " + row.Error
+					}
+					table = append(table, []string{strconv.Itoa(row.Line), row.URL, result})
+				}
+				if err := app.Printer.Table([]string{"Line", "URL", "Result"}, table); err != nil {
+					return err
+				}
+				if err := app.Printer.Print(fmt.Sprintf("%d added, %d failed", len(rows)-failed, failed)); err != nil {
+					return err
+				}
 			}
-			return app.Printer.Print(fmt.Sprintf("Queued %s", strings.Join(resp.NZOIDs, ",")))
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d URLs failed", failed, len(rows))
+			}
+			return nil
 		},
 	}
 
-	bindAddFlags(cmd.Flags(), &category, &priorityStr, &script, &password, &name)
+	bindAddFlags(cmd.Flags(), &category, &priorityStr, &ppStr, &script, &password, &name)
+	bindForceCatFlag(cmd.Flags(), &forceCat)
 	return cmd
 }
 
-func bindAddFlags(flags *pflag.FlagSet, category, priority, script, password, name *string) {
+// batchEntry is a URL read from a batch file with its 1-based source line
+// (or outline position for OPML).
+type batchEntry struct {
+	Line int
+	URL  string
+}
+
+// parseBatchFile reads NZB URLs from a plain list or a simple OPML document.
+func parseBatchFile(r io.Reader) ([]batchEntry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("<?xml")) || bytes.HasPrefix(bytes.ToLower(trimmed), []byte("<opml")) {
+		return parseBatchOPML(trimmed)
+	}
+
+	var entries []batchEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		entries = append(entries, batchEntry{Line: line, URL: text})
+	}
+	return entries, scanner.Err()
+}
+
+type opmlOutline struct {
+	XMLURL   string        `xml:"xmlUrl,attr"`
+	URL      string        `xml:"url,attr"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+func parseBatchOPML(data []byte) ([]batchEntry, error) {
+	var doc struct {
+		Outlines []opmlOutline `xml:"body>outline"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse OPML: %w", err)
+	}
+
+	var entries []batchEntry
+	var walk func([]opmlOutline)
+	walk = func(outlines []opmlOutline) {
+		for _, o := range outlines {
+			if u := strings.TrimSpace(firstNonEmpty(o.XMLURL, o.URL)); u != "" {
+				entries = append(entries, batchEntry{Line: len(entries) + 1, URL: u})
+			}
+			walk(o.Outlines)
+		}
+	}
+	walk(doc.Outlines)
+	return entries, nil
+}
+
+// noJobIDWarning explains a successful add that returned no job id.
+const noJobIDWarning = "warning: SABnzbd accepted the NZB but returned no job id (possibly a duplicate)"
+
+// printAddResponse reports a successful add. SABnzbd can answer status:true
+// with an empty nzo_ids list, so that case warns instead of printing an
+// empty job list. A --title given for the job is echoed back.
+func printAddResponse(app *cobraext.App, resp *sabapi.AddResponse, verb, title string) error {
+	if !resp.Accepted() {
+		app.Printer.Error(noJobIDWarning)
+	}
+	if app.Printer.JSON {
+		if title == "" {
+			return app.Printer.Print(resp)
+		}
+		return app.Printer.Print(struct {
+			*sabapi.AddResponse
+			Title string `json:"title"`
+		}{resp, title})
+	}
+	if !resp.Accepted() {
+		return nil
+	}
+	if title != "" {
+		return app.Printer.Print(fmt.Sprintf("%s %s as %q", verb, strings.Join(resp.NZOIDs, ","), title))
+	}
+	return app.Printer.Print(fmt.Sprintf("%s %s", verb, strings.Join(resp.NZOIDs, ",")))
+}
+
+// bindAddFlags registers the options shared by the queue add commands.
+// --title and --name both set the job title, which SABnzbd receives as
+// nzbname and uses for the queue entry and the download folder.
+func bindAddFlags(flags *pflag.FlagSet, category, priority, pp, script, password, name *string) {
 	flags.StringVar(category, "cat", "", "Category to assign")
 	flags.StringVar(priority, "priority", "", "Priority (-1 low,0 normal,1 high,2 force)")
+	flags.StringVar(pp, "pp", "", "Post-processing level: 0/skip, 1/repair, 2/unpack or 3/delete")
 	flags.StringVar(script, "script", "", "Post-processing script")
 	flags.StringVar(password, "password", "", "Archive password")
-	flags.StringVar(name, "name", "", "Override queue title")
+	flags.StringVar(name, "title", "", "Job title shown in the queue and used as the download folder name (default: the NZB's own name)")
+	flags.StringVar(name, "name", "", "Alias for --title")
+}
+
+// addTitle validates the job title set by --title or --name. Both flags
+// write the same variable, so passing both is rejected instead of letting
+// the last one win. A title must be non-blank and free of path separators
+// because SABnzbd turns it into a folder name.
+func addTitle(flags *pflag.FlagSet, title string) (string, error) {
+	titleSet, nameSet := flags.Changed("title"), flags.Changed("name")
+	if titleSet && nameSet {
+		return "", errors.New("--title and --name are the same option; pass only one")
+	}
+	if !titleSet && !nameSet {
+		return "", nil
+	}
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", errors.New("--title must not be empty")
+	}
+	if strings.ContainsAny(title, `/\`) {
+		return "", fmt.Errorf("--title %q must not contain / or \\ (SABnzbd uses it as the folder name)", title)
+	}
+	return title, nil
+}
+
+func bindAutoCatFlag(flags *pflag.FlagSet, autoCat *bool) {
+	flags.BoolVar(autoCat, "auto-cat", false, "Pick the category whose indexer rules match the NZB name (falls back to --cat)")
+}
+
+func bindForceCatFlag(flags *pflag.FlagSet, force *bool) {
+	flags.BoolVar(force, "force", false, "Send --cat even if it is not a configured category")
+}
+
+// duplicateCheck guards queue adds against jobs that are already queued.
+type duplicateCheck struct {
+	enabled bool
+	match   string
+}
+
+// duplicateSkip describes an add that was skipped because of an existing slot.
+type duplicateSkip struct {
+	Name     string `json:"name"`
+	NZOID    string `json:"nzo_id"`
+	Filename string `json:"filename"`
+}
+
+func bindDuplicateFlags(flags *pflag.FlagSet, d *duplicateCheck) {
+	flags.BoolVar(&d.enabled, "skip-duplicates", false, "Skip the add when a queued job already has a matching name")
+	flags.StringVar(&d.match, "duplicate-match", "exact", "How --skip-duplicates compares names: exact, or substring (a queued name containing the new one); case-insensitive")
+}
+
+func (d duplicateCheck) check(ctx context.Context, client *sabapi.Client, name string) (*duplicateSkip, error) {
+	mode := strings.ToLower(strings.TrimSpace(d.match))
+	if mode != "exact" && mode != "substring" {
+		return nil, fmt.Errorf("invalid --duplicate-match %q (use exact or substring)", d.match)
+	}
+	queue, err := client.Queue(ctx, 0, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	slot, ok := findDuplicateSlot(queue.Slots, name, mode == "substring")
+	if !ok {
+		return nil, nil
+	}
+	return &duplicateSkip{Name: name, NZOID: slot.NZOID, Filename: slot.Filename}, nil
+}
+
+// findDuplicateSlot reports the first slot whose filename matches name,
+// ignoring case and a trailing .nzb extension. In substring mode a slot
+// matches when its filename contains name; a short queued name never
+// swallows a longer new one.
+func findDuplicateSlot(slots []sabapi.QueueSlot, name string, substring bool) (sabapi.QueueSlot, bool) {
+	needle := normalizeJobName(name)
+	if needle == "" {
+		return sabapi.QueueSlot{}, false
+	}
+	for _, slot := range slots {
+		existing := normalizeJobName(slot.Filename)
+		if existing == needle || (substring && strings.Contains(existing, needle)) {
+			return slot, true
+		}
+	}
+	return sabapi.QueueSlot{}, false
+}
+
+func normalizeJobName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.TrimSuffix(name, ".gz")
+	return strings.TrimSuffix(name, ".nzb")
+}
+
+func urlBaseName(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Path == "" {
+		return ""
+	}
+	base := path.Base(u.Path)
+	if base == "/" || base == "." {
+		return ""
+	}
+	if unescaped, err := url.PathUnescape(base); err == nil {
+		base = unescaped
+	}
+	return base
 }
 
-func buildAddOptions(priorityStr, category, script, password, name string) (sabapi.AddOptions, error) {
+func printDuplicateSkip(app *cobraext.App, skipped *duplicateSkip) error {
+	if app.Printer.JSON {
+		return app.Printer.Print(map[string]any{
+			"added":     false,
+			"skipped":   true,
+			"duplicate": skipped,
+		})
+	}
+	return app.Printer.Print(fmt.Sprintf("Skipped %s: already queued as %s (%s)", skipped.Name, skipped.NZOID, skipped.Filename))
+}
+
+func buildAddOptions(priorityStr, ppStr, category, script, password, name string) (sabapi.AddOptions, error) {
 	opts := sabapi.AddOptions{Category: category, Script: script, Password: password, Name: name}
 	if strings.TrimSpace(priorityStr) != "" {
 		p, err := strconv.Atoi(priorityStr)
@@ -270,9 +1090,40 @@ func buildAddOptions(priorityStr, category, script, password, name string) (saba
 		}
 		opts.Priority = &p
 	}
+	if strings.TrimSpace(ppStr) != "" {
+		pp, err := parsePPLevel(ppStr)
+		if err != nil {
+			return opts, fmt.Errorf("invalid --pp: %w", err)
+		}
+		opts.PPLevel = &pp
+	}
 	return opts, nil
 }
 
+// ppLevelNames maps the names accepted for SABnzbd's post-processing levels.
+// Each level includes the ones below it: unpack also repairs, and delete
+// also repairs and unpacks before removing the source files.
+var ppLevelNames = map[string]int{
+	"skip":   0,
+	"none":   0,
+	"repair": 1,
+	"unpack": 2,
+	"delete": 3,
+}
+
+// parsePPLevel accepts a post-processing level as 0-3 or by name.
+func parsePPLevel(raw string) (int, error) {
+	value := strings.ToLower(strings.TrimSpace(raw))
+	if level, ok := ppLevelNames[value]; ok {
+		return level, nil
+	}
+	level, err := strconv.Atoi(value)
+	if err != nil || level < 0 || level > 3 {
+		return 0, fmt.Errorf("%q is not a post-processing level (use 0-3 or skip, repair, unpack, delete)", raw)
+	}
+	return level, nil
+}
+
 func queuePauseCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "pause",
@@ -313,10 +1164,12 @@ func queuePurgeCmd() *cobra.Command {
 	var purgeAll bool
 	var search string
 	var deleteData bool
+	var dryRun bool
+	var yes bool
 	cmd := &cobra.Command{
 		Use:   "purge",
 		Short: jsonShort("Purge queue entries"),
-		Long:  appendJSONLong("Deletes queue items by filter or entirely. Use --delete-data to remove downloaded files."),
+		Long:  appendJSONLong("Deletes queue items by filter or entirely. Use --with-data to remove downloaded files. The affected items are summarized first and the purge must be confirmed interactively or with --yes; --dry-run only prints the impact."),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if !purgeAll && strings.TrimSpace(search) == "" {
 				return errors.New("provide --all to purge everything or --search to filter items")
@@ -325,8 +1178,38 @@ func queuePurgeCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			ctx, cancel := timeoutContext(cmd.Context())
-			defer cancel()
+			lookupCtx, cancelLookup := timeoutContext(cmd.Context())
+			queue, err := app.Client.Queue(lookupCtx, 0, 0, search)
+			cancelLookup()
+			if err != nil {
+				return err
+			}
+			impact := computePurgeImpact(queue.Slots)
+
+			if dryRun || impact.Count == 0 {
+				if app.Printer.JSON {
+					return app.Printer.Print(map[string]any{
+						"dry_run":  dryRun,
+						"count":    impact.Count,
+						"total_mb": impact.TotalMB,
+						"items":    queue.Slots,
+					})
+				}
+				if impact.Count == 0 {
+					return app.Printer.Print("No matching queue items")
+				}
+				return printPurgeImpact(app.Printer, impact, queue.Slots, search != "")
+			}
+
+			if !app.Printer.JSON {
+				if err := printPurgeImpact(app.Printer, impact, queue.Slots, search != ""); err != nil {
+					return err
+				}
+			}
+			if err := confirmAction(cmd, yes, fmt.Sprintf("Purge %d items (%.1f MB)?", impact.Count, impact.TotalMB)); err != nil {
+				return err
+			}
+
 			params := url.Values{}
 			// Note: when purgeAll is true, no additional params required;
 			// SAB interprets empty purge as full purge
@@ -336,15 +1219,54 @@ func queuePurgeCmd() *cobra.Command {
 			if deleteData {
 				params.Set("del_files", "1")
 			}
-			return app.Client.QueueAction(ctx, "purge", params)
+			// The prompt may have taken a while; time the purge from here.
+			ctx, cancel := timeoutContext(cmd.Context())
+			defer cancel()
+			if err := app.Client.QueueAction(ctx, "purge", params); err != nil {
+				return err
+			}
+
+			if app.Printer.JSON {
+				return app.Printer.Print(map[string]any{
+					"purged":   impact.Count,
+					"total_mb": impact.TotalMB,
+				})
+			}
+			return app.Printer.Print(fmt.Sprintf("Purged %d items", impact.Count))
 		},
 	}
 	cmd.Flags().BoolVar(&purgeAll, "all", false, "Purge every queue entry")
 	cmd.Flags().StringVar(&search, "search", "", "Purge items whose name matches this substring")
 	cmd.Flags().BoolVar(&deleteData, "with-data", false, "Also delete downloaded data")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be purged without deleting anything")
+	bindYesFlag(cmd.Flags(), &yes)
 	return cmd
 }
 
+// purgeImpact summarizes the queue items a purge would remove.
+type purgeImpact struct {
+	Count   int
+	TotalMB float64
+}
+
+func computePurgeImpact(slots []sabapi.QueueSlot) purgeImpact {
+	totalMB, _ := sabapi.SumQueueMB(slots)
+	return purgeImpact{Count: len(slots), TotalMB: totalMB}
+}
+
+func printPurgeImpact(printer *output.Printer, impact purgeImpact, slots []sabapi.QueueSlot, listItems bool) error {
+	if listItems {
+		rows := make([][]string, 0, len(slots))
+		for _, slot := range slots {
+			rows = append(rows, []string{slot.NZOID, slot.Filename, slot.MB})
+		}
+		if err := printer.Table([]string{"ID", "Name", "MB"}, rows); err != nil {
+			return err
+		}
+	}
+	return printer.Print(fmt.Sprintf("%d items (%.1f MB) would be removed", impact.Count, impact.TotalMB))
+}
+
 func queueCompleteActionCmd() *cobra.Command {
 	actions := map[string]string{
 		"none":             "",
@@ -398,7 +1320,7 @@ func queueItemCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "item",
 		Short: jsonShort("Operate on individual queue items"),
-		Long:  appendJSONLong("Inspect or modify specific SABnzbd queue entries."),
+		Long:  appendJSONLong("Inspect or modify specific SABnzbd queue entries. " + refLongNote),
 	}
 
 	cmd.AddCommand(queueItemShowCmd())
@@ -415,10 +1337,12 @@ func queueItemCmd() *cobra.Command {
 }
 
 func queueItemShowCmd() *cobra.Command {
+	var withFiles bool
+	var field string
 	cmd := &cobra.Command{
-		Use:   "show <nzo-id>",
+		Use:   "show <ref>",
 		Short: jsonShort("Show detailed information for an item"),
-		Long:  appendJSONLong("Displays full queue slot metadata, including stage logs."),
+		Long:  appendJSONLong("Displays full queue slot metadata, including stage logs. Use --files to include the NZF file list and --field to print a single dotted JSON field (e.g. --field status). " + refLongNote),
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			id := args[0]
@@ -434,8 +1358,23 @@ func queueItemShowCmd() *cobra.Command {
 				return err
 			}
 
+			var files []sabapi.QueueFile
+			if withFiles {
+				files, err = app.Client.GetFiles(ctx, slot.NZOID)
+				if err != nil {
+					return err
+				}
+			}
+
+			var payload any = slot
+			if withFiles {
+				payload = queueItemShowPayload{QueueSlot: slot, Files: files}
+			}
+			if field != "" {
+				return app.Printer.PrintField(payload, field)
+			}
 			if app.Printer.JSON {
-				return app.Printer.Print(slot)
+				return app.Printer.Print(payload)
 			}
 
 			var b strings.Builder
@@ -443,20 +1382,57 @@ func queueItemShowCmd() *cobra.Command {
 			if len(slot.StageLog) > 0 {
 				b.WriteString("\nStages:")
 				for _, entry := range slot.StageLog {
-					fmt.Fprintf(&b, "\n- %s: %s", entry.Stage, entry.Log)
+					fmt.Fprintf(&b, "\n- %s:", entry.Stage)
+					for _, line := range entry.Lines() {
+						fmt.Fprintf(&b, "\n    %s", line)
+					}
 				}
 			}
-			return app.Printer.Print(b.String())
+			if err := app.Printer.Print(b.String()); err != nil {
+				return err
+			}
+			if !withFiles {
+				return nil
+			}
+			if len(files) == 0 {
+				return app.Printer.Print("No files")
+			}
+			return app.Printer.Table(queueFileHeaders, queueFileRows(files))
 		},
 	}
+	cmd.Flags().BoolVar(&withFiles, "files", false, "Also list the item's NZF files")
+	cmd.Flags().StringVar(&field, "field", "", "Print only this dotted JSON field (e.g. stage_log.0.stage)")
 	return cmd
 }
 
+// queueItemShowPayload extends a slot with its file list for JSON output.
+type queueItemShowPayload struct {
+	*sabapi.QueueSlot
+	Files []sabapi.QueueFile `json:"files"`
+}
+
+var queueFileHeaders = []string{"NZF ID", "Filename", "Status", "MB", "MB Left", "Age"}
+
+func queueFileRows(files []sabapi.QueueFile) [][]string {
+	rows := make([][]string, 0, len(files))
+	for _, file := range files {
+		rows = append(rows, []string{
+			file.NZFID,
+			file.Filename,
+			file.Status,
+			file.MB,
+			file.MBLeft,
+			file.Age,
+		})
+	}
+	return rows
+}
+
 func queueItemPauseCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "pause <nzo-id>",
+		Use:   "pause <ref>",
 		Short: jsonShort("Pause an item"),
-		Long:  appendJSONLong("Pauses a specific queue item in SABnzbd."),
+		Long:  appendJSONLong("Pauses a specific queue item in SABnzbd. " + refLongNote),
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			app, err := getApp(cmd)
@@ -465,7 +1441,11 @@ func queueItemPauseCmd() *cobra.Command {
 			}
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
-			return app.Client.QueuePause(ctx, args[0])
+			id, err := resolveQueueRef(ctx, app.Client, args[0])
+			if err != nil {
+				return err
+			}
+			return app.Client.QueuePause(ctx, id)
 		},
 	}
 	return cmd
@@ -473,9 +1453,9 @@ func queueItemPauseCmd() *cobra.Command {
 
 func queueItemResumeCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "resume <nzo-id>",
+		Use:   "resume <ref>",
 		Short: jsonShort("Resume an item"),
-		Long:  appendJSONLong("Resumes a paused queue item."),
+		Long:  appendJSONLong("Resumes a paused queue item. " + refLongNote),
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			app, err := getApp(cmd)
@@ -484,7 +1464,11 @@ func queueItemResumeCmd() *cobra.Command {
 			}
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
-			return app.Client.QueueResume(ctx, args[0])
+			id, err := resolveQueueRef(ctx, app.Client, args[0])
+			if err != nil {
+				return err
+			}
+			return app.Client.QueueResume(ctx, id)
 		},
 	}
 	return cmd
@@ -492,10 +1476,11 @@ func queueItemResumeCmd() *cobra.Command {
 
 func queueItemDeleteCmd() *cobra.Command {
 	var deleteData bool
+	var ignoreMissing bool
 	cmd := &cobra.Command{
-		Use:   "delete <nzo-id>",
+		Use:   "delete <ref>",
 		Short: jsonShort("Delete an item"),
-		Long:  appendJSONLong("Deletes a queue item. Use --with-data to also remove downloaded files when supported."),
+		Long:  appendJSONLong("Deletes a queue item. Use --with-data to also remove downloaded files when supported. With --ignore-missing an item that is already gone is reported as removed and the command exits zero. " + refLongNote),
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			app, err := getApp(cmd)
@@ -504,21 +1489,36 @@ func queueItemDeleteCmd() *cobra.Command {
 			}
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
-			return app.Client.QueueDelete(ctx, []string{args[0]}, deleteData)
+			id, err := resolveQueueRef(ctx, app.Client, args[0])
+			if err == nil {
+				err = app.Client.QueueDelete(ctx, []string{id}, deleteData)
+			}
+			switch {
+			case err == nil:
+				return nil
+			case !errors.Is(err, sabapi.ErrItemNotFound) && !errors.Is(err, ref.ErrNotFound):
+				return err
+			case !ignoreMissing:
+				return fmt.Errorf("%w (pass --ignore-missing if it was already removed)", err)
+			}
+			if app.Printer.JSON {
+				return app.Printer.Print(map[string]any{"ref": args[0], "deleted": false, "missing": true})
+			}
+			return app.Printer.Print(fmt.Sprintf("Item %s already removed", args[0]))
 		},
 	}
 	cmd.Flags().BoolVar(&deleteData, "with-data", false, "Also delete already downloaded data")
+	cmd.Flags().BoolVar(&ignoreMissing, "ignore-missing", false, "Exit zero when the item no longer exists")
 	return cmd
 }
 
 func queueItemPriorityCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "priority <nzo-id> <value>",
+		Use:   "priority <ref> <value>",
 		Short: jsonShort("Change item priority"),
-		Long:  appendJSONLong("Sets the SABnzbd priority for an item (-1..2)."),
+		Long:  appendJSONLong("Sets the SABnzbd priority for an item (-1..2). " + refLongNote),
 		Args:  cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			id := args[0]
 			priority, err := strconv.Atoi(args[1])
 			if err != nil {
 				return err
@@ -532,6 +1532,10 @@ func queueItemPriorityCmd() *cobra.Command {
 			}
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
+			id, err := resolveQueueRef(ctx, app.Client, args[0])
+			if err != nil {
+				return err
+			}
 			return app.Client.QueueSetPriority(ctx, id, priority)
 		},
 	}
@@ -539,13 +1543,14 @@ func queueItemPriorityCmd() *cobra.Command {
 }
 
 func queueItemMoveCmd() *cobra.Command {
+	var recordUndo bool
 	cmd := &cobra.Command{
-		Use:   "move <nzo-id> <top|up|down|bottom|to> [position]",
+		Use:   "move <ref> <top|up|down|bottom|to> [position]",
 		Short: jsonShort("Reorder queue items"),
-		Long:  appendJSONLong("Moves a queue item relative to others or to an absolute position."),
+		Long:  appendJSONLong("Moves a queue item relative to others or to an absolute position. With --record-undo the previous order is saved for `queue undo`. " + refLongNote),
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) < 2 {
-				return errors.New("requires nzo-id and action")
+				return errors.New("requires item reference and action")
 			}
 			if args[1] == "to" && len(args) < 3 {
 				return errors.New("action 'to' requires a position")
@@ -553,7 +1558,6 @@ func queueItemMoveCmd() *cobra.Command {
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			id := args[0]
 			action := args[1]
 
 			app, err := getApp(cmd)
@@ -564,26 +1568,45 @@ func queueItemMoveCmd() *cobra.Command {
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 
+			id, err := resolveQueueRef(ctx, app.Client, args[0])
+			if err != nil {
+				return err
+			}
+			pos := 0
 			switch action {
 			case "top", "bottom", "up", "down":
-				params := url.Values{}
-				params.Set("value", action)
-				params.Set("value2", id)
-				return app.Client.QueueAction(ctx, "move", params)
 			case "to":
-				pos, err := strconv.Atoi(args[2])
-				if err != nil {
+				if pos, err = strconv.Atoi(args[2]); err != nil {
 					return err
 				}
 				if pos < 0 {
 					return errors.New("position must be zero or positive")
 				}
-				return app.Client.QueueSwitchPosition(ctx, id, pos)
 			default:
 				return fmt.Errorf("unknown move action %s", action)
 			}
+
+			var undo queueUndoRecord
+			if recordUndo {
+				if undo, err = snapshotQueueUndo(ctx, app); err != nil {
+					return err
+				}
+			}
+			if action == "to" {
+				err = app.Client.QueueSwitchPosition(ctx, id, pos)
+			} else {
+				params := url.Values{}
+				params.Set("value", action)
+				params.Set("value2", id)
+				err = app.Client.QueueAction(ctx, "move", params)
+			}
+			if err != nil || !recordUndo {
+				return err
+			}
+			return saveQueueUndo(app, undo)
 		},
 	}
+	cmd.Flags().BoolVar(&recordUndo, "record-undo", false, "Save the current queue order so queue undo can restore it")
 	return cmd
 }
 
@@ -592,14 +1615,14 @@ func queueItemSetCmd() *cobra.Command {
 	var script string
 	var password string
 	var name string
+	var force bool
 
 	cmd := &cobra.Command{
-		Use:   "set <nzo-id>",
+		Use:   "set <ref>",
 		Short: jsonShort("Update item metadata"),
-		Long:  appendJSONLong("Adjust queue item category, script, display name, or password."),
+		Long:  appendJSONLong("Adjust queue item category, script, display name, or password. --cat must name a configured category unless --force is given. " + refLongNote),
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			id := args[0]
 			if category == "" && script == "" && name == "" && password == "" {
 				return errors.New("provide at least one field to update")
 			}
@@ -610,6 +1633,14 @@ func queueItemSetCmd() *cobra.Command {
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 
+			id, err := resolveQueueRef(ctx, app.Client, args[0])
+			if err != nil {
+				return err
+			}
+			if err := checkCategory(ctx, app, category, force); err != nil {
+				return err
+			}
+
 			if category != "" {
 				if err := app.Client.QueueSetCategory(ctx, id, category); err != nil {
 					return err
@@ -659,6 +1690,7 @@ func queueItemSetCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVar(&category, "cat", "", "Category name")
+	cmd.Flags().BoolVar(&force, "force", false, "Set --cat even if it is not a configured category")
 	cmd.Flags().StringVar(&script, "script", "", "Post-processing script")
 	cmd.Flags().StringVar(&password, "password", "", "Archive password")
 	cmd.Flags().StringVar(&name, "name", "", "Rename the item")
@@ -668,22 +1700,21 @@ func queueItemSetCmd() *cobra.Command {
 
 func queueItemOptsCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "opts <pp-level> <nzo-id> [nzo-id...]",
+		Use:   "opts <pp-level> <ref> [ref...]",
 		Short: jsonShort("Update the post-processing level for specific items"),
-		Long:  appendJSONLong("Sets the post-processing level for one or more queue items."),
+		Long: appendJSONLong("Sets the post-processing level for one or more queue items. " +
+			"The level is 0-3 or a name: skip (0), repair (1), unpack (2, repair and unpack) or delete (3, repair, unpack and delete the source files). " + refLongNote),
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) < 2 {
-				return errors.New("provide pp-level and at least one nzo-id")
+				return errors.New("provide pp-level and at least one item reference")
 			}
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			pp, err := strconv.Atoi(args[0])
+			pp, err := parsePPLevel(args[0])
 			if err != nil {
 				return fmt.Errorf("invalid pp-level: %w", err)
 			}
-			ids := args[1:]
-
 			app, err := getApp(cmd)
 			if err != nil {
 				return err
@@ -691,6 +1722,11 @@ func queueItemOptsCmd() *cobra.Command {
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 
+			ids, err := resolveQueueRefs(ctx, app.Client, args[1:])
+			if err != nil {
+				return err
+			}
+
 			if err := app.Client.QueueChangeOptions(ctx, ids, pp); err != nil {
 				return err
 			}
@@ -706,12 +1742,11 @@ func queueItemOptsCmd() *cobra.Command {
 
 func queueItemFilesCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "files <nzo-id>",
+		Use:   "files <ref>",
 		Short: jsonShort("List files for an item"),
-		Long:  appendJSONLong("Lists NZF files belonging to a queue item."),
+		Long:  appendJSONLong("Lists NZF files belonging to a queue item. " + refLongNote),
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			id := args[0]
 			app, err := getApp(cmd)
 			if err != nil {
 				return err
@@ -720,6 +1755,11 @@ func queueItemFilesCmd() *cobra.Command {
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 
+			id, err := resolveQueueRef(ctx, app.Client, args[0])
+			if err != nil {
+				return err
+			}
+
 			files, err := app.Client.GetFiles(ctx, id)
 			if err != nil {
 				return err
@@ -737,19 +1777,7 @@ func queueItemFilesCmd() *cobra.Command {
 				return app.Printer.Print(fmt.Sprintf("No files for %s", id))
 			}
 
-			headers := []string{"NZF ID", "Filename", "Status", "MB", "MB Left", "Age"}
-			rows := make([][]string, 0, len(files))
-			for _, file := range files {
-				rows = append(rows, []string{
-					file.NZFID,
-					file.Filename,
-					file.Status,
-					file.MB,
-					file.MBLeft,
-					file.Age,
-				})
-			}
-			if err := app.Printer.Table(headers, rows); err != nil {
+			if err := app.Printer.Table(queueFileHeaders, queueFileRows(files)); err != nil {
 				return err
 			}
 			return app.Printer.Print(fmt.Sprintf("%d files", len(files)))
@@ -762,12 +1790,11 @@ func queueItemFilesCmd() *cobra.Command {
 
 func queueItemFilesDeleteCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "delete <nzo-id> <nzf-id>",
+		Use:   "delete <ref> <nzf-id>",
 		Short: jsonShort("Delete a specific file from an item"),
-		Long:  appendJSONLong("Deletes a single NZF file from a queue item."),
+		Long:  appendJSONLong("Deletes a single NZF file from a queue item. " + refLongNote),
 		Args:  cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			nzoID := args[0]
 			nzfID := args[1]
 
 			app, err := getApp(cmd)
@@ -778,6 +1805,11 @@ func queueItemFilesDeleteCmd() *cobra.Command {
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 
+			nzoID, err := resolveQueueRef(ctx, app.Client, args[0])
+			if err != nil {
+				return err
+			}
+
 			if err := app.Client.QueueDeleteFile(ctx, nzoID, nzfID); err != nil {
 				return err
 			}
@@ -797,19 +1829,31 @@ func queueItemFilesDeleteCmd() *cobra.Command {
 
 func queueItemFilesMoveCmd() *cobra.Command {
 	var action string
+	var to string
 	var ids []string
 	var size int
 
 	cmd := &cobra.Command{
-		Use:   "move <nzo-id>",
+		Use:   "move <ref>",
 		Short: jsonShort("Move files within an item's NZF list"),
-		Long:  appendJSONLong("Bulk reorder NZF files within a queue item."),
+		Long:  appendJSONLong("Bulk reorder NZF files within a queue item. Use --to top|bottom as a shorthand for moving files to either end, or --action up|down with --size to shift them. " + refLongNote),
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			nzoID := args[0]
 			actionKey := strings.ToLower(strings.TrimSpace(action))
+			if toKey := strings.ToLower(strings.TrimSpace(to)); toKey != "" {
+				if actionKey != "" {
+					return errors.New("--to cannot be combined with --action")
+				}
+				if toKey != "top" && toKey != "bottom" {
+					return fmt.Errorf("unsupported --to %q (expected top or bottom)", to)
+				}
+				if cmd.Flags().Changed("size") {
+					return errors.New("--size only applies to --action up|down")
+				}
+				actionKey = toKey
+			}
 			if actionKey == "" {
-				return errors.New("provide --action top|bottom|up|down")
+				return errors.New("provide --to top|bottom or --action top|bottom|up|down")
 			}
 			if len(ids) == 0 {
 				return errors.New("provide at least one NZF id via --id")
@@ -837,6 +1881,11 @@ func queueItemFilesMoveCmd() *cobra.Command {
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 
+			nzoID, err := resolveQueueRef(ctx, app.Client, args[0])
+			if err != nil {
+				return err
+			}
+
 			if err := app.Client.QueueMoveFiles(ctx, actionValue, nzoID, ids, sizePtr); err != nil {
 				return err
 			}
@@ -854,28 +1903,39 @@ func queueItemFilesMoveCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVar(&action, "action", "", "Move direction (top, bottom, up, down)")
+	cmd.Flags().StringVar(&to, "to", "", "Move files to the top or bottom (shorthand for --action top|bottom)")
 	cmd.Flags().StringSliceVar(&ids, "id", nil, "NZF ids to move (repeat for multiple)")
 	cmd.Flags().IntVar(&size, "size", 0, "Number of positions to move when using up/down")
 	return cmd
 }
 
+// queueSortKeys maps CLI sort criteria to SABnzbd's sort strings.
+var queueSortKeys = map[string]string{
+	"name":     "name",
+	"age":      "avg_age",
+	"size":     "size",
+	"eta":      "eta",
+	"priority": "priority",
+}
+
 func queueSortCmd() *cobra.Command {
 	var desc bool
+	var recordUndo bool
 	cmd := &cobra.Command{
-		Use:   "sort <name|age|size|eta>",
+		Use:   "sort <name|age|size|eta|priority>",
 		Short: jsonShort("Sort the queue"),
-		Long:  appendJSONLong("Sorts SABnzbd's queue by the requested column."),
+		Long:  appendJSONLong("Sorts SABnzbd's queue by the requested column. Every criterion sorts ascending unless --desc is given. With --record-undo the previous order is saved for `queue undo`."),
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			criteria := args[0]
-			sortKey, ok := map[string]string{
-				"name": "name",
-				"age":  "avg_age",
-				"size": "size",
-				"eta":  "eta",
-			}[criteria]
+			criteria := strings.ToLower(strings.TrimSpace(args[0]))
+			sortKey, ok := queueSortKeys[criteria]
 			if !ok {
-				return errors.New("unsupported sort criteria")
+				supported := make([]string, 0, len(queueSortKeys))
+				for key := range queueSortKeys {
+					supported = append(supported, key)
+				}
+				sort.Strings(supported)
+				return fmt.Errorf("unsupported sort criteria %q (supported: %s)", args[0], strings.Join(supported, ", "))
 			}
 			dir := "asc"
 			if desc {
@@ -887,22 +1947,80 @@ func queueSortCmd() *cobra.Command {
 			}
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
-			return app.Client.QueueSort(ctx, sortKey, dir)
+			var undo queueUndoRecord
+			if recordUndo {
+				if undo, err = snapshotQueueUndo(ctx, app); err != nil {
+					return err
+				}
+			}
+			if err := app.Client.QueueSort(ctx, sortKey, dir); err != nil {
+				return err
+			}
+			if recordUndo {
+				if err := saveQueueUndo(app, undo); err != nil {
+					return err
+				}
+			}
+			if app.Printer.JSON {
+				return app.Printer.Print(map[string]any{"sort": sortKey, "dir": dir})
+			}
+			return app.Printer.Print(fmt.Sprintf("Sorted queue by %s (%s)", criteria, dir))
 		},
 	}
 	cmd.Flags().BoolVar(&desc, "desc", false, "Sort descending")
+	cmd.Flags().BoolVar(&recordUndo, "record-undo", false, "Save the current queue order so queue undo can restore it")
 	return cmd
 }
 
-func findQueueSlot(ctx context.Context, client *sabapi.Client, id string) (*sabapi.QueueSlot, error) {
+func findQueueSlot(ctx context.Context, client *sabapi.Client, token string) (*sabapi.QueueSlot, error) {
+	if !ref.NeedsLookup(token) {
+		slot, err := client.QueueSlotByID(ctx, token)
+		if errors.Is(err, sabapi.ErrSlotNotFound) {
+			return nil, fmt.Errorf("item %s not found", token)
+		}
+		return slot, err
+	}
 	queue, err := client.Queue(ctx, 0, 0, "")
 	if err != nil {
 		return nil, err
 	}
-	for _, slot := range queue.Slots {
-		if slot.NZOID == id {
-			return &slot, nil
+	match, err := ref.Resolve(token, queueCandidates(queue.Slots))
+	if err != nil {
+		if errors.Is(err, ref.ErrNotFound) {
+			return nil, fmt.Errorf("item %s not found", token)
+		}
+		return nil, err
+	}
+	for i := range queue.Slots {
+		if queue.Slots[i].NZOID == match.ID {
+			return &queue.Slots[i], nil
+		}
+	}
+	return nil, fmt.Errorf("item %s not found", token)
+}
+
+func resolveQueueRef(ctx context.Context, client *sabapi.Client, token string) (string, error) {
+	ids, err := resolveQueueRefs(ctx, client, []string{token})
+	if err != nil {
+		return "", err
+	}
+	return ids[0], nil
+}
+
+func resolveQueueRefs(ctx context.Context, client *sabapi.Client, tokens []string) ([]string, error) {
+	return resolveRefs(tokens, func() ([]ref.Candidate, error) {
+		queue, err := client.Queue(ctx, 0, 0, "")
+		if err != nil {
+			return nil, err
 		}
+		return queueCandidates(queue.Slots), nil
+	})
+}
+
+func queueCandidates(slots []sabapi.QueueSlot) []ref.Candidate {
+	candidates := make([]ref.Candidate, 0, len(slots))
+	for _, slot := range slots {
+		candidates = append(candidates, ref.Candidate{ID: slot.NZOID, Name: slot.Filename})
 	}
-	return nil, fmt.Errorf("item %s not found", id)
+	return candidates
 }
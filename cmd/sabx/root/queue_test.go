@@ -0,0 +1,1000 @@
+package root
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/prompt"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+func TestFindDuplicateSlot(t *testing.T) {
+	t.Parallel()
+
+	slots := []sabapi.QueueSlot{
+		{NZOID: "SABnzbd_nzo_1", Filename: "Ubuntu.24.04.Desktop"},
+		{NZOID: "SABnzbd_nzo_2", Filename: "Debian.12.Netinst"},
+	}
+
+	tests := []struct {
+		name      string
+		candidate string
+		substring bool
+		wantID    string
+		wantFound bool
+	}{
+		{name: "exact match ignores case and extension", candidate: "ubuntu.24.04.desktop.nzb", wantID: "SABnzbd_nzo_1", wantFound: true},
+		{name: "exact mode rejects partial name", candidate: "Debian.12", wantFound: false},
+		{name: "substring mode accepts partial name", candidate: "debian.12", substring: true, wantID: "SABnzbd_nzo_2", wantFound: true},
+		{name: "substring mode ignores a shorter queued name", candidate: "Debian.12.Netinst.Extra", substring: true, wantFound: false},
+		{name: "no match adds", candidate: "Fedora.40.nzb", substring: true, wantFound: false},
+		{name: "empty candidate never matches", candidate: "", substring: true, wantFound: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			slot, found := findDuplicateSlot(slots, tt.candidate, tt.substring)
+			if found != tt.wantFound {
+				t.Fatalf("found = %v, want %v", found, tt.wantFound)
+			}
+			if found && slot.NZOID != tt.wantID {
+				t.Fatalf("matched %q, want %q", slot.NZOID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestURLBaseName(t *testing.T) {
+	t.Parallel()
+
+	if got := urlBaseName("https://indexer.example/get/Some%20Show.nzb?apikey=x"); got != "Some Show.nzb" {
+		t.Fatalf("urlBaseName = %q", got)
+	}
+	if got := urlBaseName("https://indexer.example/"); got != "" {
+		t.Fatalf("expected empty base name, got %q", got)
+	}
+}
+
+func TestQueueItemShowWithFilesJSON(t *testing.T) {
+	t.Parallel()
+
+	var modes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		modes = append(modes, q.Get("mode"))
+		w.Header().Set("Content-Type", "application/json")
+		switch q.Get("mode") {
+		case "queue":
+			if q.Get("nzo_ids") != "SABnzbd_nzo_1" {
+				t.Errorf("expected nzo_ids filter, got %q", q.Get("nzo_ids"))
+			}
+			_, _ = w.Write([]byte(`{"queue":{"slots":[{"nzo_id":"SABnzbd_nzo_1","filename":"Ubuntu.24.04","status":"Downloading"}]}}`))
+		case "get_files":
+			_, _ = w.Write([]byte(`{"files":[{"nzf_id":"SABnzbd_nzf_1","filename":"ubuntu.part01.rar","mb":"100"}]}`))
+		default:
+			t.Errorf("unexpected mode %q", q.Get("mode"))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	var out bytes.Buffer
+	app := &cobraext.App{Client: client, Printer: &output.Printer{JSON: true, Out: &out}}
+	cmd := queueItemShowCmd()
+	cmd.SetContext(cobraext.WithApp(context.Background(), app))
+	cmd.SetArgs([]string{"SABnzbd_nzo_1", "--files"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+
+	var payload struct {
+		NZOID    string `json:"nzo_id"`
+		Filename string `json:"filename"`
+		Files    []struct {
+			NZFID    string `json:"nzf_id"`
+			Filename string `json:"filename"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("decode output: %v\n%s", err, out.String())
+	}
+	if payload.NZOID != "SABnzbd_nzo_1" || payload.Filename != "Ubuntu.24.04" {
+		t.Fatalf("slot metadata missing from payload: %+v", payload)
+	}
+	if len(payload.Files) != 1 || payload.Files[0].NZFID != "SABnzbd_nzf_1" {
+		t.Fatalf("files missing from payload: %+v", payload.Files)
+	}
+	if len(modes) != 2 {
+		t.Fatalf("expected exactly two API calls, got %v", modes)
+	}
+}
+
+func TestNormalizeAddURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		raw       string
+		clean     bool
+		wantValue string
+		wantLocal bool
+	}{
+		{name: "https passes through", raw: "https://indexer.example/get/1.nzb?apikey=k", wantValue: "https://indexer.example/get/1.nzb?apikey=k"},
+		{name: "nzb shorthand", raw: "nzb://indexer.example/get/1.nzb", wantValue: "http://indexer.example/get/1.nzb"},
+		{name: "nzbs shorthand", raw: "NZBS://indexer.example/get/1.nzb", wantValue: "https://indexer.example/get/1.nzb"},
+		{name: "unknown scheme passes through", raw: "magnet:?xt=urn:btih:abc", wantValue: "magnet:?xt=urn:btih:abc"},
+		{name: "file url is local", raw: "file:///tmp/show.nzb", wantValue: filepath.FromSlash("/tmp/show.nzb"), wantLocal: true},
+		{name: "file url with localhost", raw: "file://localhost/tmp/show.nzb", wantValue: filepath.FromSlash("/tmp/show.nzb"), wantLocal: true},
+		{name: "clean strips tracking only", raw: "https://indexer.example/get?apikey=k&utm_source=x&fbclid=y", clean: true, wantValue: "https://indexer.example/get?apikey=k"},
+		{name: "no clean keeps tracking", raw: "https://indexer.example/get?utm_source=x", wantValue: "https://indexer.example/get?utm_source=x"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := normalizeAddURL(tt.raw, tt.clean)
+			if err != nil {
+				t.Fatalf("normalizeAddURL returned error: %v", err)
+			}
+			if got.value != tt.wantValue || got.local != tt.wantLocal {
+				t.Fatalf("normalizeAddURL = %+v, want value=%q local=%v", got, tt.wantValue, tt.wantLocal)
+			}
+		})
+	}
+}
+
+func TestQueueAddURLDelegatesFileURLToUpload(t *testing.T) {
+	t.Parallel()
+
+	nzbPath := filepath.Join(t.TempDir(), "show.nzb")
+	if err := os.WriteFile(nzbPath, []byte("<nzb></nzb>"), 0o600); err != nil {
+		t.Fatalf("write nzb: %v", err)
+	}
+
+	var mode, method string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		mode = r.URL.Query().Get("mode")
+		if mode == "" {
+			_ = r.ParseMultipartForm(1 << 20)
+			mode = r.FormValue("mode")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":true,"nzo_ids":["SABnzbd_nzo_1"]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	var out bytes.Buffer
+	app := &cobraext.App{Client: client, Printer: &output.Printer{Out: &out}}
+	cmd := queueAddURLCmd()
+	cmd.SetContext(cobraext.WithApp(context.Background(), app))
+	cmd.SetArgs([]string{(&url.URL{Scheme: "file", Path: filepath.ToSlash(nzbPath)}).String()})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	if mode != "addfile" || method != http.MethodPost {
+		t.Fatalf("expected addfile upload, got mode=%q method=%s", mode, method)
+	}
+}
+
+func newPurgeTestApp(t *testing.T) (*cobraext.App, *bytes.Buffer, *[]url.Values) {
+	t.Helper()
+
+	var calls []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		calls = append(calls, q)
+		w.Header().Set("Content-Type", "application/json")
+		if q.Get("mode") == "queue" && q.Get("name") == "" {
+			_, _ = w.Write([]byte(`{"queue":{"slots":[{"nzo_id":"SABnzbd_nzo_1","filename":"Show.S01E01","mb":"1024.5"},{"nzo_id":"SABnzbd_nzo_2","filename":"Show.S01E02","mb":"512"}]}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":true}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	var out bytes.Buffer
+	return &cobraext.App{Client: client, Printer: &output.Printer{Out: &out}}, &out, &calls
+}
+
+func TestComputePurgeImpact(t *testing.T) {
+	t.Parallel()
+
+	impact := computePurgeImpact([]sabapi.QueueSlot{{MB: "1024.5"}, {MB: "512"}, {MB: ""}})
+	if impact.Count != 3 || impact.TotalMB != 1536.5 {
+		t.Fatalf("unexpected impact %+v", impact)
+	}
+}
+
+func TestQueuePurgeDryRunDoesNotPurge(t *testing.T) {
+	t.Parallel()
+
+	app, out, calls := newPurgeTestApp(t)
+	cmd := queuePurgeCmd()
+	cmd.SetContext(cobraext.WithApp(context.Background(), app))
+	cmd.SetArgs([]string{"--search", "Show", "--dry-run"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+
+	for _, q := range *calls {
+		if q.Get("name") == "purge" {
+			t.Fatal("dry run issued a purge request")
+		}
+	}
+	if !strings.Contains(out.String(), "2 items (1536.5 MB) would be removed") {
+		t.Fatalf("unexpected impact output %q", out.String())
+	}
+	if !strings.Contains(out.String(), "SABnzbd_nzo_2") {
+		t.Fatalf("expected matching items listed, got %q", out.String())
+	}
+}
+
+func TestQueuePurgeRequiresConfirmation(t *testing.T) {
+	t.Parallel()
+
+	app, _, calls := newPurgeTestApp(t)
+	cmd := queuePurgeCmd()
+	cmd.SetContext(cobraext.WithApp(context.Background(), app))
+	cmd.SetIn(strings.NewReader("y\n"))
+	cmd.SetArgs([]string{"--all"})
+	if err := cmd.Execute(); !errors.Is(err, prompt.ErrNotInteractive) {
+		t.Fatalf("expected ErrNotInteractive without --yes, got %v", err)
+	}
+	for _, q := range *calls {
+		if q.Get("name") == "purge" {
+			t.Fatal("purge issued without confirmation")
+		}
+	}
+
+	cmd = queuePurgeCmd()
+	cmd.SetContext(cobraext.WithApp(context.Background(), app))
+	cmd.SetArgs([]string{"--all", "--yes"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	last := (*calls)[len(*calls)-1]
+	if last.Get("mode") != "queue" || last.Get("name") != "purge" {
+		t.Fatalf("expected purge request, got %v", last)
+	}
+}
+
+func TestParseBatchFileSkipsCommentsAndBlanks(t *testing.T) {
+	t.Parallel()
+
+	input := "# nightly grabs\n\nhttps://indexer.example/get/1.nzb\n   \n  # indented comment\nhttps://indexer.example/get/2.nzb  \n"
+	entries, err := parseBatchFile(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseBatchFile returned error: %v", err)
+	}
+	want := []batchEntry{
+		{Line: 3, URL: "https://indexer.example/get/1.nzb"},
+		{Line: 6, URL: "https://indexer.example/get/2.nzb"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got %+v", len(want), entries)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Fatalf("entry %d: expected %+v, got %+v", i, want[i], entries[i])
+		}
+	}
+}
+
+func TestParseBatchFileOPML(t *testing.T) {
+	t.Parallel()
+
+	input := `<?xml version="1.0"?>
+<opml version="2.0">
+  <head><title>Feeds</title></head>
+  <body>
+    <outline text="TV">
+      <outline text="Show" xmlUrl="https://indexer.example/get/1.nzb"/>
+      <outline text="Other" url="https://indexer.example/get/2.nzb"/>
+    </outline>
+  </body>
+</opml>`
+	entries, err := parseBatchFile(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseBatchFile returned error: %v", err)
+	}
+	if len(entries) != 2 || entries[0].URL != "https://indexer.example/get/1.nzb" || entries[1].URL != "https://indexer.example/get/2.nzb" {
+		t.Fatalf("unexpected OPML entries %+v", entries)
+	}
+}
+
+// newLifecycleClient simulates a job that stays queued for queuedPolls
+// queue lookups, is briefly absent from both lists, then lands in history
+// with finalStatus.
+func newLifecycleClient(t *testing.T, queuedPolls int32, finalStatus string) *sabapi.Client {
+	t.Helper()
+
+	var queueCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		switch q.Get("mode") {
+		case "queue":
+			if queueCalls.Add(1) <= queuedPolls {
+				_, _ = w.Write([]byte(`{"queue":{"slots":[{"nzo_id":"SABnzbd_nzo_1","filename":"Show.S01E01","status":"Downloading"}]}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"queue":{"slots":[]}}`))
+		case "history":
+			if queueCalls.Load() <= queuedPolls+1 {
+				_, _ = w.Write([]byte(`{"history":{"slots":[]}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"history":{"slots":[{"nzo_id":"SABnzbd_nzo_1","name":"Show.S01E01","status":"` + finalStatus + `"}]}}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	return client
+}
+
+func TestWaitForJobsFollowsQueueToHistory(t *testing.T) {
+	t.Parallel()
+
+	client := newLifecycleClient(t, 2, "Completed")
+	jobs, err := waitForJobs(context.Background(), client, []string{"SABnzbd_nzo_1"}, time.Millisecond)
+	if err != nil {
+		t.Fatalf("waitForJobs returned error: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].Status != "Completed" || jobs[0].Failed || jobs[0].Name != "Show.S01E01" {
+		t.Fatalf("unexpected outcome %+v", jobs)
+	}
+
+	var out bytes.Buffer
+	if err := printJobOutcomes(&output.Printer{Out: &out}, &sabapi.AddResponse{NZOIDs: []string{"SABnzbd_nzo_1"}}, jobs); err != nil {
+		t.Fatalf("printJobOutcomes returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "Completed") {
+		t.Fatalf("expected final status printed, got %q", out.String())
+	}
+}
+
+func TestWaitForJobsReportsFailure(t *testing.T) {
+	t.Parallel()
+
+	client := newLifecycleClient(t, 1, "Failed")
+	jobs, err := waitForJobs(context.Background(), client, []string{"SABnzbd_nzo_1"}, time.Millisecond)
+	if err != nil {
+		t.Fatalf("waitForJobs returned error: %v", err)
+	}
+	if !jobs[0].Failed {
+		t.Fatalf("expected failed outcome, got %+v", jobs[0])
+	}
+	if err := printJobOutcomes(&output.Printer{Out: &bytes.Buffer{}}, &sabapi.AddResponse{}, jobs); err == nil || !strings.Contains(err.Error(), "1 of 1 jobs failed") {
+		t.Fatalf("expected failure error, got %v", err)
+	}
+}
+
+func TestWaitForJobsTimesOut(t *testing.T) {
+	t.Parallel()
+
+	client := newLifecycleClient(t, 1<<30, "Completed")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := waitForJobs(ctx, client, []string{"SABnzbd_nzo_1"}, time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+}
+
+func TestQueueItemFilesMoveTo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantAction string
+		wantErr    string
+	}{
+		{name: "to top", args: []string{"SABnzbd_nzo_1", "--to", "top", "--id", "SABnzbd_nzf_1", "--id", "SABnzbd_nzf_2"}, wantAction: "top"},
+		{name: "to bottom", args: []string{"SABnzbd_nzo_1", "--to", "BOTTOM", "--id", "SABnzbd_nzf_1"}, wantAction: "bottom"},
+		{name: "to with action", args: []string{"SABnzbd_nzo_1", "--to", "top", "--action", "up", "--id", "SABnzbd_nzf_1"}, wantErr: "cannot be combined"},
+		{name: "to with size", args: []string{"SABnzbd_nzo_1", "--to", "top", "--size", "2", "--id", "SABnzbd_nzf_1"}, wantErr: "--size"},
+		{name: "invalid to", args: []string{"SABnzbd_nzo_1", "--to", "up", "--id", "SABnzbd_nzf_1"}, wantErr: "unsupported --to"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls []url.Values
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, r.URL.Query())
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"status":true}`))
+			}))
+			t.Cleanup(server.Close)
+
+			client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+			if err != nil {
+				t.Fatalf("NewClient returned error: %v", err)
+			}
+			cmd := queueItemFilesMoveCmd()
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{Client: client, Printer: &output.Printer{Out: &bytes.Buffer{}}}))
+			cmd.SetArgs(tt.args)
+			err = cmd.Execute()
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				if len(calls) != 0 {
+					t.Fatalf("expected no API calls, got %v", calls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute returned error: %v", err)
+			}
+			if len(calls) != 1 {
+				t.Fatalf("expected one API call, got %v", calls)
+			}
+			q := calls[0]
+			if q.Get("mode") != "move_nzf_bulk" || q.Get("name") != tt.wantAction || q.Get("value") != "SABnzbd_nzo_1" {
+				t.Fatalf("unexpected move query %v", q)
+			}
+			if q.Has("size") {
+				t.Fatalf("expected no size param, got %q", q.Get("size"))
+			}
+		})
+	}
+}
+
+func TestQueueAddURLWarnsWhenNoJobID(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":true,"nzo_ids":[]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	var out, errOut bytes.Buffer
+	cmd := queueAddURLCmd()
+	cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{Client: client, Printer: &output.Printer{Out: &out, Err: &errOut}}))
+	cmd.SetArgs([]string{"https://indexer.example/get/1.nzb"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	if strings.Contains(out.String(), "Queued") {
+		t.Fatalf("expected no empty Queued line, got %q", out.String())
+	}
+	if !strings.Contains(errOut.String(), "possibly a duplicate") {
+		t.Fatalf("expected duplicate warning, got %q", errOut.String())
+	}
+}
+
+func TestQueueAddURLWaitFailsWithoutJobID(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if mode := r.URL.Query().Get("mode"); mode != "addurl" {
+			t.Errorf("unexpected mode %q while nothing can be tracked", mode)
+		}
+		_, _ = w.Write([]byte(`{"status":true,"nzo_ids":[]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := sabapi.NewClient(server.URL, "apikey")
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	var out bytes.Buffer
+	cmd := queueAddURLCmd()
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{Client: client, Printer: &output.Printer{Out: &out, Err: &bytes.Buffer{}}}))
+	cmd.SetArgs([]string{"https://indexer.example/get/1.nzb", "--wait"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "no job ID") {
+		t.Fatalf("expected --wait to fail without a job ID, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("expected no output, got %q", out.String())
+	}
+}
+
+func TestQueueListJSONIncludesTotals(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"queue":{"paused":false,"speed":"1024","speedlimit":"","timeleft":"0:12:30","slots":[
+			{"nzo_id":"SABnzbd_nzo_1","filename":"One","status":"Downloading","mb":"1,000.5","mbleft":"250.5"},
+			{"nzo_id":"SABnzbd_nzo_2","filename":"Two","status":"Queued","mb":"500","mbleft":"500"}
+		]}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	var out bytes.Buffer
+	cmd := queueListCmd()
+	cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{Client: client, Printer: &output.Printer{JSON: true, Out: &out}}))
+	cmd.SetArgs(nil)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("queue list returned error: %v", err)
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("decode payload: %v\n%s", err, out.String())
+	}
+	for _, key := range []string{"slots", "paused", "speed_kbps", "limit_kbps"} {
+		if _, ok := payload[key]; !ok {
+			t.Fatalf("expected existing key %q to be kept, got %v", key, payload)
+		}
+	}
+	if payload["count"] != float64(2) || payload["size_mb"] != 1500.5 || payload["mbleft"] != 750.5 || payload["timeleft"] != "0:12:30" {
+		t.Fatalf("unexpected totals: count=%v size_mb=%v mbleft=%v timeleft=%v", payload["count"], payload["size_mb"], payload["mbleft"], payload["timeleft"])
+	}
+	// The fixture reports no kbpersec, so the aggregate ETA is unknown.
+	if _, ok := payload["finish_at"]; payload["queue_eta"] != "unknown" || ok {
+		t.Fatalf("expected unknown queue_eta without finish_at, got %v / %v", payload["queue_eta"], payload["finish_at"])
+	}
+}
+
+func TestLastStageMessage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		stages []sabapi.StageLog
+		want   string
+	}{
+		{name: "no stages"},
+		{name: "latest line of last stage", stages: []sabapi.StageLog{
+			{Stage: "Download", Log: "Downloaded in 3 minutes"},
+			{Stage: "Repair", Log: "Quick Check OK<br/>Verifying 12/40 blocks"},
+		}, want: "Repair: Verifying 12/40 blocks"},
+		{name: "empty last stage falls back to its name", stages: []sabapi.StageLog{
+			{Stage: "Download", Log: "Downloaded"},
+			{Stage: "Unpack", Log: " \n "},
+		}, want: "Unpack"},
+		{name: "blank stage name keeps message", stages: []sabapi.StageLog{{Log: "Waiting\nStill waiting"}}, want: "Still waiting"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := lastStageMessage(tt.stages); got != tt.want {
+				t.Fatalf("lastStageMessage = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	t.Parallel()
+
+	if got := truncateText("short", 10); got != "short" {
+		t.Fatalf("expected short text untouched, got %q", got)
+	}
+	if got := truncateText("Verifying déjà vu", 8); got != "Verifyi…" {
+		t.Fatalf("expected truncated text, got %q", got)
+	}
+}
+
+func TestQueueListVerbose(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("x", 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"queue":{"slots":[
+			{"nzo_id":"SABnzbd_nzo_1","filename":"One","status":"Verifying","stage_log":[{"stage":"Repair","log":"Quick Check OK<br/>` + long + `"}]},
+			{"nzo_id":"SABnzbd_nzo_2","filename":"Two","status":"Queued"}
+		]}}`))
+	}))
+	t.Cleanup(server.Close)
+	client, err := sabapi.NewClient(server.URL, "apikey")
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	run := func(json bool, args ...string) string {
+		var out bytes.Buffer
+		cmd := queueListCmd()
+		cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{Client: client, Printer: &output.Printer{JSON: json, Out: &out}}))
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("queue list %v returned error: %v", args, err)
+		}
+		return out.String()
+	}
+
+	table := run(false, "--verbose")
+	if !strings.Contains(table, "Last Stage") || !strings.Contains(table, "Repair: "+strings.Repeat("x", lastStageWidth-len("Repair: ")-1)+"…") {
+		t.Fatalf("expected truncated last stage column, got:\n%s", table)
+	}
+	if strings.Contains(run(false), "Last Stage") {
+		t.Fatal("expected no Last Stage column without --verbose")
+	}
+
+	var payload struct {
+		Slots []map[string]any `json:"slots"`
+	}
+	if err := json.Unmarshal([]byte(run(true, "--verbose")), &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if len(payload.Slots) != 2 || payload.Slots[0]["last_stage"] != "Repair: "+long || payload.Slots[0]["stage_log"] == nil {
+		t.Fatalf("expected full last_stage and stage_log in JSON, got %v", payload.Slots)
+	}
+	if _, ok := payload.Slots[1]["last_stage"]; ok {
+		t.Fatalf("expected no last_stage for a job without stages, got %v", payload.Slots[1])
+	}
+}
+
+func TestQueueETA(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		mbLeft  float64
+		kbps    string
+		wantETA time.Duration
+		wantOK  bool
+	}{
+		{name: "steady download", mbLeft: 1024, kbps: "1024", wantETA: 1024 * time.Second, wantOK: true},
+		{name: "fractional speed rounds to seconds", mbLeft: 1, kbps: "3", wantETA: 341 * time.Second, wantOK: true},
+		{name: "thousands separator", mbLeft: 2048, kbps: "2,048", wantETA: 1024 * time.Second, wantOK: true},
+		{name: "zero speed is unknown", mbLeft: 100, kbps: "0"},
+		{name: "missing speed is unknown", mbLeft: 100, kbps: ""},
+		{name: "garbage speed is unknown", mbLeft: 100, kbps: "fast"},
+		{name: "empty queue is done now", mbLeft: 0, kbps: "0", wantOK: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			eta, finish, ok := queueETA(tt.mbLeft, tt.kbps, now)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				if !finish.IsZero() {
+					t.Fatalf("expected zero finish time for unknown ETA, got %v", finish)
+				}
+				return
+			}
+			if eta != tt.wantETA || !finish.Equal(now.Add(tt.wantETA)) {
+				t.Fatalf("queueETA = %v finishing %v, want %v finishing %v", eta, finish, tt.wantETA, now.Add(tt.wantETA))
+			}
+		})
+	}
+}
+
+func TestParsePPLevel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{in: "0", want: 0},
+		{in: "3", want: 3},
+		{in: "skip", want: 0},
+		{in: " Repair ", want: 1},
+		{in: "unpack", want: 2},
+		{in: "DELETE", want: 3},
+		{in: "4", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "extract", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+			got, err := parsePPLevel(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parsePPLevel(%q) = %d, expected error", tt.in, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("parsePPLevel(%q) = %d, %v; want %d", tt.in, got, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := buildAddOptions("", "unpack", "", "", "", ""); err != nil {
+		t.Fatalf("buildAddOptions rejected a named --pp: %v", err)
+	}
+	if _, err := buildAddOptions("", "5", "", "", "", ""); err == nil || !strings.Contains(err.Error(), "invalid --pp") {
+		t.Fatalf("expected --pp 5 to be rejected, got %v", err)
+	}
+}
+
+func TestQueueItemOptsNamedLevel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		level     string
+		wantValue string
+		wantErr   string
+	}{
+		{level: "delete", wantValue: "3"},
+		{level: "1", wantValue: "1"},
+		{level: "4", wantErr: "not a post-processing level"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.level, func(t *testing.T) {
+			t.Parallel()
+			var sent atomic.Value
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				q := r.URL.Query()
+				if q.Get("mode") == "change_opts" {
+					sent.Store(q.Get("value2"))
+				}
+				_, _ = w.Write([]byte(`{"status":true}`))
+			}))
+			t.Cleanup(server.Close)
+			client, err := sabapi.NewClient(server.URL, "secret")
+			if err != nil {
+				t.Fatalf("NewClient returned error: %v", err)
+			}
+
+			cmd := queueItemOptsCmd()
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{
+				Client:  client,
+				Printer: &output.Printer{Out: &bytes.Buffer{}, Err: &bytes.Buffer{}},
+			}))
+			cmd.SetArgs([]string{tt.level, "SABnzbd_nzo_1"})
+			err = cmd.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				if sent.Load() != nil {
+					t.Fatal("change_opts was sent for an invalid level")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("queue item opts returned error: %v", err)
+			}
+			if got, _ := sent.Load().(string); got != tt.wantValue {
+				t.Fatalf("change_opts value2 = %q, want %q", got, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestProgressBarRendersPercentages(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	bar := progressBar(&out, "Uploading show.nzb")
+	bar(0, 200)
+	bar(1, 200) // still 0%, no redraw
+	bar(100, 200)
+	bar(200, 200)
+
+	got := out.String()
+	if strings.Count(got, "\r") != 3 {
+		t.Fatalf("expected 3 redraws, got %q", got)
+	}
+	if !strings.Contains(got, " 50% (100.00 B/200.00 B)") || !strings.HasSuffix(got, "100% (200.00 B/200.00 B)\n") {
+		t.Fatalf("unexpected progress output %q", got)
+	}
+	if uploadProgress(&output.Printer{Err: &out}, "x") != nil {
+		t.Fatal("expected no progress bar for a non-terminal writer")
+	}
+}
+
+func TestQueueItemDeleteIgnoreMissing(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("name") != "delete" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":false,"nzo_ids":[]}`))
+	}))
+	t.Cleanup(server.Close)
+	client, err := sabapi.NewClient(server.URL, "secret")
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		json    bool
+		wantErr bool
+		want    string
+	}{
+		{name: "missing fails by default", args: []string{"SABnzbd_nzo_gone"}, wantErr: true},
+		{name: "ignore missing text", args: []string{"SABnzbd_nzo_gone", "--ignore-missing"}, want: "Item SABnzbd_nzo_gone already removed"},
+		{name: "ignore missing json", args: []string{"SABnzbd_nzo_gone", "--ignore-missing"}, json: true, want: `"missing": true`},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			cmd := queueItemDeleteCmd()
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{
+				Client:  client,
+				Printer: &output.Printer{JSON: tt.json, Out: &out, Err: &bytes.Buffer{}},
+			}))
+			cmd.SetArgs(tt.args)
+			err := cmd.Execute()
+			if tt.wantErr {
+				if !errors.Is(err, sabapi.ErrItemNotFound) || !strings.Contains(err.Error(), "--ignore-missing") {
+					t.Fatalf("expected not-found error with hint, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute returned error: %v", err)
+			}
+			if !strings.Contains(out.String(), tt.want) {
+				t.Fatalf("output %q does not contain %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestQueueAddTitleSendsNZBName(t *testing.T) {
+	t.Parallel()
+
+	nzbPath := filepath.Join(t.TempDir(), "upload.nzb")
+	if err := os.WriteFile(nzbPath, []byte("<nzb></nzb>"), 0o600); err != nil {
+		t.Fatalf("write nzb: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		newCmd   func() *cobra.Command
+		args     []string
+		wantMode string
+		want     string
+		wantErr  string
+	}{
+		{name: "url --title", newCmd: queueAddURLCmd, args: []string{"https://indexer.example/api?t=get&id=42", "--title", "  Show S01E01  "}, wantMode: "addurl", want: "Show S01E01"},
+		{name: "url --name alias", newCmd: queueAddURLCmd, args: []string{"https://indexer.example/get/1.nzb", "--name", "Alias Title"}, wantMode: "addurl", want: "Alias Title"},
+		{name: "file --title", newCmd: queueAddFileCmd, args: []string{nzbPath, "--title", "Uploaded Title"}, wantMode: "addfile", want: "Uploaded Title"},
+		{name: "local --title", newCmd: queueAddLocalCmd, args: []string{"/srv/nzb/x.nzb", "--title", "Local Title"}, wantMode: "addlocalfile", want: "Local Title"},
+		{name: "no title keeps nzbname unset", newCmd: queueAddURLCmd, args: []string{"https://indexer.example/get/1.nzb"}, wantMode: "addurl"},
+		{name: "both flags", newCmd: queueAddURLCmd, args: []string{"https://indexer.example/get/1.nzb", "--title", "A", "--name", "B"}, wantErr: "pass only one"},
+		{name: "blank title", newCmd: queueAddURLCmd, args: []string{"https://indexer.example/get/1.nzb", "--title", "  "}, wantErr: "must not be empty"},
+		{name: "path separator", newCmd: queueAddLocalCmd, args: []string{"/srv/nzb/x.nzb", "--title", "a/b"}, wantErr: "must not contain"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var mu sync.Mutex
+			var mode, nzbname string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPost {
+					_ = r.ParseMultipartForm(1 << 20)
+				}
+				mu.Lock()
+				mode, nzbname = r.FormValue("mode"), r.FormValue("nzbname")
+				mu.Unlock()
+				_, _ = w.Write([]byte(`{"status":true,"nzo_ids":["SABnzbd_nzo_1"]}`))
+			}))
+			t.Cleanup(server.Close)
+			client, err := sabapi.NewClient(server.URL, "apikey")
+			if err != nil {
+				t.Fatalf("NewClient returned error: %v", err)
+			}
+
+			var out bytes.Buffer
+			cmd := tt.newCmd()
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{Client: client, Printer: &output.Printer{Out: &out, Err: &bytes.Buffer{}}}))
+			cmd.SetArgs(tt.args)
+			err = cmd.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute returned error: %v", err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if mode != tt.wantMode || nzbname != tt.want {
+				t.Fatalf("got mode=%q nzbname=%q, want %q %q", mode, nzbname, tt.wantMode, tt.want)
+			}
+			if tt.want != "" && !strings.Contains(out.String(), fmt.Sprintf("as %q", tt.want)) {
+				t.Fatalf("expected the title in output, got %q", out.String())
+			}
+		})
+	}
+}
+
+func TestQueueAddTitleJSON(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":true,"nzo_ids":["SABnzbd_nzo_1"]}`))
+	}))
+	t.Cleanup(server.Close)
+	client, err := sabapi.NewClient(server.URL, "apikey")
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	var out bytes.Buffer
+	cmd := queueAddURLCmd()
+	cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{Client: client, Printer: &output.Printer{Out: &out, JSON: true}}))
+	cmd.SetArgs([]string{"https://indexer.example/get/1.nzb", "--title", "Show S01E01"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	var payload struct {
+		Status bool     `json:"status"`
+		NZOIDs []string `json:"nzo_ids"`
+		Title  string   `json:"title"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("decode output: %v\n%s", err, out.String())
+	}
+	if !payload.Status || len(payload.NZOIDs) != 1 || payload.Title != "Show S01E01" {
+		t.Fatalf("unexpected payload %+v", payload)
+	}
+}
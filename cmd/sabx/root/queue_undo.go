@@ -0,0 +1,225 @@
+package root
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/config"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+// queueUndoRecord is the queue order saved by --record-undo.
+type queueUndoRecord struct {
+	BaseURL    string    `json:"base_url"`
+	RecordedAt time.Time `json:"recorded_at"`
+	Order      []string  `json:"order"`
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// queueUndoPath is the file holding the recorded order for profile, kept in
+// the sabx config directory rather than a shared temp directory. Each
+// profile gets its own file so undo never replays another instance's order.
+func queueUndoPath(profile string) (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	name := unsafeFileChars.ReplaceAllString(profileOrDefault(profile), "_")
+	return filepath.Join(dir, "queue-undo-"+name+".json"), nil
+}
+
+// snapshotQueueUndo captures the current queue order before a reorder. The
+// caller saves it with saveQueueUndo once the reorder succeeded, so a failed
+// move keeps the previous undo point.
+func snapshotQueueUndo(ctx context.Context, app *cobraext.App) (queueUndoRecord, error) {
+	queue, err := app.Client.Queue(ctx, 0, 0, "")
+	if err != nil {
+		return queueUndoRecord{}, fmt.Errorf("record undo: %w", err)
+	}
+	return queueUndoRecord{
+		BaseURL:    app.BaseURL,
+		RecordedAt: time.Now().UTC(),
+		Order:      queueOrder(queue.Slots),
+	}, nil
+}
+
+// saveQueueUndo stores record so `queue undo` can restore it.
+func saveQueueUndo(app *cobraext.App, record queueUndoRecord) error {
+	path, err := queueUndoPath(app.ProfileName)
+	if err != nil {
+		return fmt.Errorf("record undo: %w", err)
+	}
+	if err := writeQueueUndo(path, record); err != nil {
+		return fmt.Errorf("record undo: %w", err)
+	}
+	return nil
+}
+
+func queueOrder(slots []sabapi.QueueSlot) []string {
+	order := make([]string, 0, len(slots))
+	for _, slot := range slots {
+		order = append(order, slot.NZOID)
+	}
+	return order
+}
+
+// writeQueueUndo writes record to a new temp file next to path and renames
+// it into place, so an existing file or symlink at path is replaced rather
+// than written through.
+func writeQueueUndo(path string, record queueUndoRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".queue-undo-*.json")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func readQueueUndo(path string) (queueUndoRecord, error) {
+	var record queueUndoRecord
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return record, errors.New("nothing to undo; reorder with --record-undo first")
+	}
+	if err != nil {
+		return record, err
+	}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return record, fmt.Errorf("read undo record %s: %w", path, err)
+	}
+	return record, nil
+}
+
+// queueRestore summarizes a replayed ordering.
+type queueRestore struct {
+	Moved   int      `json:"moved"`
+	Kept    int      `json:"kept"`
+	Missing []string `json:"missing,omitempty"`
+}
+
+// replayQueueOrder moves the jobs in recorded back to their recorded
+// positions with one switch call per job that is out of place. Jobs that
+// have since left the queue are reported as missing; jobs added after the
+// recording end up below the restored ones. Each switch gets its own request
+// timeout, so restoring a long queue is not bounded by a single deadline.
+func replayQueueOrder(ctx context.Context, client *sabapi.Client, recorded, current []string) (queueRestore, error) {
+	var result queueRestore
+	present := make(map[string]bool, len(current))
+	for _, id := range current {
+		present[id] = true
+	}
+	order := append([]string(nil), current...)
+	pos := 0
+	for _, id := range recorded {
+		if !present[id] {
+			result.Missing = append(result.Missing, id)
+			continue
+		}
+		at := indexOf(order, id)
+		if at == pos {
+			result.Kept++
+			pos++
+			continue
+		}
+		reqCtx, cancel := timeoutContext(ctx)
+		err := client.QueueSwitchPosition(reqCtx, id, pos)
+		cancel()
+		if err != nil {
+			return result, err
+		}
+		order = append(order[:at], order[at+1:]...)
+		order = append(order[:pos], append([]string{id}, order[pos:]...)...)
+		result.Moved++
+		pos++
+	}
+	return result, nil
+}
+
+func indexOf(values []string, want string) int {
+	for i, v := range values {
+		if v == want {
+			return i
+		}
+	}
+	return -1
+}
+
+func queueUndoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "undo",
+		Short: jsonShort("Restore the queue order saved by --record-undo"),
+		Long: appendJSONLong("Restores the order recorded by `queue item move --record-undo` or `queue sort --record-undo`. " +
+			"This is best effort: jobs that finished or were removed since are skipped, and jobs added since end up below the restored ones. " +
+			"The record is removed once it has been replayed."),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, err := getApp(cmd)
+			if err != nil {
+				return err
+			}
+			path, err := queueUndoPath(app.ProfileName)
+			if err != nil {
+				return err
+			}
+			record, err := readQueueUndo(path)
+			if err != nil {
+				return err
+			}
+			if record.BaseURL != "" && app.BaseURL != "" && record.BaseURL != app.BaseURL {
+				return fmt.Errorf("undo record is for %s, not %s", record.BaseURL, app.BaseURL)
+			}
+
+			ctx, cancel := timeoutContext(cmd.Context())
+			queue, err := app.Client.Queue(ctx, 0, 0, "")
+			cancel()
+			if err != nil {
+				return err
+			}
+			result, err := replayQueueOrder(cmd.Context(), app.Client, record.Order, queueOrder(queue.Slots))
+			if err != nil {
+				return err
+			}
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+
+			if app.Printer.JSON {
+				return app.Printer.Print(result)
+			}
+			msg := fmt.Sprintf("Restored queue order from %s (%d moved, %d already in place)", record.RecordedAt.Local().Format(time.RFC3339), result.Moved, result.Kept)
+			if len(result.Missing) > 0 {
+				msg += fmt.Sprintf("; %d no longer queued", len(result.Missing))
+			}
+			return app.Printer.Print(msg)
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,229 @@
+package root
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+// fakeOrderQueue serves a queue whose order changes with switch and sort
+// calls, like SABnzbd's.
+type fakeOrderQueue struct {
+	mu       sync.Mutex
+	order    []string
+	switches int
+}
+
+func (f *fakeOrderQueue) snapshot() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.order...)
+}
+
+func newFakeOrderQueue(t *testing.T, order ...string) (*fakeOrderQueue, *httptest.Server) {
+	t.Helper()
+	f := &fakeOrderQueue{order: order}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		q := r.URL.Query()
+		switch q.Get("mode") {
+		case "queue":
+			if q.Get("name") == "sort" {
+				for i, j := 0, len(f.order)-1; i < j; i, j = i+1, j-1 {
+					f.order[i], f.order[j] = f.order[j], f.order[i]
+				}
+				_, _ = w.Write([]byte(`{"status":true}`))
+				return
+			}
+			slots := make([]sabapi.QueueSlot, 0, len(f.order))
+			for _, id := range f.order {
+				slots = append(slots, sabapi.QueueSlot{NZOID: id, Filename: id})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"queue": map[string]any{"slots": slots}})
+		case "switch":
+			id := q.Get("value")
+			pos, err := strconv.Atoi(q.Get("value2"))
+			at := indexOf(f.order, id)
+			if err != nil || at < 0 || pos >= len(f.order) {
+				http.Error(w, "bad switch", http.StatusBadRequest)
+				return
+			}
+			f.switches++
+			f.order = append(f.order[:at], f.order[at+1:]...)
+			f.order = append(f.order[:pos], append([]string{id}, f.order[pos:]...)...)
+			_, _ = w.Write([]byte(`{"result":{"position":` + strconv.Itoa(pos) + `}}`))
+		default:
+			t.Errorf("unexpected mode %q", q.Get("mode"))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func TestReplayQueueOrder(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		current     []string
+		recorded    []string
+		want        []string
+		wantMoved   int
+		wantMissing []string
+	}{
+		{name: "reversed", current: []string{"c", "b", "a"}, recorded: []string{"a", "b", "c"}, want: []string{"a", "b", "c"}, wantMoved: 2},
+		{name: "already in order", current: []string{"a", "b"}, recorded: []string{"a", "b"}, want: []string{"a", "b"}},
+		{name: "completed job skipped", current: []string{"c", "a"}, recorded: []string{"a", "b", "c"}, want: []string{"a", "c"}, wantMoved: 1, wantMissing: []string{"b"}},
+		{name: "new job goes below", current: []string{"new", "b", "a"}, recorded: []string{"a", "b"}, want: []string{"a", "b", "new"}, wantMoved: 2},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fake, server := newFakeOrderQueue(t, tt.current...)
+			client, err := sabapi.NewClient(server.URL, "secret")
+			if err != nil {
+				t.Fatalf("NewClient returned error: %v", err)
+			}
+			result, err := replayQueueOrder(context.Background(), client, tt.recorded, tt.current)
+			if err != nil {
+				t.Fatalf("replayQueueOrder returned error: %v", err)
+			}
+			if got := fake.snapshot(); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("queue order = %q, want %q", got, tt.want)
+			}
+			if result.Moved != tt.wantMoved || fake.switches != tt.wantMoved || !reflect.DeepEqual(result.Missing, tt.wantMissing) {
+				t.Fatalf("unexpected result %+v after %d switch calls", result, fake.switches)
+			}
+		})
+	}
+}
+
+func TestQueueSortRecordUndoRoundTrip(t *testing.T) {
+	t.Setenv("SABX_CONFIG_DIR", t.TempDir())
+
+	fake, server := newFakeOrderQueue(t, "SABnzbd_nzo_a", "SABnzbd_nzo_b", "SABnzbd_nzo_c")
+	client, err := sabapi.NewClient(server.URL, "secret")
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		root := queueCmd()
+		root.SilenceUsage = true
+		root.SilenceErrors = true
+		root.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{
+			Client:      client,
+			BaseURL:     server.URL,
+			ProfileName: "undo-test",
+			Printer:     &output.Printer{Out: &out, Err: &bytes.Buffer{}},
+		}))
+		root.SetArgs(args)
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	if _, err := run("undo"); err == nil || !strings.Contains(err.Error(), "nothing to undo") {
+		t.Fatalf("expected nothing to undo before recording, got %v", err)
+	}
+	if _, err := run("sort", "name", "--record-undo"); err != nil {
+		t.Fatalf("queue sort returned error: %v", err)
+	}
+	if got := fake.snapshot(); got[0] != "SABnzbd_nzo_c" {
+		t.Fatalf("expected the fake sort to reorder the queue, got %q", got)
+	}
+
+	out, err := run("undo")
+	if err != nil {
+		t.Fatalf("queue undo returned error: %v", err)
+	}
+	if got, want := fake.snapshot(), []string{"SABnzbd_nzo_a", "SABnzbd_nzo_b", "SABnzbd_nzo_c"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("undo left order %q, want %q", got, want)
+	}
+	if !strings.Contains(out, "2 moved") {
+		t.Fatalf("expected restore summary, got %q", out)
+	}
+	if _, err := run("undo"); err == nil || !strings.Contains(err.Error(), "nothing to undo") {
+		t.Fatalf("expected the record to be consumed, got %v", err)
+	}
+}
+
+func TestQueueUndoKeptWhenMoveFails(t *testing.T) {
+	t.Setenv("SABX_CONFIG_DIR", t.TempDir())
+
+	_, server := newFakeOrderQueue(t, "SABnzbd_nzo_a", "SABnzbd_nzo_b")
+	client, err := sabapi.NewClient(server.URL, "secret")
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	app := &cobraext.App{Client: client, BaseURL: server.URL, Printer: &output.Printer{Out: &bytes.Buffer{}, Err: &bytes.Buffer{}}}
+	run := func(args ...string) error {
+		root := queueCmd()
+		root.SilenceUsage = true
+		root.SilenceErrors = true
+		root.SetContext(cobraext.WithApp(context.Background(), app))
+		root.SetArgs(args)
+		return root.Execute()
+	}
+
+	if err := run("sort", "name", "--record-undo"); err != nil {
+		t.Fatalf("queue sort returned error: %v", err)
+	}
+	path, err := queueUndoPath("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := readQueueUndo(path)
+	if err != nil {
+		t.Fatalf("expected an undo record after sort: %v", err)
+	}
+
+	// The fake rejects a switch past the end of the queue.
+	if err := run("item", "move", "SABnzbd_nzo_a", "to", "5", "--record-undo"); err == nil {
+		t.Fatal("expected the out-of-range move to fail")
+	}
+	after, err := readQueueUndo(path)
+	if err != nil {
+		t.Fatalf("undo record lost after failed move: %v", err)
+	}
+	if !reflect.DeepEqual(before.Order, after.Order) {
+		t.Fatalf("failed move replaced the undo point: %q -> %q", before.Order, after.Order)
+	}
+}
+
+func TestWriteQueueUndoReplacesSymlink(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	victim := filepath.Join(dir, "victim.txt")
+	if err := os.WriteFile(victim, []byte("keep me\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "queue-undo-default.json")
+	if err := os.Symlink(victim, path); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if err := writeQueueUndo(path, queueUndoRecord{Order: []string{"SABnzbd_nzo_a"}}); err != nil {
+		t.Fatalf("writeQueueUndo returned error: %v", err)
+	}
+	if data, _ := os.ReadFile(victim); string(data) != "keep me\n" {
+		t.Fatalf("undo record was written through the symlink: %q", data)
+	}
+	if info, err := os.Lstat(path); err != nil || info.Mode()&os.ModeSymlink != 0 {
+		t.Fatalf("expected a regular file at %s, got %v (%v)", path, info, err)
+	}
+}
@@ -0,0 +1,122 @@
+package root
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+func readyCmd() *cobra.Command {
+	var requireServers bool
+	cmd := &cobra.Command{
+		Use:   "ready",
+		Short: jsonShort("Exit 0 only when SABnzbd is up and able to download"),
+		Long: appendJSONLong("Readiness probe for container healthchecks (e.g. HEALTHCHECK CMD sabx ready). " +
+			"SABnzbd is ready when it answers the version call and not every enabled news server is blocked. " +
+			"--require-servers also fails when no enabled server is usable, including when none are configured. " +
+			"A fullstatus answer without a readable server list counts as not ready. " +
+			"Prints a single line and exits non-zero when not ready."),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, err := getApp(cmd)
+			if err != nil {
+				return err
+			}
+			if app.Client == nil {
+				return fmt.Errorf("not ready: not logged in; run 'sabx login'")
+			}
+			ctx, cancel := timeoutContext(cmd.Context())
+			defer cancel()
+
+			version, err := app.Client.Version(ctx)
+			if err != nil {
+				return fmt.Errorf("not ready: %w", err)
+			}
+			status, err := app.Client.FullStatus(ctx, sabapi.FullStatusOptions{SkipDashboard: true})
+			if err != nil {
+				return fmt.Errorf("not ready: %w", err)
+			}
+			ready, reason := false, ""
+			if servers, err := readyServers(status); err != nil {
+				reason = err.Error()
+			} else {
+				ready, reason = readiness(servers, requireServers)
+			}
+
+			if app.Printer.JSON {
+				payload := map[string]any{
+					"ready":   ready,
+					"version": version.Version,
+				}
+				if reason != "" {
+					payload["reason"] = reason
+				}
+				if err := app.Printer.Print(payload); err != nil {
+					return err
+				}
+			} else if ready {
+				if err := app.Printer.Print("ready"); err != nil {
+					return err
+				}
+			}
+			if !ready {
+				return fmt.Errorf("not ready: %s", reason)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&requireServers, "require-servers", false, "Also require at least one enabled, unblocked news server")
+	return cmd
+}
+
+// readyServers extracts the news servers from fullstatus. Unlike status, a
+// probe must not read an unexpected shape as "no servers", so a missing or
+// malformed list is an error.
+func readyServers(status map[string]any) ([]statusServerEntry, error) {
+	raw, ok := status["servers"]
+	if !ok {
+		return nil, errors.New("fullstatus reported no servers list")
+	}
+	switch raw.(type) {
+	case []any, []map[string]any:
+	default:
+		return nil, fmt.Errorf("fullstatus servers has unexpected type %T", raw)
+	}
+	servers, err := serversFromFullStatus(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) != len(sliceFrom(raw)) {
+		return nil, errors.New("fullstatus servers list has malformed entries")
+	}
+	return servers, nil
+}
+
+// readiness decides whether SABnzbd can download given its fullstatus
+// servers. A server is usable when it is enabled and reports no error; a
+// reported error means SABnzbd has blocked it. Disabled servers are ignored.
+// It is fatal for every enabled server to be blocked, and with
+// requireServers for no server to be usable at all.
+func readiness(servers []statusServerEntry, requireServers bool) (bool, string) {
+	enabled, usable := 0, 0
+	for _, srv := range servers {
+		if !srv.Active {
+			continue
+		}
+		enabled++
+		if msg := strings.TrimSpace(srv.Error); msg == "" || msg == "<nil>" {
+			usable++
+		}
+	}
+	switch {
+	case enabled > 0 && usable == 0:
+		return false, fmt.Sprintf("all %d enabled servers are blocked", enabled)
+	case requireServers && usable == 0:
+		return false, "no enabled news server"
+	}
+	return true, ""
+}
@@ -0,0 +1,123 @@
+package root
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+func TestReadiness(t *testing.T) {
+	t.Parallel()
+
+	ok := statusServerEntry{Name: "primary", Active: true, Error: "<nil>"}
+	blocked := statusServerEntry{Name: "backup", Active: true, Error: "Server blocked for 10 minutes"}
+	disabled := statusServerEntry{Name: "old", Active: false, Error: "<nil>"}
+
+	tests := []struct {
+		name           string
+		servers        []statusServerEntry
+		requireServers bool
+		want           bool
+		wantReason     string
+	}{
+		{name: "healthy server", servers: []statusServerEntry{ok, blocked}, want: true},
+		{name: "all enabled blocked", servers: []statusServerEntry{blocked, disabled}, wantReason: "all 1 enabled servers are blocked"},
+		{name: "no servers without requirement", want: true},
+		{name: "no servers with requirement", requireServers: true, wantReason: "no enabled news server"},
+		{name: "only disabled with requirement", servers: []statusServerEntry{disabled}, requireServers: true, wantReason: "no enabled news server"},
+		{name: "only disabled without requirement", servers: []statusServerEntry{disabled}, want: true},
+		{name: "usable with requirement", servers: []statusServerEntry{ok}, requireServers: true, want: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, reason := readiness(tt.servers, tt.requireServers)
+			if got != tt.want || reason != tt.wantReason {
+				t.Fatalf("readiness = (%v, %q), want (%v, %q)", got, reason, tt.want, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestReadyCmd(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		fullstatus string
+		version    int
+		args       []string
+		wantOut    string
+		wantErr    string
+	}{
+		{name: "ready", fullstatus: `{"status":{"servers":[{"servername":"news","serveractive":true,"servererror":""}]}}`, wantOut: "ready"},
+		{name: "servers blocked", fullstatus: `{"status":{"servers":[{"servername":"news","serveractive":true,"servererror":"Login failed"}]}}`, wantErr: "not ready: all 1 enabled servers are blocked"},
+		{name: "require servers", fullstatus: `{"status":{"servers":[]}}`, args: []string{"--require-servers"}, wantErr: "not ready: no enabled news server"},
+		{name: "version fails", version: http.StatusServiceUnavailable, wantErr: "not ready:"},
+		{name: "servers missing", fullstatus: `{"status":{"paused":false}}`, wantErr: "not ready: fullstatus reported no servers list"},
+		{name: "servers malformed", fullstatus: `{"status":{"servers":"none"}}`, wantErr: "not ready: fullstatus servers has unexpected type"},
+		{name: "server entry malformed", fullstatus: `{"status":{"servers":[{"servername":"news","serveractive":true,"servererror":""},"oops"]}}`, wantErr: "not ready: fullstatus servers list has malformed entries"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Query().Get("mode") {
+				case "version":
+					if tt.version != 0 {
+						w.WriteHeader(tt.version)
+						return
+					}
+					_, _ = w.Write([]byte(`{"version":"4.3.2"}`))
+				case "fullstatus":
+					_, _ = w.Write([]byte(tt.fullstatus))
+				default:
+					t.Errorf("unexpected mode %q", r.URL.Query().Get("mode"))
+				}
+			}))
+			t.Cleanup(server.Close)
+			client, err := sabapi.NewClient(server.URL, "secret")
+			if err != nil {
+				t.Fatalf("NewClient returned error: %v", err)
+			}
+
+			var out bytes.Buffer
+			cmd := readyCmd()
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{
+				Client:  client,
+				Printer: &output.Printer{Out: &out, Err: &bytes.Buffer{}},
+			}))
+			cmd.SetArgs(tt.args)
+			err = cmd.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				if ExitCode(err) == 0 {
+					t.Fatal("not ready must exit non-zero")
+				}
+				if out.Len() != 0 {
+					t.Fatalf("expected no stdout when not ready, got %q", out.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ready returned error: %v", err)
+			}
+			if strings.TrimSpace(out.String()) != tt.wantOut {
+				t.Fatalf("expected %q, got %q", tt.wantOut, out.String())
+			}
+		})
+	}
+}
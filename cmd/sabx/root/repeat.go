@@ -0,0 +1,127 @@
+package root
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avivsinai/sabx/internal/output"
+)
+
+// repeatableCommands are the read-only commands that accept --repeat. Anything
+// that changes SABnzbd state is excluded so a benchmark cannot, say, delete
+// history N times.
+var repeatableCommands = map[string]bool{
+	"sabx status":          true,
+	"sabx whoami":          true,
+	"sabx doctor":          true,
+	"sabx warnings list":   true,
+	"sabx logs list":       true,
+	"sabx queue list":      true,
+	"sabx queue item show": true,
+	"sabx history list":    true,
+	"sabx speed status":    true,
+	"sabx server list":     true,
+	"sabx server stats":    true,
+	"sabx categories list": true,
+	"sabx scripts list":    true,
+}
+
+// repeatExcludedFlags lists flags that turn a repeatable command into one
+// that changes SABnzbd state; --repeat is rejected when any of them is set.
+var repeatExcludedFlags = map[string][]string{
+	"sabx doctor": {"fix"},
+}
+
+// installRepeat wraps cmd's RunE so it runs n times, reporting per-run timing
+// and a min/avg/max/p95 summary on stderr. The original RunE is restored once
+// the command finishes.
+func installRepeat(cmd *cobra.Command, printer *output.Printer, n int, interval time.Duration) error {
+	if n < 1 {
+		return fmt.Errorf("--repeat must be at least 1, got %d", n)
+	}
+	if interval < 0 {
+		return fmt.Errorf("--repeat-interval must not be negative")
+	}
+	if !repeatableCommands[cmd.CommandPath()] || cmd.RunE == nil {
+		return fmt.Errorf("--repeat is only supported for read-only commands, not %q", cmd.CommandPath())
+	}
+	for _, name := range repeatExcludedFlags[cmd.CommandPath()] {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--repeat is only supported for read-only commands; %q with --%s changes state", cmd.CommandPath(), name)
+		}
+	}
+
+	run := cmd.RunE
+	cmd.RunE = func(c *cobra.Command, args []string) error {
+		defer func() { cmd.RunE = run }()
+		timings, err := runRepeated(c.Context(), n, interval, func() error { return run(c, args) }, func(i int, d time.Duration) {
+			printer.Error("run %d/%d: %s", i, n, d.Round(time.Microsecond))
+		})
+		if len(timings) > 0 {
+			s := summarizeTimings(timings)
+			printer.Error("%d runs: min %s avg %s max %s p95 %s", len(timings),
+				s.Min.Round(time.Microsecond), s.Avg.Round(time.Microsecond), s.Max.Round(time.Microsecond), s.P95.Round(time.Microsecond))
+		}
+		return err
+	}
+	return nil
+}
+
+// runRepeated calls run n times, waiting interval between calls, and returns
+// the duration of each completed run. It stops at the first error.
+func runRepeated(ctx context.Context, n int, interval time.Duration, run func() error, report func(i int, d time.Duration)) ([]time.Duration, error) {
+	timings := make([]time.Duration, 0, n)
+	for i := 1; i <= n; i++ {
+		if i > 1 && interval > 0 {
+			select {
+			case <-ctx.Done():
+				return timings, ctx.Err()
+			case <-time.After(interval):
+			}
+		}
+		start := time.Now()
+		if err := run(); err != nil {
+			return timings, err
+		}
+		d := time.Since(start)
+		timings = append(timings, d)
+		if report != nil {
+			report(i, d)
+		}
+	}
+	return timings, nil
+}
+
+// timingSummary aggregates repeated run durations.
+type timingSummary struct {
+	Min time.Duration
+	Avg time.Duration
+	Max time.Duration
+	P95 time.Duration
+}
+
+// summarizeTimings computes min/avg/max and the nearest-rank 95th percentile.
+func summarizeTimings(timings []time.Duration) timingSummary {
+	if len(timings) == 0 {
+		return timingSummary{}
+	}
+	sorted := append([]time.Duration(nil), timings...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	rank := int(math.Ceil(0.95*float64(len(sorted)))) - 1
+	return timingSummary{
+		Min: sorted[0],
+		Avg: total / time.Duration(len(sorted)),
+		Max: sorted[len(sorted)-1],
+		P95: sorted[rank],
+	}
+}
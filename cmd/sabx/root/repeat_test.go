@@ -0,0 +1,114 @@
+package root
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRepeatInvokesCommandNTimes(t *testing.T) {
+	t.Setenv("SABX_CONFIG_DIR", t.TempDir())
+	t.Setenv("SABX_BASE_URL", "")
+	t.Setenv("SABX_API_KEY", "")
+	t.Cleanup(func() {
+		baseURLFlag, apiKeyFlag, quietFlag = "", "", false
+		repeatFlag, repeatEvery = 0, 0
+	})
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("mode") == "warnings" {
+			calls.Add(1)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"warnings":[]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	args := []string{"--base-url", server.URL, "--api-key", "apikey", "--quiet", "--repeat", "3", "warnings", "list"}
+	if err := ExecuteWithArgs(args); err != nil {
+		t.Fatalf("ExecuteWithArgs returned error: %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("expected 3 warnings calls, got %d", got)
+	}
+
+	// The wrapper is removed afterwards, so a plain run calls once.
+	repeatFlag = 0
+	calls.Store(0)
+	if err := ExecuteWithArgs([]string{"--base-url", server.URL, "--api-key", "apikey", "--quiet", "warnings", "list"}); err != nil {
+		t.Fatalf("ExecuteWithArgs returned error: %v", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("expected a single call without --repeat, got %d", got)
+	}
+
+	err := ExecuteWithArgs([]string{"--base-url", server.URL, "--api-key", "apikey", "--quiet", "--repeat", "2", "warnings", "clear"})
+	if err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Fatalf("expected read-only rejection, got %v", err)
+	}
+}
+
+func TestRunRepeatedStopsOnError(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	boom := errors.New("boom")
+	timings, err := runRepeated(context.Background(), 5, 0, func() error {
+		calls++
+		if calls == 3 {
+			return boom
+		}
+		return nil
+	}, nil)
+	if !errors.Is(err, boom) || calls != 3 || len(timings) != 2 {
+		t.Fatalf("got calls=%d timings=%d err=%v", calls, len(timings), err)
+	}
+}
+
+func TestSummarizeTimings(t *testing.T) {
+	t.Parallel()
+
+	var timings []time.Duration
+	for i := 20; i >= 1; i-- {
+		timings = append(timings, time.Duration(i)*time.Millisecond)
+	}
+	s := summarizeTimings(timings)
+	if s.Min != time.Millisecond || s.Max != 20*time.Millisecond || s.P95 != 19*time.Millisecond || s.Avg != 10500*time.Microsecond {
+		t.Fatalf("unexpected summary %+v", s)
+	}
+	if got := summarizeTimings(nil); got != (timingSummary{}) {
+		t.Fatalf("expected zero summary, got %+v", got)
+	}
+}
+
+func TestRepeatRejectsDoctorFix(t *testing.T) {
+	t.Setenv("SABX_CONFIG_DIR", t.TempDir())
+	t.Setenv("SABX_BASE_URL", "")
+	t.Setenv("SABX_API_KEY", "")
+	t.Cleanup(func() {
+		baseURLFlag, apiKeyFlag, quietFlag = "", "", false
+		repeatFlag, repeatEvery = 0, 0
+	})
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+
+	err := ExecuteWithArgs([]string{"--base-url", server.URL, "--api-key", "apikey", "--quiet", "--repeat", "2", "doctor", "--fix", "--yes"})
+	if err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Fatalf("expected read-only rejection for doctor --fix, got %v", err)
+	}
+	if got := calls.Load(); got != 0 {
+		t.Fatalf("expected no API calls, got %d", got)
+	}
+}
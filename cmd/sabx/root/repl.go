@@ -0,0 +1,199 @@
+package root
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+)
+
+const replPrompt = "sabx> "
+
+func replCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "repl",
+		Short: "Start an interactive sabx shell",
+		Long: "Read sabx commands line by line and run them against a single connection, so config and keyring lookups happen once per session. " +
+			"Type commands without the leading sabx, e.g. `queue list`. Quote arguments as in a shell. " +
+			"`history` lists the lines entered so far (arrow keys recall them on a terminal), and `exit`, `quit` or Ctrl-D leave the shell. " +
+			"Each line accepts --json to switch that command to JSON output.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, err := getApp(cmd)
+			if err != nil {
+				return err
+			}
+			in := cmd.InOrStdin()
+			if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+				return runRepl(cmd.Context(), app, newTerminalReader(f, app.Printer.Out), app.Printer.Err)
+			}
+			return runRepl(cmd.Context(), app, &scannerReader{scanner: bufio.NewScanner(in)}, app.Printer.Err)
+		},
+	}
+	return cmd
+}
+
+// lineReader yields one input line at a time; io.EOF ends the session.
+type lineReader interface {
+	ReadLine() (string, error)
+}
+
+type scannerReader struct {
+	scanner *bufio.Scanner
+}
+
+func (r *scannerReader) ReadLine() (string, error) {
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return r.scanner.Text(), nil
+}
+
+// terminalReader gives line editing and arrow-key history. The terminal is
+// only in raw mode while a line is being typed so command output renders
+// normally.
+type terminalReader struct {
+	fd   int
+	term *term.Terminal
+}
+
+func newTerminalReader(in *os.File, out io.Writer) *terminalReader {
+	rw := struct {
+		io.Reader
+		io.Writer
+	}{in, out}
+	return &terminalReader{fd: int(in.Fd()), term: term.NewTerminal(rw, replPrompt)}
+}
+
+func (r *terminalReader) ReadLine() (string, error) {
+	state, err := term.MakeRaw(r.fd)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = term.Restore(r.fd, state) }()
+	return r.term.ReadLine()
+}
+
+// runRepl executes lines until exit or end of input. Command errors are
+// reported and the session continues.
+func runRepl(ctx context.Context, app *cobraext.App, in lineReader, errOut io.Writer) error {
+	var history []string
+	for {
+		line, err := in.ReadLine()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		history = append(history, line)
+
+		switch line {
+		case "exit", "quit":
+			return nil
+		case "history":
+			for i, entry := range history {
+				fmt.Fprintf(app.Printer.Out, "%4d  %s\n", i+1, entry)
+			}
+			continue
+		}
+
+		args, err := splitReplLine(line)
+		if err == nil {
+			err = runReplLine(ctx, app, args)
+		}
+		if err != nil {
+			fmt.Fprintln(errOut, err)
+		}
+	}
+}
+
+// runReplLine runs args through a fresh command tree and a fork of app, so
+// neither flags nor cached lookups from one line carry over to the next.
+func runReplLine(ctx context.Context, app *cobraext.App, args []string) error {
+	app = app.Fork()
+	var jsonLine bool
+	root := &cobra.Command{
+		Use:           "sabx",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRun: func(cmd *cobra.Command, _ []string) {
+			if !jsonLine {
+				return
+			}
+			printer := *app.Printer
+			printer.JSON = true
+			app.Printer = &printer
+		},
+	}
+	root.PersistentFlags().BoolVar(&jsonLine, "json", false, "Emit JSON output")
+	root.AddCommand(commandSet()...)
+	root.SetArgs(args)
+	root.SetOut(app.Printer.Out)
+	root.SetErr(app.Printer.Err)
+	return root.ExecuteContext(cobraext.WithApp(ctx, app))
+}
+
+// splitReplLine splits a line into arguments, honouring single quotes,
+// double quotes and backslash escapes the way a POSIX shell would for
+// simple cases.
+func splitReplLine(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, errors.New("trailing backslash")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,161 @@
+package root
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+func TestSplitReplLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		line    string
+		want    []string
+		wantErr bool
+	}{
+		{line: "queue list", want: []string{"queue", "list"}},
+		{line: `  queue   list  --search "Big Show" `, want: []string{"queue", "list", "--search", "Big Show"}},
+		{line: `history list --search 'it''s'`, want: []string{"history", "list", "--search", "its"}},
+		{line: `browse /data/My\ Files`, want: []string{"browse", "/data/My Files"}},
+		{line: `config get misc ""`, want: []string{"config", "get", "misc", ""}},
+		{line: `queue list --search "open`, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.line, func(t *testing.T) {
+			t.Parallel()
+			got, err := splitReplLine(tt.line)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("splitReplLine returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("splitReplLine(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplRunsScriptedLines(t *testing.T) {
+	t.Parallel()
+
+	var queueCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("mode") != "queue" {
+			http.Error(w, "unexpected mode", http.StatusBadRequest)
+			return
+		}
+		queueCalls.Add(1)
+		_, _ = w.Write([]byte(`{"queue":{"slots":[{"nzo_id":"SABnzbd_nzo_1","filename":"Repl.Show","status":"Downloading","mb":"100","mbleft":"50"}]}}`))
+	}))
+	t.Cleanup(server.Close)
+	client, err := sabapi.NewClient(server.URL, "secret")
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	var out, errOut bytes.Buffer
+	app := &cobraext.App{Client: client, Printer: &output.Printer{Out: &out, Err: &errOut}}
+	script := strings.Join([]string{
+		"queue list",
+		"# comments and blank lines are skipped",
+		"",
+		"queue list --json",
+		"no-such-command",
+		"history",
+		"exit",
+		"queue list",
+	}, "\n")
+
+	in := &scannerReader{scanner: bufio.NewScanner(strings.NewReader(script))}
+	if err := runRepl(context.Background(), app, in, &errOut); err != nil {
+		t.Fatalf("runRepl returned error: %v", err)
+	}
+
+	if got := queueCalls.Load(); got != 2 {
+		t.Fatalf("expected 2 queue requests (nothing after exit), got %d", got)
+	}
+	if !strings.Contains(out.String(), "Repl.Show") || !strings.Contains(out.String(), `"nzo_id": "SABnzbd_nzo_1"`) {
+		t.Fatalf("expected table and JSON output, got %q", out.String())
+	}
+	if !strings.Contains(out.String(), "   3  no-such-command") {
+		t.Fatalf("expected numbered history, got %q", out.String())
+	}
+	if !strings.Contains(errOut.String(), "unknown command") {
+		t.Fatalf("expected unknown command error on stderr, got %q", errOut.String())
+	}
+	if app.Printer.JSON {
+		t.Fatal("--json on one line must not stick to the shared printer")
+	}
+}
+
+func TestReplLinesDoNotShareLookupCache(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	categories := []string{"tv"}
+	reads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		q := r.URL.Query()
+		switch q.Get("mode") {
+		case "get_config":
+			reads++
+			items := make([]map[string]string, 0, len(categories))
+			for _, name := range categories {
+				items = append(items, map[string]string{"name": name})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"config": map[string]any{"categories": items}})
+		case "set_config":
+			categories = append(categories, q.Get("name"))
+			_, _ = w.Write([]byte(`{"status":true}`))
+		default:
+			http.Error(w, "unexpected mode", http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+	client, err := sabapi.NewClient(server.URL, "secret")
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	var out, errOut bytes.Buffer
+	app := &cobraext.App{Client: client, Printer: &output.Printer{Out: &out, Err: &errOut}}
+	script := "categories list\ncategories add movies --dir /movies\ncategories list\n"
+	in := &scannerReader{scanner: bufio.NewScanner(strings.NewReader(script))}
+	if err := runRepl(context.Background(), app, in, &errOut); err != nil {
+		t.Fatalf("runRepl returned error: %v", err)
+	}
+	if errOut.Len() != 0 {
+		t.Fatalf("unexpected errors: %s", errOut.String())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if reads != 2 {
+		t.Fatalf("expected each list to fetch categories, got %d reads", reads)
+	}
+	parts := strings.SplitN(out.String(), "Category added", 2)
+	if len(parts) != 2 || strings.Contains(parts[0], "movies") || !strings.Contains(parts[1], "movies") {
+		t.Fatalf("expected the second list to show the new category, got %q", out.String())
+	}
+}
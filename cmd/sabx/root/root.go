@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -18,19 +19,48 @@ import (
 )
 
 var (
-	profileFlag string
-	baseURLFlag string
-	apiKeyFlag  string
-	jsonFlag    bool
-	quietFlag   bool
-	envConfig   = viper.New()
+	profileFlag   string
+	baseURLFlag   string
+	apiKeyFlag    string
+	jsonFlag      bool
+	templateFlag  string
+	quietFlag     bool
+	userAgentFlag string
+	outputFlag    string
+	outputFile    string
+	outputCloser  *output.OutputFile
+	repeatFlag    int
+	repeatEvery   time.Duration
+	traceFlag     bool
+	compactJSON   bool
+	maxRespMB     int
+	retriesFlag   int
+	retryBackoff  time.Duration
+	retryBudget   time.Duration
+	envFileFlag   string
+	envConfig     = viper.New()
 )
 
+// templateCommands are the commands that render their items through
+// --template; anything else would silently ignore it, so it is rejected.
+var templateCommands = map[string]bool{
+	"sabx queue list":   true,
+	"sabx history list": true,
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "sabx",
 	Short: jsonShort("Full-fidelity SABnzbd CLI"),
 	Long:  "sabx is a fast, scriptable CLI that mirrors the SABnzbd web UI and API.",
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if envFileFlag != "" {
+			if err := loadEnvFile(envConfig, envFileFlag, true); err != nil {
+				return err
+			}
+		} else if err := loadEnvFile(envConfig, defaultEnvFile, false); err != nil {
+			return err
+		}
+
 		cfg, err := config.Load()
 		if err != nil {
 			return err
@@ -38,7 +68,41 @@ var rootCmd = &cobra.Command{
 
 		printer := output.New()
 		printer.JSON = jsonFlag
+		switch strings.ToLower(strings.TrimSpace(outputFlag)) {
+		case "", "text":
+		case "json":
+			printer.JSON = true
+		case "ndjson":
+			printer.JSON = true
+			printer.NDJSON = true
+			printer.Compact = true
+		case "logfmt":
+			if jsonFlag {
+				return errors.New("--output logfmt cannot be combined with --json")
+			}
+			printer.Logfmt = true
+		default:
+			return fmt.Errorf("unsupported --output %q (expected text, json, ndjson or logfmt)", outputFlag)
+		}
+		if printer.Structured() && templateFlag != "" {
+			return errors.New("--template cannot be combined with --json or --output logfmt")
+		}
+		if templateFlag != "" && !templateCommands[cmd.CommandPath()] {
+			return fmt.Errorf("--template is only supported by queue list and history list, not %q", cmd.CommandPath())
+		}
+
 		printer.Quiet = quietFlag
+		printer.Compact = printer.Compact || compactJSON
+		if err := printer.SetTemplate(templateFlag); err != nil {
+			return err
+		}
+		if outputFile != "" {
+			closer, err := printer.SetOutputFile(outputFile)
+			if err != nil {
+				return err
+			}
+			outputCloser = closer
+		}
 
 		app := &cobraext.App{
 			Config:  cfg,
@@ -53,7 +117,32 @@ var rootCmd = &cobra.Command{
 			app.ProfileName = profileName
 
 			if baseURL != "" && apiKey != "" {
-				client, err := sabapi.NewClient(baseURL, apiKey)
+				userAgent := strings.TrimSpace(userAgentFlag)
+				if userAgent == "" {
+					userAgent = envConfig.GetString("USER_AGENT")
+				}
+				opts := []sabapi.Option{sabapi.WithUserAgent(userAgent)}
+				if maxRespMB > 0 {
+					opts = append(opts, sabapi.WithMaxResponseSize(int64(maxRespMB)<<20))
+				}
+				if retriesFlag < 0 {
+					return fmt.Errorf("invalid --retries %d (must be 0 or more)", retriesFlag)
+				}
+				if retryBudget < 0 {
+					return fmt.Errorf("invalid --timeout-retry-budget %s (must be 0 or more)", retryBudget)
+				}
+				if retriesFlag > 0 {
+					opts = append(opts, sabapi.WithRetry(retriesFlag, retryBackoff), sabapi.WithRetryBudget(retryBudget))
+				}
+				if cfg != nil {
+					if _, prof, err := cfg.ActiveProfile(profileName); err == nil && prof.APIKeyHeader != "" {
+						opts = append(opts, sabapi.WithAPIKeyHeader(prof.APIKeyHeader), sabapi.WithAPIKeyQuery(prof.APIKeyQuery))
+					}
+				}
+				if traceFlag {
+					opts = append(opts, sabapi.WithTrace(printer.Err))
+				}
+				client, err := sabapi.NewClient(baseURL, apiKey, opts...)
 				if err != nil {
 					return err
 				}
@@ -62,6 +151,12 @@ var rootCmd = &cobra.Command{
 			}
 		}
 
+		if repeatFlag != 0 {
+			if err := installRepeat(cmd, printer, repeatFlag, repeatEvery); err != nil {
+				return err
+			}
+		}
+
 		ctx := cobraext.WithApp(cmd.Context(), app)
 		cmd.SetContext(ctx)
 		return nil
@@ -78,40 +173,68 @@ func init() {
 	envConfig.SetEnvPrefix("SABX")
 	envConfig.AutomaticEnv()
 
-	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "Profile name (defaults to config default)")
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "Profile name (defaults to config default); name@url targets an unsaved host using name's stored key")
 	rootCmd.PersistentFlags().StringVar(&baseURLFlag, "base-url", "", "Override SABnzbd base URL")
 	rootCmd.PersistentFlags().StringVar(&apiKeyFlag, "api-key", "", "Override SABnzbd API key")
 	rootCmd.PersistentFlags().BoolVar(&jsonFlag, "json", false, "Emit JSON output")
+	rootCmd.PersistentFlags().BoolVar(&compactJSON, "compact-json", false, "Emit JSON on a single line without indentation")
+	rootCmd.PersistentFlags().StringVar(&outputFlag, "output", "", "Output format: text, json, ndjson (one compact object per line for queue, history and warnings lists) or logfmt")
+	rootCmd.PersistentFlags().StringVarP(&outputFile, "output-file", "o", "", "Write command output to this file instead of stdout (errors still go to stderr)")
+	rootCmd.PersistentFlags().StringVar(&templateFlag, "template", "", "Render each item through a Go text/template (queue list, history list)")
 	rootCmd.PersistentFlags().BoolVar(&quietFlag, "quiet", false, "Only print errors")
+	rootCmd.PersistentFlags().IntVar(&repeatFlag, "repeat", 0, "Run a read-only command N times and report per-run timing with a min/avg/max/p95 summary on stderr")
+	rootCmd.PersistentFlags().DurationVar(&repeatEvery, "repeat-interval", 0, "Pause between --repeat runs")
+	rootCmd.PersistentFlags().BoolVar(&traceFlag, "trace", false, "Dump every HTTP request and response to stderr (API key redacted) for debugging proxy and TLS issues")
+	rootCmd.PersistentFlags().StringVar(&envFileFlag, "env-file", "", "Load SABX_* variables from this file (default ./.sabx.env when present); real environment variables take precedence")
+	rootCmd.PersistentFlags().IntVar(&maxRespMB, "max-response-size", int(sabapi.DefaultMaxResponseSize>>20), "Fail instead of reading API responses larger than this many MB")
+	rootCmd.PersistentFlags().IntVar(&retriesFlag, "retries", 0, "Retry read-only requests that fail with a network error, timeout or 5xx response this many times")
+	rootCmd.PersistentFlags().DurationVar(&retryBackoff, "retry-backoff", 500*time.Millisecond, "Wait before the first retry; doubles after each one")
+	rootCmd.PersistentFlags().DurationVar(&retryBudget, "timeout-retry-budget", 0, "Stop retrying once a request has spent this long across all attempts and return the last error (0 for no limit)")
+	rootCmd.PersistentFlags().StringVar(&userAgentFlag, "user-agent", "", "Override the User-Agent header (default sabx/<version>, env SABX_USER_AGENT)")
 
-	rootCmd.AddCommand(loginCmd())
-	rootCmd.AddCommand(whoamiCmd())
-	rootCmd.AddCommand(statusCmd())
-	rootCmd.AddCommand(warningsCmd())
-	rootCmd.AddCommand(logsCmd())
-	rootCmd.AddCommand(queueCmd())
-	rootCmd.AddCommand(historyCmd())
-	rootCmd.AddCommand(configCmd())
-	rootCmd.AddCommand(scriptsCmd())
-	rootCmd.AddCommand(rssCmd())
-	rootCmd.AddCommand(categoriesCmd())
-	rootCmd.AddCommand(scheduleCmd())
-	rootCmd.AddCommand(postprocessCmd())
-	rootCmd.AddCommand(browseCmd())
-	rootCmd.AddCommand(watchedCmd())
-	rootCmd.AddCommand(quotaCmd())
-	rootCmd.AddCommand(notificationsCmd())
-	rootCmd.AddCommand(debugCmd())
-	rootCmd.AddCommand(translateCmd())
-	rootCmd.AddCommand(serverCmd())
-	rootCmd.AddCommand(speedCmd())
-	rootCmd.AddCommand(dumpCmd())
-	rootCmd.AddCommand(topCmd())
-	rootCmd.AddCommand(extensionsCmd())
-	rootCmd.AddCommand(completionCmd())
-	rootCmd.AddCommand(doctorCmd())
-	rootCmd.AddCommand(versionCmd())
-	rootCmd.AddCommand(logoutCmd())
+	for _, sub := range commandSet() {
+		rootCmd.AddCommand(sub)
+	}
+	rootCmd.AddCommand(replCmd())
+}
+
+// commandSet builds a fresh instance of every top-level command. The repl
+// uses it to get flag state that does not leak between lines.
+func commandSet() []*cobra.Command {
+	return []*cobra.Command{
+		initCmd(),
+		loginCmd(),
+		whoamiCmd(),
+		statusCmd(),
+		warningsCmd(),
+		logsCmd(),
+		queueCmd(),
+		historyCmd(),
+		findCmd(),
+		configCmd(),
+		scriptsCmd(),
+		rssCmd(),
+		categoriesCmd(),
+		scheduleCmd(),
+		postprocessCmd(),
+		browseCmd(),
+		watchedCmd(),
+		quotaCmd(),
+		notificationsCmd(),
+		debugCmd(),
+		translateCmd(),
+		serverCmd(),
+		speedCmd(),
+		dumpCmd(),
+		topCmd(),
+		extensionsCmd(),
+		completionCmd(),
+		doctorCmd(),
+		readyCmd(),
+		schemaCmd(),
+		versionCmd(),
+		logoutCmd(),
+	}
 }
 
 // Execute runs the CLI.
@@ -123,6 +246,14 @@ func Execute() error {
 func ExecuteWithArgs(args []string) error {
 	rootCmd.SetArgs(args)
 	_, err := rootCmd.ExecuteC()
+	if outputCloser != nil {
+		if err != nil {
+			_ = outputCloser.Discard()
+		} else if closeErr := outputCloser.Close(); closeErr != nil {
+			err = closeErr
+		}
+		outputCloser = nil
+	}
 	if err == nil {
 		return nil
 	}
@@ -158,6 +289,17 @@ func resolveConnection(cfg *config.Config) (profile, baseURL, apiKey string, err
 	baseURL = strings.TrimSpace(baseURLFlag)
 	apiKey = strings.TrimSpace(apiKeyFlag)
 
+	profile, shorthandURL, err := parseProfileShorthand(selectedProfile())
+	if err != nil {
+		return "", "", "", err
+	}
+	if shorthandURL != "" {
+		if baseURL != "" {
+			return "", "", "", errors.New("--profile name@url cannot be combined with --base-url")
+		}
+		baseURL = shorthandURL
+	}
+
 	if env := strings.TrimSpace(envConfig.GetString("BASE_URL")); baseURL == "" && env != "" {
 		baseURL = env
 	}
@@ -165,8 +307,6 @@ func resolveConnection(cfg *config.Config) (profile, baseURL, apiKey string, err
 		apiKey = env
 	}
 
-	profile = strings.TrimSpace(profileFlag)
-
 	var profileCfg config.Profile
 	if cfg != nil {
 		resolvedProfile, cfgProfile, cfgErr := cfg.ActiveProfile(profile)
@@ -176,11 +316,12 @@ func resolveConnection(cfg *config.Config) (profile, baseURL, apiKey string, err
 			}
 			profile = resolvedProfile
 			profileCfg = cfgProfile
-		} else if profile != "" {
+		} else if profile != "" && shorthandURL == "" {
 			// Explicit profile requested but not found
 			return "", "", "", cfgErr
 		}
-		// If profile is empty and we have flags/env vars, continue without profile
+		// If profile is empty and we have flags/env vars, continue without profile.
+		// A name@url shorthand may name a profile that was never saved.
 	}
 
 	if baseURL == "" {
@@ -195,11 +336,15 @@ func resolveConnection(cfg *config.Config) (profile, baseURL, apiKey string, err
 		}
 
 		key, keyErr := auth.LoadAPIKey(profileOrDefault(profile), baseURL, storeOpts...)
+		if keyErr != nil && shorthandURL != "" && profileCfg.BaseURL != "" && profileCfg.BaseURL != baseURL {
+			// name@url borrows the key saved for the profile's own host.
+			key, keyErr = auth.LoadAPIKey(profileOrDefault(profile), profileCfg.BaseURL, storeOpts...)
+		}
 		if keyErr != nil {
 			if profileCfg.APIKey != "" {
 				apiKey = profileCfg.APIKey
 			} else {
-				return profile, baseURL, apiKey, fmt.Errorf("api key not found for profile %q (%v)", profileOrDefault(profile), keyErr)
+				return profile, baseURL, apiKey, apiKeyLookupError(profileOrDefault(profile), keyErr)
 			}
 		} else {
 			apiKey = key
@@ -209,6 +354,49 @@ func resolveConnection(cfg *config.Config) (profile, baseURL, apiKey string, err
 	return profileOrDefault(profile), baseURL, apiKey, nil
 }
 
+// apiKeyLookupError explains why no stored key could be loaded, with a hint
+// tailored to a locked keyring versus a missing keyring backend.
+func apiKeyLookupError(profile string, err error) error {
+	switch {
+	case auth.IsKeyringLockedError(err):
+		// The wrapped error already carries the unlock guidance.
+		return fmt.Errorf("api key for profile %q could not be read: %w", profile, err)
+	case auth.IsNoKeyringError(err):
+		return fmt.Errorf("api key for profile %q unavailable: no keyring backend on this host (%w); rerun 'sabx login --allow-insecure-store' or set SABX_API_KEY", profile, err)
+	default:
+		return fmt.Errorf("api key not found for profile %q (%w)", profile, err)
+	}
+}
+
+// parseProfileShorthand splits a --profile value of the form name@url into the
+// profile name used for the key lookup and a normalized base URL. Plain
+// profile names are returned unchanged with an empty URL. Nothing is saved.
+func parseProfileShorthand(raw string) (name, baseURL string, err error) {
+	raw = strings.TrimSpace(raw)
+	idx := strings.Index(raw, "@")
+	if idx < 0 {
+		return raw, "", nil
+	}
+	name = strings.TrimSpace(raw[:idx])
+	if name == "" {
+		return "", "", fmt.Errorf("invalid --profile %q: expected name@url", raw)
+	}
+	baseURL, err = sabapi.NormalizeBaseURL(raw[idx+1:])
+	if err != nil {
+		return "", "", fmt.Errorf("invalid --profile %q: %w", raw, err)
+	}
+	return name, baseURL, nil
+}
+
+// selectedProfile returns the profile named by --profile, then SABX_PROFILE.
+// Empty means the config default applies.
+func selectedProfile() string {
+	if profile := strings.TrimSpace(profileFlag); profile != "" {
+		return profile
+	}
+	return strings.TrimSpace(envConfig.GetString("PROFILE"))
+}
+
 func profileOrDefault(profile string) string {
 	if strings.TrimSpace(profile) == "" {
 		return "default"
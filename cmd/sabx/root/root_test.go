@@ -0,0 +1,312 @@
+package root
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/99designs/keyring"
+	"github.com/spf13/cobra"
+
+	"github.com/avivsinai/sabx/internal/auth"
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/config"
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+func TestParseProfileShorthand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw      string
+		wantName string
+		wantURL  string
+		wantErr  bool
+	}{
+		{raw: "home", wantName: "home"},
+		{raw: "", wantName: ""},
+		{raw: "home@https://sab.example.com:8080/api", wantName: "home", wantURL: "https://sab.example.com:8080"},
+		{raw: "lab@nas:8080", wantName: "lab", wantURL: "http://nas:8080"},
+		{raw: "@https://sab.example.com", wantErr: true},
+		{raw: "home@ftp://nas", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.raw, func(t *testing.T) {
+			t.Parallel()
+			name, baseURL, err := parseProfileShorthand(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error for %q", tt.raw)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseProfileShorthand(%q) returned error: %v", tt.raw, err)
+			}
+			if name != tt.wantName || baseURL != tt.wantURL {
+				t.Fatalf("parseProfileShorthand(%q) = %q, %q; want %q, %q", tt.raw, name, baseURL, tt.wantName, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestResolveConnectionProfileShorthand(t *testing.T) {
+	t.Setenv("SABX_BASE_URL", "")
+	t.Setenv("SABX_API_KEY", "")
+	oldProfile, oldBase, oldKey := profileFlag, baseURLFlag, apiKeyFlag
+	t.Cleanup(func() { profileFlag, baseURLFlag, apiKeyFlag = oldProfile, oldBase, oldKey })
+
+	cfg := &config.Config{
+		DefaultProfile: "home",
+		Profiles: map[string]config.Profile{
+			"home": {BaseURL: "http://nas:8080", APIKey: "stored-key"},
+		},
+	}
+
+	profileFlag, baseURLFlag, apiKeyFlag = "home@https://remote.example.com/sabnzbd/api", "", ""
+	profile, baseURL, apiKey, err := resolveConnection(cfg)
+	if err != nil {
+		t.Fatalf("resolveConnection returned error: %v", err)
+	}
+	if profile != "home" || baseURL != "https://remote.example.com/sabnzbd" || apiKey != "stored-key" {
+		t.Fatalf("got profile=%q baseURL=%q apiKey=%q", profile, baseURL, apiKey)
+	}
+
+	profileFlag, apiKeyFlag = "adhoc@http://10.0.0.5:8080", "flag-key"
+	profile, baseURL, apiKey, err = resolveConnection(cfg)
+	if err != nil {
+		t.Fatalf("unsaved shorthand profile should resolve, got %v", err)
+	}
+	if profile != "adhoc" || baseURL != "http://10.0.0.5:8080" || apiKey != "flag-key" {
+		t.Fatalf("got profile=%q baseURL=%q apiKey=%q", profile, baseURL, apiKey)
+	}
+
+	profileFlag, baseURLFlag = "home@http://nas:8080", "http://other:8080"
+	if _, _, _, err := resolveConnection(cfg); err == nil {
+		t.Fatal("expected conflict error for shorthand plus --base-url")
+	}
+}
+
+func TestResolveConnectionProfilePrecedence(t *testing.T) {
+	t.Setenv("SABX_BASE_URL", "")
+	t.Setenv("SABX_API_KEY", "")
+	oldProfile, oldBase, oldKey := profileFlag, baseURLFlag, apiKeyFlag
+	t.Cleanup(func() { profileFlag, baseURLFlag, apiKeyFlag = oldProfile, oldBase, oldKey })
+	baseURLFlag, apiKeyFlag = "", ""
+
+	cfg := &config.Config{
+		DefaultProfile: "home",
+		Profiles: map[string]config.Profile{
+			"home": {BaseURL: "http://home:8080", APIKey: "home-key"},
+			"work": {BaseURL: "http://work:8080", APIKey: "work-key"},
+			"ci":   {BaseURL: "http://ci:8080", APIKey: "ci-key"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		flag     string
+		env      string
+		wantName string
+		wantURL  string
+	}{
+		{name: "flag beats env", flag: "work", env: "ci", wantName: "work", wantURL: "http://work:8080"},
+		{name: "env beats config default", env: "ci", wantName: "ci", wantURL: "http://ci:8080"},
+		{name: "config default", wantName: "home", wantURL: "http://home:8080"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SABX_PROFILE", tt.env)
+			profileFlag = tt.flag
+			profile, baseURL, _, err := resolveConnection(cfg)
+			if err != nil {
+				t.Fatalf("resolveConnection returned error: %v", err)
+			}
+			if profile != tt.wantName || baseURL != tt.wantURL {
+				t.Fatalf("got profile=%q baseURL=%q, want %q %q", profile, baseURL, tt.wantName, tt.wantURL)
+			}
+		})
+	}
+
+	t.Setenv("SABX_PROFILE", "missing")
+	profileFlag = ""
+	if _, _, _, err := resolveConnection(cfg); err == nil {
+		t.Fatal("expected an unknown SABX_PROFILE to fail like an unknown --profile")
+	}
+}
+
+func TestAPIKeyLookupErrorHints(t *testing.T) {
+	t.Parallel()
+
+	locked := fmt.Errorf("%w: prompt dismissed (unlock your keychain or set SABX_KEYRING_PASSPHRASE)", auth.ErrKeyringLocked)
+	err := apiKeyLookupError("home", locked)
+	if !errors.Is(err, auth.ErrKeyringLocked) || !strings.Contains(err.Error(), "unlock your keychain") {
+		t.Fatalf("expected locked hint, got %v", err)
+	}
+	if strings.Contains(err.Error(), "--allow-insecure-store") {
+		t.Fatalf("locked keyring must not suggest the file fallback: %v", err)
+	}
+
+	missing := fmt.Errorf("open keyring: %w", keyring.ErrNoAvailImpl)
+	if err := apiKeyLookupError("home", missing); !strings.Contains(err.Error(), "--allow-insecure-store") {
+		t.Fatalf("expected fallback hint for missing backend, got %v", err)
+	}
+
+	if err := apiKeyLookupError("home", auth.ErrNotFound); !strings.Contains(err.Error(), `api key not found for profile "home"`) {
+		t.Fatalf("unexpected not-found error %v", err)
+	}
+}
+
+func TestNDJSONOutputAcrossListCommands(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("mode") {
+		case "queue":
+			_, _ = w.Write([]byte(`{"queue":{"slots":[{"nzo_id":"SABnzbd_nzo_1","filename":"One"},{"nzo_id":"SABnzbd_nzo_2","filename":"Two"}]}}`))
+		case "history":
+			_, _ = w.Write([]byte(`{"history":{"slots":[{"nzo_id":"SABnzbd_nzo_3","name":"Three","status":"Completed"},{"nzo_id":"SABnzbd_nzo_4","name":"Four","status":"Failed"}]}}`))
+		case "warnings":
+			_, _ = w.Write([]byte(`{"warnings":[{"type":"WARNING","text":"disk low","time":1700000000},{"type":"ERROR","text":"server down","time":1700000001}]}`))
+		default:
+			http.Error(w, "unexpected mode", http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+	client, err := sabapi.NewClient(server.URL, "secret")
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		cmd  func() *cobra.Command
+		key  string
+	}{
+		{name: "queue list", cmd: queueListCmd, key: "nzo_id"},
+		{name: "history list", cmd: historyListCmd, key: "nzo_id"},
+		{name: "warnings list", cmd: warningsListCmd, key: "text"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			cmd := tt.cmd()
+			cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{
+				Client:  client,
+				Printer: &output.Printer{JSON: true, NDJSON: true, Compact: true, Out: &out},
+			}))
+			cmd.SetArgs(nil)
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("Execute returned error: %v", err)
+			}
+			lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("expected 2 lines, got %d: %q", len(lines), out.String())
+			}
+			for _, line := range lines {
+				var item map[string]any
+				if err := json.Unmarshal([]byte(line), &item); err != nil {
+					t.Fatalf("line %q is not a JSON object: %v", line, err)
+				}
+				if _, ok := item[tt.key]; !ok {
+					t.Fatalf("line %q lacks %q", line, tt.key)
+				}
+			}
+		})
+	}
+}
+
+func TestOutputFileUntouchedWhenCommandFails(t *testing.T) {
+	t.Setenv("SABX_CONFIG_DIR", t.TempDir())
+	t.Setenv("SABX_BASE_URL", "")
+	t.Setenv("SABX_API_KEY", "")
+	t.Cleanup(func() {
+		baseURLFlag, apiKeyFlag, outputFile, jsonFlag = "", "", "", false
+	})
+
+	path := filepath.Join(t.TempDir(), "out.json")
+	if err := os.WriteFile(path, []byte("old\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExecuteWithArgs([]string{"-o", path, "status"}); err == nil {
+		t.Fatal("expected status without a connection to fail")
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "old\n" {
+		t.Fatalf("failing command changed the output file: %q (%v)", data, err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"warnings":[{"type":"WARNING","text":"disk low","time":1700000000}]}`))
+	}))
+	t.Cleanup(server.Close)
+	if err := ExecuteWithArgs([]string{"--base-url", server.URL, "--api-key", "apikey", "--json", "-o", path, "warnings", "list"}); err != nil {
+		t.Fatalf("warnings list returned error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), "disk low") {
+		t.Fatalf("expected the output file to be replaced, got %q (%v)", data, err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Fatalf("expected no staged files left behind, got %v", entries)
+	}
+}
+
+func TestNDJSONOutputIsSingleLineForObjects(t *testing.T) {
+	t.Setenv("SABX_CONFIG_DIR", t.TempDir())
+	t.Cleanup(func() {
+		outputFlag, outputFile = "", ""
+	})
+
+	path := filepath.Join(t.TempDir(), "schema.ndjson")
+	if err := ExecuteWithArgs([]string{"--output", "ndjson", "-o", path, "schema", "status"}); err != nil {
+		t.Fatalf("schema status returned error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 1 || !json.Valid([]byte(lines[0])) {
+		t.Fatalf("expected a single JSON line, got %q", data)
+	}
+}
+
+func TestTemplateRejectedOutsideListCommands(t *testing.T) {
+	t.Setenv("SABX_CONFIG_DIR", t.TempDir())
+	t.Setenv("SABX_BASE_URL", "")
+	t.Setenv("SABX_API_KEY", "")
+	t.Cleanup(func() {
+		baseURLFlag, apiKeyFlag, templateFlag, outputFile = "", "", "", ""
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"queue":{"slots":[{"nzo_id":"SABnzbd_nzo_1","filename":"One"}]}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	err := ExecuteWithArgs([]string{"--base-url", server.URL, "--api-key", "apikey", "--template", "{{.NZOID}}", "warnings", "list"})
+	if err == nil || !strings.Contains(err.Error(), "--template is only supported") {
+		t.Fatalf("expected --template rejection for warnings list, got %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "queue.txt")
+	if err := ExecuteWithArgs([]string{"--base-url", server.URL, "--api-key", "apikey", "--template", "{{.NZOID}}", "-o", path, "queue", "list"}); err != nil {
+		t.Fatalf("queue list --template returned error: %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || strings.TrimSpace(string(data)) != "SABnzbd_nzo_1" {
+		t.Fatalf("expected templated queue output, got %q (%v)", data, err)
+	}
+}
@@ -22,6 +22,8 @@ func rssCmd() *cobra.Command {
 	cmd.AddCommand(rssAddCmd())
 	cmd.AddCommand(rssSetCmd())
 	cmd.AddCommand(rssDeleteCmd())
+	cmd.AddCommand(rssToggleCmd(true))
+	cmd.AddCommand(rssToggleCmd(false))
 	cmd.AddCommand(rssRunCmd())
 	return cmd
 }
@@ -171,6 +173,61 @@ func rssDeleteCmd() *cobra.Command {
 	return cmd
 }
 
+// rssToggleCmd builds `rss enable` or `rss disable`, a shortcut for
+// `rss set <name> --set enable=1|0` that first checks the feed exists.
+func rssToggleCmd(enable bool) *cobra.Command {
+	verb, short := "disable", "Stop fetching an RSS feed"
+	if enable {
+		verb, short = "enable", "Resume fetching an RSS feed"
+	}
+	cmd := &cobra.Command{
+		Use:   verb + " <name>",
+		Short: jsonShort(short),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, err := getApp(cmd)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := timeoutContext(cmd.Context())
+			defer cancel()
+
+			payload, err := app.Client.RSSList(ctx)
+			if err != nil {
+				return err
+			}
+			feed, ok := findRSSFeed(parseRSSFeeds(payload), args[0])
+			if !ok {
+				return fmt.Errorf("unknown RSS feed %q; see 'sabx rss list'", args[0])
+			}
+			if err := applyRSSProperties(ctx, app, feed.Name, map[string]string{"enable": boolToFlag(enable)}); err != nil {
+				return err
+			}
+			if app.Printer.JSON {
+				return app.Printer.Print(map[string]any{"name": feed.Name, "enabled": enable})
+			}
+			return app.Printer.Print(fmt.Sprintf("RSS feed %s %sd", feed.Name, verb))
+		},
+	}
+	return cmd
+}
+
+// findRSSFeed looks up a feed by name, preferring an exact match over a
+// case-insensitive one.
+func findRSSFeed(feeds []rssFeed, name string) (rssFeed, bool) {
+	for _, feed := range feeds {
+		if feed.Name == name {
+			return feed, true
+		}
+	}
+	for _, feed := range feeds {
+		if strings.EqualFold(feed.Name, name) {
+			return feed, true
+		}
+	}
+	return rssFeed{}, false
+}
+
 func rssRunCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "run [name]",
@@ -0,0 +1,93 @@
+package root
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+func TestRSSToggleSetsEnable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		enable     bool
+		feed       string
+		wantValue  string
+		wantOutput string
+		wantErr    string
+	}{
+		{name: "enable", enable: true, feed: "Movies", wantValue: "1", wantOutput: "RSS feed Movies enabled"},
+		{name: "disable", feed: "Movies", wantValue: "0", wantOutput: "RSS feed Movies disabled"},
+		{name: "case-insensitive name", feed: "movies", wantValue: "0", wantOutput: "RSS feed Movies disabled"},
+		{name: "unknown feed", enable: true, feed: "Nope", wantErr: `unknown RSS feed "Nope"`},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var mu sync.Mutex
+			var sets []url.Values
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				q := r.URL.Query()
+				switch q.Get("mode") {
+				case "get_config":
+					_, _ = w.Write([]byte(`{"config":{"rss":[{"name":"Movies","uri":"https://example.com/rss","enable":1},{"name":"TV","enable":0}]}}`))
+				case "set_config":
+					mu.Lock()
+					sets = append(sets, q)
+					mu.Unlock()
+					_, _ = w.Write([]byte(`{"status":true}`))
+				default:
+					t.Errorf("unexpected mode %q", q.Get("mode"))
+				}
+			}))
+			t.Cleanup(server.Close)
+			client, err := sabapi.NewClient(server.URL, "apikey")
+			if err != nil {
+				t.Fatalf("NewClient returned error: %v", err)
+			}
+
+			var out bytes.Buffer
+			cmd := rssToggleCmd(tt.enable)
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{Client: client, Printer: &output.Printer{Out: &out}}))
+			cmd.SetArgs([]string{tt.feed})
+			err = cmd.Execute()
+
+			mu.Lock()
+			defer mu.Unlock()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				if len(sets) != 0 {
+					t.Fatalf("expected no set_config for an unknown feed, got %v", sets)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("rss toggle returned error: %v", err)
+			}
+			if len(sets) != 1 {
+				t.Fatalf("expected one set_config call, got %v", sets)
+			}
+			if got := sets[0]; got.Get("section") != "rss" || got.Get("name") != "Movies" || got.Get("enable") != tt.wantValue {
+				t.Fatalf("unexpected set_config params %v, want enable=%s", got, tt.wantValue)
+			}
+			if strings.TrimSpace(out.String()) != tt.wantOutput {
+				t.Fatalf("expected %q, got %q", tt.wantOutput, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package root
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// iniAutoDetect is the --from-ini value that searches the usual SABnzbd
+// config locations in order instead of reading a given path.
+const iniAutoDetect = "auto"
+
+// sabnzbdINI holds the connection details login needs from sabnzbd.ini.
+type sabnzbdINI struct {
+	APIKey  string
+	BaseURL string
+}
+
+// loadSABnzbdINI reads path, or the first existing default location when
+// path is iniAutoDetect.
+func loadSABnzbdINI(path string) (sabnzbdINI, string, error) {
+	if path == iniAutoDetect {
+		found, err := findSABnzbdINI(sabnzbdINICandidates())
+		if err != nil {
+			return sabnzbdINI{}, "", err
+		}
+		path = found
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return sabnzbdINI{}, "", fmt.Errorf("read sabnzbd.ini: %w", err)
+	}
+	defer f.Close()
+	ini, err := parseSABnzbdINI(f)
+	if err != nil {
+		return sabnzbdINI{}, "", fmt.Errorf("%s: %w", path, err)
+	}
+	return ini, path, nil
+}
+
+// sabnzbdINICandidates lists where SABnzbd keeps its config on this OS,
+// followed by the /config mount used by the common container images.
+func sabnzbdINICandidates() []string {
+	var candidates []string
+	home, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "windows":
+		if local := os.Getenv("LOCALAPPDATA"); local != "" {
+			candidates = append(candidates, filepath.Join(local, "sabnzbd", "sabnzbd.ini"))
+		}
+	case "darwin":
+		if home != "" {
+			candidates = append(candidates, filepath.Join(home, "Library", "Application Support", "SABnzbd", "sabnzbd.ini"))
+		}
+	}
+	if home != "" {
+		candidates = append(candidates, filepath.Join(home, ".sabnzbd", "sabnzbd.ini"))
+	}
+	return append(candidates, "/config/sabnzbd.ini")
+}
+
+func findSABnzbdINI(candidates []string) (string, error) {
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no sabnzbd.ini found (looked in %s); pass its path to --from-ini", strings.Join(candidates, ", "))
+}
+
+// parseSABnzbdINI extracts api_key and the listening address from the
+// [misc] section. Wildcard listen addresses map to localhost, and
+// enable_https switches to https_port when one is set.
+func parseSABnzbdINI(r io.Reader) (sabnzbdINI, error) {
+	misc := map[string]string{}
+	section := ""
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			// Subsections ([[server]]) belong to their parent, never to misc.
+			section = strings.Trim(line, "[] ")
+			if strings.HasPrefix(line, "[[") {
+				section = "[" + section
+			}
+			continue
+		}
+		if section != "misc" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if n := len(value); n >= 2 && (value[0] == '"' || value[0] == '\'') && value[n-1] == value[0] {
+			value = value[1 : n-1]
+		}
+		misc[strings.TrimSpace(key)] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return sabnzbdINI{}, err
+	}
+
+	apiKey := misc["api_key"]
+	if apiKey == "" {
+		return sabnzbdINI{}, errors.New("no api_key in [misc]")
+	}
+
+	host := misc["host"]
+	switch host {
+	case "", "0.0.0.0", "::", "[::]":
+		host = "localhost"
+	}
+	scheme := "http"
+	port := misc["port"]
+	if misc["enable_https"] == "1" {
+		scheme = "https"
+		if httpsPort := misc["https_port"]; httpsPort != "" {
+			port = httpsPort
+		}
+	}
+	if port == "" {
+		return sabnzbdINI{}, errors.New("no port in [misc]")
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return sabnzbdINI{}, fmt.Errorf("invalid port %q in [misc]", port)
+	}
+
+	baseURL := scheme + "://" + net.JoinHostPort(host, port)
+	if urlBase := strings.Trim(misc["url_base"], "/"); urlBase != "" {
+		baseURL += "/" + urlBase
+	}
+	return sabnzbdINI{APIKey: apiKey, BaseURL: baseURL}, nil
+}
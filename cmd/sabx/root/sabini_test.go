@@ -0,0 +1,115 @@
+package root
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/avivsinai/sabx/internal/config"
+)
+
+// harnessINI mirrors the sabnzbd.ini the e2e harness writes.
+const harnessINI = `__version__ = 19
+__encoding__ = utf-8
+[misc]
+api_key = sabx-e2e-apikey
+enable_https = 0
+host = 0.0.0.0
+port = 8080
+username =
+password =
+wait_for_unpack = 0
+[servers]
+[[news.example.com]]
+host = news.example.com
+port = 563
+`
+
+func TestParseSABnzbdINI(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		ini     string
+		want    sabnzbdINI
+		wantErr string
+	}{
+		{name: "harness template", ini: harnessINI, want: sabnzbdINI{APIKey: "sabx-e2e-apikey", BaseURL: "http://localhost:8080"}},
+		{
+			name: "https with url base",
+			ini:  "[misc]\napi_key = \"abc123\"\nhost = 192.168.1.5\nport = 8080\nhttps_port = 9090\nenable_https = 1\nurl_base = /sabnzbd\n",
+			want: sabnzbdINI{APIKey: "abc123", BaseURL: "https://192.168.1.5:9090/sabnzbd"},
+		},
+		{name: "ipv6 host", ini: "[misc]\napi_key = k\nhost = ::1\nport = 8080\n", want: sabnzbdINI{APIKey: "k", BaseURL: "http://[::1]:8080"}},
+		{name: "missing api key", ini: "[misc]\nhost = localhost\nport = 8080\n", wantErr: "no api_key"},
+		{name: "api key outside misc", ini: "[servers]\napi_key = nope\n[misc]\nport = 8080\n", wantErr: "no api_key"},
+		{name: "bad port", ini: "[misc]\napi_key = k\nport = http\n", wantErr: "invalid port"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseSABnzbdINI(strings.NewReader(tt.ini))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSABnzbdINI returned error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("parseSABnzbdINI = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindSABnzbdINIUsesFirstExisting(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	present := filepath.Join(dir, "present.ini")
+	if err := os.WriteFile(present, []byte(harnessINI), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := findSABnzbdINI([]string{filepath.Join(dir, "missing.ini"), dir, present})
+	if err != nil || got != present {
+		t.Fatalf("findSABnzbdINI = %q, %v; want %q", got, err, present)
+	}
+	if _, err := findSABnzbdINI([]string{filepath.Join(dir, "missing.ini")}); err == nil {
+		t.Fatal("expected error when no candidate exists")
+	}
+}
+
+func TestLoginFromINI(t *testing.T) {
+	t.Setenv("SABX_CONFIG_DIR", t.TempDir())
+
+	path := filepath.Join(t.TempDir(), "sabnzbd.ini")
+	if err := os.WriteFile(path, []byte(harnessINI), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := loginCmd()
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs([]string{"--from-ini", path, "--profile", "local", "--store-in-config"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("login returned error: %v", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+	_, profile, err := cfg.ActiveProfile("local")
+	if err != nil {
+		t.Fatalf("ActiveProfile: %v", err)
+	}
+	if profile.BaseURL != "http://localhost:8080" || profile.APIKey != "sabx-e2e-apikey" {
+		t.Fatalf("unexpected profile %+v", profile)
+	}
+}
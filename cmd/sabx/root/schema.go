@@ -0,0 +1,88 @@
+package root
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avivsinai/sabx/internal/sabapi"
+	"github.com/avivsinai/sabx/internal/schema"
+)
+
+// schemaPayloads maps command paths to a zero value of their JSON payload.
+var schemaPayloads = map[string]any{
+	"queue list":   queueListPayload{},
+	"status":       statusPayload{},
+	"history list": []sabapi.HistorySlot{},
+}
+
+func schemaCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:         "schema <command>",
+		Short:       jsonShort("Describe the JSON fields a command emits"),
+		Long:        appendJSONLong("Print the field names and types of a command's --json payload. Supported commands: " + strings.Join(schemaCommandNames(), ", ") + "."),
+		Args:        cobra.MinimumNArgs(1),
+		Annotations: map[string]string{"skipPersistent": "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, err := getApp(cmd)
+			if err != nil {
+				return err
+			}
+
+			name := strings.Join(args, " ")
+			fields, err := commandSchema(name)
+			if err != nil {
+				return err
+			}
+
+			if app.Printer.JSON {
+				return app.Printer.Print(map[string]any{
+					"command": name,
+					"fields":  fields,
+				})
+			}
+
+			rows := make([][]string, 0, len(fields))
+			appendSchemaRows(&rows, "", fields)
+			return app.Printer.Table([]string{"Field", "Type", "Optional"}, rows)
+		},
+	}
+	return cmd
+}
+
+func commandSchema(name string) ([]schema.Field, error) {
+	payload, ok := schemaPayloads[strings.Join(strings.Fields(name), " ")]
+	if !ok {
+		return nil, fmt.Errorf("no schema for %q (supported: %s)", name, strings.Join(schemaCommandNames(), ", "))
+	}
+	return schema.Describe(payload), nil
+}
+
+func schemaCommandNames() []string {
+	names := make([]string, 0, len(schemaPayloads))
+	for name := range schemaPayloads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func appendSchemaRows(rows *[][]string, prefix string, fields []schema.Field) {
+	for _, field := range fields {
+		path := prefix + field.Name
+		optional := ""
+		if field.Optional {
+			optional = "yes"
+		}
+		*rows = append(*rows, []string{path, field.Type, optional})
+		if len(field.Fields) > 0 {
+			child := path + "."
+			if strings.HasPrefix(field.Type, "array<") {
+				child = path + "[]."
+			}
+			appendSchemaRows(rows, child, field.Fields)
+		}
+	}
+}
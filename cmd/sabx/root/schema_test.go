@@ -0,0 +1,93 @@
+package root
+
+import (
+	"testing"
+
+	"github.com/avivsinai/sabx/internal/schema"
+)
+
+func TestCommandSchemaListsExpectedFields(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{command: "queue list", want: []string{"slots", "paused", "speed_kbps", "limit_kbps", "count", "size_mb", "mbleft", "timeleft"}},
+		{command: "status", want: []string{"profile", "base_url", "queue_slots", "status", "full_status", "servers"}},
+		{command: "history list", want: []string{"nzo_id", "name", "status", "category", "stage_log"}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.command, func(t *testing.T) {
+			t.Parallel()
+			fields, err := commandSchema(tt.command)
+			if err != nil {
+				t.Fatalf("commandSchema returned error: %v", err)
+			}
+			names := map[string]schema.Field{}
+			for _, field := range fields {
+				names[field.Name] = field
+			}
+			for _, name := range tt.want {
+				if _, ok := names[name]; !ok {
+					t.Fatalf("schema for %q missing field %q", tt.command, name)
+				}
+			}
+		})
+	}
+}
+
+func TestCommandSchemaNestedSlotFields(t *testing.T) {
+	t.Parallel()
+
+	fields, err := commandSchema("queue  list")
+	if err != nil {
+		t.Fatalf("commandSchema returned error: %v", err)
+	}
+	for _, field := range fields {
+		if field.Name != "slots" {
+			continue
+		}
+		if field.Type != "array<object>" {
+			t.Fatalf("slots type = %q", field.Type)
+		}
+		for _, nested := range field.Fields {
+			if nested.Name == "nzo_id" {
+				return
+			}
+		}
+		t.Fatalf("slots missing nzo_id: %+v", field.Fields)
+	}
+	t.Fatal("slots field not found")
+}
+
+func TestCommandSchemaUnknown(t *testing.T) {
+	t.Parallel()
+
+	if _, err := commandSchema("rss list"); err == nil {
+		t.Fatal("expected error for unsupported command")
+	}
+}
+
+func TestCommandSchemaStatusQueueFieldsOptional(t *testing.T) {
+	t.Parallel()
+
+	fields, err := commandSchema("status")
+	if err != nil {
+		t.Fatalf("commandSchema returned error: %v", err)
+	}
+	for _, field := range fields {
+		switch field.Name {
+		case "queue_slots", "paused", "speed_kbps":
+			if !field.Optional {
+				t.Fatalf("expected %q to be optional since --daemon-only omits it", field.Name)
+			}
+		case "profile", "base_url":
+			if field.Optional {
+				t.Fatalf("expected %q to be required", field.Name)
+			}
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package root
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -12,6 +15,7 @@ func scriptsCmd() *cobra.Command {
 		Short: jsonShort("Manage SABnzbd post-processing scripts"),
 	}
 	cmd.AddCommand(scriptsListCmd())
+	cmd.AddCommand(scriptsTestCmd())
 	return cmd
 }
 
@@ -28,7 +32,7 @@ func scriptsListCmd() *cobra.Command {
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 
-			scripts, err := app.Client.GetScripts(ctx)
+			scripts, err := app.GetScripts(ctx)
 			if err != nil {
 				return err
 			}
@@ -53,3 +57,69 @@ func scriptsListCmd() *cobra.Command {
 	}
 	return cmd
 }
+
+func scriptsTestCmd() *cobra.Command {
+	var parameters string
+
+	cmd := &cobra.Command{
+		Use:   "test <name>",
+		Short: jsonShort("Run a script through SABnzbd's notification script tester"),
+		Long: appendJSONLong("Invokes SABnzbd's test_nscript endpoint with the named script and reports its output. " +
+			"SABnzbd cannot run post-processing scripts on demand; those run when a job completes. " +
+			"The command exits non-zero if the script fails."),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, err := getApp(cmd)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := timeoutContext(cmd.Context())
+			defer cancel()
+
+			name := args[0]
+			scripts, err := app.GetScripts(ctx)
+			if err != nil {
+				return err
+			}
+			if !slices.Contains(scripts, name) {
+				return fmt.Errorf("script %q not found (see 'sabx scripts list')", name)
+			}
+
+			result, err := app.Client.TestScript(ctx, name, parameters)
+			if err != nil {
+				return err
+			}
+
+			if app.Printer.JSON {
+				if err := app.Printer.Print(map[string]any{
+					"script":  name,
+					"success": result.Success,
+					"message": result.Message,
+				}); err != nil {
+					return err
+				}
+			} else if result.Success {
+				if err := app.Printer.Print(fmt.Sprintf("Script %s ran successfully", name)); err != nil {
+					return err
+				}
+				if msg := strings.TrimSpace(result.Message); msg != "" {
+					if err := app.Printer.Print(msg); err != nil {
+						return err
+					}
+				}
+			}
+
+			if result.Success {
+				return nil
+			}
+			if strings.TrimSpace(result.Message) == "" {
+				return errors.New("script test failed")
+			}
+			return errors.New(result.Message)
+		},
+	}
+
+	cmd.Flags().StringVar(&parameters, "parameters", "", "Extra parameters passed to the script")
+	return cmd
+}
@@ -1,14 +1,19 @@
 package root
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/avivsinai/sabx/internal/cobraext"
 	"github.com/avivsinai/sabx/internal/sabapi"
 )
 
@@ -21,6 +26,7 @@ func serverCmd() *cobra.Command {
 	cmd.AddCommand(serverListCmd())
 	cmd.AddCommand(serverStatsCmd())
 	cmd.AddCommand(serverTestCmd())
+	cmd.AddCommand(serverAddCmd())
 	cmd.AddCommand(serverDisconnectCmd())
 	cmd.AddCommand(serverUnblockCmd())
 	cmd.AddCommand(serverRestartCmd())
@@ -31,19 +37,28 @@ func serverCmd() *cobra.Command {
 }
 
 func serverListCmd() *cobra.Command {
+	var withStats bool
+	var withRetention bool
+	var expiryWindow string
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: jsonShort("List configured news servers"),
+		Long: appendJSONLong("List configured news servers. Use --stats to add month/total usage and article success ratio from server_stats and --retention to show retention and account expiry. " +
+			"Servers whose account expires within --expiry-window are reported on stderr."),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			app, err := getApp(cmd)
 			if err != nil {
 				return err
 			}
+			window, err := parseAgeDuration(expiryWindow)
+			if err != nil {
+				return fmt.Errorf("invalid --expiry-window: %w", err)
+			}
 
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 
-			servers, err := app.Client.ServerConfigs(ctx)
+			servers, err := app.ServerConfigs(ctx)
 			if err != nil {
 				return err
 			}
@@ -52,7 +67,23 @@ func serverListCmd() *cobra.Command {
 				return servers[i].DisplayName < servers[j].DisplayName
 			})
 
+			var joined []serverWithStats
+			if withStats {
+				stats, err := app.Client.ServerStats(ctx)
+				if err != nil {
+					return err
+				}
+				joined = joinServerStats(servers, stats.Servers)
+			}
+
+			for _, exp := range expiringServers(servers, time.Now(), window) {
+				app.Printer.Error("warning: %s", exp.describe())
+			}
+
 			if app.Printer.JSON {
+				if withStats {
+					return app.Printer.Print(map[string]any{"servers": joined})
+				}
 				return app.Printer.Print(map[string]any{"servers": servers})
 			}
 
@@ -60,9 +91,16 @@ func serverListCmd() *cobra.Command {
 				return app.Printer.Print("No servers configured")
 			}
 
+			headers := []string{"Name", "Host", "Port", "SSL", "Connections", "Enabled", "Priority"}
+			if withRetention {
+				headers = append(headers, "Retention", "Expires")
+			}
+			if withStats {
+				headers = append(headers, "Month", "Total", "Success")
+			}
 			rows := make([][]string, 0, len(servers))
-			for _, srv := range servers {
-				rows = append(rows, []string{
+			for i, srv := range servers {
+				row := []string{
 					srv.DisplayName,
 					srv.Host,
 					strconv.Itoa(srv.Port),
@@ -70,20 +108,206 @@ func serverListCmd() *cobra.Command {
 					strconv.Itoa(srv.Connections),
 					boolToStr(srv.Enable),
 					strconv.Itoa(srv.Priority),
-				})
+				}
+				if withRetention {
+					row = append(row, retentionLabel(srv.Retention), firstNonEmpty(strings.TrimSpace(srv.ExpireDate), "-"))
+				}
+				if withStats {
+					row = append(row, joined[i].usageColumns()...)
+				}
+				rows = append(rows, row)
 			}
-			headers := []string{"Name", "Host", "Port", "SSL", "Connections", "Enabled", "Priority"}
 			return app.Printer.Table(headers, rows)
 		},
 	}
+	cmd.Flags().BoolVar(&withStats, "stats", false, "Include live usage and article success ratio per server")
+	cmd.Flags().BoolVar(&withRetention, "retention", false, "Include retention (days) and account expiry date per server")
+	cmd.Flags().StringVar(&expiryWindow, "expiry-window", "7d", "Warn about server accounts expiring within this window (e.g. 7d, 2w)")
 	return cmd
 }
 
+func retentionLabel(days int) string {
+	if days <= 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%dd", days)
+}
+
+// expireDateLayouts are the expire_date formats seen in SABnzbd configs;
+// the web UI stores YYYY-MM-DD but hand-edited INI files vary.
+var expireDateLayouts = []string{
+	"2006-01-02",
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006/01/02",
+	"02-01-2006",
+	"02.01.2006",
+}
+
+// parseExpireDate parses a server expire_date, also accepting Unix
+// timestamps. ok is false for empty or unrecognized values.
+func parseExpireDate(raw string) (time.Time, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range expireDateLayouts {
+		if t, err := time.ParseInLocation(layout, raw, time.Local); err == nil {
+			return t, true
+		}
+	}
+	if secs, err := strconv.ParseInt(raw, 10, 64); err == nil && secs > 0 {
+		return time.Unix(secs, 0), true
+	}
+	return time.Time{}, false
+}
+
+// serverExpiry is a server whose account has expired or expires soon.
+type serverExpiry struct {
+	Name    string
+	Expires time.Time
+	Expired bool
+}
+
+func (e serverExpiry) describe() string {
+	if e.Expired {
+		return fmt.Sprintf("server %s account expired on %s", e.Name, e.Expires.Format("2006-01-02"))
+	}
+	return fmt.Sprintf("server %s account expires on %s", e.Name, e.Expires.Format("2006-01-02"))
+}
+
+// expiringServers returns enabled servers whose expire_date falls before
+// now+window, including ones that have already expired.
+func expiringServers(servers []sabapi.ServerConfig, now time.Time, window time.Duration) []serverExpiry {
+	var result []serverExpiry
+	deadline := now.Add(window)
+	for _, srv := range servers {
+		if !srv.Enable {
+			continue
+		}
+		expires, ok := parseExpireDate(srv.ExpireDate)
+		if !ok || expires.After(deadline) {
+			continue
+		}
+		result = append(result, serverExpiry{
+			Name:    firstNonEmpty(srv.DisplayName, srv.Name),
+			Expires: expires,
+			Expired: !expires.After(now),
+		})
+	}
+	return result
+}
+
+// serverWithStats joins a server's configuration with its live usage.
+// Stats is nil for servers that have no entry in server_stats yet.
+type serverWithStats struct {
+	sabapi.ServerConfig
+	Stats        *sabapi.ServerUsageMetrics `json:"stats,omitempty"`
+	SuccessRatio *float64                   `json:"success_ratio,omitempty"`
+}
+
+// joinServerStats pairs each config with its stats by server name,
+// preserving the order of configs.
+func joinServerStats(configs []sabapi.ServerConfig, stats map[string]sabapi.ServerUsageMetrics) []serverWithStats {
+	joined := make([]serverWithStats, 0, len(configs))
+	for _, cfg := range configs {
+		entry := serverWithStats{ServerConfig: cfg}
+		if usage, ok := stats[cfg.Name]; ok {
+			usage := usage
+			entry.Stats = &usage
+			entry.SuccessRatio = articleSuccessRatio(usage)
+		}
+		joined = append(joined, entry)
+	}
+	return joined
+}
+
+func (s serverWithStats) usageColumns() []string {
+	if s.Stats == nil {
+		return []string{"-", "-", "-"}
+	}
+	return []string{humanBytes(s.Stats.Month), humanBytes(s.Stats.Total), successColumn(s.SuccessRatio)}
+}
+
+// articleSuccessRatio is ArticlesSuccess/ArticlesTried, or nil when no
+// articles have been tried yet.
+func articleSuccessRatio(usage sabapi.ServerUsageMetrics) *float64 {
+	if usage.ArticlesTried <= 0 {
+		return nil
+	}
+	ratio := usage.ArticlesSuccess / usage.ArticlesTried
+	return &ratio
+}
+
+// serverArticleHealth is one row of `server stats --articles`.
+type serverArticleHealth struct {
+	Server          string   `json:"server"`
+	Name            string   `json:"name"`
+	ArticlesTried   float64  `json:"articles_tried"`
+	ArticlesSuccess float64  `json:"articles_success"`
+	SuccessRatio    *float64 `json:"success_ratio,omitempty"`
+	Healthy         bool     `json:"healthy"`
+}
+
+// assessArticleHealth computes each server's article success ratio and marks
+// servers below minSuccess (a percentage) as unhealthy. Servers that have not
+// tried any articles have no ratio and count as healthy. Rows are sorted by
+// display label.
+func assessArticleHealth(stats map[string]sabapi.ServerUsageMetrics, labels map[string]string, minSuccess float64) []serverArticleHealth {
+	rows := make([]serverArticleHealth, 0, len(stats))
+	for name, usage := range stats {
+		row := serverArticleHealth{
+			Server:          firstNonEmpty(labels[name], name),
+			Name:            name,
+			ArticlesTried:   usage.ArticlesTried,
+			ArticlesSuccess: usage.ArticlesSuccess,
+			SuccessRatio:    articleSuccessRatio(usage),
+			Healthy:         true,
+		}
+		if row.SuccessRatio != nil && *row.SuccessRatio*100 < minSuccess {
+			row.Healthy = false
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Server < rows[j].Server
+	})
+	return rows
+}
+
+func unhealthyServersError(rows []serverArticleHealth, minSuccess float64) error {
+	var names []string
+	for _, row := range rows {
+		if !row.Healthy {
+			names = append(names, row.Server)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d server(s) below %s%% article success: %s", len(names), formatFloat(minSuccess), strings.Join(names, ", "))
+}
+
+func successColumn(ratio *float64) string {
+	if ratio == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", *ratio*100)
+}
+
 func serverStatsCmd() *cobra.Command {
+	var articles bool
+	var minSuccess float64
+	var failOnUnhealthy bool
 	cmd := &cobra.Command{
 		Use:   "stats",
 		Short: jsonShort("Show aggregate server throughput statistics"),
+		Long: appendJSONLong("Show bandwidth usage per period and per server. Use --articles for the article success view: servers below --min-success percent are flagged unhealthy, " +
+			"and --fail-on-unhealthy exits non-zero when any are. Servers with no tried articles have no ratio and are not flagged."),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if minSuccess < 0 || minSuccess > 100 {
+				return fmt.Errorf("--min-success must be between 0 and 100, got %s", formatFloat(minSuccess))
+			}
 			app, err := getApp(cmd)
 			if err != nil {
 				return err
@@ -97,8 +321,51 @@ func serverStatsCmd() *cobra.Command {
 				return err
 			}
 
+			labels := map[string]string{}
+			if len(stats.Servers) > 0 {
+				configs, _ := app.ServerConfigs(ctx) // best effort for friendly names
+				for _, cfg := range configs {
+					labels[cfg.Name] = cfg.DisplayName
+				}
+			}
+			health := assessArticleHealth(stats.Servers, labels, minSuccess)
+			var gate error
+			if failOnUnhealthy {
+				gate = unhealthyServersError(health, minSuccess)
+			}
+
+			if articles {
+				if app.Printer.JSON {
+					if err := app.Printer.Print(map[string]any{"servers": health, "min_success": minSuccess}); err != nil {
+						return err
+					}
+					return gate
+				}
+				rows := make([][]string, 0, len(health))
+				for _, row := range health {
+					status := "ok"
+					if !row.Healthy {
+						status = "UNHEALTHY"
+					}
+					rows = append(rows, []string{
+						row.Server,
+						formatFloat(row.ArticlesTried),
+						formatFloat(row.ArticlesSuccess),
+						successColumn(row.SuccessRatio),
+						status,
+					})
+				}
+				if err := app.Printer.Table([]string{"Server", "Articles Tried", "Articles Success", "Success", "Health"}, rows); err != nil {
+					return err
+				}
+				return gate
+			}
+
 			if app.Printer.JSON {
-				return app.Printer.Print(stats)
+				if err := app.Printer.Print(stats); err != nil {
+					return err
+				}
+				return gate
 			}
 
 			summary := [][]string{
@@ -112,39 +379,34 @@ func serverStatsCmd() *cobra.Command {
 			}
 
 			if len(stats.Servers) == 0 {
-				return nil
+				return gate
 			}
 
-			configs, _ := app.Client.ServerConfigs(ctx) // best effort for friendly names
-			nameMap := map[string]string{}
-			for _, cfg := range configs {
-				nameMap[cfg.Name] = cfg.DisplayName
-			}
-
-			headers := []string{"Server", "Total", "Month", "Week", "Day", "Articles Tried", "Articles Success"}
-			rows := make([][]string, 0, len(stats.Servers))
-			for key, value := range stats.Servers {
-				label := nameMap[key]
-				if label == "" {
-					label = key
-				}
+			headers := []string{"Server", "Total", "Month", "Week", "Day", "Articles Tried", "Articles Success", "Success"}
+			rows := make([][]string, 0, len(health))
+			for _, row := range health {
+				value := stats.Servers[row.Name]
 				rows = append(rows, []string{
-					label,
+					row.Server,
 					humanBytes(value.Total),
 					humanBytes(value.Month),
 					humanBytes(value.Week),
 					humanBytes(value.Day),
 					formatFloat(value.ArticlesTried),
 					formatFloat(value.ArticlesSuccess),
+					successColumn(row.SuccessRatio),
 				})
 			}
-			sort.Slice(rows, func(i, j int) bool {
-				return rows[i][0] < rows[j][0]
-			})
 
-			return app.Printer.Table(headers, rows)
+			if err := app.Printer.Table(headers, rows); err != nil {
+				return err
+			}
+			return gate
 		},
 	}
+	cmd.Flags().BoolVar(&articles, "articles", false, "Show per-server article success ratios and health")
+	cmd.Flags().Float64Var(&minSuccess, "min-success", 90, "Minimum article success percentage before a server is flagged unhealthy")
+	cmd.Flags().BoolVar(&failOnUnhealthy, "fail-on-unhealthy", false, "Exit non-zero when any server is below --min-success")
 	return cmd
 }
 
@@ -158,13 +420,27 @@ func serverTestCmd() *cobra.Command {
 	var sslFlag bool
 	var sslVerify int
 	var sslCiphers string
+	var verbose bool
+	var applyConnections int
 
 	cmd := &cobra.Command{
 		Use:   "test <server-name>",
 		Short: jsonShort("Run SABnzbd's built-in server connectivity test"),
-		Args:  cobra.ExactArgs(1),
+		Long: appendJSONLong("Runs SABnzbd's server test and reports the round-trip latency. Banner and retention details are extracted from the test message when the server provides them. " +
+			"--apply-connections N tests with N connections and, only if the test passes, saves N as the server's connection count."),
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			target := strings.TrimSpace(args[0])
+			apply := cmd.Flags().Changed("apply-connections")
+			if apply {
+				if applyConnections < 1 {
+					return errors.New("--apply-connections must be at least 1")
+				}
+				if cmd.Flags().Changed("connections") && connections != applyConnections {
+					return errors.New("--apply-connections and --connections disagree; pass only --apply-connections")
+				}
+				connections = applyConnections
+			}
 			app, err := getApp(cmd)
 			if err != nil {
 				return err
@@ -173,7 +449,7 @@ func serverTestCmd() *cobra.Command {
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 
-			configs, err := app.Client.ServerConfigs(ctx)
+			configs, err := app.ServerConfigs(ctx)
 			if err != nil {
 				return err
 			}
@@ -207,7 +483,7 @@ func serverTestCmd() *cobra.Command {
 			if cmd.Flags().Changed("password") {
 				params.Password = password
 			}
-			if cmd.Flags().Changed("connections") {
+			if apply || cmd.Flags().Changed("connections") {
 				params.Connections = connections
 			}
 			if cmd.Flags().Changed("timeout") {
@@ -223,20 +499,42 @@ func serverTestCmd() *cobra.Command {
 				params.SSLCiphers = sslCiphers
 			}
 
-			result, err := app.Client.TestServer(ctx, params)
+			report, err := runServerTestWithBudget(ctx, cmd.Context(), app.Client, params)
 			if err != nil {
 				return err
 			}
+			if !apply {
+				if app.Printer.JSON {
+					return app.Printer.Print(report)
+				}
+				return app.Printer.Print(report.describe(verbose))
+			}
 
+			if report.Result {
+				values := url.Values{}
+				values.Set("connections", strconv.Itoa(applyConnections))
+				if err := app.Client.ConfigSet(ctx, "servers", server.Name, values); err != nil {
+					return err
+				}
+			}
 			if app.Printer.JSON {
-				return app.Printer.Print(result)
+				if err := app.Printer.Print(map[string]any{"test": report, "connections": applyConnections, "applied": report.Result}); err != nil {
+					return err
+				}
+			} else {
+				if err := app.Printer.Print(report.describe(verbose)); err != nil {
+					return err
+				}
+				if report.Result {
+					if err := app.Printer.Print(fmt.Sprintf("Set %s connections to %d (was %d)", server.Name, applyConnections, server.Connections)); err != nil {
+						return err
+					}
+				}
 			}
-
-			status := "FAILED"
-			if result.Result {
-				status = "OK"
+			if !report.Result {
+				return fmt.Errorf("connections for %s not changed: test failed", server.Name)
 			}
-			return app.Printer.Print(fmt.Sprintf("[%s] %s", status, result.Message))
+			return nil
 		},
 	}
 
@@ -249,10 +547,209 @@ func serverTestCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&sslFlag, "ssl", false, "Override SSL usage for test")
 	cmd.Flags().IntVar(&sslVerify, "ssl-verify", -1, "Override SSL verification mode (0-3)")
 	cmd.Flags().StringVar(&sslCiphers, "ssl-ciphers", "", "Override custom SSL ciphers")
+	cmd.Flags().BoolVar(&verbose, "verbose", false, "Include the raw test message from SABnzbd")
+	cmd.Flags().IntVar(&applyConnections, "apply-connections", 0, "Test with this many connections and save the count to the server config when the test passes")
 
 	return cmd
 }
 
+func serverAddCmd() *cobra.Command {
+	var params sabapi.ServerTestParams
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "add <server-name>",
+		Short: jsonShort("Test a news server and save it if the test passes"),
+		Long: appendJSONLong("Runs SABnzbd's server test with the given connection details and writes the server to SABnzbd's config only when the test succeeds. " +
+			"Use --force to save even after a failed test. The test result is printed either way."),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			params.Server = strings.TrimSpace(args[0])
+			if params.Server == "" {
+				return errors.New("server name required")
+			}
+			if strings.TrimSpace(params.Host) == "" {
+				return errors.New("--host is required")
+			}
+
+			app, err := getApp(cmd)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := timeoutContext(cmd.Context())
+			defer cancel()
+
+			report, err := runServerTestWithBudget(ctx, cmd.Context(), app.Client, params)
+			if err != nil {
+				return err
+			}
+
+			saved := report.Result || force
+			if saved {
+				if err := app.Client.ConfigSet(ctx, "servers", params.Server, serverConfigValues(params)); err != nil {
+					return err
+				}
+			}
+
+			if app.Printer.JSON {
+				if err := app.Printer.Print(map[string]any{"test": report, "saved": saved}); err != nil {
+					return err
+				}
+			} else {
+				if err := app.Printer.Print(report.describe(false)); err != nil {
+					return err
+				}
+				if saved {
+					if err := app.Printer.Print(fmt.Sprintf("Saved server %s", params.Server)); err != nil {
+						return err
+					}
+				}
+			}
+
+			if !saved {
+				return fmt.Errorf("server %s not saved: test failed (use --force to save anyway)", params.Server)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&params.Host, "host", "", "Server hostname (required)")
+	cmd.Flags().IntVar(&params.Port, "port", 0, "Server port (SABnzbd default when 0)")
+	cmd.Flags().StringVar(&params.Username, "username", "", "Username")
+	cmd.Flags().StringVar(&params.Password, "password", "", "Password")
+	cmd.Flags().IntVar(&params.Connections, "connections", 0, "Connection count (SABnzbd default when 0)")
+	cmd.Flags().IntVar(&params.Timeout, "timeout", 0, "Timeout in seconds (SABnzbd default when 0)")
+	cmd.Flags().BoolVar(&params.SSL, "ssl", false, "Use SSL")
+	cmd.Flags().IntVar(&params.SSLVerify, "ssl-verify", -1, "SSL verification mode (0-3)")
+	cmd.Flags().StringVar(&params.SSLCiphers, "ssl-ciphers", "", "Custom SSL ciphers")
+	cmd.Flags().BoolVar(&force, "force", false, "Save the server even if the test fails")
+	return cmd
+}
+
+// serverConfigValues maps tested connection details to set_config fields.
+// Unset numeric options are left to SABnzbd's defaults.
+func serverConfigValues(params sabapi.ServerTestParams) url.Values {
+	values := url.Values{}
+	values.Set("host", params.Host)
+	values.Set("username", params.Username)
+	values.Set("password", params.Password)
+	values.Set("enable", "1")
+	if params.Port > 0 {
+		values.Set("port", strconv.Itoa(params.Port))
+	}
+	if params.Connections > 0 {
+		values.Set("connections", strconv.Itoa(params.Connections))
+	}
+	if params.Timeout > 0 {
+		values.Set("timeout", strconv.Itoa(params.Timeout))
+	}
+	if params.SSL {
+		values.Set("ssl", "1")
+	} else {
+		values.Set("ssl", "0")
+	}
+	if params.SSLVerify >= 0 {
+		values.Set("ssl_verify", strconv.Itoa(params.SSLVerify))
+	}
+	if params.SSLCiphers != "" {
+		values.Set("ssl_ciphers", params.SSLCiphers)
+	}
+	return values
+}
+
+type serverTestReport struct {
+	Server    string `json:"server"`
+	Result    bool   `json:"result"`
+	Message   string `json:"message"`
+	Summary   string `json:"summary"`
+	Banner    string `json:"banner,omitempty"`
+	Retention string `json:"retention,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+// runServerTest times SABnzbd's server test so providers can be compared.
+// Timing is reported for failed results as well as successful ones.
+// runServerTestWithBudget runs runServerTest, switching to a cloned client
+// and a longer deadline derived from parent when the server's own timeout
+// exceeds the default request timeout (SABnzbd waits that long).
+func runServerTestWithBudget(ctx, parent context.Context, client *sabapi.Client, params sabapi.ServerTestParams) (serverTestReport, error) {
+	if budget := time.Duration(params.Timeout)*time.Second + 5*time.Second; budget > requestTimeout {
+		client = client.Clone(sabapi.WithTimeout(budget))
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(parent, budget)
+		defer cancel()
+	}
+	return runServerTest(ctx, client, params)
+}
+
+// describe renders the report for humans.
+func (r serverTestReport) describe(verbose bool) string {
+	status := "FAILED"
+	if r.Result {
+		status = "OK"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s (%dms)", status, r.Summary, r.LatencyMS)
+	if r.Banner != "" {
+		fmt.Fprintf(&b, "\nBanner: %s", r.Banner)
+	}
+	if r.Retention != "" {
+		fmt.Fprintf(&b, "\nRetention: %s", r.Retention)
+	}
+	if verbose && r.Message != r.Summary {
+		fmt.Fprintf(&b, "\nRaw message:\n%s", r.Message)
+	}
+	return b.String()
+}
+
+func runServerTest(ctx context.Context, client *sabapi.Client, params sabapi.ServerTestParams) (serverTestReport, error) {
+	start := time.Now()
+	result, err := client.TestServer(ctx, params)
+	latency := time.Since(start)
+	if err != nil {
+		return serverTestReport{}, err
+	}
+
+	report := serverTestReport{
+		Server:    params.Server,
+		Result:    result.Result,
+		Message:   result.Message,
+		LatencyMS: latency.Milliseconds(),
+	}
+	report.Summary, report.Banner, report.Retention = parseServerTestMessage(result.Message)
+	return report, nil
+}
+
+var (
+	nntpBannerPattern = regexp.MustCompile(`^20[01][ -]\S`)
+	retentionPattern  = regexp.MustCompile(`(?i)retention[^0-9]*(\d+)\s*(days?|d)?`)
+)
+
+// parseServerTestMessage splits SABnzbd's test message into a one-line
+// summary plus any NNTP greeting banner and retention hint it contains.
+func parseServerTestMessage(message string) (summary, banner, retention string) {
+	lines := strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if summary == "" {
+			summary = line
+		}
+		if banner == "" && nntpBannerPattern.MatchString(line) {
+			banner = line
+		}
+		if retention == "" {
+			if m := retentionPattern.FindStringSubmatch(line); m != nil {
+				retention = m[1] + " days"
+			}
+		}
+	}
+	return summary, banner, retention
+}
+
 func serverDisconnectCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "disconnect",
@@ -271,27 +768,91 @@ func serverDisconnectCmd() *cobra.Command {
 }
 
 func serverUnblockCmd() *cobra.Command {
+	var all bool
 	cmd := &cobra.Command{
-		Use:   "unblock <server-name>",
+		Use:   "unblock [server-name]",
 		Short: jsonShort("Unblock a temporarily disabled news server"),
-		Args:  cobra.ExactArgs(1),
-		RunE: func(cmd *cobra.Command, args []string) error {
-			name := strings.TrimSpace(args[0])
-			if name == "" {
-				return errors.New("server name required")
+		Long:  appendJSONLong("Unblock a news server SABnzbd disabled after errors. With --all, every server reporting an error in fullstatus is unblocked, which helps after a provider outage blocks several at once."),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if all {
+				if len(args) > 0 {
+					return errors.New("--all cannot be combined with a server name")
+				}
+				return nil
 			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
 			app, err := getApp(cmd)
 			if err != nil {
 				return err
 			}
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
+			if all {
+				return unblockAllServers(ctx, app)
+			}
+			name := strings.TrimSpace(args[0])
+			if name == "" {
+				return errors.New("server name required")
+			}
 			return app.Client.UnblockServer(ctx, name)
 		},
 	}
+	cmd.Flags().BoolVar(&all, "all", false, "Unblock every server that fullstatus reports as blocked")
 	return cmd
 }
 
+func unblockAllServers(ctx context.Context, app *cobraext.App) error {
+	status, err := app.Client.FullStatus(ctx, sabapi.FullStatusOptions{SkipDashboard: true})
+	if err != nil {
+		return err
+	}
+	blocked := blockedServersFromFullStatus(status)
+
+	unblocked := make([]string, 0, len(blocked))
+	failed := map[string]string{}
+	rows := make([][]string, 0, len(blocked))
+	for _, name := range blocked {
+		if err := app.Client.UnblockServer(ctx, name); err != nil {
+			failed[name] = err.Error()
+			rows = append(rows, []string{name, "error: " + err.Error()})
+			continue
+		}
+		unblocked = append(unblocked, name)
+		rows = append(rows, []string{name, "unblocked"})
+	}
+
+	if app.Printer.JSON {
+		if err := app.Printer.Print(map[string]any{"unblocked": unblocked, "failed": failed}); err != nil {
+			return err
+		}
+	} else if len(blocked) == 0 {
+		if err := app.Printer.Print("No blocked servers"); err != nil {
+			return err
+		}
+	} else if err := app.Printer.Table([]string{"Server", "Result"}, rows); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d servers could not be unblocked", len(failed), len(blocked))
+	}
+	return nil
+}
+
+// blockedServersFromFullStatus returns the names of servers fullstatus
+// reports an error for, which is how a temporarily blocked server shows up.
+func blockedServersFromFullStatus(status map[string]any) []string {
+	servers, _ := serversFromFullStatus(status["servers"])
+	var names []string
+	for _, srv := range servers {
+		if msg := strings.TrimSpace(srv.Error); msg != "" && msg != "<nil>" && srv.Name != "" {
+			names = append(names, srv.Name)
+		}
+	}
+	return names
+}
+
 func serverRestartCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "restart",
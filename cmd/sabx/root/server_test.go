@@ -0,0 +1,515 @@
+package root
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+func newServerTestClient(t *testing.T, body string, delay time.Duration) *sabapi.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	return client
+}
+
+func TestRunServerTestReportsLatency(t *testing.T) {
+	t.Parallel()
+
+	client := newServerTestClient(t, `{"value":{"result":true,"message":"Connection Successful!\n200 news.example.com NNRP Service Ready\nRetention: 4000 days"}}`, 20*time.Millisecond)
+
+	report, err := runServerTest(context.Background(), client, sabapi.ServerTestParams{Server: "primary"})
+	if err != nil {
+		t.Fatalf("runServerTest returned error: %v", err)
+	}
+	if !report.Result {
+		t.Fatal("expected successful result")
+	}
+	if report.LatencyMS < 20 {
+		t.Fatalf("expected latency >= 20ms, got %d", report.LatencyMS)
+	}
+	if report.Summary != "Connection Successful!" {
+		t.Fatalf("unexpected summary %q", report.Summary)
+	}
+	if report.Banner != "200 news.example.com NNRP Service Ready" {
+		t.Fatalf("unexpected banner %q", report.Banner)
+	}
+	if report.Retention != "4000 days" {
+		t.Fatalf("unexpected retention %q", report.Retention)
+	}
+}
+
+func TestRunServerTestFailureStillTimed(t *testing.T) {
+	t.Parallel()
+
+	client := newServerTestClient(t, `{"value":{"result":false,"message":"Authentication failed, check username/password."}}`, 10*time.Millisecond)
+
+	report, err := runServerTest(context.Background(), client, sabapi.ServerTestParams{Server: "primary"})
+	if err != nil {
+		t.Fatalf("runServerTest returned error: %v", err)
+	}
+	if report.Result {
+		t.Fatal("expected failed result")
+	}
+	if report.LatencyMS < 10 {
+		t.Fatalf("expected latency >= 10ms for failed test, got %d", report.LatencyMS)
+	}
+	if report.Banner != "" || report.Retention != "" {
+		t.Fatalf("expected no banner/retention, got %q/%q", report.Banner, report.Retention)
+	}
+}
+
+func TestJoinServerStatsByName(t *testing.T) {
+	t.Parallel()
+
+	configs := []sabapi.ServerConfig{
+		{Name: "news.example.com", DisplayName: "Primary"},
+		{Name: "backup.example.com", DisplayName: "Backup"},
+	}
+	stats := map[string]sabapi.ServerUsageMetrics{
+		"news.example.com": {Total: 2048, Month: 1024, ArticlesTried: 200, ArticlesSuccess: 150},
+		"retired.example":  {Total: 1},
+	}
+
+	joined := joinServerStats(configs, stats)
+	if len(joined) != 2 {
+		t.Fatalf("expected 2 servers, got %d", len(joined))
+	}
+
+	primary := joined[0]
+	if primary.Name != "news.example.com" || primary.Stats == nil || primary.Stats.Month != 1024 {
+		t.Fatalf("expected stats joined onto primary, got %+v", primary)
+	}
+	if primary.SuccessRatio == nil || *primary.SuccessRatio != 0.75 {
+		t.Fatalf("expected success ratio 0.75, got %v", primary.SuccessRatio)
+	}
+	if got := primary.usageColumns(); got[0] != "1.00 KB" || got[1] != "2.00 KB" || got[2] != "75.0%" {
+		t.Fatalf("unexpected usage columns %v", got)
+	}
+
+	backup := joined[1]
+	if backup.Stats != nil || backup.SuccessRatio != nil {
+		t.Fatalf("expected no stats for backup, got %+v", backup)
+	}
+	if got := backup.usageColumns(); got[0] != "-" || got[2] != "-" {
+		t.Fatalf("expected placeholder columns, got %v", got)
+	}
+}
+
+func runServerAdd(t *testing.T, testResult bool, args ...string) (*bytes.Buffer, []url.Values, error) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var calls []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		mu.Lock()
+		calls = append(calls, q)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if q.Get("name") == "test_server" {
+			if testResult {
+				_, _ = w.Write([]byte(`{"value":{"result":true,"message":"Connection Successful!"}}`))
+			} else {
+				_, _ = w.Write([]byte(`{"value":{"result":false,"message":"Authentication failed"}}`))
+			}
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":true}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	var out bytes.Buffer
+	cmd := serverAddCmd()
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{Client: client, Printer: &output.Printer{Out: &out}}))
+	cmd.SetArgs(args)
+	err = cmd.Execute()
+	return &out, calls, err
+}
+
+func findSetConfig(calls []url.Values) url.Values {
+	for _, q := range calls {
+		if q.Get("mode") == "set_config" {
+			return q
+		}
+	}
+	return nil
+}
+
+func TestServerAddSavesOnPass(t *testing.T) {
+	t.Parallel()
+
+	out, calls, err := runServerAdd(t, true, "primary", "--host", "news.example.com", "--port", "563", "--ssl", "--username", "u", "--password", "p")
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	saved := findSetConfig(calls)
+	if saved == nil {
+		t.Fatalf("expected set_config after passing test, calls %v", calls)
+	}
+	if saved.Get("section") != "servers" || saved.Get("name") != "primary" || saved.Get("host") != "news.example.com" || saved.Get("port") != "563" || saved.Get("ssl") != "1" {
+		t.Fatalf("unexpected set_config params %v", saved)
+	}
+	if !strings.Contains(out.String(), "[OK]") || !strings.Contains(out.String(), "Saved server primary") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+}
+
+func TestServerAddSkipsOnFail(t *testing.T) {
+	t.Parallel()
+
+	out, calls, err := runServerAdd(t, false, "primary", "--host", "news.example.com")
+	if err == nil || !strings.Contains(err.Error(), "not saved") {
+		t.Fatalf("expected not-saved error, got %v", err)
+	}
+	if findSetConfig(calls) != nil {
+		t.Fatal("config must not be written after a failed test")
+	}
+	if !strings.Contains(out.String(), "[FAILED] Authentication failed") {
+		t.Fatalf("expected failed test result printed, got %q", out.String())
+	}
+
+	_, calls, err = runServerAdd(t, false, "primary", "--host", "news.example.com", "--force")
+	if err != nil {
+		t.Fatalf("expected --force to save, got %v", err)
+	}
+	if findSetConfig(calls) == nil {
+		t.Fatal("expected set_config with --force")
+	}
+}
+
+func runServerTestApply(t *testing.T, testResult bool, args ...string) (*bytes.Buffer, []url.Values, error) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var calls []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		mu.Lock()
+		calls = append(calls, q)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case q.Get("mode") == "get_config":
+			_, _ = w.Write([]byte(`{"servers":[{"name":"primary","displayname":"Primary","host":"news.example.com","port":563,"connections":8,"ssl":true,"ssl_verify":2,"enable":true}]}`))
+		case q.Get("name") == "test_server" && testResult:
+			_, _ = w.Write([]byte(`{"value":{"result":true,"message":"Connection Successful!"}}`))
+		case q.Get("name") == "test_server":
+			_, _ = w.Write([]byte(`{"value":{"result":false,"message":"Too many connections"}}`))
+		default:
+			_, _ = w.Write([]byte(`{"status":true}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	var out bytes.Buffer
+	cmd := serverTestCmd()
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{Client: client, Printer: &output.Printer{Out: &out}}))
+	cmd.SetArgs(args)
+	err = cmd.Execute()
+	return &out, calls, err
+}
+
+func TestServerTestAppliesConnectionsOnPass(t *testing.T) {
+	t.Parallel()
+
+	out, calls, err := runServerTestApply(t, true, "primary", "--apply-connections", "20")
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	for _, q := range calls {
+		if q.Get("name") == "test_server" && q.Get("connections") != "20" {
+			t.Fatalf("expected the test to use 20 connections, got %v", q)
+		}
+	}
+	saved := findSetConfig(calls)
+	if saved == nil {
+		t.Fatalf("expected set_config after passing test, calls %v", calls)
+	}
+	if saved.Get("section") != "servers" || saved.Get("name") != "primary" || saved.Get("connections") != "20" || saved.Get("host") != "" {
+		t.Fatalf("expected only connections to be written, got %v", saved)
+	}
+	if !strings.Contains(out.String(), "Set primary connections to 20 (was 8)") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+}
+
+func TestServerTestApplyConnectionsSkipsOnFail(t *testing.T) {
+	t.Parallel()
+
+	out, calls, err := runServerTestApply(t, false, "primary", "--apply-connections", "50")
+	if err == nil || !strings.Contains(err.Error(), "not changed") {
+		t.Fatalf("expected not-changed error, got %v", err)
+	}
+	if findSetConfig(calls) != nil {
+		t.Fatal("config must not be written after a failed test")
+	}
+	if !strings.Contains(out.String(), "[FAILED] Too many connections") {
+		t.Fatalf("expected failed test result printed, got %q", out.String())
+	}
+
+	_, calls, err = runServerTestApply(t, true, "primary")
+	if err != nil {
+		t.Fatalf("plain server test returned error: %v", err)
+	}
+	if findSetConfig(calls) != nil {
+		t.Fatal("server test without --apply-connections must not write config")
+	}
+}
+
+func TestParseExpireDate(t *testing.T) {
+	t.Parallel()
+
+	want := time.Date(2026, 3, 14, 0, 0, 0, 0, time.Local)
+	for _, raw := range []string{"2026-03-14", "2026/03/14", "14-03-2026", "14.03.2026", " 2026-03-14 "} {
+		got, ok := parseExpireDate(raw)
+		if !ok || !got.Equal(want) {
+			t.Fatalf("parseExpireDate(%q) = %v, %v; want %v", raw, got, ok, want)
+		}
+	}
+	if got, ok := parseExpireDate("1773446400"); !ok || got.Unix() != 1773446400 {
+		t.Fatalf("expected unix timestamp to parse, got %v, %v", got, ok)
+	}
+	for _, raw := range []string{"", "soon", "2026-13-40"} {
+		if _, ok := parseExpireDate(raw); ok {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+}
+
+func TestExpiringServers(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+	servers := []sabapi.ServerConfig{
+		{Name: "soon", Enable: true, ExpireDate: "2026-03-14"},
+		{Name: "later", Enable: true, ExpireDate: "2026-04-30"},
+		{Name: "expired", DisplayName: "Old Block", Enable: true, ExpireDate: "2026-03-01"},
+		{Name: "disabled", Enable: false, ExpireDate: "2026-03-11"},
+		{Name: "none", Enable: true},
+		{Name: "garbage", Enable: true, ExpireDate: "next year"},
+	}
+
+	got := expiringServers(servers, now, 7*24*time.Hour)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 expiring servers, got %+v", got)
+	}
+	if got[0].Name != "soon" || got[0].Expired {
+		t.Fatalf("expected soon to be expiring but active, got %+v", got[0])
+	}
+	if got[1].Name != "Old Block" || !got[1].Expired {
+		t.Fatalf("expected Old Block to be expired, got %+v", got[1])
+	}
+	if !strings.Contains(got[1].describe(), "expired on 2026-03-01") {
+		t.Fatalf("unexpected description %q", got[1].describe())
+	}
+
+	if narrow := expiringServers(servers, now, 24*time.Hour); len(narrow) != 1 || narrow[0].Name != "Old Block" {
+		t.Fatalf("expected only the expired server in a 1d window, got %+v", narrow)
+	}
+}
+
+func TestAssessArticleHealth(t *testing.T) {
+	t.Parallel()
+
+	stats := map[string]sabapi.ServerUsageMetrics{
+		"news.example.com":   {ArticlesTried: 1000, ArticlesSuccess: 990},
+		"backup.example.com": {ArticlesTried: 200, ArticlesSuccess: 100},
+		"idle.example.com":   {},
+	}
+	labels := map[string]string{"news.example.com": "Primary"}
+
+	rows := assessArticleHealth(stats, labels, 90)
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %+v", rows)
+	}
+	byName := map[string]serverArticleHealth{}
+	for _, row := range rows {
+		byName[row.Name] = row
+	}
+
+	primary := byName["news.example.com"]
+	if primary.Server != "Primary" || primary.SuccessRatio == nil || *primary.SuccessRatio != 0.99 || !primary.Healthy {
+		t.Fatalf("unexpected primary row %+v", primary)
+	}
+	backup := byName["backup.example.com"]
+	if backup.SuccessRatio == nil || *backup.SuccessRatio != 0.5 || backup.Healthy {
+		t.Fatalf("expected backup to be unhealthy at 50%%, got %+v", backup)
+	}
+	idle := byName["idle.example.com"]
+	if idle.SuccessRatio != nil || !idle.Healthy {
+		t.Fatalf("expected idle server without ratio to stay healthy, got %+v", idle)
+	}
+	if got := successColumn(idle.SuccessRatio); got != "-" {
+		t.Fatalf("expected placeholder success column, got %q", got)
+	}
+
+	err := unhealthyServersError(rows, 90)
+	if err == nil || !strings.Contains(err.Error(), "backup.example.com") || strings.Contains(err.Error(), "Primary") {
+		t.Fatalf("expected gate error naming only the backup, got %v", err)
+	}
+	if err := unhealthyServersError(assessArticleHealth(stats, labels, 40), 40); err != nil {
+		t.Fatalf("expected no gate error at 40%%, got %v", err)
+	}
+}
+
+func TestServerStatsFailOnUnhealthy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{name: "report only", args: []string{"--articles"}},
+		{name: "gate trips", args: []string{"--articles", "--fail-on-unhealthy"}, wantErr: true},
+		{name: "lower threshold passes", args: []string{"--fail-on-unhealthy", "--min-success", "50"}},
+		{name: "invalid threshold", args: []string{"--min-success", "150"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if r.URL.Query().Get("mode") == "server_stats" {
+					_, _ = w.Write([]byte(`{"total":1,"servers":{"news.example.com":{"articles_tried":100,"articles_success":60}}}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"config":{"servers":[]}}`))
+			}))
+			t.Cleanup(server.Close)
+
+			client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+			if err != nil {
+				t.Fatalf("NewClient returned error: %v", err)
+			}
+			var out bytes.Buffer
+			cmd := serverStatsCmd()
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{Client: client, Printer: &output.Printer{Out: &out}}))
+			cmd.SetArgs(tt.args)
+			err = cmd.Execute()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+const blockedFullStatus = `{"status":{"servers":[
+	{"servername":"news.ok","serveractive":true,"servererror":""},
+	{"servername":"news.eu","serveractive":true,"servererror":"Server blocked after login failures"},
+	{"servername":"news.us","serveractive":true,"servererror":"Too many connections"},
+	{"servername":"news.null","serveractive":true,"servererror":null}
+]}}`
+
+func TestBlockedServersFromFullStatus(t *testing.T) {
+	t.Parallel()
+
+	var env struct {
+		Status map[string]any `json:"status"`
+	}
+	if err := json.Unmarshal([]byte(blockedFullStatus), &env); err != nil {
+		t.Fatalf("decode fixture: %v", err)
+	}
+	got := blockedServersFromFullStatus(env.Status)
+	if want := []string{"news.eu", "news.us"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("blockedServersFromFullStatus = %v, want %v", got, want)
+	}
+	if got := blockedServersFromFullStatus(map[string]any{}); len(got) != 0 {
+		t.Fatalf("expected no servers from empty status, got %v", got)
+	}
+}
+
+func TestServerUnblockAll(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var unblocked []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		q := r.URL.Query()
+		switch {
+		case q.Get("mode") == "fullstatus":
+			_, _ = w.Write([]byte(blockedFullStatus))
+		case q.Get("mode") == "status" && q.Get("name") == "unblock_server":
+			mu.Lock()
+			unblocked = append(unblocked, q.Get("value"))
+			mu.Unlock()
+			_, _ = w.Write([]byte(`{"status": true}`))
+		default:
+			t.Errorf("unexpected request %v", q)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	var out bytes.Buffer
+	cmd := serverUnblockCmd()
+	cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{Client: client, Printer: &output.Printer{JSON: true, Out: &out}}))
+	cmd.SetArgs([]string{"--all"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unblock --all returned error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"news.eu", "news.us"}; !reflect.DeepEqual(unblocked, want) {
+		t.Fatalf("unblocked = %v, want %v", unblocked, want)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("decode output: %v\n%s", err, out.String())
+	}
+	if got, _ := payload["unblocked"].([]any); len(got) != 2 {
+		t.Fatalf("unexpected payload %v", payload)
+	}
+
+	cmd = serverUnblockCmd()
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	cmd.SetArgs([]string{"--all", "news.eu"})
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected --all with a server name to fail")
+	}
+}
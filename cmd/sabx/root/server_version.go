@@ -0,0 +1,91 @@
+package root
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/config"
+)
+
+// serverVersion is a SABnzbd version reading, either live or from the
+// per-profile cache written by earlier successful calls.
+type serverVersion struct {
+	Version   string    `json:"version"`
+	CheckedAt time.Time `json:"checked_at"`
+	Cached    bool      `json:"cached"`
+	Stale     bool      `json:"stale"`
+	Offline   bool      `json:"offline"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// String renders the version with any cache/offline qualifiers.
+func (v serverVersion) String() string {
+	if !v.Cached {
+		return v.Version
+	}
+	age := time.Since(v.CheckedAt).Round(time.Minute)
+	switch {
+	case v.Offline && v.Stale:
+		return fmt.Sprintf("%s (offline, stale: last seen %s ago)", v.Version, age)
+	case v.Offline:
+		return fmt.Sprintf("%s (offline: last seen %s ago)", v.Version, age)
+	default:
+		return fmt.Sprintf("%s (cached %s ago)", v.Version, age)
+	}
+}
+
+func versionTTL() time.Duration {
+	if ttl := envConfig.GetDuration("VERSION_TTL"); ttl > 0 {
+		return ttl
+	}
+	return config.DefaultVersionTTL
+}
+
+// lookupServerVersion returns the SABnzbd version for the active profile.
+// With preferCache a cached version younger than versionTTL is returned
+// without a round trip. Successful live calls refresh the cache (saving the
+// config only when the version changed or the entry went stale); failed
+// ones, and a missing session, fall back to the last known version, marked
+// offline.
+func lookupServerVersion(ctx context.Context, app *cobraext.App, preferCache bool) (serverVersion, error) {
+	now := time.Now()
+
+	var cached serverVersion
+	if app.Config != nil {
+		if profile, ok := app.Config.GetProfile(app.ProfileName); ok {
+			version, fresh := profile.CachedVersion(now, versionTTL())
+			if version != "" {
+				cached = serverVersion{Version: version, CheckedAt: profile.LastVersionAt, Cached: true, Stale: !fresh}
+			}
+		}
+	}
+	if preferCache && cached.Version != "" && !cached.Stale {
+		return cached, nil
+	}
+	if app.Client == nil {
+		if cached.Version != "" {
+			cached.Offline = true
+			cached.Error = "no active session; run 'sabx login'"
+			return cached, nil
+		}
+		return serverVersion{}, fmt.Errorf("no active session; run 'sabx login'")
+	}
+
+	live, err := app.Client.Version(ctx)
+	if err != nil {
+		if cached.Version == "" {
+			return serverVersion{}, err
+		}
+		cached.Offline = true
+		cached.Error = err.Error()
+		return cached, nil
+	}
+
+	if app.Config != nil && app.Config.RecordVersion(app.ProfileName, live.Version, now, versionTTL()) {
+		// Best effort: a read-only config must not fail the command.
+		_ = app.Config.Save()
+	}
+	return serverVersion{Version: live.Version, CheckedAt: now}, nil
+}
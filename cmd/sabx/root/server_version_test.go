@@ -0,0 +1,140 @@
+package root
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/config"
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+func newVersionTestApp(t *testing.T, status int, profile config.Profile) (*cobraext.App, *int32) {
+	t.Helper()
+	t.Setenv("SABX_CONFIG_DIR", t.TempDir())
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"version":"4.3.2"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load returned error: %v", err)
+	}
+	profile.BaseURL = server.URL
+	cfg.SetProfile("home", profile)
+	return &cobraext.App{Config: cfg, ProfileName: "home", Client: client, BaseURL: server.URL}, &calls
+}
+
+func TestLookupServerVersionUpdatesCache(t *testing.T) {
+	app, _ := newVersionTestApp(t, http.StatusOK, config.Profile{})
+
+	version, err := lookupServerVersion(context.Background(), app, false)
+	if err != nil {
+		t.Fatalf("lookupServerVersion returned error: %v", err)
+	}
+	if version.Version != "4.3.2" || version.Cached || version.Offline {
+		t.Fatalf("unexpected live version %+v", version)
+	}
+
+	reloaded, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load returned error: %v", err)
+	}
+	profile, ok := reloaded.GetProfile("home")
+	if !ok || profile.LastVersion != "4.3.2" || profile.LastVersionAt.IsZero() {
+		t.Fatalf("expected persisted version cache, got %+v", profile)
+	}
+}
+
+func TestLookupServerVersionSkipsSaveWhenUnchanged(t *testing.T) {
+	app, calls := newVersionTestApp(t, http.StatusOK, config.Profile{LastVersion: "4.3.2", LastVersionAt: time.Now().Add(-time.Minute)})
+
+	version, err := lookupServerVersion(context.Background(), app, false)
+	if err != nil {
+		t.Fatalf("lookupServerVersion returned error: %v", err)
+	}
+	if version.Version != "4.3.2" || version.Cached {
+		t.Fatalf("expected live version, got %+v", version)
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Fatalf("expected one version call, got %d", got)
+	}
+	if _, err := os.Stat(filepath.Join(os.Getenv("SABX_CONFIG_DIR"), "config.yml")); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected config not to be rewritten for an unchanged version, stat err %v", err)
+	}
+}
+
+func TestLookupServerVersionOfflineFallback(t *testing.T) {
+	app, _ := newVersionTestApp(t, http.StatusBadGateway, config.Profile{LastVersion: "4.2.0", LastVersionAt: time.Now().Add(-48 * time.Hour)})
+
+	version, err := lookupServerVersion(context.Background(), app, false)
+	if err != nil {
+		t.Fatalf("lookupServerVersion returned error: %v", err)
+	}
+	if version.Version != "4.2.0" || !version.Offline || !version.Stale || version.Error == "" {
+		t.Fatalf("expected stale offline version, got %+v", version)
+	}
+}
+
+func TestLookupServerVersionPrefersFreshCache(t *testing.T) {
+	app, calls := newVersionTestApp(t, http.StatusOK, config.Profile{LastVersion: "4.2.0", LastVersionAt: time.Now().Add(-time.Minute)})
+
+	version, err := lookupServerVersion(context.Background(), app, true)
+	if err != nil {
+		t.Fatalf("lookupServerVersion returned error: %v", err)
+	}
+	if version.Version != "4.2.0" || !version.Cached || version.Offline {
+		t.Fatalf("expected fresh cached version, got %+v", version)
+	}
+	if got := atomic.LoadInt32(calls); got != 0 {
+		t.Fatalf("expected no version call for a fresh cache, got %d", got)
+	}
+}
+
+func TestWhoamiWithoutSessionShowsCachedVersion(t *testing.T) {
+	app, _ := newVersionTestApp(t, http.StatusOK, config.Profile{LastVersion: "4.2.0", LastVersionAt: time.Now().Add(-time.Hour)})
+	app.Client = nil
+
+	var out bytes.Buffer
+	app.Printer = &output.Printer{Out: &out, Err: io.Discard, JSON: true}
+	cmd := whoamiCmd()
+	cmd.SetContext(cobraext.WithApp(context.Background(), app))
+	cmd.SetArgs(nil)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("whoami returned error: %v", err)
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("decode whoami output: %v", err)
+	}
+	if payload["version"] != "4.2.0" || payload["online"] != false {
+		t.Fatalf("expected offline cached version, got %v", payload)
+	}
+}
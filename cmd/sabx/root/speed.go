@@ -1,13 +1,21 @@
 package root
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
+	"os"
+	"os/signal"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/sabapi"
 )
 
 func speedCmd() *cobra.Command {
@@ -18,6 +26,7 @@ func speedCmd() *cobra.Command {
 	}
 	cmd.AddCommand(speedStatusCmd())
 	cmd.AddCommand(speedLimitCmd())
+	cmd.AddCommand(speedPresetCmd())
 	return cmd
 }
 
@@ -26,7 +35,7 @@ func speedStatusCmd() *cobra.Command {
 		Use:     "status",
 		Aliases: []string{"show"},
 		Short:   jsonShort("Display current speed information"),
-		Long:    appendJSONLong("Returns SABnzbd's reported download rate and limit state."),
+		Long:    appendJSONLong("Returns SABnzbd's reported download rate and limit state. Percentage limits are resolved to an absolute KB/s rate using bandwidth_max (or the measured internet bandwidth)."),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			app, err := getApp(cmd)
 			if err != nil {
@@ -43,18 +52,31 @@ func speedStatusCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			absKBps, isPercent, _, err := app.Client.ResolveSpeedLimit(ctx)
+			if err != nil {
+				return err
+			}
 			if app.Printer.JSON {
 				payload := map[string]any{
-					"speed_kbps":   status.Speed,
-					"limit_kbps":   status.SpeedLimit,
-					"paused":       status.Paused,
-					"queue_speed":  queue.Speed,
-					"queue_limit":  queue.SpeedLimit,
-					"queue_paused": queue.Paused,
+					"speed_kbps":       status.Speed,
+					"limit_kbps":       status.SpeedLimit,
+					"limit_abs_kbps":   absKBps,
+					"limit_is_percent": isPercent,
+					"paused":           status.Paused,
+					"queue_speed":      queue.Speed,
+					"queue_limit":      queue.SpeedLimit,
+					"queue_paused":     queue.Paused,
 				}
 				return app.Printer.Print(payload)
 			}
-			summary := fmt.Sprintf("Speed: %s KB/s (limit %s) paused=%v", status.Speed, status.SpeedLimit, status.Paused)
+			limit := status.SpeedLimit
+			if isPercent {
+				limit = strings.TrimSuffix(limit, "%") + "%"
+				if absKBps > 0 {
+					limit += fmt.Sprintf(" = %s KB/s", formatFloat(absKBps))
+				}
+			}
+			summary := fmt.Sprintf("Speed: %s KB/s (limit %s) paused=%v", status.Speed, limit, status.Paused)
 			return app.Printer.Print(summary)
 		},
 	}
@@ -64,22 +86,51 @@ func speedStatusCmd() *cobra.Command {
 func speedLimitCmd() *cobra.Command {
 	var rate string
 	var remove bool
+	var preset string
+	var untilEmpty string
+	var interval time.Duration
 	cmd := &cobra.Command{
 		Use:   "limit",
 		Short: jsonShort("Set the global speed limit"),
-		Long:  appendJSONLong("Configure SABnzbd's global speed limit or remove it entirely."),
+		Long: appendJSONLong("Configure SABnzbd's global speed limit or remove it entirely. --preset applies a rate saved with `sabx speed preset set`.\n\n" +
+			"--until-empty <rate> sets the limit now and removes it after the queue drains, i.e. once the last job has finished downloading. " +
+			"This is a foreground operation: sabx keeps running and polls the queue every --interval until it empties; " +
+			"failed polls are reported and retried at the next interval. " +
+			"Interrupting it (Ctrl-C) stops the polling and leaves the limit in place."),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if remove {
-				if cmd.Flags().Changed("rate") {
-					return errors.New("use --none without --rate")
+			set := 0
+			for _, name := range []string{"rate", "none", "preset", "until-empty"} {
+				if cmd.Flags().Changed(name) {
+					set++
 				}
-			} else if !cmd.Flags().Changed("rate") {
-				return errors.New("provide --rate or use --none")
+			}
+			if set == 0 {
+				return errors.New("provide --rate, --preset, --until-empty or use --none")
+			}
+			if set > 1 {
+				return errors.New("use only one of --rate, --preset, --until-empty and --none")
+			}
+			if interval <= 0 {
+				return errors.New("--interval must be positive")
 			}
 			app, err := getApp(cmd)
 			if err != nil {
 				return err
 			}
+			if untilEmpty != "" {
+				rate = untilEmpty
+			}
+			if preset != "" {
+				stored, ok := app.Config.SpeedPreset(preset)
+				if !ok {
+					return fmt.Errorf("unknown speed preset %q; see 'sabx speed preset list'", preset)
+				}
+				if isSpeedPresetOff(stored) {
+					remove = true
+				} else {
+					rate = stored
+				}
+			}
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 
@@ -88,27 +139,223 @@ func speedLimitCmd() *cobra.Command {
 					return err
 				}
 				if app.Printer.JSON {
-					return app.Printer.Print(map[string]any{"limit": nil})
+					payload := map[string]any{"limit": nil}
+					if preset != "" {
+						payload["preset"] = preset
+					}
+					return app.Printer.Print(payload)
 				}
 				return app.Printer.Print("Speed limit removed")
 			}
 
 			normalized, err := normalizeSpeedLimitInput(rate)
 			if err != nil {
+				if preset != "" {
+					return fmt.Errorf("speed preset %q: %w", preset, err)
+				}
 				return err
 			}
 
 			if err := app.Client.SpeedLimit(ctx, &normalized); err != nil {
 				return err
 			}
+			if untilEmpty != "" {
+				return limitUntilEmpty(cmd.Context(), app.Client, app.Printer, normalized, interval)
+			}
 			if app.Printer.JSON {
-				return app.Printer.Print(map[string]any{"value": normalized, "input": rate})
+				payload := map[string]any{"value": normalized, "input": rate}
+				if preset != "" {
+					payload["preset"] = preset
+				}
+				return app.Printer.Print(payload)
 			}
 			return app.Printer.Print(fmt.Sprintf("Speed limit set to %s", normalized))
 		},
 	}
 	cmd.Flags().StringVar(&rate, "rate", "", "Limit rate (examples: 50%, 800K, 4M, 4MB/s, 10Mbps)")
 	cmd.Flags().BoolVar(&remove, "none", false, "Remove the limit")
+	cmd.Flags().StringVar(&preset, "preset", "", "Apply a saved speed preset by name")
+	cmd.Flags().StringVar(&untilEmpty, "until-empty", "", "Set this limit now and remove it once the queue is empty (runs in the foreground)")
+	cmd.Flags().DurationVar(&interval, "interval", 30*time.Second, "Queue polling interval for --until-empty")
+	return cmd
+}
+
+// limitUntilEmpty waits in the foreground for the queue to empty and then
+// removes the speed limit that was just set to value. An interrupt stops the
+// wait and leaves the limit in place.
+func limitUntilEmpty(parent context.Context, client *sabapi.Client, printer *output.Printer, value string, interval time.Duration) error {
+	if !printer.JSON {
+		if err := printer.Print(fmt.Sprintf("Speed limit set to %s; removing it once the queue is empty (Ctrl-C to stop)", value)); err != nil {
+			return err
+		}
+	}
+	ctx, stop := signal.NotifyContext(parent, os.Interrupt)
+	defer stop()
+	if err := waitForEmptyQueue(ctx, client, printer, interval); err != nil {
+		return fmt.Errorf("speed limit %s left in place: %w", value, err)
+	}
+
+	clearCtx, cancel := timeoutContext(parent)
+	defer cancel()
+	if err := client.SpeedLimit(clearCtx, nil); err != nil {
+		return err
+	}
+	if printer.JSON {
+		return printer.Print(map[string]any{"value": value, "until_empty": true, "limit": nil})
+	}
+	return printer.Print("Queue empty; speed limit removed")
+}
+
+// waitForEmptyQueue polls the queue every interval until it has no slots.
+// Jobs in post-processing have already left the queue and do not count. A
+// failed poll is reported on stderr and retried at the next interval, so a
+// brief outage does not strand the limit.
+func waitForEmptyQueue(ctx context.Context, client *sabapi.Client, printer *output.Printer, interval time.Duration) error {
+	pending := "queued jobs"
+	for {
+		reqCtx, cancel := timeoutContext(ctx)
+		queue, err := client.Queue(reqCtx, 0, 0, "")
+		cancel()
+		switch {
+		case err != nil:
+			if ctx.Err() == nil {
+				printer.Error("Queue poll failed: %v (retrying in %s)", err, interval)
+			}
+		case len(queue.Slots) == 0:
+			return nil
+		default:
+			pending = fmt.Sprintf("%d queued jobs", len(queue.Slots))
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for %s: %w", pending, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// isSpeedPresetOff reports whether a stored preset rate removes the limit.
+func isSpeedPresetOff(rate string) bool {
+	switch strings.ToLower(strings.TrimSpace(rate)) {
+	case "none", "off":
+		return true
+	}
+	return false
+}
+
+func speedPresetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "preset",
+		Short: jsonShort("Manage named speed limit presets"),
+		Long:  appendJSONLong("Save frequently used rates under a name (for example work, night or off) and apply them with `sabx speed limit --preset <name>`. Presets live in the sabx config file, not in SABnzbd."),
+	}
+	cmd.AddCommand(speedPresetSetCmd())
+	cmd.AddCommand(speedPresetListCmd())
+	cmd.AddCommand(speedPresetDeleteCmd())
+	return cmd
+}
+
+func speedPresetSetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set <name> <rate>",
+		Short: jsonShort("Save a speed preset"),
+		Long:  appendJSONLong("Save <rate> under <name>. The rate accepts the same forms as `speed limit --rate`; use none (or off) for a preset that removes the limit."),
+		Args:  cobra.ExactArgs(2),
+		Annotations: map[string]string{
+			"skipPersistent": "true",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := strings.TrimSpace(args[0])
+			rate := strings.TrimSpace(args[1])
+			if name == "" {
+				return errors.New("preset name must not be empty")
+			}
+			normalized := "none"
+			if !isSpeedPresetOff(rate) {
+				var err error
+				if normalized, err = normalizeSpeedLimitInput(rate); err != nil {
+					return err
+				}
+			}
+			app, err := getApp(cmd)
+			if err != nil {
+				return err
+			}
+			app.Config.SetSpeedPreset(name, rate)
+			if err := app.Config.Save(); err != nil {
+				return err
+			}
+			if app.Printer.JSON {
+				return app.Printer.Print(map[string]any{"name": name, "rate": rate, "value": normalized})
+			}
+			return app.Printer.Print(fmt.Sprintf("Saved speed preset %s = %s", name, rate))
+		},
+	}
+	return cmd
+}
+
+func speedPresetListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: jsonShort("List saved speed presets"),
+		Annotations: map[string]string{
+			"skipPersistent": "true",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, err := getApp(cmd)
+			if err != nil {
+				return err
+			}
+			presets := app.Config.SpeedPresets
+			if presets == nil {
+				presets = map[string]string{}
+			}
+			if app.Printer.JSON {
+				return app.Printer.Print(presets)
+			}
+			if len(presets) == 0 {
+				return app.Printer.Print("No speed presets saved")
+			}
+			names := make([]string, 0, len(presets))
+			for name := range presets {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			rows := make([][]string, 0, len(names))
+			for _, name := range names {
+				rows = append(rows, []string{name, presets[name]})
+			}
+			return app.Printer.Table([]string{"Name", "Rate"}, rows)
+		},
+	}
+	return cmd
+}
+
+func speedPresetDeleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete <name>",
+		Short: jsonShort("Delete a speed preset"),
+		Args:  cobra.ExactArgs(1),
+		Annotations: map[string]string{
+			"skipPersistent": "true",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, err := getApp(cmd)
+			if err != nil {
+				return err
+			}
+			if !app.Config.DeleteSpeedPreset(args[0]) {
+				return fmt.Errorf("unknown speed preset %q", args[0])
+			}
+			if err := app.Config.Save(); err != nil {
+				return err
+			}
+			if app.Printer.JSON {
+				return app.Printer.Print(map[string]any{"deleted": args[0]})
+			}
+			return app.Printer.Print(fmt.Sprintf("Deleted speed preset %s", args[0]))
+		},
+	}
 	return cmd
 }
 
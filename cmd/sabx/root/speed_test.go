@@ -1,6 +1,23 @@
 package root
 
-import "testing"
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/config"
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
 
 func TestNormalizeSpeedLimitInput(t *testing.T) {
 	t.Parallel()
@@ -38,3 +55,194 @@ func TestNormalizeSpeedLimitInputError(t *testing.T) {
 		t.Fatal("expected error for negative percent, got nil")
 	}
 }
+
+func TestSpeedPresetRoundTripAndApply(t *testing.T) {
+	t.Setenv("SABX_CONFIG_DIR", t.TempDir())
+
+	run := func(cmd *cobra.Command, app *cobraext.App, args ...string) (string, error) {
+		var out bytes.Buffer
+		app.Printer = &output.Printer{JSON: true, Out: &out}
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		cmd.SetContext(cobraext.WithApp(context.Background(), app))
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return out.String(), err
+	}
+	loadApp := func() *cobraext.App {
+		cfg, err := config.Load()
+		if err != nil {
+			t.Fatalf("config.Load: %v", err)
+		}
+		return &cobraext.App{Config: cfg}
+	}
+
+	if _, err := run(speedPresetSetCmd(), loadApp(), "work", "4MB/s"); err != nil {
+		t.Fatalf("preset set returned error: %v", err)
+	}
+	if _, err := run(speedPresetSetCmd(), loadApp(), "off", "none"); err != nil {
+		t.Fatalf("preset set returned error: %v", err)
+	}
+	if _, err := run(speedPresetSetCmd(), loadApp(), "bad", "fast"); err == nil {
+		t.Fatal("expected invalid rate to be rejected")
+	}
+
+	out, err := run(speedPresetListCmd(), loadApp())
+	if err != nil {
+		t.Fatalf("preset list returned error: %v", err)
+	}
+	var presets map[string]string
+	if err := json.Unmarshal([]byte(out), &presets); err != nil {
+		t.Fatalf("decode presets: %v\n%s", err, out)
+	}
+	if !reflect.DeepEqual(presets, map[string]string{"work": "4MB/s", "off": "none"}) {
+		t.Fatalf("unexpected presets %v", presets)
+	}
+
+	var mu sync.Mutex
+	var limits []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if q := r.URL.Query(); q.Get("name") == "speedlimit" {
+			mu.Lock()
+			limits = append(limits, q.Get("value"))
+			mu.Unlock()
+		}
+		_, _ = w.Write([]byte(`{"status": true}`))
+	}))
+	t.Cleanup(server.Close)
+	client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	withClient := func() *cobraext.App {
+		app := loadApp()
+		app.Client = client
+		return app
+	}
+
+	if _, err := run(speedLimitCmd(), withClient(), "--preset", "work"); err != nil {
+		t.Fatalf("speed limit --preset work returned error: %v", err)
+	}
+	if _, err := run(speedLimitCmd(), withClient(), "--preset", "off"); err != nil {
+		t.Fatalf("speed limit --preset off returned error: %v", err)
+	}
+	if _, err := run(speedLimitCmd(), withClient(), "--preset", "missing"); err == nil || !strings.Contains(err.Error(), "unknown speed preset") {
+		t.Fatalf("expected unknown preset error, got %v", err)
+	}
+	if _, err := run(speedLimitCmd(), withClient(), "--preset", "work", "--rate", "1M"); err == nil {
+		t.Fatal("expected --preset and --rate to conflict")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(limits, []string{"4M", "0"}) {
+		t.Fatalf("speed limits sent = %v, want [4M 0]", limits)
+	}
+}
+
+func TestSpeedLimitUntilEmpty(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		queueSizes []int
+		cancelAt   int
+		failAt     int
+		wantLimits []string
+		wantErr    string
+	}{
+		{name: "removed once queue empties", queueSizes: []int{2, 1, 0}, wantLimits: []string{"2M", "0"}},
+		{name: "transient queue error keeps polling", queueSizes: []int{2, 1, 1, 0}, failAt: 2, wantLimits: []string{"2M", "0"}},
+		{name: "interrupt leaves limit", queueSizes: []int{2, 2, 2}, cancelAt: 2, wantLimits: []string{"2M"}, wantErr: "speed limit 2M left in place"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var mu sync.Mutex
+			var limits []string
+			polls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				q := r.URL.Query()
+				switch q.Get("mode") {
+				case "config":
+					if q.Get("name") != "speedlimit" {
+						t.Errorf("unexpected config call %v", q)
+					}
+					limits = append(limits, q.Get("value"))
+					_, _ = w.Write([]byte(`{"status": true}`))
+				case "queue":
+					size := tt.queueSizes[len(tt.queueSizes)-1]
+					if polls < len(tt.queueSizes) {
+						size = tt.queueSizes[polls]
+					}
+					polls++
+					if polls == tt.cancelAt {
+						cancel()
+					}
+					if polls == tt.failAt {
+						w.WriteHeader(http.StatusBadGateway)
+						return
+					}
+					slots := make([]sabapi.QueueSlot, size)
+					for i := range slots {
+						slots[i].NZOID = "SABnzbd_nzo_" + strings.Repeat("x", i+1)
+					}
+					_ = json.NewEncoder(w).Encode(map[string]any{"queue": map[string]any{"slots": slots}})
+				default:
+					t.Errorf("unexpected mode %q", q.Get("mode"))
+				}
+			}))
+			t.Cleanup(server.Close)
+			client, err := sabapi.NewClient(server.URL, "apikey")
+			if err != nil {
+				t.Fatalf("NewClient returned error: %v", err)
+			}
+
+			var out, errOut bytes.Buffer
+			cmd := speedLimitCmd()
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			cmd.SetContext(cobraext.WithApp(ctx, &cobraext.App{
+				Client:  client,
+				Printer: &output.Printer{JSON: true, Out: &out, Err: &errOut},
+			}))
+			cmd.SetArgs([]string{"--until-empty", "2MB/s", "--interval", "1ms"})
+			err = cmd.Execute()
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !reflect.DeepEqual(limits, tt.wantLimits) {
+				t.Fatalf("speed limits sent = %v, want %v", limits, tt.wantLimits)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("speed limit --until-empty returned error: %v", err)
+			}
+			if polls != len(tt.queueSizes) {
+				t.Fatalf("expected %d queue polls, got %d", len(tt.queueSizes), polls)
+			}
+			if tt.failAt > 0 && !strings.Contains(errOut.String(), "Queue poll failed") {
+				t.Fatalf("expected the failed poll on stderr, got %q", errOut.String())
+			}
+			var payload map[string]any
+			if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+				t.Fatalf("decode output: %v\n%s", err, out.String())
+			}
+			if payload["until_empty"] != true || payload["limit"] != nil {
+				t.Fatalf("unexpected payload %v", payload)
+			}
+		})
+	}
+}
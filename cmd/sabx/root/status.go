@@ -1,12 +1,17 @@
 package root
 
 import (
+	"errors"
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/output"
 	"github.com/avivsinai/sabx/internal/sabapi"
 )
 
@@ -29,11 +34,22 @@ func statusCmd() *cobra.Command {
 	var full bool
 	var performance bool
 	var skipDashboard bool
+	var failOnWarnings bool
+	var warningTypes []string
+	var queueOnly bool
+	var daemonOnly bool
+	var withHistoryCounts bool
+	var historyWindow string
+	var sortServers string
 
 	cmd := &cobra.Command{
 		Use:   "status",
 		Short: jsonShort("Show global SABnzbd status"),
-		Long:  appendJSONLong("Summarize SABnzbd's queue and daemon status. Use --full for fullstatus payloads and --performance to include calculated metrics."),
+		Long: appendJSONLong("Summarize SABnzbd's queue and daemon status; --output logfmt renders the payload as flat key=value pairs. Use --full for fullstatus payloads and --performance to include calculated metrics. " +
+			"--queue-only skips the daemon status call and --daemon-only skips the queue call for lightweight polling; skipped sections are omitted from JSON. " +
+			"--full lists each news server with its current speed; --sort-servers speed puts the fastest first. " +
+			"--with-history-counts appends completed/failed counts for jobs finished within --history-window (one extra history call). " +
+			"With --fail-on-warnings the command exits with status 3 when SABnzbd has active warnings (optionally only those of --warning-type), for CI and monitoring gates."),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			app, err := getApp(cmd)
 			if err != nil {
@@ -43,16 +59,57 @@ func statusCmd() *cobra.Command {
 				return fmt.Errorf("not logged in; run 'sabx login'")
 			}
 
+			if queueOnly && daemonOnly {
+				return errors.New("--queue-only and --daemon-only are mutually exclusive")
+			}
+			if (queueOnly || daemonOnly) && full {
+				return errors.New("--queue-only and --daemon-only cannot be combined with --full or --performance")
+			}
+			window, err := parseAgeDuration(historyWindow)
+			if err != nil {
+				return fmt.Errorf("invalid --history-window: %w", err)
+			}
+			sortServers = strings.ToLower(strings.TrimSpace(sortServers))
+			if sortServers != "name" && sortServers != "speed" {
+				return fmt.Errorf("unsupported --sort-servers %q (expected name or speed)", sortServers)
+			}
+
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 
-			queue, err := app.Client.Queue(ctx, 0, 0, "")
-			if err != nil {
-				return err
+			var queue *sabapi.QueueResponse
+			if !daemonOnly {
+				queue, err = app.Client.Queue(ctx, 0, 0, "")
+				if err != nil {
+					return err
+				}
 			}
-			status, err := app.Client.Status(ctx)
-			if err != nil {
-				return err
+			var status *sabapi.StatusResponse
+			if !queueOnly {
+				status, err = app.Client.Status(ctx)
+				if err != nil {
+					return err
+				}
+			}
+
+			var gated []sabapi.Warning
+			if failOnWarnings {
+				warnings, err := app.Client.Warnings(ctx)
+				if err != nil {
+					return err
+				}
+				gated = filterWarnings(warnings, warningTypes)
+			}
+
+			var counts *historyCounts
+			if withHistoryCounts {
+				history, err := app.Client.History(ctx, false, historyCountsFetchLimit)
+				if err != nil {
+					return err
+				}
+				c := countHistory(history.Slots, time.Now().Add(-window))
+				c.Window = historyWindow
+				counts = &c
 			}
 
 			var fullStatus map[string]any
@@ -67,27 +124,47 @@ func statusCmd() *cobra.Command {
 				}
 			}
 
-			if app.Printer.JSON {
-				payload := map[string]any{
-					"profile":      app.ProfileName,
-					"base_url":     app.BaseURL,
-					"queue_slots":  queue.Slots,
-					"queue_status": queue.Status,
-					"paused":       queue.Paused,
-					"speed_kbps":   queue.Speed,
-					"speed_limit":  queue.SpeedLimit,
-					"size_mb":      queue.SizeMB,
-					"mbleft":       queue.MBLeft,
-					"timeleft":     queue.TimeLeft,
-					"status":       status,
+			if app.Printer.Structured() {
+				payload := statusPayload{
+					Profile:  app.ProfileName,
+					BaseURL:  app.BaseURL,
+					Status:   status,
+					Warnings: gated,
+					History:  counts,
+				}
+				if queue != nil {
+					payload.statusQueue = &statusQueue{
+						QueueSlots:  queue.Slots,
+						QueueStatus: queue.Status,
+						Paused:      queue.Paused,
+						SpeedKBps:   queue.Speed,
+						SpeedLimit:  queue.SpeedLimit,
+						SizeMB:      queue.SizeMB,
+						MBLeft:      queue.MBLeft,
+						TimeLeft:    queue.TimeLeft,
+					}
 				}
 				if fullStatus != nil {
-					payload["full_status"] = fullStatus
-					if servers, err := app.Client.ServerConfigs(ctx); err == nil {
-						payload["servers"] = servers
+					payload.FullStatus = fullStatus
+					if servers, err := app.ServerConfigs(ctx); err == nil {
+						payload.Servers = servers
 					}
 				}
-				return app.Printer.Print(payload)
+				if err := app.Printer.Print(payload); err != nil {
+					return err
+				}
+				return warningsGateError(app.Printer, gated)
+			}
+
+			if daemonOnly {
+				state := "running"
+				if status.Paused {
+					state = "paused"
+				}
+				if err := app.Printer.Print(fmt.Sprintf("SABnzbd %s: %s | Speed %s KB/s (limit %s)", status.Version, state, status.Speed, status.SpeedLimit) + counts.summary()); err != nil {
+					return err
+				}
+				return warningsGateError(app.Printer, gated)
 			}
 
 			rows := [][]string{}
@@ -107,24 +184,31 @@ func statusCmd() *cobra.Command {
 			}
 
 			summary := fmt.Sprintf("Queue: %d items | Speed %s KB/s (limit %s) | Time left %s",
-				len(queue.Slots), queue.Speed, queue.SpeedLimit, queue.TimeLeft)
+				len(queue.Slots), queue.Speed, queue.SpeedLimit, queue.TimeLeft) + counts.summary()
 			if err := app.Printer.Print(summary); err != nil {
 				return err
 			}
 
 			if fullStatus != nil {
-				if err := renderFullStatus(cmd, app, fullStatus); err != nil {
+				if err := renderFullStatus(cmd, app, fullStatus, sortServers); err != nil {
 					return err
 				}
 			}
 
-			return nil
+			return warningsGateError(app.Printer, gated)
 		},
 	}
 
 	cmd.Flags().BoolVar(&full, "full", false, "Include comprehensive status data from SABnzbd")
 	cmd.Flags().BoolVar(&performance, "performance", false, "Calculate performance metrics (implies --full)")
 	cmd.Flags().BoolVar(&skipDashboard, "skip-dashboard", false, "Skip dashboard network diagnostics (with --full)")
+	cmd.Flags().StringVar(&sortServers, "sort-servers", "name", "Order the --full server table by name or speed (fastest first)")
+	cmd.Flags().BoolVar(&queueOnly, "queue-only", false, "Only query the queue (skip the daemon status call)")
+	cmd.Flags().BoolVar(&daemonOnly, "daemon-only", false, "Only query daemon status (skip the queue call)")
+	cmd.Flags().BoolVar(&withHistoryCounts, "with-history-counts", false, "Append completed/failed history counts to the summary")
+	cmd.Flags().StringVar(&historyWindow, "history-window", "24h", "Only count history entries completed within this window (e.g. 24h, 7d)")
+	cmd.Flags().BoolVar(&failOnWarnings, "fail-on-warnings", false, "Exit with status 3 if SABnzbd reports active warnings")
+	cmd.Flags().StringSliceVar(&warningTypes, "warning-type", nil, "Only gate on these warning types, e.g. WARNING,ERROR (with --fail-on-warnings)")
 
 	cmd.PreRun = func(cmd *cobra.Command, args []string) {
 		if performance {
@@ -137,7 +221,101 @@ func statusCmd() *cobra.Command {
 	return cmd
 }
 
-func renderFullStatus(cmd *cobra.Command, app *cobraext.App, data map[string]any) error {
+// statusPayload is the JSON contract for `status`. The queue section is
+// omitted with --daemon-only and the status section with --queue-only.
+type statusPayload struct {
+	Profile string `json:"profile"`
+	BaseURL string `json:"base_url"`
+	*statusQueue
+	Status     *sabapi.StatusResponse `json:"status,omitempty"`
+	FullStatus map[string]any         `json:"full_status,omitempty"`
+	Servers    []sabapi.ServerConfig  `json:"servers,omitempty"`
+	Warnings   []sabapi.Warning       `json:"warnings,omitempty"`
+	History    *historyCounts         `json:"history_counts,omitempty"`
+}
+
+// historyCountsFetchLimit caps the history fetched for --with-history-counts;
+// SABnzbd returns newest entries first, so this covers any sensible window.
+const historyCountsFetchLimit = 200
+
+// historyCounts summarizes recently finished jobs for `status`.
+type historyCounts struct {
+	Window    string `json:"window"`
+	Completed int    `json:"completed"`
+	Failed    int    `json:"failed"`
+}
+
+// countHistory counts completed and failed entries finished at or after
+// cutoff. Entries without a completion time are still post-processing.
+func countHistory(slots []sabapi.HistorySlot, cutoff time.Time) historyCounts {
+	var counts historyCounts
+	for _, slot := range slots {
+		completed := slot.Completed.Time()
+		if completed.IsZero() || completed.Before(cutoff) {
+			continue
+		}
+		switch {
+		case strings.EqualFold(slot.Status, "Completed"):
+			counts.Completed++
+		case strings.EqualFold(slot.Status, "Failed"):
+			counts.Failed++
+		}
+	}
+	return counts
+}
+
+// summary renders the counts as a suffix for the status line; nil renders
+// nothing so callers can append it unconditionally.
+func (c *historyCounts) summary() string {
+	if c == nil {
+		return ""
+	}
+	return fmt.Sprintf(" | History (%s): %d completed, %d failed", c.Window, c.Completed, c.Failed)
+}
+
+// statusQueue holds the queue fields of statusPayload.
+type statusQueue struct {
+	QueueSlots  []sabapi.QueueSlot `json:"queue_slots"`
+	QueueStatus string             `json:"queue_status"`
+	Paused      bool               `json:"paused"`
+	SpeedKBps   string             `json:"speed_kbps"`
+	SpeedLimit  string             `json:"speed_limit"`
+	SizeMB      string             `json:"size_mb"`
+	MBLeft      string             `json:"mbleft"`
+	TimeLeft    string             `json:"timeleft"`
+}
+
+// filterWarnings keeps warnings whose type matches one of types
+// (case-insensitive). No types keeps every warning.
+func filterWarnings(warnings []sabapi.Warning, types []string) []sabapi.Warning {
+	if len(types) == 0 {
+		return warnings
+	}
+	matched := make([]sabapi.Warning, 0, len(warnings))
+	for _, w := range warnings {
+		for _, t := range types {
+			if strings.EqualFold(strings.TrimSpace(t), w.Type) {
+				matched = append(matched, w)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// warningsGateError reports gated warnings on stderr and returns an error
+// carrying exitCodeWarnings, or nil when there is nothing to gate on.
+func warningsGateError(printer *output.Printer, warnings []sabapi.Warning) error {
+	if len(warnings) == 0 {
+		return nil
+	}
+	for _, w := range warnings {
+		printer.AlwaysError("%s: %s", w.Type, strings.ReplaceAll(w.Text, "\n", " "))
+	}
+	return &exitError{code: exitCodeWarnings, err: fmt.Errorf("%d active SABnzbd warnings", len(warnings))}
+}
+
+func renderFullStatus(cmd *cobra.Command, app *cobraext.App, data map[string]any, sortServers string) error {
 	infoRows := [][]string{}
 	addRow := func(label string, value any) {
 		if value == nil {
@@ -166,17 +344,20 @@ func renderFullStatus(cmd *cobra.Command, app *cobraext.App, data map[string]any
 		return nil
 	}
 
-	sort.Slice(serverEntries, func(i, j int) bool {
-		return serverEntries[i].Name < serverEntries[j].Name
-	})
+	sortServerEntries(serverEntries, sortServers)
 
-	headers := []string{"Server", "Active", "Connections", "SSL", "Warning", "Error"}
+	headers := []string{"Server", "Active", "Connections", "Speed", "SSL", "Warning", "Error"}
 	rows := make([][]string, 0, len(serverEntries))
 	for _, srv := range serverEntries {
+		speed := "-"
+		if srv.HasSpeed {
+			speed = humanBytes(srv.SpeedBPS) + "/s"
+		}
 		rows = append(rows, []string{
 			srv.Name,
 			boolToStr(srv.Active),
 			fmt.Sprintf("%d/%d", srv.ActiveConn, srv.TotalConn),
+			speed,
 			boolToStr(srv.SSL),
 			srv.Warning,
 			srv.Error,
@@ -193,6 +374,59 @@ type statusServerEntry struct {
 	SSL        bool
 	Warning    string
 	Error      string
+	SpeedBPS   float64
+	HasSpeed   bool
+}
+
+// sortServerEntries orders servers by name, or by speed with the fastest
+// first when by is "speed". Servers without a reported speed sort last and
+// ties fall back to the name.
+func sortServerEntries(entries []statusServerEntry, by string) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if by == "speed" {
+			if a.HasSpeed != b.HasSpeed {
+				return a.HasSpeed
+			}
+			if a.SpeedBPS != b.SpeedBPS {
+				return a.SpeedBPS > b.SpeedBPS
+			}
+		}
+		return a.Name < b.Name
+	})
+}
+
+var serverSpeedUnits = map[string]float64{"": 1, "K": 1 << 10, "M": 1 << 20, "G": 1 << 30, "T": 1 << 40}
+
+// serverSpeedFrom reads fullstatus serverbps, which SABnzbd reports either
+// as a number of bytes per second or as a string with a 1024-based unit
+// suffix such as "1.5 M". ok is false when the field is missing or not a
+// speed.
+func serverSpeedFrom(val any) (bps float64, ok bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, v >= 0
+	case string:
+		numPart, unitPart := splitRate(strings.ReplaceAll(strings.TrimSpace(v), " ", ""))
+		if numPart == "" {
+			return 0, false
+		}
+		n, err := strconv.ParseFloat(numPart, 64)
+		if err != nil {
+			return 0, false
+		}
+		unit := strings.ToUpper(unitPart)
+		for _, suffix := range []string{"/S", "PS", "B"} {
+			unit = strings.TrimSuffix(unit, suffix)
+		}
+		factor, known := serverSpeedUnits[unit]
+		if !known {
+			return 0, false
+		}
+		return n * factor, true
+	default:
+		return 0, false
+	}
 }
 
 func serversFromFullStatus(val any) ([]statusServerEntry, error) {
@@ -203,7 +437,7 @@ func serversFromFullStatus(val any) ([]statusServerEntry, error) {
 		if !ok {
 			continue
 		}
-		results = append(results, statusServerEntry{
+		entry := statusServerEntry{
 			Name:       fmt.Sprint(m["servername"]),
 			Active:     boolFrom(m["serveractive"]),
 			ActiveConn: intFrom(m["serveractiveconn"]),
@@ -211,7 +445,9 @@ func serversFromFullStatus(val any) ([]statusServerEntry, error) {
 			SSL:        boolFrom(m["serverssl"]),
 			Warning:    fmt.Sprint(m["serverwarning"]),
 			Error:      fmt.Sprint(m["servererror"]),
-		})
+		}
+		entry.SpeedBPS, entry.HasSpeed = serverSpeedFrom(m["serverbps"])
+		results = append(results, entry)
 	}
 	return results, nil
 }
@@ -251,9 +487,8 @@ func intFrom(val any) int {
 	case int:
 		return v
 	case string:
-		var n int
-		_, _ = fmt.Sscanf(v, "%d", &n)
-		return n
+		n, _ := sabapi.ParseSABInt(v)
+		return int(n)
 	default:
 		return 0
 	}
@@ -0,0 +1,247 @@
+package root
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+func newStatusTestApp(t *testing.T, warnings string) (*cobraext.App, *bytes.Buffer) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("mode") {
+		case "queue":
+			_, _ = w.Write([]byte(`{"queue":{"slots":[],"speed":"0","timeleft":"0:00:00"}}`))
+		case "warnings":
+			_, _ = w.Write([]byte(`{"warnings":` + warnings + `}`))
+		default:
+			_, _ = w.Write([]byte(`{"status":true}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	var errOut bytes.Buffer
+	return &cobraext.App{Client: client, Printer: &output.Printer{Quiet: true, Out: &bytes.Buffer{}, Err: &errOut}}, &errOut
+}
+
+func TestStatusFailOnWarnings(t *testing.T) {
+	t.Parallel()
+
+	const active = `[{"type":"WARNING","text":"Disk almost full","time":1700000000},{"type":"ERROR","text":"Server unreachable","time":1700000001}]`
+
+	tests := []struct {
+		name     string
+		warnings string
+		args     []string
+		wantCode int
+		wantErr  string
+	}{
+		{name: "no flag ignores warnings", warnings: active, args: nil, wantCode: 0},
+		{name: "no warnings passes", warnings: `[]`, args: []string{"--fail-on-warnings"}, wantCode: 0},
+		{name: "warnings fail", warnings: active, args: []string{"--fail-on-warnings"}, wantCode: exitCodeWarnings, wantErr: "Disk almost full"},
+		{name: "type filter matches", warnings: active, args: []string{"--fail-on-warnings", "--warning-type", "error"}, wantCode: exitCodeWarnings, wantErr: "Server unreachable"},
+		{name: "type filter excludes", warnings: active, args: []string{"--fail-on-warnings", "--warning-type", "INFO"}, wantCode: 0},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			app, errOut := newStatusTestApp(t, tt.warnings)
+			cmd := statusCmd()
+			cmd.SetContext(cobraext.WithApp(context.Background(), app))
+			cmd.SetArgs(tt.args)
+			err := cmd.Execute()
+			if got := ExitCode(err); got != tt.wantCode {
+				t.Fatalf("exit code = %d, want %d (err %v)", got, tt.wantCode, err)
+			}
+			if tt.wantErr != "" && !strings.Contains(errOut.String(), tt.wantErr) {
+				t.Fatalf("expected %q on stderr under --quiet, got %q", tt.wantErr, errOut.String())
+			}
+		})
+	}
+}
+
+func TestExitCodeDefaultsToOne(t *testing.T) {
+	t.Parallel()
+
+	if got := ExitCode(errors.New("boom")); got != 1 {
+		t.Fatalf("expected 1 for plain errors, got %d", got)
+	}
+	if got := ExitCode(nil); got != 0 {
+		t.Fatalf("expected 0 for nil, got %d", got)
+	}
+}
+
+func TestStatusSectionFlagsSkipCalls(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantModes  []string
+		wantKeys   []string
+		absentKeys []string
+		wantErr    string
+	}{
+		{name: "default queries both", args: nil, wantModes: []string{"queue", "status"}, wantKeys: []string{`"queue_slots"`, `"status"`}, absentKeys: []string{`"history_counts"`}},
+		{name: "history counts", args: []string{"--with-history-counts"}, wantModes: []string{"queue", "status", "history"}, wantKeys: []string{`"history_counts"`, `"window": "24h"`}},
+		{name: "invalid history window", args: []string{"--with-history-counts", "--history-window", "soon"}, wantErr: "--history-window"},
+		{name: "queue only", args: []string{"--queue-only"}, wantModes: []string{"queue"}, wantKeys: []string{`"queue_slots"`}, absentKeys: []string{`"status"`}},
+		{name: "daemon only", args: []string{"--daemon-only"}, wantModes: []string{"status"}, wantKeys: []string{`"status"`}, absentKeys: []string{`"queue_slots"`, `"timeleft"`}},
+		{name: "mutually exclusive", args: []string{"--queue-only", "--daemon-only"}, wantErr: "mutually exclusive"},
+		{name: "incompatible with full", args: []string{"--daemon-only", "--full"}, wantErr: "--full"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var mu sync.Mutex
+			var modes []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mode := r.URL.Query().Get("mode")
+				mu.Lock()
+				modes = append(modes, mode)
+				mu.Unlock()
+				w.Header().Set("Content-Type", "application/json")
+				if mode == "queue" {
+					_, _ = w.Write([]byte(`{"queue":{"slots":[],"speed":"0","timeleft":"0:00:00"}}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"version":"4.3.0","paused":false,"kbpersec":"0"}`))
+			}))
+			t.Cleanup(server.Close)
+
+			client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+			if err != nil {
+				t.Fatalf("NewClient returned error: %v", err)
+			}
+			var out bytes.Buffer
+			app := &cobraext.App{Client: client, Printer: &output.Printer{JSON: true, Out: &out, Err: &bytes.Buffer{}}}
+
+			cmd := statusCmd()
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			cmd.SetContext(cobraext.WithApp(context.Background(), app))
+			cmd.SetArgs(tt.args)
+			err = cmd.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				if len(modes) != 0 {
+					t.Fatalf("expected no API calls, got %v", modes)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("status returned error: %v", err)
+			}
+			if strings.Join(modes, ",") != strings.Join(tt.wantModes, ",") {
+				t.Fatalf("API modes = %v, want %v", modes, tt.wantModes)
+			}
+			for _, key := range tt.wantKeys {
+				if !strings.Contains(out.String(), key) {
+					t.Fatalf("expected %s in payload, got %s", key, out.String())
+				}
+			}
+			for _, key := range tt.absentKeys {
+				if strings.Contains(out.String(), key) {
+					t.Fatalf("expected %s omitted from payload, got %s", key, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestCountHistory(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	ago := func(h int) sabapi.UnixTime { return sabapi.UnixTime(now.Add(-time.Duration(h) * time.Hour).Unix()) }
+	slots := []sabapi.HistorySlot{
+		{Status: "Completed", Completed: ago(1)},
+		{Status: "completed", Completed: ago(5)},
+		{Status: "Failed", Completed: ago(2)},
+		{Status: "Extracting"},
+		{Status: "Completed", Completed: ago(30)},
+		{Status: "Failed", Completed: ago(48)},
+	}
+
+	got := countHistory(slots, now.Add(-24*time.Hour))
+	if got.Completed != 2 || got.Failed != 1 {
+		t.Fatalf("countHistory = %+v, want 2 completed and 1 failed", got)
+	}
+	got.Window = "24h"
+	if summary := got.summary(); summary != " | History (24h): 2 completed, 1 failed" {
+		t.Fatalf("unexpected summary %q", summary)
+	}
+	var none *historyCounts
+	if none.summary() != "" {
+		t.Fatal("expected no summary without --with-history-counts")
+	}
+}
+
+func TestServersFromFullStatusSpeed(t *testing.T) {
+	t.Parallel()
+
+	raw := []any{
+		map[string]any{"servername": "slow", "serverbps": "512 K", "serveractiveconn": 2.0, "servertotalconn": 8.0},
+		map[string]any{"servername": "fast", "serverbps": "1.5 M"},
+		map[string]any{"servername": "numeric", "serverbps": 2048.0},
+		map[string]any{"servername": "idle"},
+		map[string]any{"servername": "garbled", "serverbps": "n/a"},
+	}
+	entries, err := serversFromFullStatus(raw)
+	if err != nil {
+		t.Fatalf("serversFromFullStatus returned error: %v", err)
+	}
+	want := map[string]struct {
+		bps float64
+		ok  bool
+	}{
+		"slow":    {bps: 512 << 10, ok: true},
+		"fast":    {bps: 1.5 * (1 << 20), ok: true},
+		"numeric": {bps: 2048, ok: true},
+		"idle":    {},
+		"garbled": {},
+	}
+	for _, e := range entries {
+		w := want[e.Name]
+		if e.HasSpeed != w.ok || e.SpeedBPS != w.bps {
+			t.Fatalf("%s: speed = %v (known %v), want %v (known %v)", e.Name, e.SpeedBPS, e.HasSpeed, w.bps, w.ok)
+		}
+	}
+
+	sortServerEntries(entries, "speed")
+	var order []string
+	for _, e := range entries {
+		order = append(order, e.Name)
+	}
+	if got := strings.Join(order, ","); got != "fast,slow,numeric,garbled,idle" {
+		t.Fatalf("speed order = %s", got)
+	}
+	sortServerEntries(entries, "name")
+	if entries[0].Name != "fast" || entries[len(entries)-1].Name != "slow" {
+		t.Fatalf("name order = %+v", entries)
+	}
+}
@@ -3,16 +3,22 @@ package root
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/avivsinai/sabx/internal/sabapi"
 	"github.com/avivsinai/sabx/internal/ui/top"
 )
 
 func topCmd() *cobra.Command {
+	var minInterval time.Duration
 	cmd := &cobra.Command{
 		Use:   "top",
 		Short: jsonShort("Interactive dashboard for SABnzbd queues"),
+		Long: "Interactive dashboard for SABnzbd queues.\n\n" +
+			"Keys: q quits, h shows or hides the history panel, tab switches focus between queue and history, " +
+			"and +/- show more or fewer rows in the focused panel.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			app, err := getApp(cmd)
 			if err != nil {
@@ -21,10 +27,12 @@ func topCmd() *cobra.Command {
 			if app.Client == nil {
 				return fmt.Errorf("not logged in; run 'sabx login'")
 			}
+			client := app.Client.Clone(sabapi.WithMinInterval(minInterval))
 			ctx, cancel := context.WithCancel(cmd.Context())
 			defer cancel()
-			return top.Run(ctx, app.Client)
+			return top.Run(ctx, client)
 		},
 	}
+	cmd.Flags().DurationVar(&minInterval, "min-interval", defaultPollMinInterval, "Minimum delay between SABnzbd requests while polling")
 	return cmd
 }
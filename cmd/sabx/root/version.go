@@ -12,6 +12,7 @@ import (
 )
 
 func versionCmd() *cobra.Command {
+	var field string
 	cmd := &cobra.Command{
 		Use:   "version",
 		Short: jsonShort("Print sabx version information"),
@@ -24,6 +25,9 @@ func versionCmd() *cobra.Command {
 			printer := output.New()
 			printer.JSON = jsonFlag
 			printer.Quiet = quietFlag
+			if field != "" {
+				return printer.PrintField(info, field)
+			}
 			if printer.JSON {
 				return printer.Print(info)
 			}
@@ -31,6 +35,7 @@ func versionCmd() *cobra.Command {
 			return nil
 		},
 	}
+	cmd.Flags().StringVar(&field, "field", "", "Print only this JSON field (version, commit or date)")
 	return cmd
 }
 
@@ -2,10 +2,13 @@ package root
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/avivsinai/sabx/internal/sabapi"
 )
 
 func warningsCmd() *cobra.Command {
@@ -19,27 +22,43 @@ func warningsCmd() *cobra.Command {
 }
 
 func warningsListCmd() *cobra.Command {
+	var (
+		warningType string
+		limit       int
+	)
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: jsonShort("List current warnings"),
+		Long:  appendJSONLong("List stored warnings, newest first. --type keeps only warnings or only errors and --limit caps how many are shown."),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			app, err := getApp(cmd)
 			if err != nil {
 				return err
 			}
+			warningType = strings.ToLower(strings.TrimSpace(warningType))
+			switch warningType {
+			case "", "warning", "error":
+			default:
+				return fmt.Errorf("unsupported --type %q (expected warning or error)", warningType)
+			}
 
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 
-			warnings, err := app.Client.Warnings(ctx)
+			all, err := app.Client.Warnings(ctx)
 			if err != nil {
 				return err
 			}
+			warnings := selectWarnings(all, warningType, limit)
 
+			if app.Printer.NDJSON {
+				return app.Printer.PrintLines(warnings)
+			}
 			if app.Printer.JSON {
 				payload := map[string]any{
 					"warnings": warnings,
 					"count":    len(warnings),
+					"total":    len(all),
 				}
 				return app.Printer.Print(payload)
 			}
@@ -61,12 +80,36 @@ func warningsListCmd() *cobra.Command {
 			if err := app.Printer.Table(headers, rows); err != nil {
 				return err
 			}
+			if len(warnings) < len(all) {
+				return app.Printer.Print(fmt.Sprintf("%d of %d warnings", len(warnings), len(all)))
+			}
 			return app.Printer.Print(fmt.Sprintf("%d warnings", len(warnings)))
 		},
 	}
+	cmd.Flags().StringVar(&warningType, "type", "", "Only show entries of this type: warning or error")
+	cmd.Flags().IntVar(&limit, "limit", 0, "Show at most this many entries (0 for all)")
 	return cmd
 }
 
+// selectWarnings keeps entries whose type matches warningType (any type when
+// empty), newest first, capped at limit when it is positive. SABnzbd reports
+// types in upper case, so the match ignores case.
+func selectWarnings(warnings []sabapi.Warning, warningType string, limit int) []sabapi.Warning {
+	selected := make([]sabapi.Warning, 0, len(warnings))
+	for _, w := range warnings {
+		if warningType == "" || strings.EqualFold(w.Type, warningType) {
+			selected = append(selected, w)
+		}
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].Time > selected[j].Time
+	})
+	if limit > 0 && len(selected) > limit {
+		selected = selected[:limit]
+	}
+	return selected
+}
+
 func warningsClearCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "clear",
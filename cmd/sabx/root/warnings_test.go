@@ -0,0 +1,119 @@
+package root
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/avivsinai/sabx/internal/cobraext"
+	"github.com/avivsinai/sabx/internal/output"
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+func TestSelectWarnings(t *testing.T) {
+	t.Parallel()
+
+	warnings := []sabapi.Warning{
+		{Type: "WARNING", Text: "old warning", Time: 100},
+		{Type: "ERROR", Text: "new error", Time: 300},
+		{Type: "WARNING", Text: "new warning", Time: 400},
+		{Type: "ERROR", Text: "old error", Time: 200},
+	}
+	tests := []struct {
+		name        string
+		warningType string
+		limit       int
+		want        []string
+	}{
+		{name: "all newest first", want: []string{"new warning", "new error", "old error", "old warning"}},
+		{name: "errors only", warningType: "error", want: []string{"new error", "old error"}},
+		{name: "warnings only", warningType: "warning", want: []string{"new warning", "old warning"}},
+		{name: "limit after sort", limit: 2, want: []string{"new warning", "new error"}},
+		{name: "limit above count", warningType: "error", limit: 5, want: []string{"new error", "old error"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := []string{}
+			for _, w := range selectWarnings(warnings, tt.warningType, tt.limit) {
+				got = append(got, w.Text)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("selectWarnings(%q, %d) = %q, want %q", tt.warningType, tt.limit, got, tt.want)
+			}
+		})
+	}
+	if warnings[0].Text != "old warning" {
+		t.Fatal("selectWarnings must not reorder its input")
+	}
+}
+
+func TestWarningsListTypeFilterJSON(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"warnings":[{"type":"ERROR","text":"first","time":10},{"type":"WARNING","text":"noise","time":20},{"type":"ERROR","text":"second","time":30}]}`))
+	}))
+	t.Cleanup(server.Close)
+	client, err := sabapi.NewClient(server.URL, "secret")
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	tests := []struct {
+		args    []string
+		want    []string
+		wantErr string
+	}{
+		{args: []string{"--type", "ERROR"}, want: []string{"second", "first"}},
+		{args: []string{"--type", "error", "--limit", "1"}, want: []string{"second"}},
+		{args: []string{"--type", "info"}, wantErr: "unsupported --type"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			cmd := warningsListCmd()
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			cmd.SetContext(cobraext.WithApp(context.Background(), &cobraext.App{
+				Client:  client,
+				Printer: &output.Printer{Out: &out, Err: &bytes.Buffer{}, JSON: true},
+			}))
+			cmd.SetArgs(tt.args)
+			err := cmd.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("warnings list returned error: %v", err)
+			}
+
+			var payload struct {
+				Warnings []sabapi.Warning `json:"warnings"`
+				Count    int              `json:"count"`
+				Total    int              `json:"total"`
+			}
+			if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+				t.Fatalf("decode output: %v\n%s", err, out.String())
+			}
+			got := []string{}
+			for _, w := range payload.Warnings {
+				got = append(got, w.Text)
+			}
+			if !reflect.DeepEqual(got, tt.want) || payload.Count != len(tt.want) || payload.Total != 3 {
+				t.Fatalf("got %q (count %d, total %d), want %q of 3", got, payload.Count, payload.Total, tt.want)
+			}
+		})
+	}
+}
@@ -7,45 +7,64 @@ import (
 )
 
 func whoamiCmd() *cobra.Command {
+	var field string
 	cmd := &cobra.Command{
 		Use:   "whoami",
 		Short: jsonShort("Show the connected SABnzbd instance"),
+		Long:  appendJSONLong("Show the active profile, base URL and daemon state. Use --field to print a single dotted JSON field (e.g. --field version)."),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			app, err := getApp(cmd)
 			if err != nil {
 				return err
 			}
-			if app.Client == nil {
-				return fmt.Errorf("no active session; run 'sabx login'")
-			}
-
 			ctx, cancel := timeoutContext(cmd.Context())
 			defer cancel()
 
-			version, err := app.Client.Version(ctx)
+			version, err := lookupServerVersion(ctx, app, false)
 			if err != nil {
 				return err
 			}
+			if version.Offline {
+				payload := map[string]any{
+					"profile":  app.ProfileName,
+					"base_url": app.BaseURL,
+					"version":  version.Version,
+					"online":   false,
+					"cache":    version,
+				}
+				if field != "" {
+					return app.Printer.PrintField(payload, field)
+				}
+				if app.Printer.JSON {
+					return app.Printer.Print(payload)
+				}
+				return app.Printer.Print(fmt.Sprintf("%s (%s) unreachable: %s", app.BaseURL, version, version.Error))
+			}
 			status, err := app.Client.Status(ctx)
 			if err != nil {
 				return err
 			}
 
+			payload := map[string]any{
+				"profile":     app.ProfileName,
+				"base_url":    app.BaseURL,
+				"version":     version.Version,
+				"online":      true,
+				"paused":      status.Paused,
+				"speed_kbps":  status.Speed,
+				"speed_limit": status.SpeedLimit,
+			}
+			if field != "" {
+				return app.Printer.PrintField(payload, field)
+			}
 			if app.Printer.JSON {
-				payload := map[string]any{
-					"profile":     app.ProfileName,
-					"base_url":    app.BaseURL,
-					"version":     version.Version,
-					"paused":      status.Paused,
-					"speed_kbps":  status.Speed,
-					"speed_limit": status.SpeedLimit,
-				}
 				return app.Printer.Print(payload)
 			}
 
-			return app.Printer.Print(fmt.Sprintf("%s (%s) paused=%v speed=%sKB/s limit=%sKB/s", app.BaseURL, version.Version, status.Paused, status.Speed, status.SpeedLimit))
+			return app.Printer.Print(fmt.Sprintf("%s (%s) paused=%v speed=%sKB/s limit=%sKB/s", app.BaseURL, version, status.Paused, status.Speed, status.SpeedLimit))
 		},
 	}
+	cmd.Flags().StringVar(&field, "field", "", "Print only this dotted JSON field (e.g. version)")
 
 	return cmd
 }
@@ -25,6 +25,53 @@ const (
 // ErrNotFound is returned when the requested credential cannot be located.
 var ErrNotFound = os.ErrNotExist
 
+// ErrKeyringLocked is returned when a keyring backend exists but refused
+// access: the keychain is locked, the unlock prompt was dismissed, or the
+// encrypted file passphrase is wrong. It is distinct from IsNoKeyringError,
+// which means no backend is available at all.
+var ErrKeyringLocked = errors.New("keyring is locked or access was denied")
+
+// lockedMarkers are lower-cased fragments of the errors keyring backends
+// return when access is refused rather than impossible.
+var lockedMarkers = []string{
+	"islocked",                              // Secret Service org.freedesktop.Secret.Error.IsLocked
+	"locked object",                         // Secret Service "cannot get secret of a locked object"
+	"prompt dismissed",                      // Secret Service unlock prompt cancelled
+	"user interaction is not allowed",       // macOS keychain without a GUI session
+	"user canceled",                         // macOS keychain prompt cancelled
+	"passphrase you entered is not correct", // macOS wrong keychain password
+	"integrity check failed",                // file backend with the wrong passphrase
+	"access denied",
+	"permission denied",
+}
+
+// classifyKeyringError maps backend errors that mean "locked or denied" to
+// ErrKeyringLocked with unlock guidance; other errors pass through unchanged.
+func classifyKeyringError(err error) error {
+	if err == nil || errors.Is(err, ErrKeyringLocked) {
+		return err
+	}
+	locked := errors.Is(err, os.ErrPermission)
+	if !locked {
+		msg := strings.ToLower(err.Error())
+		for _, marker := range lockedMarkers {
+			if strings.Contains(msg, marker) {
+				locked = true
+				break
+			}
+		}
+	}
+	if !locked {
+		return err
+	}
+	return fmt.Errorf("%w: %v (unlock your keychain or set %s)", ErrKeyringLocked, err, envPassphrase)
+}
+
+// IsKeyringLockedError reports whether err means the keyring refused access.
+func IsKeyringLockedError(err error) bool {
+	return errors.Is(err, ErrKeyringLocked)
+}
+
 // Store manages credential persistence backed by the OS keyring or an
 // encrypted file fallback.
 type Store struct {
@@ -105,7 +152,7 @@ func Open(opts ...Option) (*Store, error) {
 		if errors.Is(err, keyring.ErrNoAvailImpl) && !usesFileBackend(cfg.AllowedBackends) {
 			return nil, fmt.Errorf("open keyring: %w (set %s=1 or rerun with --allow-insecure-store to permit encrypted file fallback)", err, envAllowInsecure)
 		}
-		return nil, fmt.Errorf("open keyring: %w", err)
+		return nil, fmt.Errorf("open keyring: %w", classifyKeyringError(err))
 	}
 
 	return &Store{kr: kr}, nil
@@ -117,11 +164,11 @@ func (s *Store) Save(profile, baseURL, apiKey string) error {
 		return errors.New("secret store not initialized")
 	}
 
-	return s.kr.Set(keyring.Item{
+	return classifyKeyringError(s.kr.Set(keyring.Item{
 		Key:   keyFor(profile, baseURL),
 		Data:  []byte(apiKey),
 		Label: fmt.Sprintf("sabx profile %s API key", sanitize(profile)),
-	})
+	}))
 }
 
 // Load retrieves a SABnzbd API key.
@@ -135,7 +182,7 @@ func (s *Store) Load(profile, baseURL string) (string, error) {
 		if errors.Is(err, keyring.ErrKeyNotFound) {
 			return "", ErrNotFound
 		}
-		return "", err
+		return "", classifyKeyringError(err)
 	}
 	return string(item.Data), nil
 }
@@ -150,7 +197,7 @@ func (s *Store) Delete(profile, baseURL string) error {
 	if errors.Is(err, keyring.ErrKeyNotFound) {
 		return nil
 	}
-	return err
+	return classifyKeyringError(err)
 }
 
 // IsNoKeyringError reports whether the provided error indicates that no native
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/99designs/keyring"
@@ -182,3 +183,58 @@ func TestErrNotFoundCompatibility(t *testing.T) {
 		t.Fatalf("ErrNotFound should match os.ErrNotExist")
 	}
 }
+
+// lockedKeyring simulates a backend that exists but refuses access.
+type lockedKeyring struct {
+	err error
+}
+
+func (k lockedKeyring) Get(string) (keyring.Item, error) { return keyring.Item{}, k.err }
+func (k lockedKeyring) GetMetadata(string) (keyring.Metadata, error) {
+	return keyring.Metadata{}, k.err
+}
+func (k lockedKeyring) Set(keyring.Item) error  { return k.err }
+func (k lockedKeyring) Remove(string) error     { return k.err }
+func (k lockedKeyring) Keys() ([]string, error) { return nil, k.err }
+
+func TestLockedKeyringErrorsMapToErrKeyringLocked(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		err        error
+		wantLocked bool
+	}{
+		{name: "secret service locked", err: errors.New("org.freedesktop.Secret.Error.IsLocked: Cannot get secret of a locked object"), wantLocked: true},
+		{name: "secret service prompt dismissed", err: errors.New("failed to unlock correct collection '/org/freedesktop/secrets/aliases/default': prompt dismissed"), wantLocked: true},
+		{name: "macOS no GUI session", err: errors.New("User interaction is not allowed. (-25308)"), wantLocked: true},
+		{name: "file backend wrong passphrase", err: errors.New("aes.KeyUnwrap(): integrity check failed."), wantLocked: true},
+		{name: "permission error", err: &os.PathError{Op: "open", Path: "/keys/sabx", Err: os.ErrPermission}, wantLocked: true},
+		{name: "generic failure", err: errors.New("dbus: connection closed"), wantLocked: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			store := &Store{kr: lockedKeyring{err: tt.err}}
+
+			_, loadErr := store.Load("home", "http://sab.local")
+			saveErr := store.Save("home", "http://sab.local", "key")
+			for _, err := range []error{loadErr, saveErr} {
+				if got := IsKeyringLockedError(err); got != tt.wantLocked {
+					t.Fatalf("IsKeyringLockedError(%v) = %v, want %v", err, got, tt.wantLocked)
+				}
+				if IsNoKeyringError(err) {
+					t.Fatalf("locked/denied errors must not look like a missing backend: %v", err)
+				}
+			}
+			if tt.wantLocked && !strings.Contains(loadErr.Error(), envPassphrase) {
+				t.Fatalf("expected unlock guidance in %q", loadErr)
+			}
+		})
+	}
+
+	if _, err := (&Store{kr: lockedKeyring{err: keyring.ErrKeyNotFound}}).Load("home", "http://sab.local"); !errors.Is(err, ErrNotFound) || IsKeyringLockedError(err) {
+		t.Fatalf("missing key must stay ErrNotFound, got %v", err)
+	}
+}
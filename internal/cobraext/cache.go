@@ -0,0 +1,80 @@
+package cobraext
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+// errNoClient is returned by cached lookups when no client is configured.
+var errNoClient = errors.New("sabnzbd client not configured")
+
+// lookupCache memoizes read-mostly SABnzbd lookups for a single process
+// invocation. Errors are never cached so a later call can retry.
+type lookupCache struct {
+	mu         sync.Mutex
+	servers    []sabapi.ServerConfig
+	hasServers bool
+	categories map[string]any
+	scripts    []string
+	hasScripts bool
+}
+
+// ServerConfigs returns the configured news servers, fetching them once.
+func (a *App) ServerConfigs(ctx context.Context) ([]sabapi.ServerConfig, error) {
+	if a.Client == nil {
+		return nil, errNoClient
+	}
+	a.cache.mu.Lock()
+	defer a.cache.mu.Unlock()
+	if a.cache.hasServers {
+		return a.cache.servers, nil
+	}
+	servers, err := a.Client.ServerConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a.cache.servers, a.cache.hasServers = servers, true
+	return servers, nil
+}
+
+// CategoriesList returns the category configuration, fetching it once.
+func (a *App) CategoriesList(ctx context.Context) (map[string]any, error) {
+	if a.Client == nil {
+		return nil, errNoClient
+	}
+	a.cache.mu.Lock()
+	defer a.cache.mu.Unlock()
+	if a.cache.categories != nil {
+		return a.cache.categories, nil
+	}
+	categories, err := a.Client.CategoriesList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if categories == nil {
+		categories = map[string]any{}
+	}
+	a.cache.categories = categories
+	return categories, nil
+}
+
+// GetScripts returns the available post-processing scripts, fetching them once.
+func (a *App) GetScripts(ctx context.Context) ([]string, error) {
+	if a.Client == nil {
+		return nil, errNoClient
+	}
+	a.cache.mu.Lock()
+	defer a.cache.mu.Unlock()
+	if a.cache.hasScripts {
+		return a.cache.scripts, nil
+	}
+	scripts, err := a.Client.GetScripts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a.cache.scripts, a.cache.hasScripts = scripts, true
+	return scripts, nil
+}
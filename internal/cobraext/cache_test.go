@@ -0,0 +1,81 @@
+package cobraext
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+func newCountingApp(t *testing.T, body string) (*App, *int32) {
+	t.Helper()
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := sabapi.NewClient(server.URL, "apikey", sabapi.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	return &App{Client: client}, &calls
+}
+
+func TestServerConfigsCached(t *testing.T) {
+	t.Parallel()
+
+	app, calls := newCountingApp(t, `{"servers":[{"name":"primary"}]}`)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := app.ServerConfigs(ctx); err != nil {
+			t.Fatalf("ServerConfigs returned error: %v", err)
+		}
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Fatalf("expected 1 request, got %d", got)
+	}
+}
+
+func TestScriptsCached(t *testing.T) {
+	t.Parallel()
+
+	app, calls := newCountingApp(t, `{"scripts":["None","notify.py"]}`)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		scripts, err := app.GetScripts(ctx)
+		if err != nil {
+			t.Fatalf("GetScripts returned error: %v", err)
+		}
+		if len(scripts) != 2 {
+			t.Fatalf("unexpected scripts %v", scripts)
+		}
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Fatalf("expected 1 request, got %d", got)
+	}
+}
+
+func TestCategoriesCached(t *testing.T) {
+	t.Parallel()
+
+	app, calls := newCountingApp(t, `{"config":{"categories":[{"name":"tv"}]}}`)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := app.CategoriesList(ctx); err != nil {
+			t.Fatalf("CategoriesList returned error: %v", err)
+		}
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Fatalf("expected 1 request, got %d", got)
+	}
+}
@@ -19,6 +19,21 @@ type App struct {
 	Printer     *output.Printer
 	Client      *sabapi.Client
 	BaseURL     string
+
+	cache lookupCache
+}
+
+// Fork returns a copy of a that shares its config, printer and client but
+// starts with an empty lookup cache, for running another command in the
+// same process (the REPL runs one per line).
+func (a *App) Fork() *App {
+	return &App{
+		Config:      a.Config,
+		ProfileName: a.ProfileName,
+		Printer:     a.Printer,
+		Client:      a.Client,
+		BaseURL:     a.BaseURL,
+	}
 }
 
 // WithApp attaches application state to a context.Context.
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -15,15 +16,36 @@ import (
 type Config struct {
 	DefaultProfile string             `yaml:"default_profile"`
 	Profiles       map[string]Profile `yaml:"profiles"`
+	SpeedPresets   map[string]string  `yaml:"speed_presets,omitempty"`
 	path           string             `yaml:"-"`
 	mu             sync.RWMutex       `yaml:"-"`
 }
 
 // Profile stores base URL for a SABnzbd instance.
 type Profile struct {
-	BaseURL            string `yaml:"base_url"`
-	APIKey             string `yaml:"api_key,omitempty"`
-	AllowInsecureStore bool   `yaml:"allow_insecure_store,omitempty"`
+	BaseURL            string    `yaml:"base_url"`
+	APIKey             string    `yaml:"api_key,omitempty"`
+	AllowInsecureStore bool      `yaml:"allow_insecure_store,omitempty"`
+	LastVersion        string    `yaml:"last_version,omitempty"`
+	LastVersionAt      time.Time `yaml:"last_version_at,omitempty"`
+	// APIKeyHeader names a header that carries the API key for an API
+	// gateway in front of SABnzbd; the apikey query parameter is then only
+	// sent when APIKeyQuery is set.
+	APIKeyHeader string `yaml:"api_key_header,omitempty"`
+	APIKeyQuery  bool   `yaml:"api_key_query,omitempty"`
+}
+
+// DefaultVersionTTL is how long a cached SABnzbd version is considered fresh.
+const DefaultVersionTTL = 24 * time.Hour
+
+// CachedVersion returns the last recorded SABnzbd version and whether it is
+// younger than ttl at now. An empty version means nothing has been recorded.
+func (p Profile) CachedVersion(now time.Time, ttl time.Duration) (string, bool) {
+	if p.LastVersion == "" || p.LastVersionAt.IsZero() {
+		return "", false
+	}
+	age := now.Sub(p.LastVersionAt)
+	return p.LastVersion, age >= 0 && age < ttl
 }
 
 // Load reads configuration from disk, returning an initialized Config.
@@ -153,6 +175,54 @@ func (c *Config) SetProfile(name string, profile Profile) {
 	c.Profiles[name] = profile
 }
 
+// RecordVersion stores the SABnzbd version seen for an existing profile.
+// A cached entry with the same version that is still fresh under ttl is
+// left alone. It reports whether the profile was updated and needs saving.
+func (c *Config) RecordVersion(name, version string, at time.Time, ttl time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	profile, ok := c.Profiles[name]
+	if !ok || version == "" {
+		return false
+	}
+	if cached, fresh := profile.CachedVersion(at, ttl); cached == version && fresh {
+		return false
+	}
+	profile.LastVersion = version
+	profile.LastVersionAt = at.UTC()
+	c.Profiles[name] = profile
+	return true
+}
+
+// SetSpeedPreset stores a named speed limit rate as the user typed it.
+func (c *Config) SetSpeedPreset(name, rate string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.SpeedPresets == nil {
+		c.SpeedPresets = map[string]string{}
+	}
+	c.SpeedPresets[name] = rate
+}
+
+// DeleteSpeedPreset removes a named rate, reporting whether it existed.
+func (c *Config) DeleteSpeedPreset(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.SpeedPresets[name]; !ok {
+		return false
+	}
+	delete(c.SpeedPresets, name)
+	return true
+}
+
+// SpeedPreset looks up a named rate.
+func (c *Config) SpeedPreset(name string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	rate, ok := c.SpeedPresets[name]
+	return rate, ok
+}
+
 // GetProfile retrieves a profile, returning bool indicating existence.
 func (c *Config) GetProfile(name string) (Profile, bool) {
 	c.mu.RLock()
@@ -183,6 +253,12 @@ func (c *Config) ActiveProfile(override string) (string, Profile, error) {
 	return name, profile, nil
 }
 
+// Dir returns the sabx config directory: $SABX_CONFIG_DIR, or sabx under
+// the user's config directory.
+func Dir() (string, error) {
+	return resolveConfigDir()
+}
+
 func resolveConfigDir() (string, error) {
 	if base := strings.TrimSpace(os.Getenv("SABX_CONFIG_DIR")); base != "" {
 		return base, nil
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCachedVersionFreshness(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		profile     Profile
+		wantVersion string
+		wantFresh   bool
+	}{
+		{name: "nothing recorded", profile: Profile{}, wantVersion: "", wantFresh: false},
+		{name: "fresh", profile: Profile{LastVersion: "4.3.2", LastVersionAt: now.Add(-time.Hour)}, wantVersion: "4.3.2", wantFresh: true},
+		{name: "stale", profile: Profile{LastVersion: "4.3.2", LastVersionAt: now.Add(-25 * time.Hour)}, wantVersion: "4.3.2", wantFresh: false},
+		{name: "future timestamp is not fresh", profile: Profile{LastVersion: "4.3.2", LastVersionAt: now.Add(time.Hour)}, wantVersion: "4.3.2", wantFresh: false},
+		{name: "missing timestamp", profile: Profile{LastVersion: "4.3.2"}, wantVersion: "", wantFresh: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			version, fresh := tt.profile.CachedVersion(now, DefaultVersionTTL)
+			if version != tt.wantVersion || fresh != tt.wantFresh {
+				t.Fatalf("CachedVersion = (%q, %v), want (%q, %v)", version, fresh, tt.wantVersion, tt.wantFresh)
+			}
+		})
+	}
+}
+
+func TestRecordVersionOnlyUpdatesKnownProfiles(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+	cfg := &Config{Profiles: map[string]Profile{"home": {BaseURL: "http://sab"}}}
+
+	if cfg.RecordVersion("missing", "4.3.2", at, DefaultVersionTTL) {
+		t.Fatal("expected unknown profile to be ignored")
+	}
+	if !cfg.RecordVersion("home", "4.3.2", at, DefaultVersionTTL) {
+		t.Fatal("expected known profile to be updated")
+	}
+	profile, _ := cfg.GetProfile("home")
+	if profile.LastVersion != "4.3.2" || !profile.LastVersionAt.Equal(at) {
+		t.Fatalf("unexpected cached version %+v", profile)
+	}
+	if profile.BaseURL != "http://sab" {
+		t.Fatalf("RecordVersion clobbered profile: %+v", profile)
+	}
+
+	if cfg.RecordVersion("home", "4.3.2", at.Add(time.Hour), DefaultVersionTTL) {
+		t.Fatal("expected an unchanged, fresh version to need no update")
+	}
+	if !cfg.RecordVersion("home", "4.3.3", at.Add(time.Hour), DefaultVersionTTL) {
+		t.Fatal("expected a changed version to be recorded")
+	}
+	if !cfg.RecordVersion("home", "4.3.3", at.Add(48*time.Hour), DefaultVersionTTL) {
+		t.Fatal("expected a stale entry to be refreshed")
+	}
+}
+
+func TestSpeedPresetsRoundTrip(t *testing.T) {
+	t.Setenv("SABX_CONFIG_DIR", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cfg.SetSpeedPreset("work", "2M")
+	cfg.SetSpeedPreset("night", "100%")
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	reloaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if rate, ok := reloaded.SpeedPreset("work"); !ok || rate != "2M" {
+		t.Fatalf("SpeedPreset(work) = %q, %v", rate, ok)
+	}
+	if !reloaded.DeleteSpeedPreset("night") || reloaded.DeleteSpeedPreset("night") {
+		t.Fatal("expected night to be deleted exactly once")
+	}
+	if _, ok := reloaded.SpeedPreset("night"); ok {
+		t.Fatal("expected night preset to be gone")
+	}
+}
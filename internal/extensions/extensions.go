@@ -35,7 +35,7 @@ func List() ([]InstalledExtension, error) {
 	seen := map[string]struct{}{}
 
 	for name, ext := range meta.Extensions {
-		if ext.Kind == "git" || ext.Kind == "local" {
+		if ext.Kind == "git" || ext.Kind == "local" || ext.Kind == "link" {
 			if _, err := os.Stat(ext.Binary); errors.Is(err, fs.ErrNotExist) {
 				// skip missing binary but keep metadata for debugging
 				continue
@@ -127,6 +127,62 @@ func Install(source string, overwrite bool) (InstalledExtension, error) {
 	return meta.Extensions[name], nil
 }
 
+// Link registers a development directory as an extension without copying
+// it. The metadata points at the live sabx-<name> binary (Kind "link"), so
+// rebuilds take effect immediately; Remove only forgets the link.
+func Link(dir string, overwrite bool) (InstalledExtension, error) {
+	if dir == "" {
+		return InstalledExtension{}, errors.New("directory is required")
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return InstalledExtension{}, err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return InstalledExtension{}, err
+	}
+	if !info.IsDir() {
+		return InstalledExtension{}, fmt.Errorf("%s is not a directory", abs)
+	}
+
+	base := filepath.Base(abs)
+	name := strings.TrimPrefix(base, "sabx-")
+	if name == "" {
+		name = base
+	}
+	binaryPath, err := findBinary(abs, name)
+	if err != nil {
+		return InstalledExtension{}, err
+	}
+
+	meta, err := loadMetadata()
+	if err != nil {
+		return InstalledExtension{}, err
+	}
+	if existing, ok := meta.Extensions[name]; ok {
+		if !overwrite {
+			return InstalledExtension{}, fmt.Errorf("extension %q already installed", name)
+		}
+		if existing.InstallDir != "" {
+			if err := os.RemoveAll(existing.InstallDir); err != nil {
+				return InstalledExtension{}, err
+			}
+		}
+	}
+
+	meta.Extensions[name] = InstalledExtension{
+		Name:   name,
+		Binary: binaryPath,
+		Source: abs,
+		Kind:   "link",
+	}
+	if err := saveMetadata(meta); err != nil {
+		return InstalledExtension{}, err
+	}
+	return meta.Extensions[name], nil
+}
+
 // Remove deletes an installed extension and its metadata entry.
 func Remove(name string) error {
 	meta, err := loadMetadata()
@@ -0,0 +1,59 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Field extracts the value at a dotted path (status.version, servers.0.name)
+// from data's JSON form. Numeric segments index arrays. A missing path is an
+// error.
+func Field(data any, path string) (any, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, fmt.Errorf("field path required")
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var current any
+	if err := dec.Decode(&current); err != nil {
+		return nil, err
+	}
+
+	for i, segment := range strings.Split(path, ".") {
+		found := false
+		switch node := current.(type) {
+		case map[string]any:
+			current, found = node[segment]
+		case []any:
+			if idx, err := strconv.Atoi(segment); err == nil && idx >= 0 && idx < len(node) {
+				current, found = node[idx], true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("field %q not found (missing %q)", path, strings.Join(strings.Split(path, ".")[:i+1], "."))
+		}
+	}
+	return current, nil
+}
+
+// PrintField prints only the value at path. Text output shows strings and
+// numbers bare and anything else as JSON; --json output is always JSON.
+func (p *Printer) PrintField(data any, path string) error {
+	value, err := Field(data, path)
+	if err != nil {
+		return err
+	}
+	if s, ok := value.(string); ok && !p.JSON {
+		return p.Print(s)
+	}
+	return p.Print(value)
+}
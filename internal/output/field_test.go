@@ -0,0 +1,71 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFieldExtractsNestedValues(t *testing.T) {
+	t.Parallel()
+
+	payload := map[string]any{
+		"status": map[string]any{"version": "4.3.0", "paused": false},
+		"servers": []map[string]any{
+			{"name": "primary", "connections": 20},
+		},
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "status.version", want: "4.3.0\n"},
+		{path: "servers.0.name", want: "primary\n"},
+		{path: "servers.0.connections", want: "20\n"},
+		{path: "status.paused", want: "false\n"},
+		{path: "status", want: "{\n  \"paused\": false,\n  \"version\": \"4.3.0\"\n}\n"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			p := &Printer{Out: &out}
+			if err := p.PrintField(payload, tt.path); err != nil {
+				t.Fatalf("PrintField(%q) returned error: %v", tt.path, err)
+			}
+			if out.String() != tt.want {
+				t.Fatalf("PrintField(%q) = %q, want %q", tt.path, out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldJSONQuotesStrings(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	p := &Printer{JSON: true, Out: &out}
+	if err := p.PrintField(map[string]any{"version": "4.3.0"}, "version"); err != nil {
+		t.Fatalf("PrintField returned error: %v", err)
+	}
+	if out.String() != "\"4.3.0\"\n" {
+		t.Fatalf("expected JSON string, got %q", out.String())
+	}
+}
+
+func TestFieldMissingPath(t *testing.T) {
+	t.Parallel()
+
+	payload := map[string]any{"status": map[string]any{"version": "4.3.0"}, "list": []any{"a"}}
+	for _, path := range []string{"status.missing", "nope.version", "list.3", "status.version.deeper", ""} {
+		if _, err := Field(payload, path); err == nil {
+			t.Fatalf("expected error for path %q", path)
+		}
+	}
+	_, err := Field(payload, "status.missing.x")
+	if err == nil || !strings.Contains(err.Error(), `missing "status.missing"`) {
+		t.Fatalf("expected error naming the first missing segment, got %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Logfmt renders data as a single line of key=value pairs. Nested objects
+// and arrays are flattened into dotted keys (servers.0.name=...), keys are
+// sorted, and values are quoted when they contain spaces, quotes or '='.
+func Logfmt(data any) (string, error) {
+	if s, ok := data.(string); ok {
+		return "msg=" + logfmtValue(s), nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return "", err
+	}
+
+	pairs := map[string]string{}
+	flattenLogfmt("", generic, pairs)
+	if len(pairs) == 0 {
+		return "", nil
+	}
+
+	keys := make([]string, 0, len(pairs))
+	for k := range pairs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(pairs[k])
+	}
+	return b.String(), nil
+}
+
+func flattenLogfmt(prefix string, value any, pairs map[string]string) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for k, child := range v {
+			flattenLogfmt(join(logfmtKey(k)), child, pairs)
+		}
+	case []any:
+		for i, child := range v {
+			flattenLogfmt(join(strconv.Itoa(i)), child, pairs)
+		}
+	case nil:
+		if prefix != "" {
+			pairs[prefix] = ""
+		}
+	case string:
+		pairs[prefix] = logfmtValue(v)
+	default:
+		pairs[prefix] = logfmtValue(fmt.Sprint(v))
+	}
+}
+
+func logfmtKey(k string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '=' || r == '"' {
+			return '_'
+		}
+		return r
+	}, k)
+}
+
+func logfmtValue(v string) string {
+	if v == "" {
+		return `""`
+	}
+	if strings.ContainsAny(v, " =\"\t\n\r") {
+		return strconv.Quote(v)
+	}
+	return v
+}
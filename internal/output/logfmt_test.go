@@ -0,0 +1,58 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+type logfmtServer struct {
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
+}
+
+type logfmtStatus struct {
+	Profile  string         `json:"profile"`
+	Paused   bool           `json:"paused"`
+	Speed    string         `json:"speed_kbps"`
+	Status   map[string]any `json:"status"`
+	Servers  []logfmtServer `json:"servers"`
+	Warnings []string       `json:"warnings,omitempty"`
+	Missing  *string        `json:"missing"`
+}
+
+func TestLogfmtFlattensNestedStatus(t *testing.T) {
+	t.Parallel()
+
+	payload := logfmtStatus{
+		Profile: "home",
+		Paused:  false,
+		Speed:   "1024.5",
+		Status:  map[string]any{"version": "4.3.2", "disk": map[string]any{"free_gb": 12.5}},
+		Servers: []logfmtServer{{Name: "news example", Active: true}},
+	}
+	got, err := Logfmt(payload)
+	if err != nil {
+		t.Fatalf("Logfmt returned error: %v", err)
+	}
+	want := `missing= paused=false profile=home servers.0.active=true servers.0.name="news example" speed_kbps=1024.5 status.disk.free_gb=12.5 status.version=4.3.2`
+	if got != want {
+		t.Fatalf("Logfmt =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLogfmtPrinterMessagesAndTables(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	p := &Printer{Logfmt: true, Out: &out}
+	if err := p.Print(`Deleted "a"`); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Table([]string{"ID", "Time Left"}, [][]string{{"SABnzbd_nzo_1", "0:05:00"}}); err != nil {
+		t.Fatal(err)
+	}
+	want := "msg=\"Deleted \\\"a\\\"\"\nid=SABnzbd_nzo_1 time_left=0:05:00\n"
+	if out.String() != want {
+		t.Fatalf("unexpected output %q, want %q", out.String(), want)
+	}
+}
@@ -5,16 +5,23 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"text/tabwriter"
+	"text/template"
 )
 
 // Printer renders human or machine output.
 type Printer struct {
-	JSON  bool
-	Quiet bool
-	Out   io.Writer
-	Err   io.Writer
+	JSON     bool
+	Logfmt   bool
+	Quiet    bool
+	Compact  bool
+	NDJSON   bool
+	Template *template.Template
+	Out      io.Writer
+	Err      io.Writer
 }
 
 // New returns a Printer with sensible defaults.
@@ -22,15 +29,59 @@ func New() *Printer {
 	return &Printer{Out: os.Stdout, Err: os.Stderr}
 }
 
+// SetOutputFile redirects Print, Table and template output to path. Errors
+// keep going to Err. Output is staged in a temp file next to path so a
+// failing command leaves an existing file untouched: the caller calls Close
+// once the command succeeded, which replaces path, or Discard otherwise.
+func (p *Printer) SetOutputFile(path string) (*OutputFile, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("open output file: %w", err)
+	}
+	p.Out = f
+	return &OutputFile{f: f, path: path}, nil
+}
+
+// OutputFile is command output staged for SetOutputFile's target path.
+type OutputFile struct {
+	f    *os.File
+	path string
+}
+
+// Close moves the staged output over the target path.
+func (o *OutputFile) Close() error {
+	if err := o.f.Close(); err != nil {
+		_ = os.Remove(o.f.Name())
+		return fmt.Errorf("write output file: %w", err)
+	}
+	if err := os.Rename(o.f.Name(), o.path); err != nil {
+		_ = os.Remove(o.f.Name())
+		return fmt.Errorf("write output file: %w", err)
+	}
+	return nil
+}
+
+// Discard drops the staged output, leaving the target path as it was.
+func (o *OutputFile) Discard() error {
+	_ = o.f.Close()
+	return os.Remove(o.f.Name())
+}
+
 // Print writes data respecting the configured format.
 func (p *Printer) Print(data any) error {
 	if p.Quiet {
 		return nil
 	}
+	if p.Logfmt {
+		line, err := Logfmt(data)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(p.Out, line)
+		return err
+	}
 	if p.JSON {
-		enc := json.NewEncoder(p.Out)
-		enc.SetIndent("", "  ")
-		return enc.Encode(data)
+		return p.encodeJSON(data)
 	}
 	switch v := data.(type) {
 	case string:
@@ -40,10 +91,42 @@ func (p *Printer) Print(data any) error {
 		_, err := fmt.Fprintln(p.Out, v.String())
 		return err
 	default:
-		enc := json.NewEncoder(p.Out)
+		return p.encodeJSON(v)
+	}
+}
+
+func (p *Printer) encodeJSON(data any) error {
+	enc := json.NewEncoder(p.Out)
+	if !p.Compact {
 		enc.SetIndent("", "  ")
-		return enc.Encode(v)
 	}
+	return enc.Encode(data)
+}
+
+// PrintLines writes newline-delimited JSON: slices emit one compact object
+// per element, anything else a single compact line. Commands call it for
+// their item lists when NDJSON is set.
+func (p *Printer) PrintLines(data any) error {
+	if p.Quiet {
+		return nil
+	}
+	enc := json.NewEncoder(p.Out)
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return enc.Encode(data)
+	}
+	for i := 0; i < v.Len(); i++ {
+		if err := enc.Encode(v.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Structured reports whether commands should emit their machine-readable
+// payload (JSON or logfmt) instead of human tables.
+func (p *Printer) Structured() bool {
+	return p.JSON || p.Logfmt
 }
 
 // Table renders a simple tabular view.
@@ -55,6 +138,20 @@ func (p *Printer) Table(headers []string, rows [][]string) error {
 		data := map[string]any{"headers": headers, "rows": rows}
 		return p.Print(data)
 	}
+	if p.Logfmt {
+		for _, row := range rows {
+			record := make(map[string]string, len(headers))
+			for i, header := range headers {
+				if i < len(row) {
+					record[strings.ToLower(logfmtKey(header))] = row[i]
+				}
+			}
+			if err := p.Print(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 	tw := tabwriter.NewWriter(p.Out, 2, 4, 2, ' ', 0)
 	if len(headers) > 0 {
 		fmt.Fprintln(tw, strings.Join(headers, "\t"))
@@ -72,3 +169,12 @@ func (p *Printer) Error(format string, args ...any) {
 	}
 	fmt.Fprintf(p.Err, format+"\n", args...)
 }
+
+// AlwaysError writes an error message even when Quiet is set, so scripts
+// running with --quiet still see per-item failures of bulk operations.
+func (p *Printer) AlwaysError(format string, args ...any) {
+	if p.Err == nil {
+		return
+	}
+	fmt.Fprintf(p.Err, format+"\n", args...)
+}
@@ -0,0 +1,126 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestQuietSuppressesErrorButNotAlwaysError(t *testing.T) {
+	t.Parallel()
+
+	var errOut bytes.Buffer
+	p := &Printer{Quiet: true, Out: &bytes.Buffer{}, Err: &errOut}
+
+	p.Error("chatter %d", 1)
+	if errOut.Len() != 0 {
+		t.Fatalf("expected Error to respect quiet, got %q", errOut.String())
+	}
+
+	p.AlwaysError("delete %s failed", "SABnzbd_nzo_1")
+	if got := errOut.String(); got != "delete SABnzbd_nzo_1 failed\n" {
+		t.Fatalf("unexpected AlwaysError output %q", got)
+	}
+}
+
+func TestSetOutputFileWritesTableAndJSON(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	tablePath := filepath.Join(dir, "table.txt")
+	var errOut bytes.Buffer
+	p := &Printer{Err: &errOut}
+	closer, err := p.SetOutputFile(tablePath)
+	if err != nil {
+		t.Fatalf("SetOutputFile returned error: %v", err)
+	}
+	if err := p.Table([]string{"ID", "Name"}, [][]string{{"SABnzbd_nzo_1", "Show"}}); err != nil {
+		t.Fatal(err)
+	}
+	p.Error("still on stderr")
+	if err := closer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(tablePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "SABnzbd_nzo_1") || strings.Contains(string(data), "stderr") {
+		t.Fatalf("unexpected table file contents %q", data)
+	}
+	if errOut.String() != "still on stderr\n" {
+		t.Fatalf("expected errors on Err, got %q", errOut.String())
+	}
+
+	jsonPath := filepath.Join(dir, "out.json")
+	p = &Printer{JSON: true}
+	closer, err = p.SetOutputFile(jsonPath)
+	if err != nil {
+		t.Fatalf("SetOutputFile returned error: %v", err)
+	}
+	if err := p.Print(map[string]any{"count": 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := closer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err = os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]int
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded["count"] != 2 {
+		t.Fatalf("unexpected JSON file contents %q (%v)", data, err)
+	}
+}
+
+func TestCompactJSONOutput(t *testing.T) {
+	t.Parallel()
+
+	payload := map[string]any{"paused": false, "slots": []string{"a", "b"}}
+	tests := []struct {
+		name    string
+		compact bool
+		want    string
+	}{
+		{name: "indented", want: "{\n  \"paused\": false,\n  \"slots\": [\n    \"a\",\n    \"b\"\n  ]\n}\n"},
+		{name: "compact", compact: true, want: "{\"paused\":false,\"slots\":[\"a\",\"b\"]}\n"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			p := &Printer{JSON: true, Compact: tt.compact, Out: &out}
+			if err := p.Print(payload); err != nil {
+				t.Fatalf("Print returned error: %v", err)
+			}
+			if out.String() != tt.want {
+				t.Fatalf("output = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintLinesEmitsOneObjectPerElement(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	p := &Printer{JSON: true, NDJSON: true, Compact: true, Out: &out}
+	items := []map[string]any{{"id": "a", "n": 1}, {"id": "b", "n": 2}}
+	if err := p.PrintLines(items); err != nil {
+		t.Fatalf("PrintLines returned error: %v", err)
+	}
+	if want := "{\"id\":\"a\",\"n\":1}\n{\"id\":\"b\",\"n\":2}\n"; out.String() != want {
+		t.Fatalf("output = %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	if err := p.PrintLines([]string{}); err != nil || out.Len() != 0 {
+		t.Fatalf("expected no output for an empty slice, got %q (%v)", out.String(), err)
+	}
+}
@@ -0,0 +1,108 @@
+package output
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// TemplateFuncs are the helpers available to --template expressions.
+var TemplateFuncs = template.FuncMap{
+	"humanBytes": templateHumanBytes,
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"trunc":      templateTrunc,
+}
+
+// SetTemplate parses a user-supplied Go text/template used in place of
+// tables. An empty string clears any previously configured template.
+func (p *Printer) SetTemplate(text string) error {
+	if strings.TrimSpace(text) == "" {
+		p.Template = nil
+		return nil
+	}
+	tmpl, err := template.New("output").Funcs(TemplateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("parse template: %w", err)
+	}
+	p.Template = tmpl
+	return nil
+}
+
+// RenderTemplate executes the configured template. Slices render one line
+// per element; any other value renders once.
+func (p *Printer) RenderTemplate(data any) error {
+	if p.Template == nil {
+		return fmt.Errorf("no output template configured")
+	}
+	if p.Quiet {
+		return nil
+	}
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		for i := 0; i < v.Len(); i++ {
+			if err := p.renderTemplateLine(v.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return p.renderTemplateLine(data)
+}
+
+func (p *Printer) renderTemplateLine(data any) error {
+	var b strings.Builder
+	if err := p.Template.Execute(&b, data); err != nil {
+		return fmt.Errorf("render template: %w", err)
+	}
+	line := b.String()
+	if !strings.HasSuffix(line, "\n") {
+		line += "\n"
+	}
+	_, err := fmt.Fprint(p.Out, line)
+	return err
+}
+
+func templateHumanBytes(value any) (string, error) {
+	var n float64
+	switch v := value.(type) {
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return "", nil
+		}
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return "", fmt.Errorf("humanBytes: %w", err)
+		}
+		n = parsed
+	case int:
+		n = float64(v)
+	case int64:
+		n = float64(v)
+	case float64:
+		n = v
+	default:
+		return "", fmt.Errorf("humanBytes: unsupported type %T", value)
+	}
+
+	units := []string{"B", "KB", "MB", "GB", "TB", "PB"}
+	i := 0
+	for n >= 1024 && i < len(units)-1 {
+		n /= 1024
+		i++
+	}
+	return fmt.Sprintf("%.2f %s", n, units[i]), nil
+}
+
+func templateTrunc(length int, s string) string {
+	if length < 0 {
+		return s
+	}
+	runes := []rune(s)
+	if len(runes) <= length {
+		return s
+	}
+	return string(runes[:length])
+}
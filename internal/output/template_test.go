@@ -0,0 +1,61 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type templateSlot struct {
+	NZOID    string
+	Filename string
+	Bytes    string
+}
+
+func TestRenderTemplateEachItem(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	p := &Printer{Out: &out}
+	if err := p.SetTemplate(`{{.NZOID}} {{.Filename | trunc 6 | upper}} {{humanBytes .Bytes}}`); err != nil {
+		t.Fatalf("SetTemplate returned error: %v", err)
+	}
+
+	slots := []templateSlot{
+		{NZOID: "SABnzbd_nzo_1", Filename: "ubuntu.24.04", Bytes: "1048576"},
+		{NZOID: "SABnzbd_nzo_2", Filename: "debian", Bytes: "512"},
+	}
+	if err := p.RenderTemplate(slots); err != nil {
+		t.Fatalf("RenderTemplate returned error: %v", err)
+	}
+
+	want := "SABnzbd_nzo_1 UBUNTU 1.00 MB\nSABnzbd_nzo_2 DEBIAN 512.00 B\n"
+	if got := out.String(); got != want {
+		t.Fatalf("unexpected output:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestRenderTemplateSingleValue(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	p := &Printer{Out: &out}
+	if err := p.SetTemplate(`{{.Filename}}`); err != nil {
+		t.Fatalf("SetTemplate returned error: %v", err)
+	}
+	if err := p.RenderTemplate(templateSlot{Filename: "single"}); err != nil {
+		t.Fatalf("RenderTemplate returned error: %v", err)
+	}
+	if got := out.String(); got != "single\n" {
+		t.Fatalf("unexpected output %q", got)
+	}
+}
+
+func TestSetTemplateRejectsInvalidSyntax(t *testing.T) {
+	t.Parallel()
+
+	p := &Printer{}
+	if err := p.SetTemplate(`{{.Filename`); err == nil || !strings.Contains(err.Error(), "parse template") {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+}
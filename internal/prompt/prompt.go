@@ -0,0 +1,134 @@
+// Package prompt provides minimal line-oriented terminal prompts.
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrNotInteractive is returned when prompting without a terminal attached.
+var ErrNotInteractive = errors.New("not an interactive terminal")
+
+// Prompter reads answers from In and writes questions to Out.
+type Prompter struct {
+	In     io.Reader
+	Out    io.Writer
+	reader *bufio.Reader
+}
+
+// New returns a Prompter bound to the given streams.
+func New(in io.Reader, out io.Writer) *Prompter {
+	return &Prompter{In: in, Out: out, reader: bufio.NewReader(in)}
+}
+
+// IsTerminal reports whether r is a file attached to a terminal.
+func IsTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// String asks for a free-form value, returning def when the answer is empty.
+func (p *Prompter) String(label, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.Out, "%s [%s]: ", label, def)
+	} else {
+		fmt.Fprintf(p.Out, "%s: ", label)
+	}
+	line, err := p.readLine()
+	if err != nil {
+		return "", err
+	}
+	if line == "" {
+		return def, nil
+	}
+	return line, nil
+}
+
+// Secret asks for a value without echoing it when In is a terminal.
+func (p *Prompter) Secret(label string) (string, error) {
+	fmt.Fprintf(p.Out, "%s: ", label)
+	if f, ok := p.In.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		data, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(p.Out)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return p.readLine()
+}
+
+// Confirm asks a yes/no question, returning def when the answer is empty.
+func (p *Prompter) Confirm(label string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	for {
+		fmt.Fprintf(p.Out, "%s [%s]: ", label, hint)
+		line, err := p.readLine()
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(line) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		fmt.Fprintln(p.Out, "Please answer y or n.")
+	}
+}
+
+func (p *Prompter) readLine() (string, error) {
+	if p.reader == nil {
+		p.reader = bufio.NewReader(p.In)
+	}
+	line, err := p.reader.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// ReadClipboard returns the system clipboard contents using the platform's
+// clipboard utility (pbpaste, PowerShell, wl-paste, xclip, or xsel).
+func ReadClipboard() (string, error) {
+	var candidates [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = [][]string{{"pbpaste"}}
+	case "windows":
+		candidates = [][]string{{"powershell", "-NoProfile", "-Command", "Get-Clipboard"}}
+	default:
+		candidates = [][]string{
+			{"wl-paste", "--no-newline"},
+			{"xclip", "-selection", "clipboard", "-o"},
+			{"xsel", "--clipboard", "--output"},
+		}
+	}
+	for _, args := range candidates {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		out, err := exec.Command(args[0], args[1:]...).Output()
+		if err != nil {
+			return "", fmt.Errorf("read clipboard: %w", err)
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+	return "", errors.New("no clipboard utility available")
+}
@@ -0,0 +1,185 @@
+// Package ref resolves user-supplied references to queue or history slots.
+//
+// A reference is one of:
+//   - an exact NZO ID (e.g. SABnzbd_nzo_abc123)
+//   - a unique prefix of an NZO ID's random part (e.g. abc for SABnzbd_nzo_abc123)
+//   - a 1-based positional index prefixed with '#' (e.g. #1 for the top item)
+//   - a case-insensitive name substring prefixed with '@' (e.g. @ubuntu)
+package ref
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kind identifies how a reference token should be matched.
+type Kind int
+
+const (
+	// KindID matches a slot by its exact NZO ID.
+	KindID Kind = iota
+	// KindIndex matches a slot by its 1-based position.
+	KindIndex
+	// KindName matches a slot by a case-insensitive name substring.
+	KindName
+	// KindPrefix matches a slot whose NZO ID starts with the token, with or
+	// without the SABnzbd_nzo_ part.
+	KindPrefix
+)
+
+// IDPrefix starts every NZO ID SABnzbd generates. Tokens that carry it are
+// taken as complete IDs; anything else is matched as a prefix.
+const IDPrefix = "SABnzbd_nzo_"
+
+func (k Kind) String() string {
+	switch k {
+	case KindIndex:
+		return "index"
+	case KindName:
+		return "name"
+	case KindPrefix:
+		return "prefix"
+	default:
+		return "id"
+	}
+}
+
+// Ref is a parsed reference token.
+type Ref struct {
+	Raw   string
+	Kind  Kind
+	ID    string
+	Index int
+	Name  string
+}
+
+// Candidate is the minimal view of a slot needed for resolution.
+type Candidate struct {
+	ID   string `json:"nzo_id"`
+	Name string `json:"name"`
+}
+
+// ErrNotFound is returned when no candidate matches a reference.
+var ErrNotFound = errors.New("no matching item")
+
+// AmbiguousError reports a name reference matching more than one candidate.
+type AmbiguousError struct {
+	Ref        string
+	Candidates []Candidate
+}
+
+func (e *AmbiguousError) Error() string {
+	lines := make([]string, 0, len(e.Candidates))
+	for _, c := range e.Candidates {
+		lines = append(lines, fmt.Sprintf("  %s  %s", c.ID, c.Name))
+	}
+	return fmt.Sprintf("reference %q is ambiguous; candidates:\n%s", e.Ref, strings.Join(lines, "\n"))
+}
+
+// Parse classifies a reference token without resolving it.
+func Parse(token string) (Ref, error) {
+	raw := strings.TrimSpace(token)
+	if raw == "" {
+		return Ref{}, errors.New("empty reference")
+	}
+
+	switch raw[0] {
+	case '#':
+		n, err := strconv.Atoi(strings.TrimSpace(raw[1:]))
+		if err != nil {
+			return Ref{}, fmt.Errorf("invalid index reference %q", raw)
+		}
+		if n < 1 {
+			return Ref{}, fmt.Errorf("index reference %q must be 1 or greater", raw)
+		}
+		return Ref{Raw: raw, Kind: KindIndex, Index: n}, nil
+	case '@':
+		name := strings.TrimSpace(raw[1:])
+		if name == "" {
+			return Ref{}, fmt.Errorf("empty name reference %q", raw)
+		}
+		return Ref{Raw: raw, Kind: KindName, Name: name}, nil
+	default:
+		if strings.HasPrefix(raw, IDPrefix) {
+			return Ref{Raw: raw, Kind: KindID, ID: raw}, nil
+		}
+		return Ref{Raw: raw, Kind: KindPrefix, ID: raw}, nil
+	}
+}
+
+// NeedsLookup reports whether the token requires a slot listing to resolve.
+// Complete NZO IDs can be passed through to SABnzbd unchanged.
+func NeedsLookup(token string) bool {
+	r, err := Parse(token)
+	return err == nil && r.Kind != KindID
+}
+
+// Resolve matches a reference token against candidates in display order.
+func Resolve(token string, candidates []Candidate) (Candidate, error) {
+	r, err := Parse(token)
+	if err != nil {
+		return Candidate{}, err
+	}
+	return r.Resolve(candidates)
+}
+
+// Resolve matches the parsed reference against candidates in display order.
+func (r Ref) Resolve(candidates []Candidate) (Candidate, error) {
+	switch r.Kind {
+	case KindIndex:
+		if r.Index > len(candidates) {
+			return Candidate{}, fmt.Errorf("%w: index %s out of range (%d items)", ErrNotFound, r.Raw, len(candidates))
+		}
+		return candidates[r.Index-1], nil
+	case KindName:
+		needle := strings.ToLower(r.Name)
+		var exact, partial []Candidate
+		for _, c := range candidates {
+			name := strings.ToLower(c.Name)
+			if name == needle {
+				exact = append(exact, c)
+			} else if strings.Contains(name, needle) {
+				partial = append(partial, c)
+			}
+		}
+		if len(exact) == 1 {
+			return exact[0], nil
+		}
+		matches := append(exact, partial...)
+		switch len(matches) {
+		case 0:
+			return Candidate{}, fmt.Errorf("%w: name %q", ErrNotFound, r.Name)
+		case 1:
+			return matches[0], nil
+		default:
+			return Candidate{}, &AmbiguousError{Ref: r.Raw, Candidates: matches}
+		}
+	case KindPrefix:
+		var matches []Candidate
+		for _, c := range candidates {
+			if c.ID == r.ID {
+				return c, nil
+			}
+			if strings.HasPrefix(c.ID, r.ID) || strings.HasPrefix(strings.TrimPrefix(c.ID, IDPrefix), r.ID) {
+				matches = append(matches, c)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			return Candidate{}, fmt.Errorf("%w: no id starts with %s", ErrNotFound, r.ID)
+		case 1:
+			return matches[0], nil
+		default:
+			return Candidate{}, &AmbiguousError{Ref: r.Raw, Candidates: matches}
+		}
+	default:
+		for _, c := range candidates {
+			if c.ID == r.ID {
+				return c, nil
+			}
+		}
+		return Candidate{}, fmt.Errorf("%w: id %s", ErrNotFound, r.ID)
+	}
+}
@@ -0,0 +1,191 @@
+package ref
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testCandidates = []Candidate{
+	{ID: "SABnzbd_nzo_aaa", Name: "Ubuntu.24.04.Desktop"},
+	{ID: "SABnzbd_nzo_bbb", Name: "Ubuntu.24.04.Server"},
+	{ID: "SABnzbd_nzo_ccc", Name: "Debian.12"},
+	{ID: "SABnzbd_nzo_ddd", Name: "debian"},
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		token string
+		kind  Kind
+		index int
+		name  string
+		id    string
+	}{
+		{token: "SABnzbd_nzo_aaa", kind: KindID, id: "SABnzbd_nzo_aaa"},
+		{token: "  SABnzbd_nzo_aaa ", kind: KindID, id: "SABnzbd_nzo_aaa"},
+		{token: "#1", kind: KindIndex, index: 1},
+		{token: "#12", kind: KindIndex, index: 12},
+		{token: "@ubuntu", kind: KindName, name: "ubuntu"},
+		{token: "@ Debian 12 ", kind: KindName, name: "Debian 12"},
+	}
+
+	for _, tc := range tests {
+		got, err := Parse(tc.token)
+		if err != nil {
+			t.Fatalf("Parse(%q) returned error: %v", tc.token, err)
+		}
+		if got.Kind != tc.kind || got.Index != tc.index || got.Name != tc.name || got.ID != tc.id {
+			t.Fatalf("Parse(%q) = %+v, want kind=%s index=%d name=%q id=%q", tc.token, got, tc.kind, tc.index, tc.name, tc.id)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, token := range []string{"", "   ", "#", "#0", "#-1", "#abc", "@", "@  "} {
+		if _, err := Parse(token); err == nil {
+			t.Fatalf("Parse(%q) expected error, got nil", token)
+		}
+	}
+}
+
+func TestResolveByID(t *testing.T) {
+	t.Parallel()
+
+	got, err := Resolve("SABnzbd_nzo_ccc", testCandidates)
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if got.ID != "SABnzbd_nzo_ccc" {
+		t.Fatalf("expected SABnzbd_nzo_ccc, got %q", got.ID)
+	}
+
+	if _, err := Resolve("SABnzbd_nzo_zzz", testCandidates); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for unknown id, got %v", err)
+	}
+}
+
+func TestResolveByIndex(t *testing.T) {
+	t.Parallel()
+
+	got, err := Resolve("#1", testCandidates)
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if got.ID != "SABnzbd_nzo_aaa" {
+		t.Fatalf("#1 should resolve to the top item, got %q", got.ID)
+	}
+
+	got, err = Resolve("#4", testCandidates)
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if got.ID != "SABnzbd_nzo_ddd" {
+		t.Fatalf("#4 should resolve to the last item, got %q", got.ID)
+	}
+
+	if _, err := Resolve("#5", testCandidates); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for out-of-range index, got %v", err)
+	}
+	if _, err := Resolve("#1", nil); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for empty candidates, got %v", err)
+	}
+}
+
+func TestResolveByName(t *testing.T) {
+	t.Parallel()
+
+	got, err := Resolve("@server", testCandidates)
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if got.ID != "SABnzbd_nzo_bbb" {
+		t.Fatalf("expected case-insensitive substring match, got %q", got.ID)
+	}
+
+	// An exact (case-insensitive) match wins over other substring matches.
+	got, err = Resolve("@DEBIAN", testCandidates)
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if got.ID != "SABnzbd_nzo_ddd" {
+		t.Fatalf("expected exact name match to win, got %q", got.ID)
+	}
+
+	if _, err := Resolve("@fedora", testCandidates); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for unmatched name, got %v", err)
+	}
+}
+
+func TestResolveAmbiguousName(t *testing.T) {
+	t.Parallel()
+
+	_, err := Resolve("@ubuntu", testCandidates)
+	var ambiguous *AmbiguousError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("expected AmbiguousError, got %v", err)
+	}
+	if len(ambiguous.Candidates) != 2 {
+		t.Fatalf("expected 2 candidates, got %d", len(ambiguous.Candidates))
+	}
+	if ambiguous.Candidates[0].ID != "SABnzbd_nzo_aaa" || ambiguous.Candidates[1].ID != "SABnzbd_nzo_bbb" {
+		t.Fatalf("unexpected candidates: %+v", ambiguous.Candidates)
+	}
+	msg := err.Error()
+	for _, want := range []string{"SABnzbd_nzo_aaa", "SABnzbd_nzo_bbb", "ambiguous"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("error %q missing %q", msg, want)
+		}
+	}
+}
+
+func TestNeedsLookup(t *testing.T) {
+	t.Parallel()
+
+	if NeedsLookup("SABnzbd_nzo_aaa") {
+		t.Fatal("plain ids should not need a lookup")
+	}
+	if !NeedsLookup("#1") || !NeedsLookup("@name") {
+		t.Fatal("index and name refs should need a lookup")
+	}
+}
+
+func TestResolveByIDPrefix(t *testing.T) {
+	t.Parallel()
+
+	candidates := []Candidate{
+		{ID: "SABnzbd_nzo_p86tgx1u", Name: "Show.S01E01"},
+		{ID: "SABnzbd_nzo_p8k2m0qa", Name: "Show.S01E02"},
+		{ID: "SABnzbd_nzo_zz91ab3c", Name: "Movie.2024"},
+	}
+
+	for _, token := range []string{"zz", "p86", "p86tgx1u"} {
+		got, err := Resolve(token, candidates)
+		if err != nil {
+			t.Fatalf("Resolve(%q) returned error: %v", token, err)
+		}
+		if !strings.HasPrefix(got.ID, IDPrefix+token) {
+			t.Fatalf("Resolve(%q) = %q", token, got.ID)
+		}
+	}
+
+	_, err := Resolve("p8", candidates)
+	var ambiguous *AmbiguousError
+	if !errors.As(err, &ambiguous) || len(ambiguous.Candidates) != 2 {
+		t.Fatalf("expected ambiguity between the two p8 ids, got %v", err)
+	}
+	if _, err := Resolve("qq", candidates); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for unmatched prefix, got %v", err)
+	}
+
+	r, err := Parse("p86")
+	if err != nil || r.Kind != KindPrefix {
+		t.Fatalf("Parse(p86) = %+v, %v; want prefix kind", r, err)
+	}
+	if !NeedsLookup("p86") {
+		t.Fatal("id prefixes should need a lookup")
+	}
+}
@@ -9,23 +9,58 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/http/httputil"
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/avivsinai/sabx/internal/buildinfo"
 )
 
 const (
 	defaultTimeout = 15 * time.Second
+
+	// DefaultMaxResponseSize bounds how much of a response body is read. It
+	// is far above anything SABnzbd returns normally, even for showlog.
+	DefaultMaxResponseSize int64 = 64 << 20
 )
 
-// Client wraps SABnzbd's HTTP API.
+// ErrResponseTooLarge reports a response body larger than the configured
+// maximum.
+var ErrResponseTooLarge = errors.New("response exceeded limit")
+
+// Client wraps SABnzbd's HTTP API. A Client is safe for concurrent use by
+// multiple goroutines: its configuration is immutable after construction and
+// requests go through the underlying http.Client, whose transport pools
+// connections safely. Use Clone to derive a client with different options.
 type Client struct {
-	baseURL string
-	apiKey  string
-	http    *http.Client
+	baseURL   string
+	apiKey    string
+	userAgent string
+	http      *http.Client
+	maxBody   int64
+
+	// apiKeyHeader, when set, carries the API key for a gateway in front of
+	// SABnzbd; apiKeyQuery controls whether the apikey parameter SABnzbd
+	// itself checks is still sent.
+	apiKeyHeader string
+	apiKeyQuery  bool
+
+	// retries is how many times a failed request is repeated, waiting
+	// retryBackoff (doubling each time) in between; retryBudget, when set,
+	// caps the total time spent across all attempts.
+	retries      int
+	retryBackoff time.Duration
+	retryBudget  time.Duration
+
+	minInterval time.Duration
+	throttleMu  sync.Mutex
+	lastRequest time.Time
 }
 
 // Option configures the Client.
@@ -38,21 +73,237 @@ func WithHTTPClient(httpClient *http.Client) Option {
 	}
 }
 
+// WithMinInterval enforces a minimum delay between consecutive requests so
+// polling loops cannot hammer SABnzbd. Zero disables the limit.
+func WithMinInterval(d time.Duration) Option {
+	return func(c *Client) {
+		c.throttleMu.Lock()
+		defer c.throttleMu.Unlock()
+		c.minInterval = d
+	}
+}
+
+// WithUserAgent overrides the User-Agent header sent with every request.
+// An empty value keeps the default sabx/<version>.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) {
+		if ua = strings.TrimSpace(ua); ua != "" {
+			c.userAgent = ua
+		}
+	}
+}
+
+// WithMaxResponseSize caps the number of response body bytes read per
+// request; larger bodies fail with ErrResponseTooLarge instead of being
+// buffered. Non-positive values keep DefaultMaxResponseSize.
+func WithMaxResponseSize(n int64) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.maxBody = n
+		}
+	}
+}
+
+// WithAPIKeyHeader sends the API key in the named request header and drops
+// the apikey query parameter, keeping the key out of proxy access logs. This
+// is for API gateways that authenticate by header: SABnzbd itself only reads
+// apikey, so when the gateway forwards requests unchanged combine it with
+// WithAPIKeyQuery(true). An empty name keeps the default query-only mode.
+func WithAPIKeyHeader(name string) Option {
+	return func(c *Client) {
+		if name = strings.TrimSpace(name); name != "" {
+			c.apiKeyHeader = name
+			c.apiKeyQuery = false
+		}
+	}
+}
+
+// WithAPIKeyQuery controls whether the apikey parameter is sent. Apply it
+// after WithAPIKeyHeader to send the key both ways. Disabling the parameter
+// without a header is ignored, since SABnzbd would then reject every call.
+func WithAPIKeyQuery(enabled bool) Option {
+	return func(c *Client) {
+		c.apiKeyQuery = enabled || c.apiKeyHeader == ""
+	}
+}
+
+// WithRetry repeats a request up to retries more times when it fails with a
+// transport error (including a per-request timeout) or a 5xx response,
+// waiting backoff before the first retry and doubling it after each one.
+// Redirects, 4xx responses and a cancelled context are never retried, and
+// neither is any request that may change SABnzbd's state (adding, deleting,
+// retrying or reconfiguring): a 5xx from a proxy does not prove SABnzbd did
+// not act on it. See readOnlyRequest.
+func WithRetry(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		if retries < 0 {
+			retries = 0
+		}
+		if backoff < 0 {
+			backoff = 0
+		}
+		c.retries = retries
+		c.retryBackoff = backoff
+	}
+}
+
+// WithRetryBudget stops retrying once d has elapsed since a request's first
+// attempt, or when the next backoff would end past it, and returns the last
+// error however many retries remain. Zero means no budget.
+func WithRetryBudget(d time.Duration) Option {
+	return func(c *Client) {
+		if d < 0 {
+			d = 0
+		}
+		c.retryBudget = d
+	}
+}
+
+// DefaultUserAgent identifies sabx traffic to SABnzbd and any reverse proxy.
+func DefaultUserAgent() string {
+	return "sabx/" + buildinfo.Version
+}
+
+// WithTimeout sets the HTTP timeout applied to each request. The HTTP client
+// is copied first so a client shared via WithHTTPClient is not modified.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		hc := *c.http
+		hc.Timeout = d
+		c.http = &hc
+	}
+}
+
+// WithTrace dumps every HTTP request and response to w, with the API key
+// redacted. It is a last-resort aid for proxy and TLS problems and is never
+// enabled by default. Apply it after WithHTTPClient so the supplied transport
+// is the one being traced.
+func WithTrace(w io.Writer) Option {
+	return func(c *Client) {
+		if w == nil {
+			return
+		}
+		hc := *c.http
+		base := hc.Transport
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		hc.Transport = &traceTransport{base: base, w: w, apiKey: c.apiKey}
+		c.http = &hc
+	}
+}
+
+// traceTransport writes a wire dump of each round trip. Multipart upload
+// bodies are omitted because they can be large and binary.
+type traceTransport struct {
+	base   http.RoundTripper
+	w      io.Writer
+	apiKey string
+	mu     sync.Mutex
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	withBody := !strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/")
+	dump, err := httputil.DumpRequestOut(req, withBody)
+	if err != nil {
+		return nil, err
+	}
+	t.write("request", dump)
+	if !withBody {
+		t.write("", []byte(fmt.Sprintf("[multipart body of %d bytes omitted]\n", req.ContentLength)))
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		t.write(fmt.Sprintf("error after %s", elapsed), []byte(err.Error()+"\n"))
+		return nil, err
+	}
+	dump, err = httputil.DumpResponse(resp, true)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	t.write(fmt.Sprintf("response after %s", elapsed), dump)
+	return resp, nil
+}
+
+func (t *traceTransport) write(label string, dump []byte) {
+	text := string(dump)
+	if t.apiKey != "" {
+		text = strings.ReplaceAll(text, t.apiKey, "REDACTED")
+		if escaped := url.QueryEscape(t.apiKey); escaped != t.apiKey {
+			text = strings.ReplaceAll(text, escaped, "REDACTED")
+		}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if label != "" {
+		fmt.Fprintf(t.w, "--- %s ---\n", label)
+	}
+	fmt.Fprint(t.w, text)
+	if !strings.HasSuffix(text, "\n") {
+		fmt.Fprintln(t.w)
+	}
+}
+
+// NormalizeBaseURL cleans a user-supplied SABnzbd address: it defaults the
+// scheme to http, strips a pasted /api endpoint and trailing slashes, and
+// rejects values that are not absolute http(s) URLs with a host.
+func NormalizeBaseURL(raw string) (string, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return "", errors.New("base URL required")
+	}
+	if !strings.Contains(trimmed, "://") {
+		trimmed = "http://" + trimmed
+	}
+
+	u, err := url.Parse(trimmed)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL %q: %w", raw, err)
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return "", fmt.Errorf("invalid base URL %q: scheme must be http or https", raw)
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("invalid base URL %q: missing host", raw)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("invalid base URL %q: remove the query string", raw)
+	}
+
+	path := strings.TrimRight(u.Path, "/")
+	if strings.HasSuffix(strings.ToLower(path), "/api") {
+		path = strings.TrimRight(path[:len(path)-len("/api")], "/")
+	}
+	u.Scheme = scheme
+	u.Path = path
+	u.RawPath = ""
+	return u.String(), nil
+}
+
 // NewClient constructs an API client.
 func NewClient(baseURL, apiKey string, opts ...Option) (*Client, error) {
-	if baseURL == "" {
-		return nil, errors.New("base URL required")
+	cleaned, err := NormalizeBaseURL(baseURL)
+	if err != nil {
+		return nil, err
 	}
 	if apiKey == "" {
 		return nil, errors.New("API key required")
 	}
 
-	cleaned := strings.TrimSuffix(baseURL, "/")
 	client := &Client{
-		baseURL: cleaned,
-		apiKey:  apiKey,
+		baseURL:     cleaned,
+		apiKey:      apiKey,
+		userAgent:   DefaultUserAgent(),
+		maxBody:     DefaultMaxResponseSize,
+		apiKeyQuery: true,
 		http: &http.Client{
-			Timeout: defaultTimeout,
+			Timeout:       defaultTimeout,
+			CheckRedirect: noRedirect,
 		},
 	}
 	for _, opt := range opts {
@@ -61,33 +312,205 @@ func NewClient(baseURL, apiKey string, opts ...Option) (*Client, error) {
 	return client, nil
 }
 
+// Clone returns an independent client for the same SABnzbd instance with
+// opts applied on top. The clone shares the HTTP transport (and therefore
+// its connection pool) but has its own timeout and request throttle.
+func (c *Client) Clone(opts ...Option) *Client {
+	hc := *c.http
+	clone := &Client{
+		baseURL:      c.baseURL,
+		apiKey:       c.apiKey,
+		userAgent:    c.userAgent,
+		http:         &hc,
+		maxBody:      c.maxBody,
+		apiKeyHeader: c.apiKeyHeader,
+		apiKeyQuery:  c.apiKeyQuery,
+		retries:      c.retries,
+		retryBackoff: c.retryBackoff,
+		retryBudget:  c.retryBudget,
+	}
+	c.throttleMu.Lock()
+	clone.minInterval = c.minInterval
+	c.throttleMu.Unlock()
+	for _, opt := range opts {
+		opt(clone)
+	}
+	return clone
+}
+
 // do performs a request and returns the raw HTTP response.
 func (c *Client) do(ctx context.Context, mode string, params url.Values) (*http.Response, error) {
 	if params == nil {
 		params = url.Values{}
 	}
 	params.Set("mode", mode)
-	params.Set("apikey", c.apiKey)
+	if c.apiKeyQuery {
+		params.Set("apikey", c.apiKey)
+	}
 
 	endpoint := c.baseURL + "/api"
 	reqURL := endpoint + "?" + params.Encode()
 
+	retries := c.retries
+	if !readOnlyRequest(mode, params) {
+		retries = 0
+	}
+	start := time.Now()
+	backoff := c.retryBackoff
+	for attempt := 0; ; attempt++ {
+		resp, retryable, err := c.send(ctx, reqURL)
+		if err == nil || !retryable || attempt >= retries || ctx.Err() != nil {
+			return resp, err
+		}
+		if c.retryBudget > 0 && time.Since(start)+backoff >= c.retryBudget {
+			return nil, err
+		}
+		if backoff > 0 {
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, err
+			case <-timer.C:
+			}
+		}
+		backoff *= 2
+	}
+}
+
+// readOnlyModes never change SABnzbd's state.
+var readOnlyModes = map[string]bool{
+	"browse":       true,
+	"eval_sort":    true,
+	"fullstatus":   true,
+	"gc_stats":     true,
+	"get_config":   true,
+	"get_files":    true,
+	"get_scripts":  true,
+	"server_stats": true,
+	"showlog":      true,
+	"translate":    true,
+	"version":      true,
+}
+
+// readOnlyWithoutName are modes that only read unless a name selects an
+// action (queue name=delete, status name=unblock_server, warnings
+// name=clear, ...).
+var readOnlyWithoutName = map[string]bool{
+	"history":  true,
+	"queue":    true,
+	"status":   true,
+	"warnings": true,
+}
+
+// readOnlyRequest reports whether a request is safe to repeat, i.e. it
+// cannot enqueue, delete or change anything in SABnzbd.
+func readOnlyRequest(mode string, params url.Values) bool {
+	if readOnlyModes[mode] {
+		return true
+	}
+	return readOnlyWithoutName[mode] && params.Get("name") == ""
+}
+
+// send performs one attempt of a GET request. retryable reports whether the
+// failure is one WithRetry may repeat: a transport error or a 5xx response.
+func (c *Client) send(ctx context.Context, reqURL string) (resp *http.Response, retryable bool, err error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
+	req.Header.Set("User-Agent", c.userAgent)
+	c.setAPIKeyHeader(req)
 
-	resp, err := c.http.Do(req)
+	if err := c.throttle(ctx); err != nil {
+		return nil, false, err
+	}
+
+	resp, err = c.http.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, true, err
 	}
 
+	if err := checkResponse(req, resp); err != nil {
+		resp.Body.Close()
+		return nil, resp.StatusCode >= 500, err
+	}
+
+	return resp, false, nil
+}
+
+func (c *Client) setAPIKeyHeader(req *http.Request) {
+	if c.apiKeyHeader != "" {
+		req.Header.Set(c.apiKeyHeader, c.apiKey)
+	}
+}
+
+// ErrRedirected is returned when the API endpoint answers with a redirect,
+// typically a reverse proxy sending unauthenticated requests to a login page.
+var ErrRedirected = errors.New("base URL redirected")
+
+// noRedirect stops the default HTTP client from following redirects so a
+// proxy login page is reported instead of being decoded as an API response.
+func noRedirect(*http.Request, []*http.Request) error {
+	return http.ErrUseLastResponse
+}
+
+// checkResponse rejects HTTP errors and redirects. Redirects are caught both
+// as a 3xx answer and, for caller-supplied clients that follow them, as a
+// final URL that no longer points at the requested endpoint.
+func checkResponse(req *http.Request, resp *http.Response) error {
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		location := resp.Header.Get("Location")
+		if loc, err := resp.Location(); err == nil {
+			location = redirectTarget(loc)
+		}
+		if location == "" {
+			location = "an unknown location"
+		}
+		return fmt.Errorf("%w to %s; check auth/proxy", ErrRedirected, location)
+	}
+	if final := resp.Request; final != nil && final.URL != nil &&
+		(final.URL.Host != req.URL.Host || final.URL.Path != req.URL.Path) {
+		return fmt.Errorf("%w to %s; check auth/proxy", ErrRedirected, redirectTarget(final.URL))
+	}
 	if resp.StatusCode >= 400 {
-		defer resp.Body.Close()
-		return nil, fmt.Errorf("sabnzbd API error: %s", resp.Status)
+		return fmt.Errorf("sabnzbd API error: %s", resp.Status)
 	}
+	return nil
+}
 
-	return resp, nil
+// redirectTarget drops the query string, which may echo the API key back in
+// a return-to parameter.
+func redirectTarget(u *url.URL) string {
+	clean := *u
+	clean.RawQuery = ""
+	clean.Fragment = ""
+	clean.User = nil
+	return clean.String()
+}
+
+// throttle blocks until the configured minimum interval has elapsed since the
+// previous request, or until ctx is done.
+func (c *Client) throttle(ctx context.Context) error {
+	c.throttleMu.Lock()
+	defer c.throttleMu.Unlock()
+
+	if c.minInterval <= 0 {
+		return nil
+	}
+	if !c.lastRequest.IsZero() {
+		if wait := c.minInterval - time.Since(c.lastRequest); wait > 0 {
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+	c.lastRequest = time.Now()
+	return nil
 }
 
 // call performs a request and decodes JSON into dest if provided.
@@ -108,7 +531,7 @@ func (c *Client) call(ctx context.Context, mode string, params url.Values, dest
 		return nil
 	}
 
-	decoder := json.NewDecoder(resp.Body)
+	decoder := json.NewDecoder(c.limitBody(resp.Body))
 	if err := decoder.Decode(dest); err != nil {
 		return err
 	}
@@ -116,8 +539,97 @@ func (c *Client) call(ctx context.Context, mode string, params url.Values, dest
 	return nil
 }
 
+// limitBody wraps a response body so reads fail once more than maxBody bytes
+// have arrived.
+func (c *Client) limitBody(r io.Reader) io.Reader {
+	limit := c.maxBody
+	if limit <= 0 {
+		limit = DefaultMaxResponseSize
+	}
+	return &limitedBody{r: r, limit: limit}
+}
+
+type limitedBody struct {
+	r     io.Reader
+	limit int64
+	read  int64
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		return 0, fmt.Errorf("%w of %d bytes; raise --max-response-size if this is expected", ErrResponseTooLarge, l.limit)
+	}
+	return n, err
+}
+
+// SearchField scopes a queue search term.
+type SearchField string
+
+const (
+	// SearchFieldAll passes the term to SABnzbd's own search parameter,
+	// which applies SABnzbd's matching rules (including * wildcards).
+	SearchFieldAll SearchField = "all"
+	// SearchFieldName matches the term literally, case-insensitively, as a
+	// substring of job names; sabx filters the queue itself.
+	SearchFieldName SearchField = "name"
+	// SearchFieldCategory matches jobs in the named category (comma-separate
+	// several categories).
+	SearchFieldCategory SearchField = "category"
+)
+
+// ParseSearchField validates a search scope; an empty value means all.
+func ParseSearchField(raw string) (SearchField, error) {
+	switch field := SearchField(strings.ToLower(strings.TrimSpace(raw))); field {
+	case "", SearchFieldAll:
+		return SearchFieldAll, nil
+	case SearchFieldName, SearchFieldCategory:
+		return field, nil
+	default:
+		return "", fmt.Errorf("unsupported search field %q (expected name, category or all)", raw)
+	}
+}
+
+// filterSlotsByName keeps slots whose filename contains term
+// (case-insensitive), then skips start matches and keeps at most limit.
+func filterSlotsByName(slots []QueueSlot, term string, start, limit int) []QueueSlot {
+	term = strings.ToLower(term)
+	matched := make([]QueueSlot, 0, len(slots))
+	for _, slot := range slots {
+		if strings.Contains(strings.ToLower(slot.Filename), term) {
+			matched = append(matched, slot)
+		}
+	}
+	if start > len(matched) {
+		start = len(matched)
+	}
+	matched = matched[start:]
+	if limit > 0 && limit < len(matched) {
+		matched = matched[:limit]
+	}
+	return matched
+}
+
 // Queue returns current queue state.
 func (c *Client) Queue(ctx context.Context, start, limit int, search string) (*QueueResponse, error) {
+	return c.QueueSearch(ctx, start, limit, search, SearchFieldAll)
+}
+
+// QueueSearch returns queue state filtered by search within field. All uses
+// SABnzbd's search parameter and category its cat filter. Name fetches the
+// whole queue and keeps jobs whose name contains search literally, applying
+// start and limit to the matches.
+func (c *Client) QueueSearch(ctx context.Context, start, limit int, search string, field SearchField) (*QueueResponse, error) {
+	if field == SearchFieldName && search != "" {
+		queue, err := c.QueueSearch(ctx, 0, 0, "", SearchFieldAll)
+		if err != nil {
+			return nil, err
+		}
+		queue.Slots = filterSlotsByName(queue.Slots, search, start, limit)
+		return queue, nil
+	}
+
 	params := url.Values{}
 	if start > 0 {
 		params.Set("start", fmt.Sprintf("%d", start))
@@ -126,16 +638,69 @@ func (c *Client) Queue(ctx context.Context, start, limit int, search string) (*Q
 		params.Set("limit", fmt.Sprintf("%d", limit))
 	}
 	if search != "" {
-		params.Set("search", search)
+		switch field {
+		case SearchFieldCategory:
+			params.Set("cat", search)
+		case "", SearchFieldAll:
+			params.Set("search", search)
+		default:
+			return nil, fmt.Errorf("unsupported search field %q", field)
+		}
 	}
 
 	var resp QueueEnvelope
 	if err := c.call(ctx, "queue", params, &resp); err != nil {
 		return nil, err
 	}
+	classifySlots(resp.Queue.Slots)
 	return &resp.Queue, nil
 }
 
+// ErrSlotNotFound is returned when a queue or history lookup matches no slot.
+var ErrSlotNotFound = errors.New("slot not found")
+
+// QueueSlotByID fetches a single queue slot using SABnzbd's nzo_ids filter.
+func (c *Client) QueueSlotByID(ctx context.Context, nzoID string) (*QueueSlot, error) {
+	if strings.TrimSpace(nzoID) == "" {
+		return nil, errors.New("nzo id required")
+	}
+	params := url.Values{}
+	params.Set("nzo_ids", nzoID)
+
+	var resp QueueEnvelope
+	if err := c.call(ctx, "queue", params, &resp); err != nil {
+		return nil, err
+	}
+	classifySlots(resp.Queue.Slots)
+	for i := range resp.Queue.Slots {
+		if resp.Queue.Slots[i].NZOID == nzoID {
+			return &resp.Queue.Slots[i], nil
+		}
+	}
+	return nil, ErrSlotNotFound
+}
+
+// StageLog is a post-processing stage (verify, repair, unpack, ...) and its
+// log output as reported by SABnzbd.
+type StageLog struct {
+	Stage string `json:"stage"`
+	Log   string `json:"log"`
+}
+
+var stageLogBreak = regexp.MustCompile(`(?i)\r?\n|<br\s*/?>`)
+
+// Lines splits the packed Log into individual messages, dropping blanks.
+func (s StageLog) Lines() []string {
+	parts := stageLogBreak.Split(s.Log, -1)
+	lines := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			lines = append(lines, part)
+		}
+	}
+	return lines
+}
+
 // QueueResponse models the queue API payload.
 type QueueResponse struct {
 	Slots      []QueueSlot `json:"slots"`
@@ -156,23 +721,79 @@ type QueueEnvelope struct {
 
 // QueueSlot represents an item in the queue.
 type QueueSlot struct {
-	NZOID      string `json:"nzo_id"`
-	Filename   string `json:"filename"`
-	Status     string `json:"status"`
-	Paused     bool   `json:"paused"`
-	Speed      string `json:"kbpersec"`
-	MB         string `json:"mb"`
-	MBLeft     string `json:"mbleft"`
-	Percentage string `json:"percentage"`
-	Priority   string `json:"priority"`
-	Category   string `json:"cat"`
-	Script     string `json:"script"`
-	Eta        string `json:"eta"`
-	TimeLeft   string `json:"timeleft"`
-	StageLog   []struct {
-		Stage string `json:"stage"`
-		Log   string `json:"log"`
-	} `json:"stage_log"`
+	NZOID      string     `json:"nzo_id"`
+	Filename   string     `json:"filename"`
+	Status     string     `json:"status"`
+	Paused     bool       `json:"paused"`
+	Speed      string     `json:"kbpersec"`
+	MB         string     `json:"mb"`
+	MBLeft     string     `json:"mbleft"`
+	Percentage string     `json:"percentage"`
+	Priority   string     `json:"priority"`
+	Category   string     `json:"cat"`
+	Script     string     `json:"script"`
+	Eta        string     `json:"eta"`
+	TimeLeft   string     `json:"timeleft"`
+	StageLog   []StageLog `json:"stage_log"`
+	// StatusClass is filled in by the client from Status; SABnzbd does not
+	// send it.
+	StatusClass StatusClass `json:"status_class,omitempty"`
+}
+
+// StatusClass groups SABnzbd's job statuses into a few stable buckets.
+type StatusClass string
+
+// Status classes reported as status_class.
+const (
+	StatusClassActive         StatusClass = "active"
+	StatusClassPending        StatusClass = "pending"
+	StatusClassPaused         StatusClass = "paused"
+	StatusClassPostProcessing StatusClass = "post_processing"
+	StatusClassCompleted      StatusClass = "completed"
+	StatusClassFailed         StatusClass = "failed"
+	StatusClassUnknown        StatusClass = "unknown"
+)
+
+var statusClasses = map[string]StatusClass{
+	"downloading": StatusClassActive,
+	"fetching":    StatusClassActive,
+	"grabbing":    StatusClassActive,
+	"queued":      StatusClassPending,
+	"propagating": StatusClassPending,
+	"checking":    StatusClassPending,
+	"paused":      StatusClassPaused,
+	"quickcheck":  StatusClassPostProcessing,
+	"verifying":   StatusClassPostProcessing,
+	"repairing":   StatusClassPostProcessing,
+	"extracting":  StatusClassPostProcessing,
+	"moving":      StatusClassPostProcessing,
+	"running":     StatusClassPostProcessing,
+	"completed":   StatusClassCompleted,
+	"failed":      StatusClassFailed,
+}
+
+// ClassifyStatus maps a raw SABnzbd status ("Downloading", "Propagating",
+// "Extracting", ...) to its StatusClass, ignoring case.
+func ClassifyStatus(status string) StatusClass {
+	if class, ok := statusClasses[strings.ToLower(strings.TrimSpace(status))]; ok {
+		return class
+	}
+	return StatusClassUnknown
+}
+
+// Class reports the slot's StatusClass. A slot flagged paused counts as
+// paused whatever its status text says.
+func (s QueueSlot) Class() StatusClass {
+	if s.Paused {
+		return StatusClassPaused
+	}
+	return ClassifyStatus(s.Status)
+}
+
+func classifySlots(slots []QueueSlot) {
+	for i := range slots {
+		slots[i].StatusClass = slots[i].Class()
+	}
 }
 
 // QueueAction executes queue-affecting commands.
@@ -197,6 +818,9 @@ func (c *Client) AddURL(ctx context.Context, nzbURL string, opts AddOptions) (*A
 	if opts.Priority != nil {
 		params.Set("priority", fmt.Sprintf("%d", *opts.Priority))
 	}
+	if opts.PPLevel != nil {
+		params.Set("pp", fmt.Sprintf("%d", *opts.PPLevel))
+	}
 	if opts.Password != "" {
 		params.Set("password", opts.Password)
 	}
@@ -213,6 +837,38 @@ func (c *Client) AddURL(ctx context.Context, nzbURL string, opts AddOptions) (*A
 	return &resp, nil
 }
 
+// BatchAddResult is the outcome of one URL in BatchAddURL.
+type BatchAddResult struct {
+	URL      string
+	Response *AddResponse
+	Err      error
+}
+
+// BatchAddURL adds each URL in order with shared options. Failures are
+// recorded per URL and do not stop the batch; a cancelled context does.
+func (c *Client) BatchAddURL(ctx context.Context, urls []string, opts AddOptions) []BatchAddResult {
+	results := make([]BatchAddResult, 0, len(urls))
+	for _, nzbURL := range urls {
+		if err := ctx.Err(); err != nil {
+			results = append(results, BatchAddResult{URL: nzbURL, Err: err})
+			continue
+		}
+		resp, err := c.AddURL(ctx, nzbURL, opts)
+		if err == nil && !resp.Success() {
+			reason := resp.Error
+			if reason == "" {
+				reason = resp.Message
+			}
+			if reason == "" {
+				reason = "unknown error"
+			}
+			err = fmt.Errorf("sabnzbd refused nzb: %s", reason)
+		}
+		results = append(results, BatchAddResult{URL: nzbURL, Response: resp, Err: err})
+	}
+	return results
+}
+
 // AddFile uploads an NZB file via multipart form upload.
 func (c *Client) AddFile(ctx context.Context, path string, opts AddOptions) (*AddResponse, error) {
 	file, err := os.Open(path)
@@ -226,9 +882,11 @@ func (c *Client) AddFile(ctx context.Context, path string, opts AddOptions) (*Ad
 
 	fields := map[string]string{
 		"mode":   "addfile",
-		"apikey": c.apiKey,
 		"output": "json",
 	}
+	if c.apiKeyQuery {
+		fields["apikey"] = c.apiKey
+	}
 	if opts.Category != "" {
 		fields["cat"] = opts.Category
 	}
@@ -241,6 +899,9 @@ func (c *Client) AddFile(ctx context.Context, path string, opts AddOptions) (*Ad
 	if opts.Priority != nil {
 		fields["priority"] = fmt.Sprintf("%d", *opts.Priority)
 	}
+	if opts.PPLevel != nil {
+		fields["pp"] = fmt.Sprintf("%d", *opts.PPLevel)
+	}
 	if opts.Name != "" {
 		fields["nzbname"] = opts.Name
 	}
@@ -262,11 +923,23 @@ func (c *Client) AddFile(ctx context.Context, path string, opts AddOptions) (*Ad
 		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api", body)
+	total := int64(body.Len())
+	var reader io.Reader = body
+	if opts.Progress != nil {
+		reader = &progressReader{r: body, total: total, fn: opts.Progress}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api", reader)
 	if err != nil {
 		return nil, err
 	}
+	req.ContentLength = total
 	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("User-Agent", c.userAgent)
+	c.setAPIKeyHeader(req)
+
+	if err := c.throttle(ctx); err != nil {
+		return nil, err
+	}
 
 	resp, err := c.http.Do(req)
 	if err != nil {
@@ -274,18 +947,38 @@ func (c *Client) AddFile(ctx context.Context, path string, opts AddOptions) (*Ad
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("sabnzbd API error: %s", resp.Status)
+	if err := checkResponse(req, resp); err != nil {
+		return nil, err
 	}
 
 	var addResp AddResponse
-	decoder := json.NewDecoder(resp.Body)
+	decoder := json.NewDecoder(c.limitBody(resp.Body))
 	if err := decoder.Decode(&addResp); err != nil {
 		return nil, err
 	}
 	return &addResp, nil
 }
 
+// ProgressFunc receives the number of bytes uploaded so far and the total.
+type ProgressFunc func(sent, total int64)
+
+// progressReader reports cumulative reads to fn.
+type progressReader struct {
+	r     io.Reader
+	total int64
+	sent  int64
+	fn    ProgressFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.sent += int64(n)
+		p.fn(p.sent, p.total)
+	}
+	return n, err
+}
+
 // AddLocalFile instructs SABnzbd to enqueue an NZB located on the server filesystem.
 func (c *Client) AddLocalFile(ctx context.Context, remotePath string, opts AddOptions) (*AddResponse, error) {
 	if strings.TrimSpace(remotePath) == "" {
@@ -299,6 +992,9 @@ func (c *Client) AddLocalFile(ctx context.Context, remotePath string, opts AddOp
 	if opts.Priority != nil {
 		params.Set("priority", fmt.Sprintf("%d", *opts.Priority))
 	}
+	if opts.PPLevel != nil {
+		params.Set("pp", fmt.Sprintf("%d", *opts.PPLevel))
+	}
 	if opts.Password != "" {
 		params.Set("password", opts.Password)
 	}
@@ -345,7 +1041,39 @@ func (c *Client) QueueDelete(ctx context.Context, ids []string, withData bool) e
 	if withData {
 		params.Set("del_files", "1")
 	}
-	return c.QueueAction(ctx, "delete", params)
+	params.Set("name", "delete")
+
+	var resp queueDeleteEnvelope
+	if err := c.call(ctx, "queue", params, &resp); err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		if isNotFoundMessage(resp.Error) {
+			return fmt.Errorf("%w: %s", ErrItemNotFound, strings.Join(ids, ","))
+		}
+		return fmt.Errorf("delete rejected by SABnzbd: %s", resp.Error)
+	}
+	// SABnzbd reports status false with no removed ids when none of the
+	// requested jobs exist any more.
+	if resp.Status != nil && !bool(*resp.Status) && len(resp.NZOIDs) == 0 && len(ids) > 0 {
+		return fmt.Errorf("%w: %s", ErrItemNotFound, strings.Join(ids, ","))
+	}
+	return nil
+}
+
+// ErrItemNotFound is returned when SABnzbd reports that the targeted job no
+// longer exists, typically because it was already removed.
+var ErrItemNotFound = errors.New("item not found")
+
+type queueDeleteEnvelope struct {
+	Status *Boolish `json:"status"`
+	NZOIDs []string `json:"nzo_ids"`
+	Error  string   `json:"error"`
+}
+
+func isNotFoundMessage(msg string) bool {
+	lower := strings.ToLower(msg)
+	return strings.Contains(lower, "not found") || strings.Contains(lower, "no such")
 }
 
 // QueueSetPriority sets item priority (-1 low,0 normal,1 high,2 force).
@@ -393,6 +1121,17 @@ func (c *Client) QueueSwitchPosition(ctx context.Context, id string, position in
 
 // QueueSort sorts the queue by supported criteria.
 func (c *Client) QueueSort(ctx context.Context, sortCrit, direction string) error {
+	switch sortCrit {
+	case "name", "avg_age", "size", "eta", "priority":
+	default:
+		return fmt.Errorf("unsupported queue sort %q", sortCrit)
+	}
+	switch direction {
+	case "", "asc", "desc":
+	default:
+		return fmt.Errorf("unsupported sort direction %q", direction)
+	}
+
 	params := url.Values{}
 	params.Set("sort", sortCrit)
 	if direction != "" {
@@ -418,6 +1157,45 @@ func (c *Client) History(ctx context.Context, failed bool, limit int) (*HistoryR
 	return &resp.History, nil
 }
 
+// HistorySearch fetches history entries whose name matches search, using
+// SABnzbd's own history filter (case-insensitive, * wildcards). An empty
+// search returns the full history, like History.
+func (c *Client) HistorySearch(ctx context.Context, search string, limit int) (*HistoryResponse, error) {
+	params := url.Values{}
+	if search != "" {
+		params.Set("search", search)
+	}
+	if limit > 0 {
+		params.Set("limit", fmt.Sprintf("%d", limit))
+	}
+
+	var resp HistoryEnvelope
+	if err := c.call(ctx, "history", params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.History, nil
+}
+
+// HistorySlotByID fetches a single history entry using SABnzbd's nzo_ids filter.
+func (c *Client) HistorySlotByID(ctx context.Context, nzoID string) (*HistorySlot, error) {
+	if strings.TrimSpace(nzoID) == "" {
+		return nil, errors.New("nzo id required")
+	}
+	params := url.Values{}
+	params.Set("nzo_ids", nzoID)
+
+	var resp HistoryEnvelope
+	if err := c.call(ctx, "history", params, &resp); err != nil {
+		return nil, err
+	}
+	for i := range resp.History.Slots {
+		if resp.History.Slots[i].NZOID == nzoID {
+			return &resp.History.Slots[i], nil
+		}
+	}
+	return nil, ErrSlotNotFound
+}
+
 // HistoryResponse wraps history items.
 type HistoryResponse struct {
 	Slots []HistorySlot `json:"slots"`
@@ -430,15 +1208,13 @@ type HistoryEnvelope struct {
 
 // HistorySlot describes a history entry.
 type HistorySlot struct {
-	NZOID    string `json:"nzo_id"`
-	Name     string `json:"name"`
-	Status   string `json:"status"`
-	Category string `json:"category"`
-	StageLog []struct {
-		Stage string `json:"stage"`
-		Log   string `json:"log"`
-	} `json:"stage_log"`
-	Completed string `json:"completed"`
+	NZOID     string     `json:"nzo_id"`
+	Name      string     `json:"name"`
+	Status    string     `json:"status"`
+	Category  string     `json:"category"`
+	StageLog  []StageLog `json:"stage_log"`
+	Completed UnixTime   `json:"completed"`
+	Storage   string     `json:"storage"`
 }
 
 // DeleteHistory removes items from history.
@@ -532,6 +1308,21 @@ func (c *Client) ConfigGet(ctx context.Context, section, key string) (map[string
 	return resp, nil
 }
 
+// ConfigGetAll retrieves every configuration section in one request, keyed
+// by section name.
+func (c *Client) ConfigGetAll(ctx context.Context) (map[string]any, error) {
+	var resp struct {
+		Config map[string]any `json:"config"`
+	}
+	if err := c.call(ctx, "get_config", url.Values{}, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Config == nil {
+		return nil, errors.New("get_config returned no config sections")
+	}
+	return resp.Config, nil
+}
+
 // ConfigSet sets configuration values.
 func (c *Client) ConfigSet(ctx context.Context, section, name string, values url.Values) error {
 	params := url.Values{}
@@ -678,6 +1469,122 @@ func (c *Client) Status(ctx context.Context) (*StatusResponse, error) {
 	return &resp, nil
 }
 
+// ResolveSpeedLimit reports the effective global speed limit in KB/s. SABnzbd
+// stores a bare number as a percentage of misc/bandwidth_max; when that is
+// unset the measured internetbandwidth from fullstatus is used instead.
+// absKBps is zero when no limit is set or a percentage cannot be resolved.
+func (c *Client) ResolveSpeedLimit(ctx context.Context) (absKBps float64, isPercent bool, raw string, err error) {
+	status, err := c.Status(ctx)
+	if err != nil {
+		return 0, false, "", err
+	}
+	raw = strings.TrimSpace(status.SpeedLimit)
+	if !speedLimitIsPercent(raw) {
+		absKBps, err = SpeedLimitKBps(raw, 0)
+		return absKBps, false, raw, err
+	}
+
+	cfg, err := c.ConfigGet(ctx, "misc", "bandwidth_max")
+	if err != nil {
+		return 0, true, raw, err
+	}
+	maxBytes, err := parseBandwidth(miscConfigString(cfg, "bandwidth_max"))
+	if err != nil {
+		return 0, true, raw, err
+	}
+	if maxBytes == 0 {
+		full, err := c.FullStatus(ctx, FullStatusOptions{SkipDashboard: true})
+		if err != nil {
+			return 0, true, raw, err
+		}
+		// internetbandwidth is measured in MB/s.
+		if measured, ok := full["internetbandwidth"]; ok && measured != nil {
+			mbps, _ := ParseSABFloat(fmt.Sprint(measured))
+			maxBytes = mbps * 1024 * 1024
+		}
+	}
+	absKBps, err = SpeedLimitKBps(raw, maxBytes)
+	return absKBps, true, raw, err
+}
+
+// SpeedLimitKBps converts a SABnzbd speed limit value to KB/s. Values with a
+// K, M or G suffix are absolute; bare numbers are a percentage of
+// maxBytesPerSec. Zero means no limit (or an unknown maximum).
+func SpeedLimitKBps(raw string, maxBytesPerSec float64) (float64, error) {
+	raw = strings.TrimSpace(raw)
+	if !speedLimitIsPercent(raw) {
+		bytes, err := parseBandwidth(raw)
+		if err != nil {
+			return 0, err
+		}
+		return bytes / 1024, nil
+	}
+	percent, err := ParseSABFloat(strings.TrimSuffix(raw, "%"))
+	if err != nil {
+		return 0, err
+	}
+	if percent < 0 {
+		return 0, fmt.Errorf("invalid speed limit %q", raw)
+	}
+	return maxBytesPerSec * percent / 100 / 1024, nil
+}
+
+func speedLimitIsPercent(raw string) bool {
+	if raw == "" {
+		return false
+	}
+	switch raw[len(raw)-1] {
+	case 'K', 'k', 'M', 'm', 'G', 'g', 'B', 'b':
+		return false
+	}
+	return true
+}
+
+// parseBandwidth parses SABnzbd's bandwidth notation ("800K", "10M", "1.5G")
+// into bytes per second using binary multiples. Empty and "0" are zero.
+func parseBandwidth(value string) (float64, error) {
+	value = strings.TrimSuffix(strings.TrimSpace(value), "B")
+	value = strings.TrimSuffix(value, "b")
+	multiplier := 1.0
+	if value != "" {
+		switch value[len(value)-1] {
+		case 'K', 'k':
+			multiplier = 1024
+		case 'M', 'm':
+			multiplier = 1024 * 1024
+		case 'G', 'g':
+			multiplier = 1024 * 1024 * 1024
+		}
+		if multiplier != 1 {
+			value = value[:len(value)-1]
+		}
+	}
+	v, err := ParseSABFloat(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid bandwidth %q", value)
+	}
+	if v < 0 {
+		return 0, fmt.Errorf("invalid bandwidth %q", value)
+	}
+	return v * multiplier, nil
+}
+
+// miscConfigString extracts a misc keyword from a get_config response, which
+// SABnzbd nests under config.misc.
+func miscConfigString(resp map[string]any, key string) string {
+	section := resp
+	if cfg, ok := resp["config"].(map[string]any); ok {
+		section = cfg
+	}
+	if misc, ok := section["misc"].(map[string]any); ok {
+		section = misc
+	}
+	if v, ok := section[key]; ok && v != nil {
+		return strings.TrimSpace(fmt.Sprint(v))
+	}
+	return ""
+}
+
 // Version returns SABnzbd version info.
 func (c *Client) Version(ctx context.Context) (*VersionResponse, error) {
 	var resp VersionResponse
@@ -716,6 +1623,68 @@ func (c *Client) Translate(ctx context.Context, key string) (string, error) {
 	return env.Value, nil
 }
 
+// translateConcurrency bounds the number of in-flight translate requests.
+const translateConcurrency = 4
+
+// TranslateMany resolves several translation keys concurrently and returns
+// them keyed by the original string. The first failure cancels the batch.
+func (c *Client) TranslateMany(ctx context.Context, keys []string) (map[string]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		results  = make(map[string]string, len(keys))
+		seen     = make(map[string]struct{}, len(keys))
+		sem      = make(chan struct{}, translateConcurrency)
+	)
+
+	for _, key := range keys {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			value, err := c.Translate(ctx, key)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("translate %q: %w", key, err)
+					cancel()
+				}
+				return
+			}
+			results[key] = value
+		}(key)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
 // BrowseOptions configures parameters for SABnzbd's filesystem browser.
 type BrowseOptions struct {
 	Compact           bool
@@ -885,9 +1854,12 @@ func (c *Client) CategoriesList(ctx context.Context) (map[string]any, error) {
 type AddOptions struct {
 	Category string
 	Priority *int
+	PPLevel  *int
 	Password string
 	Script   string
 	Name     string
+	// Progress, when set, is called as AddFile uploads the request body.
+	Progress ProgressFunc
 }
 
 // AddResponse represents addurl/addfile response payloads from SABnzbd.
@@ -903,6 +1875,48 @@ func (a AddResponse) Success() bool {
 	return bool(a.Status)
 }
 
+// Accepted reports whether SABnzbd accepted the add and returned at least one
+// job id. A successful response without ids usually means SABnzbd dropped the
+// NZB, for example as a duplicate of a history entry.
+func (a AddResponse) Accepted() bool {
+	return a.Success() && len(a.NZOIDs) > 0
+}
+
+// UnixTime is a Unix timestamp in seconds that SABnzbd may encode as a
+// number or a quoted string. Zero means unknown.
+type UnixTime int64
+
+// UnmarshalJSON supports numeric, quoted and empty values.
+func (u *UnixTime) UnmarshalJSON(data []byte) error {
+	raw := strings.Trim(strings.TrimSpace(string(data)), "\"")
+	if raw == "" || raw == "null" {
+		*u = 0
+		return nil
+	}
+	parsed, err := ParseSABFloat(raw)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp: %w", err)
+	}
+	*u = UnixTime(parsed)
+	return nil
+}
+
+// Time converts the timestamp, returning the zero time when unknown.
+func (u UnixTime) Time() time.Time {
+	if u <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(u), 0)
+}
+
+// String formats the timestamp in local time, or "" when unknown.
+func (u UnixTime) String() string {
+	if u <= 0 {
+		return ""
+	}
+	return u.Time().Format("2006-01-02 15:04")
+}
+
 // Boolish handles SABnzbd's inconsistent boolean encoding.
 type Boolish bool
 
@@ -936,6 +1950,73 @@ func (b *Boolish) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// Number is a numeric SABnzbd field that may arrive as a JSON number or as a
+// string such as "1,234.5". Empty strings and null decode to zero.
+type Number float64
+
+// UnmarshalJSON supports numeric, quoted and empty values.
+func (n *Number) UnmarshalJSON(data []byte) error {
+	raw := strings.TrimSpace(string(data))
+	if raw == "null" {
+		*n = 0
+		return nil
+	}
+	parsed, err := ParseSABFloat(strings.Trim(raw, "\""))
+	if err != nil {
+		return err
+	}
+	*n = Number(parsed)
+	return nil
+}
+
+// Float returns the value as a float64.
+func (n Number) Float() float64 {
+	return float64(n)
+}
+
+// Int returns the value truncated to an int64.
+func (n Number) Int() int64 {
+	return int64(n)
+}
+
+// ParseSABFloat parses SABnzbd's string-encoded numbers, tolerating
+// surrounding whitespace and thousands separators. An empty string is zero.
+func ParseSABFloat(s string) (float64, error) {
+	cleaned := strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	if cleaned == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return v, nil
+}
+
+// SumQueueMB adds up the size and remaining MB of slots. Unparseable values
+// count as zero so one odd slot cannot spoil the totals.
+func SumQueueMB(slots []QueueSlot) (size, left float64) {
+	for _, slot := range slots {
+		if v, err := ParseSABFloat(slot.MB); err == nil {
+			size += v
+		}
+		if v, err := ParseSABFloat(slot.MBLeft); err == nil {
+			left += v
+		}
+	}
+	return size, left
+}
+
+// ParseSABInt parses an integer field using ParseSABFloat's rules and
+// truncates any fractional part.
+func ParseSABInt(s string) (int64, error) {
+	v, err := ParseSABFloat(s)
+	if err != nil {
+		return 0, err
+	}
+	return int64(v), nil
+}
+
 // Warning represents a SABnzbd warning entry.
 type Warning struct {
 	Type   string `json:"type"`
@@ -988,7 +2069,7 @@ func (c *Client) ShowLog(ctx context.Context) (string, error) {
 	}
 	defer resp.Body.Close()
 
-	data, err := io.ReadAll(resp.Body)
+	data, err := io.ReadAll(c.limitBody(resp.Body))
 	if err != nil {
 		return "", err
 	}
@@ -1096,8 +2177,8 @@ func (c *Client) QueueChangeOptions(ctx context.Context, nzoIDs []string, ppLeve
 	if len(nzoIDs) == 0 {
 		return errors.New("at least one nzo id required")
 	}
-	if ppLevel < 0 {
-		return errors.New("pp level must be non-negative")
+	if ppLevel < 0 || ppLevel > 3 {
+		return fmt.Errorf("pp level %d out of range 0-3", ppLevel)
 	}
 	params := url.Values{}
 	params.Set("value", strings.Join(nzoIDs, ","))
@@ -1268,6 +2349,21 @@ func (c *Client) TestNotification(ctx context.Context, mode string, params url.V
 	return &TestNotificationResult{Success: bool(env.Status), Message: env.Error}, nil
 }
 
+// TestScript runs a script through SABnzbd's notification-script tester
+// (test_nscript). SABnzbd has no endpoint to run a post-processing script on
+// demand; job scripts only run when a download completes.
+func (c *Client) TestScript(ctx context.Context, script, parameters string) (*TestNotificationResult, error) {
+	if strings.TrimSpace(script) == "" {
+		return nil, errors.New("script name required")
+	}
+	params := url.Values{}
+	params.Set("nscript_script", script)
+	if parameters != "" {
+		params.Set("nscript_parameters", parameters)
+	}
+	return c.TestNotification(ctx, "test_nscript", params)
+}
+
 // ServerTestParams configures a server connectivity test.
 type ServerTestParams struct {
 	Server      string
@@ -1331,3 +2427,22 @@ func (c *Client) TestServer(ctx context.Context, params ServerTestParams) (*Serv
 	}
 	return &env.Value, nil
 }
+
+type pingEnvelope struct {
+	Error string `json:"error"`
+}
+
+// Ping verifies connectivity and that the API key is accepted.
+func (c *Client) Ping(ctx context.Context) error {
+	params := url.Values{}
+	params.Set("limit", "1")
+
+	var env pingEnvelope
+	if err := c.call(ctx, "queue", params, &env); err != nil {
+		return err
+	}
+	if env.Error != "" {
+		return fmt.Errorf("sabnzbd rejected request: %s", env.Error)
+	}
+	return nil
+}
@@ -1,13 +1,24 @@
 package sabapi
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/avivsinai/sabx/internal/buildinfo"
 )
 
 func newTestClient(t *testing.T) (*Client, <-chan url.Values) {
@@ -146,6 +157,33 @@ func TestServerConfigsMode(t *testing.T) {
 	}
 }
 
+func TestConfigGetAllOmitsSection(t *testing.T) {
+	client, queries := newTestClientWithResponse(t, `{"config":{"misc":{"port":"8080"},"servers":[{"name":"primary"}]}}`)
+
+	sections, err := client.ConfigGetAll(context.Background())
+	if err != nil {
+		t.Fatalf("ConfigGetAll returned error: %v", err)
+	}
+	if _, ok := sections["servers"]; !ok {
+		t.Fatalf("expected servers section, got %v", sections)
+	}
+
+	q := requireQuery(t, queries)
+	if got := q.Get("mode"); got != "get_config" {
+		t.Fatalf("expected mode=get_config, got %q", got)
+	}
+	if q.Has("section") {
+		t.Fatalf("expected no section parameter, got %q", q.Get("section"))
+	}
+}
+
+func TestConfigGetAllRejectsMissingConfig(t *testing.T) {
+	client, _ := newTestClientWithResponse(t, `{"status":true}`)
+	if _, err := client.ConfigGetAll(context.Background()); err == nil {
+		t.Fatal("expected error when response has no config object")
+	}
+}
+
 func TestTestServerSendsParameters(t *testing.T) {
 	client, queries := newTestClientWithResponse(t, `{"value":{"result":true,"message":"ok"}}`)
 	ctx := context.Background()
@@ -890,6 +928,47 @@ func TestQueueSortParameters(t *testing.T) {
 	}
 }
 
+func TestQueueSortPriorityAscending(t *testing.T) {
+	client, queries := newTestClient(t)
+	ctx := context.Background()
+
+	if err := client.QueueSort(ctx, "priority", "asc"); err != nil {
+		t.Fatalf("QueueSort returned error: %v", err)
+	}
+	q := requireQuery(t, queries)
+	if q.Get("name") != "sort" || q.Get("sort") != "priority" || q.Get("dir") != "asc" {
+		t.Fatalf("unexpected sort query %v", q)
+	}
+}
+
+func TestQueueSortRejectsUnsupportedValues(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	if err := client.QueueSort(ctx, "colour", "asc"); err == nil || !strings.Contains(err.Error(), "colour") {
+		t.Fatalf("expected unsupported sort error, got %v", err)
+	}
+	if err := client.QueueSort(ctx, "name", "sideways"); err == nil || !strings.Contains(err.Error(), "sideways") {
+		t.Fatalf("expected unsupported direction error, got %v", err)
+	}
+}
+
+func TestHistorySearchSendsSearch(t *testing.T) {
+	client, queries := newTestClientWithResponse(t, `{"history":{"slots":[{"nzo_id":"SABnzbd_nzo_h1","name":"Ubuntu"}]}}`)
+
+	history, err := client.HistorySearch(context.Background(), "ubuntu", 20)
+	if err != nil {
+		t.Fatalf("HistorySearch returned error: %v", err)
+	}
+	q := requireQuery(t, queries)
+	if q.Get("mode") != "history" || q.Get("search") != "ubuntu" || q.Get("limit") != "20" {
+		t.Fatalf("unexpected history search query %v", q)
+	}
+	if len(history.Slots) != 1 || history.Slots[0].NZOID != "SABnzbd_nzo_h1" {
+		t.Fatalf("unexpected history slots %+v", history.Slots)
+	}
+}
+
 func TestHistoryRetryUsesRetryMode(t *testing.T) {
 	client, queries := newTestClient(t)
 	ctx := context.Background()
@@ -1024,3 +1103,1160 @@ func TestSpeedLimitRemove(t *testing.T) {
 		t.Fatalf("expected value=0, got %q", got)
 	}
 }
+
+func TestPingUsesQueueMode(t *testing.T) {
+	client, queries := newTestClientWithResponse(t, `{"queue":{"slots":[]}}`)
+
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping returned error: %v", err)
+	}
+
+	q := requireQuery(t, queries)
+	if got := q.Get("mode"); got != "queue" {
+		t.Fatalf("expected mode=queue, got %q", got)
+	}
+	if got := q.Get("limit"); got != "1" {
+		t.Fatalf("expected limit=1, got %q", got)
+	}
+}
+
+func TestPingReportsAPIKeyError(t *testing.T) {
+	client, _ := newTestClientWithResponse(t, `{"status":false,"error":"API Key Incorrect"}`)
+
+	err := client.Ping(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "API Key Incorrect") {
+		t.Fatalf("expected API key error, got %v", err)
+	}
+}
+
+func TestMinIntervalSpacesRequests(t *testing.T) {
+	var times []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		times = append(times, time.Now())
+		_, _ = w.Write([]byte(`{"status": true}`))
+	}))
+	t.Cleanup(server.Close)
+
+	interval := 50 * time.Millisecond
+	client, err := NewClient(server.URL, "apikey", WithHTTPClient(server.Client()), WithMinInterval(interval))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := client.WatchedNow(ctx); err != nil {
+		t.Fatalf("first call returned error: %v", err)
+	}
+	if err := client.WatchedNow(ctx); err != nil {
+		t.Fatalf("second call returned error: %v", err)
+	}
+
+	if len(times) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(times))
+	}
+	if gap := times[1].Sub(times[0]); gap < interval {
+		t.Fatalf("expected requests spaced by at least %s, got %s", interval, gap)
+	}
+}
+
+func TestMinIntervalRespectsContext(t *testing.T) {
+	client, queries := newTestClient(t)
+	WithMinInterval(time.Hour)(client)
+
+	if err := client.WatchedNow(context.Background()); err != nil {
+		t.Fatalf("first call returned error: %v", err)
+	}
+	requireQuery(t, queries)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := client.WatchedNow(ctx); err == nil {
+		t.Fatal("expected context error while throttled")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("throttle ignored context cancellation (waited %s)", elapsed)
+	}
+}
+
+func TestTranslateManyAggregatesResults(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		inFlight int
+		peak     int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > peak {
+			peak = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		_, _ = fmt.Fprintf(w, `{"value":%q}`, strings.ToUpper(r.URL.Query().Get("value")))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL, "apikey", WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	keys := []string{"a", "b", "c", "d", "e", "f", "a"}
+	got, err := client.TranslateMany(context.Background(), keys)
+	if err != nil {
+		t.Fatalf("TranslateMany returned error: %v", err)
+	}
+	if len(got) != 6 {
+		t.Fatalf("expected 6 unique results, got %d: %v", len(got), got)
+	}
+	for _, key := range keys {
+		if got[key] != strings.ToUpper(key) {
+			t.Fatalf("expected %q -> %q, got %q", key, strings.ToUpper(key), got[key])
+		}
+	}
+	if peak > translateConcurrency {
+		t.Fatalf("expected at most %d concurrent requests, saw %d", translateConcurrency, peak)
+	}
+}
+
+func TestTranslateManyReportsFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("value") == "bad" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{"value":"ok"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL, "apikey", WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	if _, err := client.TranslateMany(context.Background(), []string{"good", "bad"}); err == nil || !strings.Contains(err.Error(), `"bad"`) {
+		t.Fatalf("expected error naming the failed key, got %v", err)
+	}
+}
+
+func TestStageLogLines(t *testing.T) {
+	entry := StageLog{
+		Stage: "Repair",
+		Log:   "[Show.S01E01] Quick Check OK\n[Show.S01E01] Repaired in 12 seconds<br/>  <br />[Show.S01E01] Verified in 3 seconds\r\n\n",
+	}
+
+	got := entry.Lines()
+	want := []string{
+		"[Show.S01E01] Quick Check OK",
+		"[Show.S01E01] Repaired in 12 seconds",
+		"[Show.S01E01] Verified in 3 seconds",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d lines, got %d: %q", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("line %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	if lines := (StageLog{Stage: "Unpack"}).Lines(); len(lines) != 0 {
+		t.Fatalf("expected no lines for empty log, got %q", lines)
+	}
+}
+
+func TestAddURLSendsPPLevel(t *testing.T) {
+	client, queries := newTestClientWithResponse(t, `{"status":true,"nzo_ids":["XYZ"]}`)
+	pp := 3
+
+	if _, err := client.AddURL(context.Background(), "https://indexer.example/get/1.nzb", AddOptions{PPLevel: &pp}); err != nil {
+		t.Fatalf("AddURL returned error: %v", err)
+	}
+
+	q := requireQuery(t, queries)
+	if got := q.Get("mode"); got != "addurl" {
+		t.Fatalf("expected mode=addurl, got %q", got)
+	}
+	if got := q.Get("pp"); got != "3" {
+		t.Fatalf("expected pp=3, got %q", got)
+	}
+}
+
+func TestAddFileSendsPPLevel(t *testing.T) {
+	fields := make(chan url.Values, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("parse multipart: %v", err)
+		}
+		fields <- url.Values(r.MultipartForm.Value)
+		_, _ = w.Write([]byte(`{"status":true,"nzo_ids":["XYZ"]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL, "apikey", WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "show.nzb")
+	if err := os.WriteFile(path, []byte("<nzb></nzb>"), 0o600); err != nil {
+		t.Fatalf("write nzb: %v", err)
+	}
+	pp := 1
+	if _, err := client.AddFile(context.Background(), path, AddOptions{PPLevel: &pp}); err != nil {
+		t.Fatalf("AddFile returned error: %v", err)
+	}
+
+	form := <-fields
+	if got := form.Get("mode"); got != "addfile" {
+		t.Fatalf("expected mode=addfile, got %q", got)
+	}
+	if got := form.Get("pp"); got != "1" {
+		t.Fatalf("expected pp=1, got %q", got)
+	}
+}
+
+func TestAddURLOmitsPPLevelByDefault(t *testing.T) {
+	client, queries := newTestClientWithResponse(t, `{"status":true,"nzo_ids":["XYZ"]}`)
+
+	if _, err := client.AddURL(context.Background(), "https://indexer.example/get/1.nzb", AddOptions{}); err != nil {
+		t.Fatalf("AddURL returned error: %v", err)
+	}
+	if q := requireQuery(t, queries); q.Has("pp") {
+		t.Fatalf("expected no pp parameter, got %q", q.Get("pp"))
+	}
+}
+
+func TestClientConcurrentQueueCalls(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		_, _ = w.Write([]byte(`{"queue":{"slots":[{"nzo_id":"SABnzbd_nzo_1"}]}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL, "apikey", WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	const workers = 16
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c := client
+			if i%2 == 1 {
+				c = client.Clone(WithTimeout(time.Second))
+			}
+			queue, err := c.Queue(context.Background(), 0, 0, "")
+			if err != nil {
+				errs <- err
+				return
+			}
+			if len(queue.Slots) != 1 {
+				errs <- fmt.Errorf("expected 1 slot, got %d", len(queue.Slots))
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("concurrent Queue call failed: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != workers {
+		t.Fatalf("expected %d requests, got %d", workers, got)
+	}
+}
+
+func TestCloneIsIndependent(t *testing.T) {
+	shared := &http.Client{Timeout: 3 * time.Second}
+	client, err := NewClient("http://localhost:8080", "apikey", WithHTTPClient(shared), WithMinInterval(time.Second))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	clone := client.Clone(WithTimeout(time.Minute))
+	if clone.http.Timeout != time.Minute {
+		t.Fatalf("expected clone timeout 1m, got %s", clone.http.Timeout)
+	}
+	if client.http.Timeout != 3*time.Second || shared.Timeout != 3*time.Second {
+		t.Fatal("Clone modified the original HTTP client")
+	}
+	if clone.http.Transport != client.http.Transport {
+		t.Fatal("expected clone to share the transport")
+	}
+	if clone.minInterval != time.Second {
+		t.Fatalf("expected min interval carried over, got %s", clone.minInterval)
+	}
+	if clone.baseURL != client.baseURL || clone.apiKey != client.apiKey {
+		t.Fatal("expected clone to target the same instance")
+	}
+}
+
+func TestBatchAddURLRecordsPerURLResults(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(r.URL.Query().Get("name"), "bad") {
+			_, _ = w.Write([]byte(`{"status": false, "error": "invalid nzb"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status": true, "nzo_ids": ["SABnzbd_nzo_1"]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL, "apikey", WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	urls := []string{"https://indexer.example/good.nzb", "https://indexer.example/bad.nzb"}
+	results := client.BatchAddURL(context.Background(), urls, AddOptions{})
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].Err != nil || results[0].Response == nil || len(results[0].Response.NZOIDs) != 1 {
+		t.Fatalf("expected first URL queued, got %+v", results[0])
+	}
+	if results[1].URL != urls[1] || results[1].Err == nil || !strings.Contains(results[1].Err.Error(), "invalid nzb") {
+		t.Fatalf("expected refusal recorded for second URL, got %+v", results[1])
+	}
+}
+
+func TestRequestsSendUserAgent(t *testing.T) {
+	t.Parallel()
+
+	agents := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents <- r.Header.Get("User-Agent")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status": true}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL, "apikey", WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	if _, err := client.Version(context.Background()); err != nil {
+		t.Fatalf("Version returned error: %v", err)
+	}
+	if got := <-agents; got != "sabx/"+buildinfo.Version {
+		t.Fatalf("expected version-stamped user agent, got %q", got)
+	}
+
+	nzb := filepath.Join(t.TempDir(), "job.nzb")
+	if err := os.WriteFile(nzb, []byte("<nzb/>"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	custom := client.Clone(WithUserAgent("acme-proxy/1.0"))
+	if _, err := custom.AddFile(context.Background(), nzb, AddOptions{}); err != nil {
+		t.Fatalf("AddFile returned error: %v", err)
+	}
+	if got := <-agents; got != "acme-proxy/1.0" {
+		t.Fatalf("expected overridden user agent on upload, got %q", got)
+	}
+}
+
+func TestTestScriptSendsScriptName(t *testing.T) {
+	client, queries := newTestClientWithResponse(t, `{"status":false,"error":"exit code 1"}`)
+	result, err := client.TestScript(context.Background(), "notify.py", "--dry")
+	if err != nil {
+		t.Fatalf("TestScript error: %v", err)
+	}
+	if result.Success || result.Message != "exit code 1" {
+		t.Fatalf("expected failure with script output, got %+v", result)
+	}
+	q := requireQuery(t, queries)
+	if q.Get("mode") != "test_nscript" || q.Get("nscript_script") != "notify.py" || q.Get("nscript_parameters") != "--dry" {
+		t.Fatalf("unexpected query %v", q)
+	}
+}
+
+func TestNormalizeBaseURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{name: "bare host", raw: "localhost:8080", want: "http://localhost:8080"},
+		{name: "trailing slash", raw: "https://sab.example.com/", want: "https://sab.example.com"},
+		{name: "pasted api endpoint", raw: "http://nas:8080/api", want: "http://nas:8080"},
+		{name: "api under prefix", raw: "https://home.example/sabnzbd/api/", want: "https://home.example/sabnzbd"},
+		{name: "uppercase scheme", raw: "HTTP://nas:8080/sabnzbd", want: "http://nas:8080/sabnzbd"},
+		{name: "empty", raw: "  ", wantErr: true},
+		{name: "unsupported scheme", raw: "ftp://nas", wantErr: true},
+		{name: "missing host", raw: "http:///api", wantErr: true},
+		{name: "query string", raw: "http://nas:8080/api?mode=queue", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NormalizeBaseURL(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error for %q, got %q", tt.raw, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeBaseURL(%q) returned error: %v", tt.raw, err)
+			}
+			if got != tt.want {
+				t.Fatalf("NormalizeBaseURL(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistoryDecodesCompletedTimestamps(t *testing.T) {
+	client, _ := newTestClientWithResponse(t, `{"history":{"slots":[{"nzo_id":"a","completed":1700000000},{"nzo_id":"b","completed":"1700000001"},{"nzo_id":"c","completed":""}]}}`)
+	history, err := client.History(context.Background(), false, 0)
+	if err != nil {
+		t.Fatalf("History returned error: %v", err)
+	}
+	got := []UnixTime{history.Slots[0].Completed, history.Slots[1].Completed, history.Slots[2].Completed}
+	if got[0] != 1700000000 || got[1] != 1700000001 || got[2] != 0 {
+		t.Fatalf("unexpected completed values %v", got)
+	}
+	if !history.Slots[2].Completed.Time().IsZero() {
+		t.Fatal("expected zero time for unknown completion")
+	}
+}
+
+func TestNumberUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    Number
+		wantErr bool
+	}{
+		{name: "json number", input: `1536.25`, want: 1536.25},
+		{name: "quoted number", input: `"512"`, want: 512},
+		{name: "thousands separator", input: `"1,234.5"`, want: 1234.5},
+		{name: "empty string", input: `""`, want: 0},
+		{name: "null", input: `null`, want: 0},
+		{name: "padded", input: `" 42 "`, want: 42},
+		{name: "garbage", input: `"n/a"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var got struct {
+				MB Number `json:"mb"`
+			}
+			err := json.Unmarshal([]byte(`{"mb":`+tt.input+`}`), &got)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error for %s, got %v", tt.input, got.MB)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal(%s) returned error: %v", tt.input, err)
+			}
+			if got.MB != tt.want {
+				t.Fatalf("Unmarshal(%s) = %v, want %v", tt.input, got.MB, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSABInt(t *testing.T) {
+	t.Parallel()
+
+	if n, err := ParseSABInt("1,024"); err != nil || n != 1024 {
+		t.Fatalf("ParseSABInt(1,024) = %d, %v", n, err)
+	}
+	if n, err := ParseSABInt(""); err != nil || n != 0 {
+		t.Fatalf("ParseSABInt(empty) = %d, %v", n, err)
+	}
+	if _, err := ParseSABInt("ten"); err == nil {
+		t.Fatal("expected error for non-numeric input")
+	}
+}
+
+func TestSumQueueMB(t *testing.T) {
+	t.Parallel()
+
+	size, left := SumQueueMB([]QueueSlot{{MB: "1,024.5", MBLeft: "512"}, {MB: "100", MBLeft: "n/a"}, {}})
+	if size != 1124.5 || left != 512 {
+		t.Fatalf("SumQueueMB = %v, %v; want 1124.5, 512", size, left)
+	}
+}
+
+func TestAddResponseAcceptedRequiresJobIDs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		resp         AddResponse
+		wantSuccess  bool
+		wantAccepted bool
+	}{
+		{name: "queued", resp: AddResponse{Status: true, NZOIDs: []string{"SABnzbd_nzo_1"}}, wantSuccess: true, wantAccepted: true},
+		{name: "success without ids", resp: AddResponse{Status: true, NZOIDs: []string{}}, wantSuccess: true, wantAccepted: false},
+		{name: "refused", resp: AddResponse{Status: false, Error: "bad nzb"}, wantSuccess: false, wantAccepted: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.resp.Success(); got != tt.wantSuccess {
+				t.Fatalf("Success() = %v, want %v", got, tt.wantSuccess)
+			}
+			if got := tt.resp.Accepted(); got != tt.wantAccepted {
+				t.Fatalf("Accepted() = %v, want %v", got, tt.wantAccepted)
+			}
+		})
+	}
+}
+
+func TestSpeedLimitKBps(t *testing.T) {
+	t.Parallel()
+
+	const tenMB = 10 * 1024 * 1024
+	tests := []struct {
+		name    string
+		raw     string
+		max     float64
+		want    float64
+		wantErr bool
+	}{
+		{name: "percent of max", raw: "50", max: tenMB, want: 5120},
+		{name: "percent sign", raw: "25%", max: tenMB, want: 2560},
+		{name: "percent without max", raw: "50", max: 0, want: 0},
+		{name: "absolute kilobytes", raw: "800K", max: tenMB, want: 800},
+		{name: "absolute megabytes", raw: "4M", want: 4096},
+		{name: "no limit", raw: "", want: 0},
+		{name: "negative", raw: "-5", max: tenMB, wantErr: true},
+		{name: "garbage", raw: "fast", max: tenMB, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := SpeedLimitKBps(tt.raw, tt.max)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error for %q", tt.raw)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SpeedLimitKBps(%q) returned error: %v", tt.raw, err)
+			}
+			if got != tt.want {
+				t.Fatalf("SpeedLimitKBps(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveSpeedLimit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		limit       string
+		bandwidth   string
+		measured    string
+		wantKBps    float64
+		wantPercent bool
+	}{
+		{name: "percent of bandwidth_max", limit: "50", bandwidth: "10M", wantKBps: 5120, wantPercent: true},
+		{name: "falls back to internetbandwidth", limit: "50", bandwidth: "", measured: "8", wantKBps: 4096, wantPercent: true},
+		{name: "absolute skips config", limit: "800K", wantKBps: 800},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var modes []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mode := r.URL.Query().Get("mode")
+				modes = append(modes, mode)
+				w.Header().Set("Content-Type", "application/json")
+				switch mode {
+				case "status":
+					_, _ = w.Write([]byte(`{"speedlimit":"` + tt.limit + `"}`))
+				case "get_config":
+					_, _ = w.Write([]byte(`{"config":{"misc":{"bandwidth_max":"` + tt.bandwidth + `"}}}`))
+				case "fullstatus":
+					_, _ = w.Write([]byte(`{"status":{"internetbandwidth":` + tt.measured + `}}`))
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			client, err := NewClient(server.URL, "apikey", WithHTTPClient(server.Client()))
+			if err != nil {
+				t.Fatalf("NewClient returned error: %v", err)
+			}
+			kbps, isPercent, raw, err := client.ResolveSpeedLimit(context.Background())
+			if err != nil {
+				t.Fatalf("ResolveSpeedLimit returned error: %v", err)
+			}
+			if kbps != tt.wantKBps || isPercent != tt.wantPercent || raw != tt.limit {
+				t.Fatalf("ResolveSpeedLimit = (%v, %v, %q), want (%v, %v, %q); modes %v", kbps, isPercent, raw, tt.wantKBps, tt.wantPercent, tt.limit, modes)
+			}
+			if !tt.wantPercent && len(modes) != 1 {
+				t.Fatalf("expected only the status call for an absolute limit, got %v", modes)
+			}
+		})
+	}
+}
+
+func TestClassifyStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]StatusClass{
+		"Downloading": StatusClassActive,
+		"fetching":    StatusClassActive,
+		"Grabbing":    StatusClassActive,
+		"Queued":      StatusClassPending,
+		"Propagating": StatusClassPending,
+		"Paused":      StatusClassPaused,
+		"Verifying":   StatusClassPostProcessing,
+		"Extracting":  StatusClassPostProcessing,
+		" Running ":   StatusClassPostProcessing,
+		"Completed":   StatusClassCompleted,
+		"Failed":      StatusClassFailed,
+		"Mystery":     StatusClassUnknown,
+		"":            StatusClassUnknown,
+	}
+	for raw, want := range tests {
+		if got := ClassifyStatus(raw); got != want {
+			t.Fatalf("ClassifyStatus(%q) = %q, want %q", raw, got, want)
+		}
+	}
+
+	if got := (QueueSlot{Status: "Downloading", Paused: true}).Class(); got != StatusClassPaused {
+		t.Fatalf("paused flag should win, got %q", got)
+	}
+}
+
+func TestQueueSetsStatusClass(t *testing.T) {
+	t.Parallel()
+
+	client, queries := newTestClientWithResponse(t, `{"queue":{"slots":[{"nzo_id":"a","status":"Grabbing"},{"nzo_id":"b","status":"Propagating"}]}}`)
+	queue, err := client.Queue(context.Background(), 0, 0, "")
+	if err != nil {
+		t.Fatalf("Queue returned error: %v", err)
+	}
+	requireQuery(t, queries)
+	if queue.Slots[0].StatusClass != StatusClassActive || queue.Slots[1].StatusClass != StatusClassPending {
+		t.Fatalf("unexpected classes %q, %q", queue.Slots[0].StatusClass, queue.Slots[1].StatusClass)
+	}
+	raw, err := json.Marshal(queue.Slots[0])
+	if err != nil {
+		t.Fatalf("marshal slot: %v", err)
+	}
+	if !strings.Contains(string(raw), `"status_class":"active"`) {
+		t.Fatalf("expected status_class in JSON, got %s", raw)
+	}
+}
+
+func TestAddFileReportsUploadProgress(t *testing.T) {
+	var received int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := io.Copy(io.Discard, r.Body)
+		received = n
+		_, _ = w.Write([]byte(`{"status":true,"nzo_ids":["XYZ"]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL, "apikey", WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "large.nzb")
+	if err := os.WriteFile(path, bytes.Repeat([]byte("<segment/>"), 50_000), 0o600); err != nil {
+		t.Fatalf("write nzb: %v", err)
+	}
+
+	var sent []int64
+	var lastTotal int64
+	progress := func(n, total int64) {
+		sent = append(sent, n)
+		lastTotal = total
+	}
+	if _, err := client.AddFile(context.Background(), path, AddOptions{Progress: progress}); err != nil {
+		t.Fatalf("AddFile returned error: %v", err)
+	}
+
+	if len(sent) < 2 {
+		t.Fatalf("expected several progress callbacks, got %v", sent)
+	}
+	for i := 1; i < len(sent); i++ {
+		if sent[i] <= sent[i-1] {
+			t.Fatalf("progress not increasing: %v", sent)
+		}
+	}
+	if final := sent[len(sent)-1]; final != lastTotal || final != received {
+		t.Fatalf("final progress %d, total %d, server received %d", final, lastTotal, received)
+	}
+}
+
+func TestWithTraceRedactsAPIKey(t *testing.T) {
+	t.Parallel()
+
+	const key = "s3cr3t+key/value"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"config":{"misc":{"api_key":%q}}}`, r.URL.Query().Get("apikey"))
+	}))
+	t.Cleanup(server.Close)
+
+	var trace bytes.Buffer
+	client, err := NewClient(server.URL, key, WithHTTPClient(server.Client()), WithTrace(&trace))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	if _, err := client.Version(context.Background()); err != nil {
+		t.Fatalf("Version returned error: %v", err)
+	}
+
+	dump := trace.String()
+	if strings.Contains(dump, key) || strings.Contains(dump, url.QueryEscape(key)) {
+		t.Fatalf("trace leaked the API key:\n%s", dump)
+	}
+	var requestLine string
+	for _, line := range strings.Split(dump, "\n") {
+		if strings.HasPrefix(line, "GET ") {
+			requestLine = line
+			break
+		}
+	}
+	if !strings.Contains(requestLine, "apikey=REDACTED") || !strings.Contains(requestLine, "mode=version") {
+		t.Fatalf("unexpected request line %q in trace:\n%s", requestLine, dump)
+	}
+	if !strings.Contains(dump, "--- response after ") || !strings.Contains(dump, "HTTP/1.1 200 OK") {
+		t.Fatalf("expected response dump, got:\n%s", dump)
+	}
+}
+
+func TestRedirectToLoginReturnsDescriptiveError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html>Sign in</html>"))
+			return
+		}
+		http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "default client"},
+		{name: "client that follows redirects", opts: []Option{WithHTTPClient(server.Client())}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			client, err := NewClient(server.URL, "secret-key", tt.opts...)
+			if err != nil {
+				t.Fatalf("NewClient returned error: %v", err)
+			}
+			_, err = client.Version(context.Background())
+			if !errors.Is(err, ErrRedirected) {
+				t.Fatalf("expected ErrRedirected, got %v", err)
+			}
+			want := "base URL redirected to " + server.URL + "/login; check auth/proxy"
+			if err.Error() != want {
+				t.Fatalf("error = %q, want %q", err.Error(), want)
+			}
+			if strings.Contains(err.Error(), "secret-key") {
+				t.Fatalf("error leaked the API key: %v", err)
+			}
+		})
+	}
+}
+
+func TestQueueSearchNameFiltersLiterally(t *testing.T) {
+	t.Parallel()
+
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		_, _ = w.Write([]byte(`{"queue":{"slots":[
+			{"nzo_id":"1","filename":"Show.S01E01","cat":"tv"},
+			{"nzo_id":"2","filename":"Movie.2024","cat":"tv"},
+			{"nzo_id":"3","filename":"show.S01E02","cat":"tv"},
+			{"nzo_id":"4","filename":"Show*Special","cat":"tv"}
+		]}}`))
+	}))
+	t.Cleanup(server.Close)
+	client, err := NewClient(server.URL, "secret")
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	queue, err := client.QueueSearch(context.Background(), 1, 1, "SHOW.", SearchFieldName)
+	if err != nil {
+		t.Fatalf("QueueSearch returned error: %v", err)
+	}
+	if query.Get("search") != "" || query.Get("limit") != "" || query.Get("start") != "" {
+		t.Fatalf("expected name search to fetch the whole queue, got %v", query)
+	}
+	if len(queue.Slots) != 1 || queue.Slots[0].NZOID != "3" {
+		t.Fatalf("expected the second name match only, got %+v", queue.Slots)
+	}
+
+	queue, err = client.QueueSearch(context.Background(), 0, 0, "show*", SearchFieldName)
+	if err != nil {
+		t.Fatalf("QueueSearch returned error: %v", err)
+	}
+	if len(queue.Slots) != 1 || queue.Slots[0].NZOID != "4" {
+		t.Fatalf("expected * to match literally, got %+v", queue.Slots)
+	}
+}
+
+func TestQueueSearchFieldParameters(t *testing.T) {
+	tests := []struct {
+		name       string
+		field      SearchField
+		wantSearch string
+		wantCat    string
+	}{
+		{name: "default", field: "", wantSearch: "tv"},
+		{name: "all", field: SearchFieldAll, wantSearch: "tv"},
+		{name: "category", field: SearchFieldCategory, wantCat: "tv"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			client, queries := newTestClient(t)
+			if _, err := client.QueueSearch(context.Background(), 0, 5, "tv", tt.field); err != nil {
+				t.Fatalf("QueueSearch returned error: %v", err)
+			}
+			q := requireQuery(t, queries)
+			if got := q.Get("search"); got != tt.wantSearch {
+				t.Fatalf("expected search=%q, got %q", tt.wantSearch, got)
+			}
+			if got := q.Get("cat"); got != tt.wantCat {
+				t.Fatalf("expected cat=%q, got %q", tt.wantCat, got)
+			}
+			if got := q.Get("limit"); got != "5" {
+				t.Fatalf("expected limit=5, got %q", got)
+			}
+		})
+	}
+
+	if _, err := ParseSearchField("status"); err == nil {
+		t.Fatal("expected error for unsupported search field")
+	}
+	if field, err := ParseSearchField(" Category "); err != nil || field != SearchFieldCategory {
+		t.Fatalf("ParseSearchField = %q, %v", field, err)
+	}
+}
+
+func TestMaxResponseSize(t *testing.T) {
+	t.Parallel()
+
+	const limit = 64
+	tests := []struct {
+		name    string
+		size    int
+		wantErr bool
+	}{
+		{name: "at limit", size: limit},
+		{name: "just under limit", size: limit - 1},
+		{name: "just over limit", size: limit + 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			// Pad a JSON string so the whole document is exactly tt.size bytes.
+			version := `{"version":"` + strings.Repeat("9", tt.size-len(`{"version":""}`)) + `"}`
+			logBody := strings.Repeat("x", tt.size)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("mode") == "showlog" {
+					_, _ = w.Write([]byte(logBody))
+					return
+				}
+				_, _ = w.Write([]byte(version))
+			}))
+			t.Cleanup(server.Close)
+
+			client, err := NewClient(server.URL, "secret-key", WithMaxResponseSize(limit))
+			if err != nil {
+				t.Fatalf("NewClient returned error: %v", err)
+			}
+
+			_, versionErr := client.Version(context.Background())
+			log, logErr := client.ShowLog(context.Background())
+			for _, err := range []error{versionErr, logErr} {
+				if tt.wantErr {
+					if !errors.Is(err, ErrResponseTooLarge) {
+						t.Fatalf("expected ErrResponseTooLarge, got %v", err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("unexpected error under the limit: %v", err)
+				}
+			}
+			if !tt.wantErr && log != logBody {
+				t.Fatalf("ShowLog returned %d bytes, want %d", len(log), len(logBody))
+			}
+		})
+	}
+}
+
+func TestQueueDeleteDetectsMissingItem(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		body        string
+		wantMissing bool
+		wantErr     bool
+	}{
+		{name: "removed", body: `{"status":true,"nzo_ids":["SABnzbd_nzo_1"]}`},
+		{name: "legacy status only", body: `{"status":true}`},
+		{name: "nothing removed", body: `{"status":false,"nzo_ids":[]}`, wantMissing: true, wantErr: true},
+		{name: "not found error", body: `{"status":false,"error":"NZO not found"}`, wantMissing: true, wantErr: true},
+		{name: "no such item error", body: `{"error":"No such item"}`, wantMissing: true, wantErr: true},
+		{name: "other error", body: `{"status":false,"error":"Queue locked"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			client, _ := newTestClientWithResponse(t, tt.body)
+			err := client.QueueDelete(context.Background(), []string{"SABnzbd_nzo_1"}, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("QueueDelete error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := errors.Is(err, ErrItemNotFound); got != tt.wantMissing {
+				t.Fatalf("errors.Is(%v, ErrItemNotFound) = %v, want %v", err, got, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestAPIKeyHeaderMode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		wantQuery bool
+	}{
+		{name: "header only", opts: []Option{WithAPIKeyHeader("X-Api-Key")}},
+		{name: "header and query", opts: []Option{WithAPIKeyHeader("X-Api-Key"), WithAPIKeyQuery(true)}, wantQuery: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			type seen struct {
+				header, query, form string
+			}
+			requests := make(chan seen, 2)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var form string
+				if r.Method == http.MethodPost {
+					if err := r.ParseMultipartForm(1 << 20); err != nil {
+						t.Errorf("parse multipart: %v", err)
+					}
+					form = r.PostFormValue("apikey")
+				}
+				requests <- seen{header: r.Header.Get("X-Api-Key"), query: r.URL.Query().Get("apikey"), form: form}
+				_, _ = w.Write([]byte(`{"status":true,"version":"4.3.0","nzo_ids":["SABnzbd_nzo_1"]}`))
+			}))
+			t.Cleanup(server.Close)
+
+			client, err := NewClient(server.URL, "secret-key", tt.opts...)
+			if err != nil {
+				t.Fatalf("NewClient returned error: %v", err)
+			}
+			nzb := filepath.Join(t.TempDir(), "job.nzb")
+			if err := os.WriteFile(nzb, []byte("<nzb/>"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := client.Version(context.Background()); err != nil {
+				t.Fatalf("Version returned error: %v", err)
+			}
+			if _, err := client.Clone().AddFile(context.Background(), nzb, AddOptions{}); err != nil {
+				t.Fatalf("AddFile returned error: %v", err)
+			}
+
+			for _, got := range []seen{<-requests, <-requests} {
+				if got.header != "secret-key" {
+					t.Fatalf("expected key in X-Api-Key header, got %q", got.header)
+				}
+				sent := got.query != "" || got.form != ""
+				if sent != tt.wantQuery {
+					t.Fatalf("apikey parameter sent = %v, want %v (query %q, form %q)", sent, tt.wantQuery, got.query, got.form)
+				}
+			}
+		})
+	}
+
+	client, err := NewClient("http://sab.local", "secret-key", WithAPIKeyQuery(false))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	if !client.apiKeyQuery {
+		t.Fatal("disabling the apikey parameter without a header must be ignored")
+	}
+}
+
+func TestWithRetryRepeatsTransientFailures(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		failures     int32
+		wantAttempts int32
+		wantErr      bool
+	}{
+		{name: "5xx recovers", status: http.StatusServiceUnavailable, failures: 2, wantAttempts: 3},
+		{name: "5xx exhausts retries", status: http.StatusBadGateway, failures: 10, wantAttempts: 4, wantErr: true},
+		{name: "4xx not retried", status: http.StatusForbidden, failures: 10, wantAttempts: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if attempts.Add(1) <= tt.failures {
+					w.WriteHeader(tt.status)
+					return
+				}
+				_, _ = w.Write([]byte(`{"version":"4.3.2"}`))
+			}))
+			t.Cleanup(server.Close)
+
+			client, err := NewClient(server.URL, "secret", WithRetry(3, time.Millisecond))
+			if err != nil {
+				t.Fatalf("NewClient returned error: %v", err)
+			}
+			_, err = client.Version(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Version error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Fatalf("expected %d attempts, got %d", tt.wantAttempts, got)
+			}
+		})
+	}
+}
+
+func TestWithRetrySkipsStateChangingRequests(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		call func(*Client) error
+		want int32
+	}{
+		{name: "addurl sent once", call: func(c *Client) error {
+			_, err := c.AddURL(context.Background(), "https://example.com/a.nzb", AddOptions{})
+			return err
+		}, want: 1},
+		{name: "queue delete sent once", call: func(c *Client) error {
+			return c.QueueDelete(context.Background(), []string{"SABnzbd_nzo_1"}, false)
+		}, want: 1},
+		{name: "queue read retried", call: func(c *Client) error {
+			_, err := c.Queue(context.Background(), 0, 0, "")
+			return err
+		}, want: 3},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				w.WriteHeader(http.StatusBadGateway)
+			}))
+			t.Cleanup(server.Close)
+
+			client, err := NewClient(server.URL, "secret", WithRetry(2, 0))
+			if err != nil {
+				t.Fatalf("NewClient returned error: %v", err)
+			}
+			if err := tt.call(client); err == nil {
+				t.Fatal("expected the 502 to be reported")
+			}
+			if got := attempts.Load(); got != tt.want {
+				t.Fatalf("expected %d attempts, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRetryBudgetCapsAttemptsUnderSlowResponses(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL, "secret",
+		WithTimeout(30*time.Millisecond),
+		WithRetry(100, 0),
+		WithRetryBudget(100*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	start := time.Now()
+	_, err = client.Version(context.Background())
+	elapsed := time.Since(start)
+	if err == nil {
+		t.Fatal("expected the last timeout error once the budget ran out")
+	}
+	if got := attempts.Load(); got < 2 || got > 10 {
+		t.Fatalf("expected the budget to stop retries after a few attempts, got %d", got)
+	}
+	if elapsed > 2*time.Second {
+		t.Fatalf("retries ran for %s despite a 100ms budget", elapsed)
+	}
+
+	// Clones keep the retry settings.
+	attempts.Store(0)
+	if _, err := client.Clone().Version(context.Background()); err == nil {
+		t.Fatal("expected clone to fail against the slow server")
+	}
+	if got := attempts.Load(); got < 2 || got > 10 {
+		t.Fatalf("expected clone to retry within the budget, got %d attempts", got)
+	}
+}
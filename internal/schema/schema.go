@@ -0,0 +1,142 @@
+// Package schema describes JSON payloads by reflecting over the Go types
+// that produce them, so the output contract can be inspected programmatically.
+package schema
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Field describes a single JSON field. Nested objects and arrays of objects
+// carry their own field list.
+type Field struct {
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Optional bool    `json:"optional,omitempty"`
+	Fields   []Field `json:"fields,omitempty"`
+}
+
+// Describe returns the JSON fields emitted when v (a value or a pointer to
+// one) is encoded with encoding/json.
+func Describe(v any) []Field {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return nil
+	}
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = elem(t.Elem())
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return structFields(t, false)
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// TypeName reports the JSON type name used for t. Types with their own
+// encoding are described by what they encode to: time.Time and
+// encoding.TextMarshaler types are strings, other json.Marshaler types
+// could be anything.
+func TypeName(t reflect.Type) string {
+	t = elem(t)
+	switch {
+	case t == timeType:
+		return "string"
+	case implements(t, jsonMarshalerType):
+		return "any"
+	case implements(t, textMarshalerType):
+		return "string"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array<" + TypeName(t.Elem()) + ">"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	default:
+		return "any"
+	}
+}
+
+// structFields lists t's JSON fields. optional marks every field as
+// optional, for fields promoted from an embedded pointer that is omitted
+// entirely when nil.
+func structFields(t reflect.Type, optional bool) []Field {
+	var fields []Field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, opts, skip := jsonName(sf)
+		if skip {
+			continue
+		}
+
+		ft := elem(sf.Type)
+		if sf.Anonymous && ft.Kind() == reflect.Struct && name == "" {
+			fields = append(fields, structFields(ft, optional || sf.Type.Kind() == reflect.Pointer)...)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		field := Field{Name: name, Type: TypeName(sf.Type), Optional: optional || strings.Contains(opts, "omitempty")}
+		switch {
+		case customEncoding(ft):
+		case ft.Kind() == reflect.Struct:
+			field.Fields = structFields(ft, false)
+		case (ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array) && elem(ft.Elem()).Kind() == reflect.Struct:
+			field.Fields = structFields(elem(ft.Elem()), false)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// implements reports whether t or *t implements iface, as encoding/json
+// would find it for addressable values.
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+// customEncoding reports whether t controls its own JSON encoding, so its
+// Go fields say nothing about the output.
+func customEncoding(t reflect.Type) bool {
+	return t == timeType || implements(t, jsonMarshalerType) || implements(t, textMarshalerType)
+}
+
+func jsonName(sf reflect.StructField) (name, opts string, skip bool) {
+	tag := sf.Tag.Get("json")
+	if tag == "-" {
+		return "", "", true
+	}
+	name, opts, _ = strings.Cut(tag, ",")
+	return name, opts, false
+}
+
+func elem(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
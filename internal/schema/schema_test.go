@@ -0,0 +1,118 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type inner struct {
+	Stage string `json:"stage"`
+}
+
+type sample struct {
+	ID      string            `json:"id"`
+	Count   int               `json:"count"`
+	Enabled bool              `json:"enabled"`
+	Tags    []string          `json:"tags,omitempty"`
+	Stages  []inner           `json:"stages"`
+	Extra   map[string]any    `json:"extra,omitempty"`
+	Ignored string            `json:"-"`
+	Nested  *inner            `json:"nested"`
+	Labels  map[string]string `json:"labels"`
+}
+
+func TestDescribe(t *testing.T) {
+	t.Parallel()
+
+	fields := Describe(&sample{})
+	want := map[string]string{
+		"id":      "string",
+		"count":   "number",
+		"enabled": "boolean",
+		"tags":    "array<string>",
+		"stages":  "array<object>",
+		"extra":   "object",
+		"nested":  "object",
+		"labels":  "object",
+	}
+	if len(fields) != len(want) {
+		t.Fatalf("expected %d fields, got %d: %+v", len(want), len(fields), fields)
+	}
+	for _, field := range fields {
+		typ, ok := want[field.Name]
+		if !ok {
+			t.Fatalf("unexpected field %q", field.Name)
+		}
+		if field.Type != typ {
+			t.Fatalf("field %q type = %q, want %q", field.Name, field.Type, typ)
+		}
+		if field.Name == "tags" && !field.Optional {
+			t.Fatal("expected tags to be optional")
+		}
+		if field.Name == "stages" && (len(field.Fields) != 1 || field.Fields[0].Name != "stage") {
+			t.Fatalf("expected nested stage field, got %+v", field.Fields)
+		}
+	}
+}
+
+func TestDescribeSlice(t *testing.T) {
+	t.Parallel()
+
+	fields := Describe([]inner{})
+	if len(fields) != 1 || fields[0].Name != "stage" || fields[0].Type != "string" {
+		t.Fatalf("unexpected fields %+v", fields)
+	}
+}
+
+type promoted struct {
+	Paused bool `json:"paused"`
+}
+
+type withEmbedded struct {
+	Profile string `json:"profile"`
+	*promoted
+	inner
+}
+
+func TestDescribeEmbeddedPointerFieldsAreOptional(t *testing.T) {
+	t.Parallel()
+
+	optional := map[string]bool{}
+	for _, field := range Describe(withEmbedded{}) {
+		optional[field.Name] = field.Optional
+	}
+	want := map[string]bool{"profile": false, "paused": true, "stage": false}
+	if len(optional) != len(want) {
+		t.Fatalf("unexpected fields %v", optional)
+	}
+	for name, opt := range want {
+		if got, ok := optional[name]; !ok || got != opt {
+			t.Fatalf("field %q optional = %v (present %v), want %v", name, got, ok, opt)
+		}
+	}
+}
+
+type rawValue struct{}
+
+func (rawValue) MarshalJSON() ([]byte, error) { return []byte("1"), nil }
+
+func TestDescribeCustomEncodings(t *testing.T) {
+	t.Parallel()
+
+	type payload struct {
+		FinishAt *time.Time      `json:"finish_at,omitempty"`
+		Created  time.Time       `json:"created"`
+		Raw      rawValue        `json:"raw"`
+		Message  json.RawMessage `json:"message"`
+	}
+	want := map[string]string{"finish_at": "string", "created": "string", "raw": "any", "message": "any"}
+	for _, field := range Describe(payload{}) {
+		if field.Type != want[field.Name] {
+			t.Fatalf("field %q type = %q, want %q", field.Name, field.Type, want[field.Name])
+		}
+		if len(field.Fields) != 0 {
+			t.Fatalf("field %q should not list Go fields, got %+v", field.Name, field.Fields)
+		}
+	}
+}
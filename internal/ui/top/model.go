@@ -11,7 +11,12 @@ import (
 	"github.com/avivsinai/sabx/internal/sabapi"
 )
 
-const refreshInterval = 2 * time.Second
+const (
+	refreshInterval = 2 * time.Second
+	maxRetryBackoff = 30 * time.Second
+
+	defaultHistoryRows = 5
+)
 
 // Run launches the Bubble Tea dashboard.
 func Run(ctx context.Context, client *sabapi.Client) error {
@@ -39,6 +44,88 @@ type model struct {
 	history      []sabapi.HistorySlot
 	err          error
 	historyLimit int
+	interval     time.Duration
+	layout       layout
+}
+
+// panel identifies a section of the dashboard.
+type panel int
+
+const (
+	panelQueue panel = iota
+	panelHistory
+)
+
+// layout is the user's panel arrangement. The zero value is the default:
+// every queue row, five history rows, queue focused.
+type layout struct {
+	hideHistory bool
+	// queueRows limits the queue panel; zero shows every row.
+	queueRows int
+	// historyRows limits the history panel; zero means defaultHistoryRows.
+	historyRows int
+	focus       panel
+}
+
+func (l layout) visibleHistoryRows() int {
+	if l.historyRows <= 0 {
+		return defaultHistoryRows
+	}
+	return l.historyRows
+}
+
+// toggleHistory shows or hides the history panel, moving focus back to the
+// queue when the focused panel disappears.
+func (l layout) toggleHistory() layout {
+	l.hideHistory = !l.hideHistory
+	if l.hideHistory {
+		l.focus = panelQueue
+	}
+	return l
+}
+
+// switchFocus alternates focus between the visible panels.
+func (l layout) switchFocus() layout {
+	if l.focus == panelQueue && !l.hideHistory {
+		l.focus = panelHistory
+	} else {
+		l.focus = panelQueue
+	}
+	return l
+}
+
+// resize grows or shrinks the focused panel by delta rows. The queue panel
+// starts from its current length when it is showing every row; history is
+// bounded by the number of entries fetched.
+func (l layout) resize(delta, queueLen, historyMax int) layout {
+	if l.focus == panelHistory {
+		rows := l.visibleHistoryRows() + delta
+		if historyMax > 0 && rows > historyMax {
+			rows = historyMax
+		}
+		if rows < 1 {
+			rows = 1
+		}
+		l.historyRows = rows
+		return l
+	}
+
+	rows := l.queueRows
+	if rows == 0 {
+		if delta > 0 {
+			return l
+		}
+		rows = queueLen
+	}
+	rows += delta
+	if rows < 1 {
+		rows = 1
+	}
+	if queueLen > 0 && rows >= queueLen && delta > 0 {
+		rows = 0
+	}
+	l.queueRows = rows
+	return l
 }
 
 type dataMsg struct {
@@ -51,7 +138,8 @@ type dataMsg struct {
 type tickMsg struct{}
 
 func (m model) Init() tea.Cmd {
-	return tea.Batch(fetchCmd(m.client, m.historyLimit), tickCmd())
+	// Each fetch result schedules the next tick, so only fetch here.
+	return fetchCmd(m.client, m.historyLimit)
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -60,17 +148,27 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch msg.String() {
 		case "ctrl+c", "q":
 			return m, tea.Quit
+		case "h":
+			m.layout = m.layout.toggleHistory()
+		case "tab":
+			m.layout = m.layout.switchFocus()
+		case "+", "=":
+			m.layout = m.layout.resize(1, m.queueLen(), m.historyLimit)
+		case "-", "_":
+			m.layout = m.layout.resize(-1, m.queueLen(), m.historyLimit)
 		}
 	case dataMsg:
 		if msg.err != nil {
 			m.err = msg.err
+			m.interval = nextBackoff(m.interval)
 		} else {
 			m.queue = msg.queue
 			m.status = msg.status
 			m.history = msg.history
 			m.err = nil
+			m.interval = refreshInterval
 		}
-		return m, tickCmd()
+		return m, tickCmd(m.interval)
 	case tickMsg:
 		return m, fetchCmd(m.client, m.historyLimit)
 	}
@@ -79,10 +177,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m model) View() string {
 	var b strings.Builder
-	b.WriteString(" sabx top (press q to quit)\n\n")
+	b.WriteString(" sabx top (q quit, h history, tab focus, +/- rows)\n\n")
 
 	if m.err != nil {
-		b.WriteString(fmt.Sprintf(" error: %v\n", m.err))
+		b.WriteString(fmt.Sprintf(" error: %v (reconnecting in %s)\n", m.err, m.interval.Round(time.Second)))
 	}
 
 	if m.status != nil {
@@ -90,26 +188,86 @@ func (m model) View() string {
 	}
 
 	if m.queue != nil {
-		b.WriteString(fmt.Sprintf(" queue: %d items, eta=%s, mbleft=%s\n", len(m.queue.Slots), m.queue.TimeLeft, m.queue.MBLeft))
+		b.WriteString(fmt.Sprintf("%squeue: %d items, eta=%s, mbleft=%s\n", m.focusMarker(panelQueue), len(m.queue.Slots), m.queue.TimeLeft, m.queue.MBLeft))
+		totals := sumQueue(m.queue.Slots)
+		b.WriteString(fmt.Sprintf(" totals: %.1f MB left of %.1f MB\n", totals.mbLeft, totals.mb))
 		b.WriteString(" -------------------------------------------------------------\n")
-		for _, slot := range m.queue.Slots {
+		slots := m.queue.Slots
+		if m.layout.queueRows > 0 && len(slots) > m.layout.queueRows {
+			slots = slots[:m.layout.queueRows]
+		}
+		for _, slot := range slots {
 			b.WriteString(fmt.Sprintf(" %-20s %-8s %-8s %-12s\n", trim(slot.Filename, 20), priorityLabel(slot.Priority), slot.Status, slot.Eta))
 		}
+		if hidden := len(m.queue.Slots) - len(slots); hidden > 0 {
+			b.WriteString(fmt.Sprintf(" … %d more\n", hidden))
+		}
 	}
 
-	if len(m.history) > 0 {
-		b.WriteString("\n recent history:\n")
+	if len(m.history) > 0 && !m.layout.hideHistory {
+		b.WriteString(fmt.Sprintf("\n%srecent history:\n", m.focusMarker(panelHistory)))
 		for i, slot := range m.history {
-			if i >= 5 {
+			if i >= m.layout.visibleHistoryRows() {
 				break
 			}
 			b.WriteString(fmt.Sprintf(" %-20s %-10s %s\n", trim(slot.Name, 20), slot.Status, slot.Completed))
 		}
+		counts := countHistory(m.history)
+		b.WriteString(fmt.Sprintf(" history: %d completed, %d failed, %d other\n", counts.completed, counts.failed, counts.other))
 	}
 
 	return b.String()
 }
 
+type queueTotals struct {
+	mb     float64
+	mbLeft float64
+}
+
+// sumQueue adds up per-slot sizes for the header.
+func sumQueue(slots []sabapi.QueueSlot) queueTotals {
+	mb, mbLeft := sabapi.SumQueueMB(slots)
+	return queueTotals{mb: mb, mbLeft: mbLeft}
+}
+
+type historyCounts struct {
+	completed int
+	failed    int
+	other     int
+}
+
+// countHistory summarises the fetched history slice by outcome. Jobs still
+// post-processing (extracting, verifying, ...) land in other.
+func countHistory(slots []sabapi.HistorySlot) historyCounts {
+	var counts historyCounts
+	for _, slot := range slots {
+		switch {
+		case strings.EqualFold(slot.Status, "Completed"):
+			counts.completed++
+		case strings.EqualFold(slot.Status, "Failed"):
+			counts.failed++
+		default:
+			counts.other++
+		}
+	}
+	return counts
+}
+
+func (m model) queueLen() int {
+	if m.queue == nil {
+		return 0
+	}
+	return len(m.queue.Slots)
+}
+
+// focusMarker prefixes the focused panel's title so +/- have a visible target.
+func (m model) focusMarker(p panel) string {
+	if m.layout.focus == p {
+		return ">"
+	}
+	return " "
+}
+
 func fetchCmd(client *sabapi.Client, historyLimit int) tea.Cmd {
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(context.Background(), 8*time.Second)
@@ -131,8 +289,24 @@ func fetchCmd(client *sabapi.Client, historyLimit int) tea.Cmd {
 	}
 }
 
-func tickCmd() tea.Cmd {
-	return tea.Tick(refreshInterval, func(time.Time) tea.Msg { return tickMsg{} })
+func tickCmd(interval time.Duration) tea.Cmd {
+	if interval <= 0 {
+		interval = refreshInterval
+	}
+	return tea.Tick(interval, func(time.Time) tea.Msg { return tickMsg{} })
+}
+
+// nextBackoff doubles the polling interval after a failed fetch, capped at
+// maxRetryBackoff.
+func nextBackoff(current time.Duration) time.Duration {
+	if current < refreshInterval {
+		current = refreshInterval
+	}
+	next := current * 2
+	if next > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return next
 }
 
 func trim(s string, max int) string {
@@ -0,0 +1,170 @@
+package top
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/avivsinai/sabx/internal/sabapi"
+)
+
+func TestNextBackoffProgression(t *testing.T) {
+	t.Parallel()
+
+	want := []time.Duration{4 * time.Second, 8 * time.Second, 16 * time.Second, 30 * time.Second, 30 * time.Second}
+	current := time.Duration(0)
+	for i, expected := range want {
+		current = nextBackoff(current)
+		if current != expected {
+			t.Fatalf("step %d: backoff = %s, want %s", i, current, expected)
+		}
+	}
+}
+
+func TestUpdateBacksOffAndResets(t *testing.T) {
+	t.Parallel()
+
+	var m model
+	fail := dataMsg{err: errors.New("connection refused")}
+
+	for _, expected := range []time.Duration{4 * time.Second, 8 * time.Second, 16 * time.Second} {
+		next, cmd := m.Update(fail)
+		m = next.(model)
+		if cmd == nil {
+			t.Fatal("expected a tick to be scheduled")
+		}
+		if m.interval != expected {
+			t.Fatalf("interval = %s, want %s", m.interval, expected)
+		}
+	}
+	if view := m.View(); !strings.Contains(view, "reconnecting in 16s") {
+		t.Fatalf("expected reconnect notice, got %q", view)
+	}
+
+	next, _ := m.Update(dataMsg{queue: &sabapi.QueueResponse{}, status: &sabapi.StatusResponse{}})
+	m = next.(model)
+	if m.interval != refreshInterval || m.err != nil {
+		t.Fatalf("expected reset to %s with no error, got %s / %v", refreshInterval, m.interval, m.err)
+	}
+}
+
+func pressKeys(t *testing.T, m model, keys ...string) model {
+	t.Helper()
+	for _, key := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		if key == "tab" {
+			msg = tea.KeyMsg{Type: tea.KeyTab}
+		}
+		next, _ := m.Update(msg)
+		m = next.(model)
+	}
+	return m
+}
+
+func TestLayoutKeyTransitions(t *testing.T) {
+	t.Parallel()
+
+	slots := make([]sabapi.QueueSlot, 4)
+	for i := range slots {
+		slots[i].Filename = fmt.Sprintf("job-%d", i)
+	}
+	history := make([]sabapi.HistorySlot, 10)
+	for i := range history {
+		history[i].Name = fmt.Sprintf("done-%d", i)
+	}
+	base := model{queue: &sabapi.QueueResponse{Slots: slots}, history: history, historyLimit: 8}
+
+	tests := []struct {
+		name string
+		keys []string
+		want layout
+	}{
+		{name: "default", want: layout{}},
+		{name: "hide history", keys: []string{"h"}, want: layout{hideHistory: true}},
+		{name: "show history again", keys: []string{"h", "h"}, want: layout{}},
+		{name: "focus history", keys: []string{"tab"}, want: layout{focus: panelHistory}},
+		{name: "focus wraps", keys: []string{"tab", "tab"}, want: layout{focus: panelQueue}},
+		{name: "hiding focused history refocuses queue", keys: []string{"tab", "h"}, want: layout{hideHistory: true}},
+		{name: "tab ignores hidden history", keys: []string{"h", "tab"}, want: layout{hideHistory: true}},
+		{name: "shrink queue from all", keys: []string{"-"}, want: layout{queueRows: 3}},
+		{name: "shrink queue floors at one", keys: []string{"-", "-", "-", "-", "-"}, want: layout{queueRows: 1}},
+		{name: "grow queue back to all", keys: []string{"-", "+"}, want: layout{}},
+		{name: "grow history", keys: []string{"tab", "+", "+"}, want: layout{focus: panelHistory, historyRows: 7}},
+		{name: "history capped at fetch limit", keys: []string{"tab", "+", "+", "+", "+", "+"}, want: layout{focus: panelHistory, historyRows: 8}},
+		{name: "shrink history", keys: []string{"tab", "-"}, want: layout{focus: panelHistory, historyRows: 4}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := pressKeys(t, base, tt.keys...).layout
+			if got != tt.want {
+				t.Fatalf("layout = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestViewHonoursLayout(t *testing.T) {
+	t.Parallel()
+
+	m := model{
+		queue:   &sabapi.QueueResponse{Slots: []sabapi.QueueSlot{{Filename: "first"}, {Filename: "second"}, {Filename: "third"}}},
+		history: []sabapi.HistorySlot{{Name: "old-1"}, {Name: "old-2"}},
+		layout:  layout{queueRows: 1, historyRows: 1, focus: panelHistory},
+	}
+	view := m.View()
+	if !strings.Contains(view, "first") || strings.Contains(view, "second") || !strings.Contains(view, "… 2 more") {
+		t.Fatalf("expected one queue row and an overflow note, got %q", view)
+	}
+	if !strings.Contains(view, ">recent history:") || !strings.Contains(view, "old-1") || strings.Contains(view, "old-2") {
+		t.Fatalf("expected one focused history row, got %q", view)
+	}
+
+	m.layout = m.layout.toggleHistory()
+	if view := m.View(); strings.Contains(view, "recent history") {
+		t.Fatalf("expected history panel hidden, got %q", view)
+	}
+}
+
+func TestSumQueue(t *testing.T) {
+	t.Parallel()
+
+	slots := []sabapi.QueueSlot{
+		{MB: "1,024.5", MBLeft: "512.25"},
+		{MB: "100", MBLeft: "0"},
+		{MB: "bogus", MBLeft: ""},
+	}
+	got := sumQueue(slots)
+	if got.mb != 1124.5 || got.mbLeft != 512.25 {
+		t.Fatalf("sumQueue = %+v, want mb=1124.5 mbLeft=512.25", got)
+	}
+	if empty := sumQueue(nil); empty != (queueTotals{}) {
+		t.Fatalf("expected zero totals for empty queue, got %+v", empty)
+	}
+}
+
+func TestCountHistory(t *testing.T) {
+	t.Parallel()
+
+	slots := []sabapi.HistorySlot{
+		{Status: "Completed"},
+		{Status: "completed"},
+		{Status: "Failed"},
+		{Status: "Extracting"},
+	}
+	want := historyCounts{completed: 2, failed: 1, other: 1}
+	if got := countHistory(slots); got != want {
+		t.Fatalf("countHistory = %+v, want %+v", got, want)
+	}
+
+	m := model{queue: &sabapi.QueueResponse{Slots: []sabapi.QueueSlot{{Filename: "a", MB: "10", MBLeft: "4"}}}, history: slots}
+	view := m.View()
+	if !strings.Contains(view, "totals: 4.0 MB left of 10.0 MB") || !strings.Contains(view, "history: 2 completed, 1 failed, 1 other") {
+		t.Fatalf("expected totals header and history footer, got %q", view)
+	}
+}
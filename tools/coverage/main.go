@@ -7,9 +7,11 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -26,27 +28,100 @@ type combo struct {
 
 func main() {
 	source := flag.String("source", "internal/sabapi/client.go", "path to sabapi client source")
+	cliDir := flag.String("cli", "cmd/sabx/root", "directory of CLI sources calling QueueAction and mode helpers (empty to skip)")
 	format := flag.String("format", "table", "output format: table|json")
+	specPath := flag.String("spec", "", "file of known SABnzbd modes (see tools/coverage/sabnzbd-modes.txt); reports the ones sabx does not call")
 	flag.Parse()
 
-	entries, err := collectCoverage(*source)
+	entries, err := collectCoverage(*source, *cliDir)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 
+	var gaps []gap
+	if *specPath != "" {
+		spec, err := loadSpec(*specPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		gaps = missingModes(entries, spec)
+	}
+
 	switch strings.ToLower(*format) {
 	case "json":
-		if err := json.NewEncoder(os.Stdout).Encode(entries); err != nil {
+		var payload any = entries
+		if *specPath != "" {
+			payload = map[string]any{"operations": entries, "missing": gaps}
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(payload); err != nil {
 			fmt.Fprintf(os.Stderr, "encode error: %v\n", err)
 			os.Exit(1)
 		}
 	default:
 		printTable(entries)
+		if *specPath != "" {
+			printGaps(os.Stdout, gaps)
+		}
+	}
+}
+
+// gap is a spec operation that no sabx code calls.
+type gap struct {
+	Mode string `json:"mode"`
+	Name string `json:"name,omitempty"`
+}
+
+// loadSpec reads a mode list: one "mode" or "mode name" per line, with blank
+// lines and # comments ignored.
+func loadSpec(path string) ([]gap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var spec []gap
+	for i, line := range strings.Split(string(data), "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		switch len(fields) {
+		case 0:
+		case 1:
+			spec = append(spec, gap{Mode: fields[0]})
+		case 2:
+			spec = append(spec, gap{Mode: fields[0], Name: fields[1]})
+		default:
+			return nil, fmt.Errorf("%s:%d: expected \"mode\" or \"mode name\", got %q", path, i+1, strings.TrimSpace(line))
+		}
 	}
+	return spec, nil
 }
 
-func collectCoverage(path string) ([]entry, error) {
+// missingModes returns the spec operations absent from entries, in spec
+// order. A bare mode counts as covered when any entry uses it.
+func missingModes(entries []entry, spec []gap) []gap {
+	modes := map[string]bool{}
+	ops := map[combo]bool{}
+	for _, e := range entries {
+		modes[e.Mode] = true
+		ops[combo{mode: e.Mode, name: e.Name}] = true
+	}
+	missing := []gap{}
+	for _, g := range spec {
+		covered := ops[combo{mode: g.Mode, name: g.Name}]
+		if g.Name == "" {
+			covered = modes[g.Mode]
+		}
+		if !covered {
+			missing = append(missing, g)
+		}
+	}
+	return missing
+}
+
+func collectCoverage(path, cliDir string) ([]entry, error) {
 	abs, err := filepath.Abs(path)
 	if err != nil {
 		return nil, err
@@ -60,14 +135,9 @@ func collectCoverage(path string) ([]entry, error) {
 
 	entries := map[combo]*entry{}
 
+	// TestNotification's modes come from a lookup table in the CLI, which
+	// the walker cannot follow.
 	manualExtras := map[string][]combo{
-		"AddFile": {
-			{mode: "addfile"},
-		},
-		"ServerControl": {
-			{mode: "restart"},
-			{mode: "shutdown"},
-		},
 		"TestNotification": {
 			{mode: "test_email"},
 			{mode: "test_windows"},
@@ -81,10 +151,8 @@ func collectCoverage(path string) ([]entry, error) {
 		},
 	}
 
-	globalExtras := map[combo]string{
-		{mode: "queue", name: "purge"}: "queuePurgeCmd",
-		{mode: "queue", name: "move"}:  "queueItemMoveCmd",
-	}
+	consts := fileConsts(file)
+	fw := findForwarders(file)
 
 	ast.Inspect(file, func(n ast.Node) bool {
 		fd, ok := n.(*ast.FuncDecl)
@@ -92,11 +160,21 @@ func collectCoverage(path string) ([]entry, error) {
 			return true
 		}
 
+		locals := localStrings(fd.Body, consts)
+		values := func(expr ast.Expr) []string { return stringValues(expr, locals, consts) }
+
 		modes := map[string]struct{}{}
 		names := map[string]struct{}{}
 		queueNames := map[string]struct{}{}
 
 		ast.Inspect(fd.Body, func(node ast.Node) bool {
+			if kv, ok := node.(*ast.KeyValueExpr); ok {
+				// Multipart uploads name the mode in a form field map.
+				if key := values(kv.Key); len(key) == 1 && key[0] == "mode" {
+					addAll(modes, values(kv.Value))
+				}
+				return true
+			}
 			call, ok := node.(*ast.CallExpr)
 			if !ok {
 				return true
@@ -104,36 +182,31 @@ func collectCoverage(path string) ([]entry, error) {
 
 			if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
 				receiver, _ := sel.X.(*ast.Ident)
+				isClient := receiver != nil && receiver.Name == "c"
 				switch {
-				case receiver != nil && receiver.Name == "c" && (sel.Sel.Name == "call" || sel.Sel.Name == "do"):
+				case isClient && (sel.Sel.Name == "call" || sel.Sel.Name == "do"):
 					if len(call.Args) > 1 {
-						if lit, ok := call.Args[1].(*ast.BasicLit); ok && lit.Kind == token.STRING {
-							mode := strings.Trim(lit.Value, "`\"")
-							if mode != "" {
-								modes[mode] = struct{}{}
-							}
-						}
+						addAll(modes, values(call.Args[1]))
 					}
-				case receiver != nil && receiver.Name == "c" && sel.Sel.Name == "QueueAction":
+				case isClient && sel.Sel.Name == "QueueAction":
 					if len(call.Args) > 1 {
-						if lit, ok := call.Args[1].(*ast.BasicLit); ok && lit.Kind == token.STRING {
-							name := strings.Trim(lit.Value, "`\"")
-							if name != "" {
-								queueNames[name] = struct{}{}
-							}
-						}
+						addAll(queueNames, values(call.Args[1]))
+					}
+				case isClient && fw.mode[sel.Sel.Name] > 0:
+					if idx := fw.mode[sel.Sel.Name]; len(call.Args) > idx {
+						addAll(modes, values(call.Args[idx]))
+					}
+				case isClient && fw.queue[sel.Sel.Name] > 0:
+					if idx := fw.queue[sel.Sel.Name]; len(call.Args) > idx {
+						addAll(queueNames, values(call.Args[idx]))
 					}
 				case sel.Sel.Name == "Set":
 					if len(call.Args) >= 2 {
-						if key, ok := call.Args[0].(*ast.BasicLit); ok && key.Kind == token.STRING {
-							if strings.Trim(key.Value, "`\"") == "name" {
-								if val, ok := call.Args[1].(*ast.BasicLit); ok && val.Kind == token.STRING {
-									name := strings.Trim(val.Value, "`\"")
-									if name != "" {
-										names[name] = struct{}{}
-									}
-								}
-							}
+						switch key := values(call.Args[0]); {
+						case len(key) == 1 && key[0] == "name":
+							addAll(names, values(call.Args[1]))
+						case len(key) == 1 && key[0] == "mode":
+							addAll(modes, values(call.Args[1]))
 						}
 					}
 				}
@@ -164,8 +237,10 @@ func collectCoverage(path string) ([]entry, error) {
 		return false
 	})
 
-	for combo, fn := range globalExtras {
-		addEntry(entries, combo, fn)
+	if cliDir != "" {
+		if err := collectCLICalls(cliDir, fw, entries); err != nil {
+			return nil, err
+		}
 	}
 
 	out := make([]entry, 0, len(entries))
@@ -182,6 +257,234 @@ func collectCoverage(path string) ([]entry, error) {
 	return out, nil
 }
 
+// forwarders records client methods that pass one of their parameters
+// straight through as the API mode (mode) or as a QueueAction name (queue),
+// keyed by method name with the 1-based argument position. QueueAction
+// itself is handled directly.
+type forwarders struct {
+	mode  map[string]int
+	queue map[string]int
+}
+
+func findForwarders(file *ast.File) forwarders {
+	fw := forwarders{mode: map[string]int{}, queue: map[string]int{}}
+	for _, decl := range file.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || fd.Recv == nil || fd.Body == nil || fd.Name.Name == "QueueAction" {
+			continue
+		}
+		params := paramIndexes(fd)
+		assigned := localStrings(fd.Body, nil)
+		ast.Inspect(fd.Body, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok || len(call.Args) < 2 {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			if receiver, ok := sel.X.(*ast.Ident); !ok || receiver.Name != "c" {
+				return true
+			}
+			ident, ok := call.Args[1].(*ast.Ident)
+			if !ok {
+				return true
+			}
+			idx, isParam := params[ident.Name]
+			if !isParam || len(assigned[ident.Name]) > 0 {
+				return true
+			}
+			switch sel.Sel.Name {
+			case "call", "do":
+				fw.mode[fd.Name.Name] = idx
+			case "QueueAction":
+				fw.queue[fd.Name.Name] = idx
+			}
+			return true
+		})
+	}
+	return fw
+}
+
+// paramIndexes maps each named parameter of fd to its position.
+func paramIndexes(fd *ast.FuncDecl) map[string]int {
+	params := map[string]int{}
+	idx := 0
+	for _, field := range fd.Type.Params.List {
+		if len(field.Names) == 0 {
+			idx++
+			continue
+		}
+		for _, name := range field.Names {
+			params[name.Name] = idx
+			idx++
+		}
+	}
+	return params
+}
+
+// collectCLICalls scans the CLI sources in dir for QueueAction and forwarder
+// calls made with fixed values, such as queue purge or server restart, and
+// credits them to the enclosing command function.
+func collectCLICalls(dir string, fw forwarders, entries map[combo]*entry) error {
+	fset := token.NewFileSet()
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		consts := fileConsts(file)
+		for _, decl := range file.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Body == nil {
+				continue
+			}
+			locals := localStrings(fd.Body, consts)
+			ast.Inspect(fd.Body, func(node ast.Node) bool {
+				call, ok := node.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				sel, ok := call.Fun.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+				mode, idx := "", 0
+				switch {
+				case sel.Sel.Name == "QueueAction":
+					mode, idx = "queue", 1
+				case fw.queue[sel.Sel.Name] > 0:
+					mode, idx = "queue", fw.queue[sel.Sel.Name]
+				case fw.mode[sel.Sel.Name] > 0:
+					idx = fw.mode[sel.Sel.Name]
+				default:
+					return true
+				}
+				if len(call.Args) <= idx {
+					return true
+				}
+				for _, val := range stringValues(call.Args[idx], locals, consts) {
+					key := combo{mode: val}
+					if mode != "" {
+						key = combo{mode: mode, name: val}
+					}
+					addEntry(entries, key, fd.Name.Name)
+				}
+				return true
+			})
+		}
+	}
+	return nil
+}
+
+// fileConsts returns the string constants declared at file level.
+func fileConsts(file *ast.File) map[string]string {
+	consts := map[string]string{}
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			vs, ok := spec.(*ast.ValueSpec)
+			if !ok {
+				continue
+			}
+			for i, name := range vs.Names {
+				if i < len(vs.Values) {
+					if val, ok := stringLit(vs.Values[i]); ok {
+						consts[name.Name] = val
+					}
+				}
+			}
+		}
+	}
+	return consts
+}
+
+// localStrings collects, for each local variable or constant in body, every
+// string value assigned to it, so a mode picked in a switch resolves to all
+// of its cases.
+func localStrings(body *ast.BlockStmt, consts map[string]string) map[string][]string {
+	locals := map[string][]string{}
+	record := func(name string, expr ast.Expr) {
+		for _, val := range stringValues(expr, nil, consts) {
+			if !contains(locals[name], val) {
+				locals[name] = append(locals[name], val)
+			}
+		}
+	}
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch stmt := node.(type) {
+		case *ast.AssignStmt:
+			if len(stmt.Lhs) != len(stmt.Rhs) {
+				return true
+			}
+			for i, lhs := range stmt.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok {
+					record(ident.Name, stmt.Rhs[i])
+				}
+			}
+		case *ast.ValueSpec:
+			for i, name := range stmt.Names {
+				if i < len(stmt.Values) {
+					record(name.Name, stmt.Values[i])
+				}
+			}
+		}
+		return true
+	})
+	return locals
+}
+
+// stringValues resolves expr to the string values it can take: a literal, a
+// constant, or a local variable assigned from either.
+func stringValues(expr ast.Expr, locals map[string][]string, consts map[string]string) []string {
+	if val, ok := stringLit(expr); ok {
+		if val == "" {
+			return nil
+		}
+		return []string{val}
+	}
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	if vals, ok := locals[ident.Name]; ok {
+		return vals
+	}
+	if val, ok := consts[ident.Name]; ok && val != "" {
+		return []string{val}
+	}
+	return nil
+}
+
+func stringLit(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	val, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", false
+	}
+	return val, true
+}
+
+func addAll(set map[string]struct{}, values []string) {
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+}
+
 func addEntry(entries map[combo]*entry, key combo, fn string) {
 	e, ok := entries[key]
 	if !ok {
@@ -216,3 +519,18 @@ func printTable(entries []entry) {
 	}
 	fmt.Printf("\nTotal operations: %d\n", len(entries))
 }
+
+func printGaps(w io.Writer, gaps []gap) {
+	if len(gaps) == 0 {
+		fmt.Fprintln(w, "\nEvery mode in the spec is covered.")
+		return
+	}
+	fmt.Fprintf(w, "\nMissing from sabx (%d):\n", len(gaps))
+	for _, g := range gaps {
+		if g.Name == "" {
+			fmt.Fprintf(w, "- %s\n", g.Mode)
+			continue
+		}
+		fmt.Fprintf(w, "- %s (name=%s)\n", g.Mode, g.Name)
+	}
+}
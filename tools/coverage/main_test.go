@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sampleClient = `package sabapi
+
+const modeWarnings = "warnings"
+
+const queueDeleteAll = "delete_all"
+
+type Client struct{}
+
+func (c *Client) FullStatus(ctx context.Context) error {
+	mode := "fullstatus"
+	return c.call(ctx, mode, nil, nil)
+}
+
+func (c *Client) Warnings(ctx context.Context, clear bool) error {
+	params := url.Values{}
+	name := "show"
+	if clear {
+		name = "clear"
+	}
+	params.Set("name", name)
+	return c.call(ctx, modeWarnings, params, nil)
+}
+
+func (c *Client) PurgeAll(ctx context.Context) error {
+	return c.QueueAction(ctx, queueDeleteAll, nil)
+}
+
+func (c *Client) Control(ctx context.Context, mode string) error {
+	return c.call(ctx, mode, nil, nil)
+}
+
+func (c *Client) Upload(ctx context.Context) error {
+	fields := map[string]string{"mode": "addfile"}
+	return c.post(ctx, fields)
+}
+
+func (c *Client) QueueAction(ctx context.Context, name string, extra url.Values) error {
+	return c.call(ctx, "queue", nil, nil)
+}
+`
+
+const sampleCLI = `package root
+
+func restartCmd() {
+	app.Client.Control(ctx, "restart")
+}
+
+func purgeCmd() {
+	action := "purge"
+	app.Client.QueueAction(ctx, action, nil)
+}
+`
+
+func TestCollectCoverageResolvesVariables(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "client.go")
+	if err := os.WriteFile(source, []byte(sampleClient), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cliDir := filepath.Join(dir, "cli")
+	if err := os.Mkdir(cliDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cliDir, "cmd.go"), []byte(sampleCLI), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := collectCoverage(source, cliDir)
+	if err != nil {
+		t.Fatalf("collectCoverage: %v", err)
+	}
+	got := map[combo][]string{}
+	for _, e := range entries {
+		got[combo{mode: e.Mode, name: e.Name}] = e.Functions
+	}
+	want := map[combo][]string{
+		{mode: "fullstatus"}:                {"FullStatus"},
+		{mode: "warnings", name: "show"}:    {"Warnings"},
+		{mode: "warnings", name: "clear"}:   {"Warnings"},
+		{mode: "queue", name: "delete_all"}: {"PurgeAll"},
+		{mode: "queue"}:                     {"QueueAction"},
+		{mode: "addfile"}:                   {"Upload"},
+		{mode: "restart"}:                   {"restartCmd"},
+		{mode: "queue", name: "purge"}:      {"purgeCmd"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("coverage = %v, want %v", got, want)
+	}
+}
+
+func TestMissingModesReportsSpecGaps(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "client.go")
+	if err := os.WriteFile(source, []byte(sampleClient), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	specPath := filepath.Join(dir, "modes.txt")
+	spec := "# sample\nfullstatus\nwarnings clear\nwarnings\nget_cats   # not implemented\n\nqueue delete_all\nqueue delete_nzf\n"
+	if err := os.WriteFile(specPath, []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := collectCoverage(source, "")
+	if err != nil {
+		t.Fatalf("collectCoverage: %v", err)
+	}
+	known, err := loadSpec(specPath)
+	if err != nil {
+		t.Fatalf("loadSpec: %v", err)
+	}
+	gaps := missingModes(entries, known)
+	want := []gap{{Mode: "get_cats"}, {Mode: "queue", Name: "delete_nzf"}}
+	if !reflect.DeepEqual(gaps, want) {
+		t.Fatalf("missingModes = %+v, want %+v", gaps, want)
+	}
+
+	var out bytes.Buffer
+	printGaps(&out, gaps)
+	for _, line := range []string{"Missing from sabx (2):", "- get_cats", "- queue (name=delete_nzf)"} {
+		if !strings.Contains(out.String(), line) {
+			t.Fatalf("expected %q in gap output:\n%s", line, out.String())
+		}
+	}
+
+	if err := os.WriteFile(specPath, []byte("queue delete extra\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadSpec(specPath); err == nil || !strings.Contains(err.Error(), ":1:") {
+		t.Fatalf("expected a line-numbered spec error, got %v", err)
+	}
+}
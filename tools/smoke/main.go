@@ -10,6 +10,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"time"
 )
@@ -20,6 +22,9 @@ type smokeCommand struct {
 	Description string
 	ExpectJSON  bool
 	Fixture     string
+	// ExpectFields lists keys the JSON output must contain; dotted paths
+	// such as "a.b" reach into nested objects.
+	ExpectFields []string
 }
 
 type commandReport struct {
@@ -51,6 +56,8 @@ func main() {
 		outputDir = flag.String("output", "testdata/smoke/latest", "Directory for recorded fixtures")
 		failFast  = flag.Bool("fail-fast", true, "Stop after the first failing command")
 		record    = flag.Bool("record", true, "Persist stdout to fixture files")
+		verify    = flag.Bool("verify", false, "Fail commands whose redacted output differs from the committed fixture")
+		goldenDir = flag.String("fixtures", "testdata/smoke/golden", "Directory of committed fixtures compared by --verify")
 		timeout   = flag.Duration("timeout", 30*time.Second, "Per-command timeout")
 	)
 	flag.Parse()
@@ -78,67 +85,76 @@ func main() {
 
 	commands := []smokeCommand{
 		{
-			Name:        "notifications-email",
-			Args:        []string{"notifications", "test", "email"},
-			Description: "Exercise test_email notification endpoint",
-			ExpectJSON:  true,
-			Fixture:     "notifications-email.json",
+			Name:         "notifications-email",
+			Args:         []string{"notifications", "test", "email"},
+			Description:  "Exercise test_email notification endpoint",
+			ExpectJSON:   true,
+			ExpectFields: []string{"type", "success", "message"},
+			Fixture:      "notifications-email.json",
 		},
 		{
-			Name:        "notifications-pushover",
-			Args:        []string{"notifications", "test", "pushover"},
-			Description: "Exercise test_pushover notification endpoint",
-			ExpectJSON:  true,
-			Fixture:     "notifications-pushover.json",
+			Name:         "notifications-pushover",
+			Args:         []string{"notifications", "test", "pushover"},
+			Description:  "Exercise test_pushover notification endpoint",
+			ExpectJSON:   true,
+			ExpectFields: []string{"type", "success", "message"},
+			Fixture:      "notifications-pushover.json",
 		},
 		{
-			Name:        "browse-root",
-			Args:        []string{"browse"},
-			Description: "Browse SAB host root directory",
-			ExpectJSON:  true,
-			Fixture:     "browse-root.json",
+			Name:         "browse-root",
+			Args:         []string{"browse"},
+			Description:  "Browse SAB host root directory",
+			ExpectJSON:   true,
+			ExpectFields: []string{"path", "entries"},
+			Fixture:      "browse-root.json",
 		},
 		{
-			Name:        "browse-compact",
-			Args:        []string{"browse", "--compact"},
-			Description: "Browse SAB host root in compact mode",
-			ExpectJSON:  true,
-			Fixture:     "browse-compact.json",
+			Name:         "browse-compact",
+			Args:         []string{"browse", "--compact"},
+			Description:  "Browse SAB host root in compact mode",
+			ExpectJSON:   true,
+			ExpectFields: []string{"path", "entries"},
+			Fixture:      "browse-compact.json",
 		},
 		{
-			Name:        "debug-eval-sort",
-			Args:        []string{"debug", "eval-sort", "title"},
-			Description: "Ensure eval_sort endpoint responds",
-			ExpectJSON:  true,
-			Fixture:     "debug-eval-sort.json",
+			Name:         "debug-eval-sort",
+			Args:         []string{"debug", "eval-sort", "title"},
+			Description:  "Ensure eval_sort endpoint responds",
+			ExpectJSON:   true,
+			ExpectFields: []string{"expression", "result"},
+			Fixture:      "debug-eval-sort.json",
 		},
 		{
-			Name:        "watched-scan",
-			Args:        []string{"watched", "scan"},
-			Description: "Trigger watched_now scan",
-			ExpectJSON:  true,
-			Fixture:     "watched-scan.json",
+			Name:         "watched-scan",
+			Args:         []string{"watched", "scan"},
+			Description:  "Trigger watched_now scan",
+			ExpectJSON:   true,
+			ExpectFields: []string{"triggered"},
+			Fixture:      "watched-scan.json",
 		},
 		{
-			Name:        "orphans-list",
-			Args:        []string{"status", "orphans", "list"},
-			Description: "List orphaned jobs (fullstatus folders)",
-			ExpectJSON:  true,
-			Fixture:     "orphans-list.json",
+			Name:         "orphans-list",
+			Args:         []string{"status", "orphans", "list"},
+			Description:  "List orphaned jobs (fullstatus folders)",
+			ExpectJSON:   true,
+			ExpectFields: []string{"orphans"},
+			Fixture:      "orphans-list.json",
 		},
 		{
-			Name:        "orphans-delete-all",
-			Args:        []string{"status", "orphans", "delete-all"},
-			Description: "Delete all orphaned jobs",
-			ExpectJSON:  true,
-			Fixture:     "orphans-delete-all.json",
+			Name:         "orphans-delete-all",
+			Args:         []string{"status", "orphans", "delete-all"},
+			Description:  "Delete all orphaned jobs",
+			ExpectJSON:   true,
+			ExpectFields: []string{"deleted_all"},
+			Fixture:      "orphans-delete-all.json",
 		},
 		{
-			Name:        "orphans-add-all",
-			Args:        []string{"status", "orphans", "add-all"},
-			Description: "Re-add all orphans to queue",
-			ExpectJSON:  true,
-			Fixture:     "orphans-add-all.json",
+			Name:         "orphans-add-all",
+			Args:         []string{"status", "orphans", "add-all"},
+			Description:  "Re-add all orphans to queue",
+			ExpectJSON:   true,
+			ExpectFields: []string{"added_all"},
+			Fixture:      "orphans-add-all.json",
 		},
 	}
 
@@ -158,6 +174,9 @@ func main() {
 
 	for _, cmd := range commands {
 		res := runSmokeCommand(binPath, *baseURL, *apiKey, *timeout, cmd)
+		if *verify && res.ExitCode == 0 {
+			verifyFixture(*goldenDir, cmd, &res)
+		}
 		report.Commands = append(report.Commands, res)
 		if res.ExitCode != 0 {
 			report.Failures++
@@ -268,18 +287,43 @@ func runSmokeCommand(binary, baseURL, apiKey string, timeout time.Duration, cmd
 	if cmd.ExpectJSON {
 		var decoded map[string]any
 		if parseErr := json.Unmarshal(stdout.Bytes(), &decoded); parseErr != nil {
-			report.Err = strings.TrimSpace(report.Err + "\njson decode error: " + parseErr.Error())
-			if report.ExitCode == 0 {
-				report.ExitCode = -1
-			}
+			failReport(&report, "json decode error: "+parseErr.Error())
 		} else {
 			report.ParsedJSON = decoded
+			if missing := missingFields(decoded, cmd.ExpectFields); len(missing) > 0 {
+				failReport(&report, "missing expected field(s): "+strings.Join(missing, ", "))
+			}
 		}
 	}
 
 	return report
 }
 
+// missingFields returns the entries of fields that payload does not contain.
+// A dotted path must resolve through nested objects; a key whose value is
+// null still counts as present.
+func missingFields(payload map[string]any, fields []string) []string {
+	var missing []string
+	for _, field := range fields {
+		if !hasField(payload, strings.Split(field, ".")) {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
+
+func hasField(payload map[string]any, path []string) bool {
+	val, ok := payload[path[0]]
+	if !ok {
+		return false
+	}
+	if len(path) == 1 {
+		return true
+	}
+	nested, ok := val.(map[string]any)
+	return ok && hasField(nested, path[1:])
+}
+
 func writeFixture(dir string, cmd smokeCommand, report commandReport) error {
 	if report.ParsedJSON == nil {
 		return os.WriteFile(filepath.Join(dir, cmd.Fixture), []byte(report.Stdout), 0o644)
@@ -293,6 +337,116 @@ func writeFixture(dir string, cmd smokeCommand, report commandReport) error {
 	return os.WriteFile(filepath.Join(dir, cmd.Fixture), append(data, '\n'), 0o644)
 }
 
+// verifyFixture compares a command's output with its committed fixture and
+// marks the command failed when they differ. JSON output is compared after
+// the same redaction writeFixture applies, so redacted fields never count as
+// a difference.
+func verifyFixture(dir string, cmd smokeCommand, report *commandReport) {
+	data, err := os.ReadFile(filepath.Join(dir, cmd.Fixture))
+	if err != nil {
+		failReport(report, "fixture: "+err.Error())
+		return
+	}
+	var diffs []string
+	if report.ParsedJSON == nil {
+		if strings.TrimSpace(string(data)) != strings.TrimSpace(report.Stdout) {
+			diffs = []string{"output differs"}
+		}
+	} else {
+		var want map[string]any
+		if err := json.Unmarshal(data, &want); err != nil {
+			failReport(report, "fixture: "+err.Error())
+			return
+		}
+		diffs = diffJSON("", want, redactDynamicFields(report.ParsedJSON))
+	}
+	if len(diffs) > 0 {
+		failReport(report, "fixture mismatch: "+strings.Join(diffs, "; "))
+	}
+}
+
+// failReport records msg on the report and marks it failed if the command
+// itself succeeded.
+func failReport(report *commandReport, msg string) {
+	report.Err = strings.TrimSpace(report.Err + "\n" + msg)
+	if report.ExitCode == 0 {
+		report.ExitCode = -1
+	}
+}
+
+// diffJSON describes where got differs from want, one entry per path in
+// sorted order. A value redacted on either side matches anything.
+func diffJSON(path string, want, got any) []string {
+	if want == redactedValue || got == redactedValue {
+		return nil
+	}
+	label := path
+	if label == "" {
+		label = "(root)"
+	}
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected object, got %s", label, jsonString(got))}
+		}
+		keys := make([]string, 0, len(w)+len(g))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		for k := range g {
+			if _, ok := w[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		var diffs []string
+		for _, k := range keys {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			wv, inWant := w[k]
+			gv, inGot := g[k]
+			switch {
+			case !inGot:
+				diffs = append(diffs, child+": missing")
+			case !inWant:
+				diffs = append(diffs, child+": unexpected")
+			default:
+				diffs = append(diffs, diffJSON(child, wv, gv)...)
+			}
+		}
+		return diffs
+	case []any:
+		g, ok := got.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected array, got %s", label, jsonString(got))}
+		}
+		if len(w) != len(g) {
+			return []string{fmt.Sprintf("%s: expected %d elements, got %d", label, len(w), len(g))}
+		}
+		var diffs []string
+		for i := range w {
+			diffs = append(diffs, diffJSON(fmt.Sprintf("%s[%d]", path, i), w[i], g[i])...)
+		}
+		return diffs
+	default:
+		if !reflect.DeepEqual(want, got) {
+			return []string{fmt.Sprintf("%s: expected %s, got %s", label, jsonString(want), jsonString(got))}
+		}
+		return nil
+	}
+}
+
+func jsonString(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
 func emitReport(record bool, dir string, report runReport) error {
 	if !record {
 		return nil
@@ -304,13 +458,16 @@ func emitReport(record bool, dir string, report runReport) error {
 	return os.WriteFile(filepath.Join(dir, "report.json"), append(data, '\n'), 0o644)
 }
 
+// redactedValue replaces dynamic or secret values in recorded fixtures.
+const redactedValue = "***redacted***"
+
 func redactDynamicFields(payload map[string]any) map[string]any {
 	clean := make(map[string]any, len(payload))
 	for k, v := range payload {
 		switch val := v.(type) {
 		case string:
 			if strings.HasPrefix(strings.ToLower(k), "apikey") || strings.Contains(strings.ToLower(k), "apikey") {
-				clean[k] = "***redacted***"
+				clean[k] = redactedValue
 				continue
 			}
 			clean[k] = val
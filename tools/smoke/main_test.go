@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffJSON(t *testing.T) {
+	t.Parallel()
+
+	want := map[string]any{
+		"apikey":  redactedValue,
+		"success": true,
+		"entries": []any{map[string]any{"name": "downloads", "apikey_hint": redactedValue}},
+	}
+	tests := []struct {
+		name string
+		got  map[string]any
+		want []string
+	}{
+		{
+			name: "redacted fields ignored",
+			got: map[string]any{
+				"apikey":  "fresh-key",
+				"success": true,
+				"entries": []any{map[string]any{"name": "downloads", "apikey_hint": "other"}},
+			},
+		},
+		{
+			name: "changed value",
+			got: map[string]any{
+				"apikey":  redactedValue,
+				"success": false,
+				"entries": []any{map[string]any{"name": "incomplete", "apikey_hint": redactedValue}},
+			},
+			want: []string{`entries[0].name: expected "downloads", got "incomplete"`, "success: expected true, got false"},
+		},
+		{
+			name: "missing and unexpected keys",
+			got: map[string]any{
+				"apikey":  redactedValue,
+				"entries": []any{map[string]any{"name": "downloads", "apikey_hint": redactedValue}},
+				"extra":   1.0,
+			},
+			want: []string{"extra: unexpected", "success: missing"},
+		},
+		{
+			name: "array length",
+			got: map[string]any{
+				"apikey":  redactedValue,
+				"success": true,
+				"entries": []any{},
+			},
+			want: []string{"entries: expected 1 elements, got 0"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := diffJSON("", want, redactDynamicFields(tt.got))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("diffJSON = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyFixture(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cmd := smokeCommand{Name: "browse-root", Fixture: "browse-root.json"}
+	recorded := commandReport{ParsedJSON: map[string]any{"path": "/", "apikey": "old-key"}}
+	if err := writeFixture(dir, cmd, recorded); err != nil {
+		t.Fatalf("writeFixture: %v", err)
+	}
+
+	same := commandReport{ParsedJSON: map[string]any{"path": "/", "apikey": "new-key"}}
+	verifyFixture(dir, cmd, &same)
+	if same.ExitCode != 0 || same.Err != "" {
+		t.Fatalf("redacted-only change must pass, got exit %d: %s", same.ExitCode, same.Err)
+	}
+
+	changed := commandReport{ParsedJSON: map[string]any{"path": "/data", "apikey": "new-key"}}
+	verifyFixture(dir, cmd, &changed)
+	if changed.ExitCode == 0 || !strings.Contains(changed.Err, `path: expected "/", got "/data"`) {
+		t.Fatalf("expected a fixture mismatch, got exit %d: %s", changed.ExitCode, changed.Err)
+	}
+
+	missing := commandReport{ParsedJSON: map[string]any{}}
+	verifyFixture(dir, smokeCommand{Name: "x", Fixture: "absent.json"}, &missing)
+	if missing.ExitCode == 0 || !strings.Contains(missing.Err, "fixture:") {
+		t.Fatalf("expected a missing fixture to fail, got exit %d: %s", missing.ExitCode, missing.Err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, cmd.Fixture))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	var stored map[string]any
+	if err := json.Unmarshal(data, &stored); err != nil || stored["apikey"] != redactedValue {
+		t.Fatalf("expected the stored fixture to be redacted, got %s (%v)", data, err)
+	}
+}